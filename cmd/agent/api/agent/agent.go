@@ -40,6 +40,7 @@ func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/{component}/status", componentStatusHandler).Methods("POST")
 	r.HandleFunc("/{component}/configs", componentConfigHandler).Methods("GET")
 	r.HandleFunc("/gui/csrf-token", getCSRFToken).Methods("GET")
+	r.HandleFunc("/config-check", getConfigCheck).Methods("GET")
 }
 
 func stopAgent(w http.ResponseWriter, r *http.Request) {
@@ -150,6 +151,39 @@ func componentStatusHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func getConfigCheck(w http.ResponseWriter, r *http.Request) {
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if common.AC == nil {
+		body, _ := json.Marshal(map[string]string{"error": "autodiscovery is not running"})
+		http.Error(w, string(body), 503)
+		return
+	}
+
+	// the resolved configurations can hold secrets, they are scrubbed like
+	// the flare files
+	response, err := flare.CleanConfigCheck(common.AC.GetConfigCheck())
+	if err != nil {
+		log.Errorf("Error scrubbing the configurations: %v", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	jsonConfigs, err := json.Marshal(response)
+	if err != nil {
+		log.Errorf("Error marshalling the configurations: %v", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	w.Write(jsonConfigs)
+}
+
 func getStatus(w http.ResponseWriter, r *http.Request) {
 	if err := apiutil.Validate(w, r); err != nil {
 		return
@@ -9,11 +9,27 @@
 package check
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/runner"
 )
 
+// instanceStatus describes a check instance, the next run is only known for
+// the checks scheduled with a cron expression
+type instanceStatus struct {
+	CheckName string
+	CheckID   check.ID
+	Stats     *check.Stats `json:",omitempty"`
+	Schedule  string       `json:",omitempty"`
+	NextRun   int64        `json:",omitempty"`
+}
+
 // SetupHandlers adds the specific handlers for /check endpoints
 func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/", listChecks).Methods("GET")
@@ -27,11 +43,79 @@ func reloadCheck(w http.ResponseWriter, r *http.Request) {
 }
 
 func listChecks(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain")
-	w.Write([]byte("Not yet implemented."))
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+
+	writeInstances(w, getInstances(""))
 }
 
 func listCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain")
-	w.Write([]byte("Not yet implemented."))
+	if r.Method != "GET" {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("Not yet implemented."))
+		return
+	}
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	instances := getInstances(name)
+	if len(instances) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := json.Marshal(map[string]string{"error": "no running instance of check " + name})
+		http.Error(w, string(body), 404)
+		return
+	}
+	writeInstances(w, instances)
+}
+
+// getInstances returns the status of the instances of a check, or of every
+// check if name is empty
+func getInstances(name string) []instanceStatus {
+	instances := []instanceStatus{}
+	seen := make(map[check.ID]bool)
+	for id, stats := range runner.GetCheckStats() {
+		if name != "" && stats.CheckName != name {
+			continue
+		}
+		instances = append(instances, instanceStatus{CheckName: stats.CheckName, CheckID: id, Stats: stats})
+		seen[id] = true
+	}
+
+	if common.Coll == nil {
+		return instances
+	}
+	schedules := common.Coll.GetSchedules()
+	for i := range instances {
+		if schedule, found := schedules[instances[i].CheckID]; found {
+			instances[i].Schedule = schedule.Schedule
+			instances[i].NextRun = schedule.NextRun
+		}
+	}
+	// scheduled checks that haven't run yet
+	for id, schedule := range schedules {
+		if seen[id] || (name != "" && schedule.CheckName != name) {
+			continue
+		}
+		instances = append(instances, instanceStatus{
+			CheckName: schedule.CheckName,
+			CheckID:   id,
+			Schedule:  schedule.Schedule,
+			NextRun:   schedule.NextRun,
+		})
+	}
+	return instances
+}
+
+func writeInstances(w http.ResponseWriter, instances []instanceStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	body, err := json.Marshal(instances)
+	if err != nil {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+	w.Write(body)
 }
@@ -50,6 +50,11 @@ var checkCmd = &cobra.Command{
 			fmt.Printf("Cannot setup config, exiting: %v\n", err)
 			return err
 		}
+		// the check configurations can hold ENC[] values as well
+		if err := config.ResolveSecrets(); err != nil {
+			fmt.Printf("Cannot resolve the secrets, exiting: %v\n", err)
+			return err
+		}
 
 		if logLevel == "" {
 			if confFilePath != "" {
@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/collector"
+	"github.com/DataDog/datadog-agent/pkg/collector/isolated"
+	"github.com/DataDog/datadog-agent/pkg/collector/loaders"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	AgentCmd.AddCommand(checkWorkerCmd)
+}
+
+// checkWorkerCmd runs an isolated check on behalf of the agent, it's started
+// by the agent itself and talks to it on the file descriptors 3 and 4
+var checkWorkerCmd = &cobra.Command{
+	Use:    "check-worker",
+	Short:  "Run a check in a worker process of the agent",
+	Long:   ``,
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := common.SetupConfig(confFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		// the agent logs the output of its workers
+		err = config.SetupLogger(config.Datadog.GetString("log_level"), "", "", false, false, "", true, false)
+		if err != nil {
+			return fmt.Errorf("unable to set up logger: %v", err)
+		}
+
+		// NOTICE: this sets up the Python environment, if available
+		common.Coll = collector.NewCollector(common.GetPythonPaths()...)
+		defer common.Coll.Stop()
+
+		requests := os.NewFile(3, "requests")
+		replies := os.NewFile(4, "replies")
+		return isolated.Serve(requests, replies, loaders.LoaderCatalog())
+	},
+}
@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/collector/autodiscovery"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configCheckJSON bool
+)
+
+func init() {
+	AgentCmd.AddCommand(configCheckCmd)
+	configCheckCmd.Flags().BoolVarP(&configCheckJSON, "json", "j", false, "print out raw json")
+}
+
+var configCheckCmd = &cobra.Command{
+	Use:   "configcheck",
+	Short: "Print all the configurations loaded and resolved by the running agent",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := common.SetupConfig(confFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+		return requestConfigCheck()
+	},
+}
+
+func requestConfigCheck() error {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	urlstr := fmt.Sprintf("https://localhost:%v/agent/config-check", config.Datadog.GetInt("cmd_port"))
+
+	// Set session token
+	e := util.SetAuthToken()
+	if e != nil {
+		return e
+	}
+
+	r, e := util.DoGet(c, urlstr)
+	if e != nil {
+		var errMap = make(map[string]string)
+		json.Unmarshal(r, &errMap)
+		// If the error has been marshalled into a json object, check it and return it properly
+		if err, found := errMap["error"]; found {
+			e = fmt.Errorf(err)
+		}
+
+		fmt.Printf("Could not reach agent: %v \nMake sure the agent is running before requesting the configurations and contact support if you continue having issues. \n", e)
+		return e
+	}
+
+	if configCheckJSON {
+		var prettyJSON bytes.Buffer
+		json.Indent(&prettyJSON, r, "", "  ")
+		fmt.Println(prettyJSON.String())
+		return nil
+	}
+
+	var response autodiscovery.ConfigCheckResponse
+	if e = json.Unmarshal(r, &response); e != nil {
+		return e
+	}
+	printConfigCheck(os.Stdout, response)
+	return nil
+}
+
+// printConfigCheck writes the configurations in a human readable way
+func printConfigCheck(w io.Writer, response autodiscovery.ConfigCheckResponse) {
+	fmt.Fprintln(w, "=== Loaded configurations ===")
+	for _, c := range response.Configs {
+		printConfig(w, c.Config)
+		if c.ServiceID != "" {
+			fmt.Fprintf(w, "Resolved for service: %s\n", c.ServiceID)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "=== Unresolved templates ===")
+	for _, u := range response.Unresolved {
+		printConfig(w, u.Template)
+		for _, reason := range u.Reasons {
+			fmt.Fprintf(w, "Reason: %s\n", reason)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "=== Services ===")
+	for _, svc := range response.Services {
+		fmt.Fprintf(w, "%s (AD identifiers: %s)\n", svc.ID, strings.Join(svc.ADIdentifiers, ", "))
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "=== Template cache ===")
+	for _, tpl := range response.Templates {
+		printConfig(w, tpl)
+		fmt.Fprintln(w)
+	}
+
+	if len(response.ConfigErrors) > 0 {
+		fmt.Fprintln(w, "=== Configuration errors ===")
+		names := make([]string, 0, len(response.ConfigErrors))
+		for name := range response.ConfigErrors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "%s: %s\n", name, response.ConfigErrors[name])
+		}
+	}
+}
+
+func printConfig(w io.Writer, c check.Config) {
+	fmt.Fprintf(w, "--- %s check ---\n", c.Name)
+	if c.Provider != "" {
+		fmt.Fprintf(w, "Source: %s\n", c.Provider)
+	}
+	if len(c.ADIdentifiers) > 0 {
+		fmt.Fprintf(w, "AD identifiers: %s\n", strings.Join(c.ADIdentifiers, ", "))
+	}
+	fmt.Fprint(w, c.String())
+}
@@ -38,6 +38,11 @@ var flareCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		// the API key is needed to send the flare when the agent isn't
+		// running, failing to resolve it doesn't prevent creating the flare
+		if err := config.ResolveSecrets(); err != nil {
+			fmt.Printf("Cannot resolve the secrets: %v\n", err)
+		}
 
 		caseID := ""
 		if len(args) > 0 {
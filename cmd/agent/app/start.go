@@ -29,6 +29,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metadata"
 	"github.com/DataDog/datadog-agent/pkg/pidfile"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
+	"github.com/DataDog/datadog-agent/pkg/snmp/traps"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/version"
 	log "github.com/cihub/seelog"
@@ -37,10 +38,14 @@ import (
 	// register core checks
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/cluster"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/containers"
+	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/containers/kubelet"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/network"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/system"
 
+	// register container runtimes
+	_ "github.com/DataDog/datadog-agent/pkg/util/container/cri"
+
 	// register metadata providers
 	_ "github.com/DataDog/datadog-agent/pkg/collector/metadata"
 	_ "github.com/DataDog/datadog-agent/pkg/metadata"
@@ -121,6 +126,10 @@ func StartAgent() error {
 	if err != nil {
 		return fmt.Errorf("unable to set up global agent configuration: %v", err)
 	}
+	// replace the ENC[] values with their secrets
+	if err := config.ResolveSecrets(); err != nil {
+		return err
+	}
 
 	// Setup logger
 	syslogURI := config.GetSyslogURI()
@@ -192,6 +201,9 @@ func StartAgent() error {
 	log.Debugf("Forwarder started")
 
 	// setup the aggregator
+	if err := serializer.InitCompression(); err != nil {
+		log.Errorf("Misconfiguration of the payload compression, falling back to the default: %s", err)
+	}
 	s := &serializer.Serializer{Forwarder: common.Forwarder}
 	agg := aggregator.InitAggregator(s, hostname)
 	agg.AddAgentStartupEvent(version.AgentVersion)
@@ -206,10 +218,17 @@ func StartAgent() error {
 	}
 	log.Debugf("statsd started")
 
+	// start the SNMP traps server, before the logs-agent that may read the traps
+	if config.Datadog.GetBool("snmp_traps_enabled") {
+		_, eventOut, _ := agg.GetChannels()
+		common.TrapServer, err = traps.NewTrapServer(eventOut)
+		if err != nil {
+			log.Errorf("Could not start the SNMP traps server: %s", err)
+		}
+	}
+
 	// start logs-agent
 	if config.Datadog.GetBool("log_enabled") {
-		// logs-agent does not provide any Stop method yet
-		// data loss may happen when stopping the agent
 		err := logs.Start()
 		if err != nil {
 			log.Error("Could not start logs-agent: ", err)
@@ -267,10 +286,16 @@ func StartAgent() error {
 
 // StopAgent Tears down the agent process
 func StopAgent() {
-	// gracefully shut down any component
+	// the data in flight is flushed until the shutdown deadline
+	deadline := time.Now().Add(config.Datadog.GetDuration("shutdown_timeout") * time.Second)
+
+	// gracefully shut down any component, starting with the ones producing data
 	if common.DSD != nil {
 		common.DSD.Stop()
 	}
+	if common.TrapServer != nil {
+		common.TrapServer.Stop()
+	}
 	if common.AC != nil {
 		common.AC.Stop()
 	}
@@ -278,8 +303,23 @@ func StopAgent() {
 		common.MetadataScheduler.Stop()
 	}
 	api.StopServer()
+
+	if err := aggregator.StopDefaultAggregator(time.Until(deadline)); err != nil {
+		log.Warnf("Aggregator not flushed: %s", err)
+	} else {
+		log.Info("Aggregator flushed")
+	}
 	if common.Forwarder != nil {
-		common.Forwarder.Stop()
+		if err := common.Forwarder.Drain(time.Until(deadline)); err != nil {
+			log.Warnf("Forwarder not drained: %s", err)
+		} else {
+			log.Info("Forwarder drained")
+		}
+	}
+	if err := logs.Stop(time.Until(deadline)); err != nil {
+		log.Warnf("Logs-agent not drained: %s", err)
+	} else {
+		log.Info("Logs-agent stopped")
 	}
 	gui.StopGUIServer()
 	os.Remove(pidfilePath)
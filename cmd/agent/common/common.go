@@ -16,6 +16,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/metadata"
+	"github.com/DataDog/datadog-agent/pkg/snmp/traps"
 	"github.com/DataDog/datadog-agent/pkg/util/executable"
 )
 
@@ -29,6 +30,9 @@ var (
 	// DSD is the global dogstastd instance
 	DSD *dogstatsd.Server
 
+	// TrapServer is the global SNMP traps server instance
+	TrapServer *traps.TrapServer
+
 	// MetadataScheduler is responsible to orchestrate metadata collection
 	MetadataScheduler *metadata.Scheduler
 
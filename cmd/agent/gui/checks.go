@@ -34,6 +34,7 @@ var (
 // Adds the specific handlers for /checks/ endpoints
 func checkHandler(r *mux.Router) {
 	r.HandleFunc("/running", http.HandlerFunc(sendRunningChecks)).Methods("POST")
+	r.HandleFunc("/top/{sort}", http.HandlerFunc(sendTopChecks)).Methods("POST")
 	r.HandleFunc("/run/{name}", http.HandlerFunc(runCheck)).Methods("POST")
 	r.HandleFunc("/run/{name}/once", http.HandlerFunc(runCheckOnce)).Methods("POST")
 	r.HandleFunc("/reload/{name}", http.HandlerFunc(reloadCheck)).Methods("POST")
@@ -57,6 +58,18 @@ func sendRunningChecks(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(html))
 }
 
+// Sends the check instances consuming the most resources
+func sendTopChecks(w http.ResponseWriter, r *http.Request) {
+	html, e := renderTopChecks(mux.Vars(r)["sort"])
+	if e != nil {
+		w.Write([]byte("Error generating status html: " + e.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.Write([]byte(html))
+}
+
 // Schedules a specific check
 func runCheck(w http.ResponseWriter, r *http.Request) {
 	// Fetch the desired check
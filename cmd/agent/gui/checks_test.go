@@ -24,3 +24,23 @@ func TestReadConfDir(t *testing.T) {
 
 	assert.Equal(t, expected, files)
 }
+
+func TestTopChecks(t *testing.T) {
+	checks := map[string]interface{}{
+		"a": map[string]interface{}{"CheckID": "a", "LastCPUTime": 10.0, "Metrics": 3.0},
+		"b": map[string]interface{}{"CheckID": "b", "LastCPUTime": 30.0, "Metrics": 1.0},
+		"c": map[string]interface{}{"CheckID": "c", "LastCPUTime": 10.0, "Metrics": 2.0},
+		"d": "not a check",
+	}
+
+	ids := func(top []map[string]interface{}) []string {
+		res := []string{}
+		for _, c := range top {
+			res = append(res, c["CheckID"].(string))
+		}
+		return res
+	}
+	assert.Equal(t, []string{"b", "a", "c"}, ids(topChecks(checks, "LastCPUTime")))
+	assert.Equal(t, []string{"a", "c", "b"}, ids(topChecks(checks, "Metrics")))
+	assert.Equal(t, []string{"a", "b", "c"}, ids(topChecks(checks, "LastRSSDelta")))
+}
@@ -8,6 +8,7 @@ import (
 	"html/template"
 	"io"
 	"path/filepath"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -23,6 +24,8 @@ var fmap = template.FuncMap{
 	"lastErrorMessage":   status.LastErrorMessage,
 	"pythonLoaderError":  pythonLoaderError,
 	"formatUnixTime":     status.FormatUnixTime,
+	"formatBytes":        status.FormatBytes,
+	"formatMillis":       status.FormatMillis,
 	"humanizeF":          status.MkHuman,
 	"humanizeI":          mkHumanI,
 	"formatTitle":        formatTitle,
@@ -37,6 +40,16 @@ type Data struct {
 	ConfigErrs map[string]string
 	Stats      map[string]interface{}
 	CheckStats []*check.Stats
+	TopChecks  []map[string]interface{}
+}
+
+// the fields of the check stats the top checks can be sorted by
+var topChecksFields = map[string]string{
+	"cpu":         "LastCPUTime",
+	"allocations": "LastAllocatedBytes",
+	"rss":         "LastRSSDelta",
+	"time":        "LastExecutionTime",
+	"metrics":     "Metrics",
 }
 
 func renderStatus(rawData []byte, request string) (string, error) {
@@ -69,6 +82,27 @@ func renderRunningChecks() (string, error) {
 	return b.String(), nil
 }
 
+func renderTopChecks(sortBy string) (string, error) {
+	var b = new(bytes.Buffer)
+
+	field, found := topChecksFields[sortBy]
+	if !found {
+		return "", fmt.Errorf("unknown sort %q", sortBy)
+	}
+
+	runnerStatsJSON := []byte(expvar.Get("runner").String())
+	runnerStats := make(map[string]interface{})
+	json.Unmarshal(runnerStatsJSON, &runnerStats)
+	checks, _ := runnerStats["Checks"].(map[string]interface{})
+
+	data := Data{Name: sortBy, TopChecks: topChecks(checks, field)}
+	e := fillTemplate(b, data, "topChecks")
+	if e != nil {
+		return "", e
+	}
+	return b.String(), nil
+}
+
 func renderCheck(name string, stats []*check.Stats) (string, error) {
 	var b = new(bytes.Buffer)
 
@@ -192,3 +226,28 @@ func instances(checks map[string]interface{}) map[string][]interface{} {
 	}
 	return instances
 }
+
+// topChecks returns the check instances sorted by decreasing value of the
+// given field of their stats
+func topChecks(checks map[string]interface{}, field string) []map[string]interface{} {
+	top := []map[string]interface{}{}
+	for _, ch := range checks {
+		if check, ok := ch.(map[string]interface{}); ok {
+			top = append(top, check)
+		}
+	}
+
+	value := func(i int) float64 {
+		v, _ := top[i][field].(float64)
+		return v
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		if value(i) != value(j) {
+			return value(i) > value(j)
+		}
+		idI, _ := top[i]["CheckID"].(string)
+		idJ, _ := top[j]["CheckID"].(string)
+		return idI < idJ
+	})
+	return top
+}
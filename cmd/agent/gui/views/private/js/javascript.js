@@ -578,6 +578,24 @@ function seeRunningChecks() {
 }
 
 
+/*************************************************************************
+                            See Top Checks
+*************************************************************************/
+
+// Display the check instances consuming the most resources, sorted by `sort`
+function seeTopChecks(sort) {
+  $(".page").css("display", "none");
+  $("#top_checks").css("display", "block");
+
+  sendMessage("checks/top/" + sort, "",
+  function(data, status, xhr){
+    $("#top_checks").html(data);
+  }, function() {
+    $("#top_checks").html("An error occurred.");
+  });
+}
+
+
 /*************************************************************************
                                 Flare
 *************************************************************************/
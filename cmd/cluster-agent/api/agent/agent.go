@@ -9,18 +9,23 @@
 package agent
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	as "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/cmd/agent/common/signals"
 	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/collector/providers"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/flare"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
 	"github.com/DataDog/datadog-agent/pkg/version"
 	log "github.com/cihub/seelog"
 	"github.com/gorilla/mux"
@@ -31,6 +36,15 @@ var EventChecks = []string{
 	"kubernetes",
 }
 
+// checkConfigsRefreshInterval is how often the check configurations of the
+// ConfigMaps are collected again once a node agent asked for them
+const checkConfigsRefreshInterval = 10 * time.Second
+
+var (
+	checkConfigs      *checkConfigsCache
+	startCheckConfigs sync.Once
+)
+
 // SetupHandlers adds the specific handlers for cluster agent endpoints
 func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/version", getVersion).Methods("GET")
@@ -41,6 +55,7 @@ func SetupHandlers(r *mux.Router) {
 	// r.HandleFunc("/status/formatted", getFormattedStatus).Methods("GET")
 	r.HandleFunc("/api/v1/metadata/{nodeName}/{podName}", getPodMetadata).Methods("GET")
 	r.HandleFunc("/api/v1/{check}/events", getCheckLatestEvents).Methods("GET")
+	r.HandleFunc("/api/v1/checkconfigs", getCheckConfigs).Methods("GET")
 }
 
 // TODO: make sure it works for DCA
@@ -143,3 +158,94 @@ func getPodMetadata(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(fmt.Sprintf("Could not find associated services mapped to the pod: %s on node: %s", podName, nodeName)))
 
 }
+
+// getCheckConfigs serves the check configurations stored in Kubernetes
+// ConfigMaps to the node agents, authenticated with the cluster agent token.
+// The configurations are served from a cache, and not at all when the version
+// in the `If-None-Match` header of the request is still the current one.
+func getCheckConfigs(w http.ResponseWriter, r *http.Request) {
+	authToken, err := clusteragent.GetClusterAgentAuthToken()
+	if err != nil {
+		log.Errorf("Cannot serve the check configurations: %s", err)
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	expected := []byte(fmt.Sprintf("Bearer %s", authToken))
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer realm=\"Datadog Cluster Agent\"")
+		http.Error(w, "invalid session token", 403)
+		return
+	}
+
+	startCheckConfigs.Do(func() {
+		checkConfigs = newCheckConfigsCache(config.Datadog.GetString("kubernetes_check_configmap_label"))
+		checkConfigs.refresh()
+		go checkConfigs.run()
+	})
+
+	payload, version, err := checkConfigs.get()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	etag := fmt.Sprintf("%q", version)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(payload)
+}
+
+// checkConfigsCache holds the check configurations of the ConfigMaps, so that
+// the polls of the node agents don't list the ConfigMaps every time
+type checkConfigsCache struct {
+	labelSelector string
+	payload       []byte // JSON-encoded clusteragent.CheckConfigs
+	version       string
+	err           error // error of the last collection if none succeeded yet
+	m             sync.RWMutex
+}
+
+func newCheckConfigsCache(labelSelector string) *checkConfigsCache {
+	return &checkConfigsCache{labelSelector: labelSelector}
+}
+
+// get returns the cached configurations and their version
+func (c *checkConfigsCache) get() ([]byte, string, error) {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.payload, c.version, c.err
+}
+
+// refresh collects the configurations again, the previous ones are kept when
+// the collection fails
+func (c *checkConfigsCache) refresh() {
+	configs, version, err := providers.CollectConfigMapChecks(c.labelSelector)
+	var payload []byte
+	if err == nil {
+		payload, err = json.Marshal(clusteragent.CheckConfigs{Version: version, Configs: configs})
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	if err != nil {
+		log.Errorf("Could not collect the check configurations: %s", err)
+		if c.payload == nil {
+			c.err = err
+		}
+		return
+	}
+	c.payload, c.version, c.err = payload, version, nil
+}
+
+// run refreshes the configurations every checkConfigsRefreshInterval
+func (c *checkConfigsCache) run() {
+	ticker := time.NewTicker(checkConfigsRefreshInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		c.refresh()
+	}
+}
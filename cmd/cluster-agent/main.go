@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	_ "expvar" // Blank import used because this isn't directly used in this file
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
@@ -98,6 +99,10 @@ func start(cmd *cobra.Command, args []string) error {
 	if confErr != nil {
 		log.Infof("unable to parse Datadog config file, running with env variables: %s", confErr)
 	}
+	if err := config.ResolveSecrets(); err != nil {
+		log.Criticalf("Unable to resolve the secrets of the configuration: %s", err)
+		return nil
+	}
 
 	if !config.Datadog.IsSet("api_key") {
 		log.Critical("no API key configured, exiting")
@@ -123,6 +128,9 @@ func start(cmd *cobra.Command, args []string) error {
 	}
 	f := forwarder.NewDefaultForwarder(keysPerDomain)
 	f.Start()
+	if err := serializer.InitCompression(); err != nil {
+		log.Errorf("Misconfiguration of the payload compression, falling back to the default: %s", err)
+	}
 	s := &serializer.Serializer{Forwarder: f}
 
 	hname, err := util.GetHostname()
@@ -147,7 +155,20 @@ func start(cmd *cobra.Command, args []string) error {
 	// Block here until we receive the interrupt signal
 	<-signalCh
 
+	// the data in flight is flushed until the shutdown deadline
+	deadline := time.Now().Add(config.Datadog.GetDuration("shutdown_timeout") * time.Second)
+
 	clusterAgent.Stop()
+	if err := aggregatorInstance.Stop(time.Until(deadline)); err != nil {
+		log.Warnf("Aggregator not flushed: %s", err)
+	} else {
+		log.Info("Aggregator flushed")
+	}
+	if err := f.Drain(time.Until(deadline)); err != nil {
+		log.Warnf("Forwarder not drained: %s", err)
+	} else {
+		log.Info("Forwarder drained")
+	}
 	log.Info("See ya!")
 	log.Flush()
 	return nil
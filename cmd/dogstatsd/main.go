@@ -92,6 +92,9 @@ func start(cmd *cobra.Command, args []string) error {
 			log.Error(confErr)
 		} else {
 			configFound = true
+			if err := config.ResolveSecrets(); err != nil {
+				log.Error(err)
+			}
 		}
 	}
 
@@ -138,6 +141,9 @@ func start(cmd *cobra.Command, args []string) error {
 	}
 	f := forwarder.NewDefaultForwarder(keysPerDomain)
 	f.Start()
+	if err := serializer.InitCompression(); err != nil {
+		log.Errorf("Misconfiguration of the payload compression, falling back to the default: %s", err)
+	}
 	s := &serializer.Serializer{Forwarder: f}
 
 	hname, err := util.GetHostname()
@@ -185,10 +191,23 @@ func start(cmd *cobra.Command, args []string) error {
 	// Block here until we receive the interrupt signal
 	<-signalCh
 
+	// the data in flight is flushed until the shutdown deadline
+	deadline := time.Now().Add(config.Datadog.GetDuration("shutdown_timeout") * time.Second)
+
 	if metaScheduler != nil {
 		metaScheduler.Stop()
 	}
 	statsd.Stop()
+	if err := aggregatorInstance.Stop(time.Until(deadline)); err != nil {
+		log.Warnf("Aggregator not flushed: %s", err)
+	} else {
+		log.Info("Aggregator flushed")
+	}
+	if err := f.Drain(time.Until(deadline)); err != nil {
+		log.Warnf("Forwarder not drained: %s", err)
+	} else {
+		log.Info("Forwarder drained")
+	}
 	log.Info("See ya!")
 	log.Flush()
 	return nil
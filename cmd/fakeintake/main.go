@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/test/fakeintake"
+)
+
+func main() {
+	var addr = flag.String("addr", "127.0.0.1:8080", "address of the HTTP intake and query endpoints")
+	var logsAddr = flag.String("logs-addr", "127.0.0.1:10516", "address of the logs TCP endpoint")
+	flag.Usage = func() {
+		fmt.Print("This binary emulates the Datadog intake, it stores the payloads sent by the agent\n" +
+			"in memory and serves them on the /fakeintake/ query endpoints.\n\n")
+
+		fmt.Printf("Usage: %s [-addr HOST:PORT] [-logs-addr HOST:PORT]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	defer log.Flush()
+
+	server := fakeintake.NewServer()
+	if err := server.Start(*addr, *logsAddr); err != nil {
+		fmt.Printf("unable to start the fake intake: %s\n", err)
+		os.Exit(1)
+	}
+	defer server.Stop()
+
+	fmt.Printf("Fake intake started, agent configuration:\n%s", server.AgentConfig())
+
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	<-signalCh
+}
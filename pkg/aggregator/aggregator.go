@@ -14,15 +14,23 @@ import (
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/metrics/percentile"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
+	"github.com/DataDog/datadog-agent/pkg/util/cloudtags"
 )
 
 // DefaultFlushInterval aggregator default flush interval
 const DefaultFlushInterval = 15 * time.Second // flush interval
 const bucketSize = 10                         // fixed for now
 
+// defaultCloudTagsRefreshInterval is used when cloud_tags_refresh_interval is invalid
+const defaultCloudTagsRefreshInterval = 600 * time.Second
+
+// declared as a var to ease testing
+var getCloudTags = cloudtags.GetTags
+
 // Stats stores a statistic from several past flushes allowing computations like median or percentiles
 type Stats struct {
 	Flushes    [32]int64 // circular buffer of recent flushes stat
@@ -106,6 +114,15 @@ func InitAggregatorWithFlushInterval(s *serializer.Serializer, hostname string,
 	return aggregatorInstance
 }
 
+// StopDefaultAggregator stops the default aggregator after a last flush, see
+// BufferedAggregator.Stop
+func StopDefaultAggregator(timeout time.Duration) error {
+	if aggregatorInstance == nil {
+		return nil
+	}
+	return aggregatorInstance.Stop(timeout)
+}
+
 // SetDefaultAggregator allows to force a custom Aggregator as the default one and run it.
 // This is useful for testing or benchmarking.
 func SetDefaultAggregator(agg *BufferedAggregator) {
@@ -129,7 +146,13 @@ type BufferedAggregator struct {
 	serializer         *serializer.Serializer
 	hostname           string
 	hostnameUpdate     chan string
-	hostnameUpdateDone chan struct{}    // signals that the hostname update is finished
+	hostnameUpdateDone chan struct{} // signals that the hostname update is finished
+	stopChan           chan chan struct{}
+	stopped            chan struct{}    // closed once run returns, the channels aren't read anymore
+	cloudTagsOnMetrics bool             // attach the cloud tags to the series of the host
+	hostTags           []string         // cloud tags of the host, refreshed in the background
+	hostTagsMutex      sync.RWMutex     // protects hostTags
+	flushWg            sync.WaitGroup   // tracks the flushes not yet handed to the serializer
 	TickerChan         <-chan time.Time // For test/benchmark purposes: it allows the flush to be controlled from the outside
 }
 
@@ -148,6 +171,9 @@ func NewBufferedAggregator(s *serializer.Serializer, hostname string, flushInter
 		hostname:           hostname,
 		hostnameUpdate:     make(chan string),
 		hostnameUpdateDone: make(chan struct{}),
+		stopChan:           make(chan chan struct{}),
+		stopped:            make(chan struct{}),
+		cloudTagsOnMetrics: config.Datadog.GetBool("cloud_tags_on_metrics"),
 	}
 
 	return aggregator
@@ -184,8 +210,11 @@ func (agg *BufferedAggregator) GetChannels() (chan *metrics.MetricSample, chan m
 // SetHostname sets the hostname that the aggregator uses by default on all the data it sends
 // Blocks until the main aggregator goroutine has finished handling the update
 func (agg *BufferedAggregator) SetHostname(hostname string) {
-	agg.hostnameUpdate <- hostname
-	<-agg.hostnameUpdateDone
+	select {
+	case agg.hostnameUpdate <- hostname:
+		<-agg.hostnameUpdateDone
+	case <-agg.stopped:
+	}
 }
 
 // AddAgentStartupEvent adds the startup event to the events that'll be sent on the next flush
@@ -195,7 +224,10 @@ func (agg *BufferedAggregator) AddAgentStartupEvent(agentVersion string) {
 		SourceTypeName: "System",
 		EventType:      "Agent Startup",
 	}
-	agg.eventIn <- event
+	select {
+	case agg.eventIn <- event:
+	case <-agg.stopped:
+	}
 }
 
 func (agg *BufferedAggregator) registerSender(id check.ID) error {
@@ -268,18 +300,78 @@ func (agg *BufferedAggregator) addSample(metricSample *metrics.MetricSample, tim
 
 // GetSeries grabs all the series from the queue and clears the queue
 func (agg *BufferedAggregator) GetSeries() metrics.Series {
-	series := agg.sampler.flush(timeNowNano())
+	return agg.getSeries(timeNowNano())
+}
+
+// getSeries grabs the series of the buckets older than timestamp
+func (agg *BufferedAggregator) getSeries(timestamp float64) metrics.Series {
+	series := agg.sampler.flush(timestamp)
 	agg.mu.Lock()
 	for _, checkSampler := range agg.checkSamplers {
 		series = append(series, checkSampler.flush()...)
 	}
 	agg.mu.Unlock()
+
+	if hostTags := agg.getHostTags(); len(hostTags) > 0 {
+		for _, serie := range series {
+			if serie.Host == agg.hostname {
+				serie.Tags = appendTags(serie.Tags, hostTags)
+			}
+		}
+	}
 	return series
 }
 
-func (agg *BufferedAggregator) flushSeries() {
+// getHostTags returns the cloud tags attached to the series of the host
+func (agg *BufferedAggregator) getHostTags() []string {
+	agg.hostTagsMutex.RLock()
+	defer agg.hostTagsMutex.RUnlock()
+	return agg.hostTags
+}
+
+// updateHostTags queries the cloud tags of the host, it may block on the
+// metadata services of the cloud providers
+func (agg *BufferedAggregator) updateHostTags() {
+	hostTags := getCloudTags()
+	agg.hostTagsMutex.Lock()
+	agg.hostTags = hostTags
+	agg.hostTagsMutex.Unlock()
+}
+
+// refreshHostTags updates the cloud tags of the host periodically until the
+// aggregator stops, so that the flushes don't wait for the metadata services.
+// The series flushed before the first update have no cloud tags.
+func (agg *BufferedAggregator) refreshHostTags() {
+	refreshInterval := config.Datadog.GetDuration("cloud_tags_refresh_interval") * time.Second
+	if refreshInterval <= 0 {
+		refreshInterval = defaultCloudTagsRefreshInterval
+	}
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		agg.updateHostTags()
+		select {
+		case <-ticker.C:
+		case <-agg.stopped:
+			return
+		}
+	}
+}
+
+// appendTags returns a new slice holding tags and extraTags, the context
+// samplers keep a reference to the tags so they can't be appended in place
+func appendTags(tags []string, extraTags []string) []string {
+	if len(extraTags) == 0 {
+		return tags
+	}
+	merged := make([]string, 0, len(tags)+len(extraTags))
+	merged = append(merged, tags...)
+	return append(merged, extraTags...)
+}
+
+func (agg *BufferedAggregator) flushSeries(timestamp float64) {
 	start := time.Now()
-	series := agg.GetSeries()
+	series := agg.getSeries(timestamp)
 	addFlushCount("Series", int64(len(series)))
 
 	if len(series) == 0 {
@@ -287,7 +379,9 @@ func (agg *BufferedAggregator) flushSeries() {
 	}
 
 	// Serialize and forward in a separate goroutine
+	agg.flushWg.Add(1)
 	go func() {
+		defer agg.flushWg.Done()
 		log.Debug("Flushing ", len(series), " series to the forwarder")
 		err := agg.serializer.SendSeries(series)
 		if err != nil {
@@ -321,7 +415,9 @@ func (agg *BufferedAggregator) flushServiceChecks() {
 	addFlushCount("ServiceChecks", int64(len(serviceChecks)))
 
 	// Serialize and forward in a separate goroutine
+	agg.flushWg.Add(1)
 	go func() {
+		defer agg.flushWg.Done()
 		log.Debug("Flushing ", len(serviceChecks), " service checks to the forwarder")
 		err := agg.serializer.SendServiceChecks(serviceChecks)
 		if err != nil {
@@ -335,21 +431,37 @@ func (agg *BufferedAggregator) flushServiceChecks() {
 
 // GetSketches grabs all the sketches from the queue and clears the queue
 func (agg *BufferedAggregator) GetSketches() percentile.SketchSeriesList {
+	return agg.getSketches(timeNowNano())
+}
+
+// getSketches grabs the sketches of the buckets older than timestamp
+func (agg *BufferedAggregator) getSketches(timestamp float64) percentile.SketchSeriesList {
 	agg.mu.Lock()
-	defer agg.mu.Unlock()
-	return agg.distSampler.flush(timeNowNano())
+	sketches := agg.distSampler.flush(timestamp)
+	agg.mu.Unlock()
+
+	if hostTags := agg.getHostTags(); len(hostTags) > 0 {
+		for _, sketch := range sketches {
+			if sketch.Host == agg.hostname {
+				sketch.Tags = appendTags(sketch.Tags, hostTags)
+			}
+		}
+	}
+	return sketches
 }
 
-func (agg *BufferedAggregator) flushSketches() {
+func (agg *BufferedAggregator) flushSketches(timestamp float64) {
 	// Serialize and forward in a separate goroutine
 	start := time.Now()
-	sketchSeries := agg.GetSketches()
+	sketchSeries := agg.getSketches(timestamp)
 	addFlushCount("Sketches", int64(len(sketchSeries)))
 	if len(sketchSeries) == 0 {
 		return
 	}
 
+	agg.flushWg.Add(1)
 	go func() {
+		defer agg.flushWg.Done()
 		log.Debug("Flushing ", len(sketchSeries), " sketches to the forwarder")
 		err := agg.serializer.SendSketch(sketchSeries)
 		if err != nil {
@@ -380,7 +492,9 @@ func (agg *BufferedAggregator) flushEvents() {
 	}
 	addFlushCount("Events", int64(len(events)))
 
+	agg.flushWg.Add(1)
 	go func() {
+		defer agg.flushWg.Done()
 		log.Debug("Flushing ", len(events), " events to the forwarder")
 		err := agg.serializer.SendEvents(events)
 		if err != nil {
@@ -392,23 +506,82 @@ func (agg *BufferedAggregator) flushEvents() {
 	}()
 }
 
-func (agg *BufferedAggregator) flush() {
-	agg.flushSeries()
-	agg.flushSketches()
+// flush flushes the buckets older than timestamp
+func (agg *BufferedAggregator) flush(timestamp float64) {
+	agg.flushSeries(timestamp)
+	agg.flushSketches(timestamp)
 	agg.flushServiceChecks()
 	agg.flushEvents()
 }
 
+// Stop stops the aggregator after a last flush: the samples left in the input
+// channels are processed and every bucket, including the current one, is
+// flushed. It returns once the payloads are handed to the serializer, or with
+// an error if timeout expires first.
+func (agg *BufferedAggregator) Stop(timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	done := make(chan struct{})
+	select {
+	case agg.stopChan <- done:
+	case <-timer.C:
+		return fmt.Errorf("the aggregator did not stop within %s", timeout)
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("the last flush of the aggregator did not complete within %s", timeout)
+	}
+}
+
+// stop processes the samples left in the input channels and flushes all the
+// buckets, done is closed once the payloads are handed to the serializer
+func (agg *BufferedAggregator) stop(done chan struct{}) {
+	for {
+		select {
+		case sample := <-agg.dogstatsdIn:
+			aggregatorExpvar.Add("DogstatsdMetricSample", 1)
+			agg.addSample(sample, timeNowNano())
+		case ss := <-agg.checkMetricIn:
+			aggregatorExpvar.Add("ChecksMetricSample", 1)
+			agg.handleSenderSample(ss)
+		case sc := <-agg.serviceCheckIn:
+			aggregatorExpvar.Add("ServiceCheck", 1)
+			agg.addServiceCheck(sc)
+		case e := <-agg.eventIn:
+			aggregatorExpvar.Add("Event", 1)
+			agg.addEvent(e)
+		default:
+			// the current bucket is not complete but won't be anymore
+			agg.flush(timeNowNano() + bucketSize)
+			aggregatorExpvar.Add("NumberOfFlush", 1)
+			go func() {
+				agg.flushWg.Wait()
+				close(done)
+			}()
+			return
+		}
+	}
+}
+
 func (agg *BufferedAggregator) run() {
 	if agg.TickerChan == nil {
 		flushPeriod := agg.flushInterval
-		agg.TickerChan = time.NewTicker(flushPeriod).C
+		ticker := time.NewTicker(flushPeriod)
+		defer ticker.Stop()
+		agg.TickerChan = ticker.C
+	}
+	if agg.cloudTagsOnMetrics {
+		go agg.refreshHostTags()
 	}
 	for {
 		select {
 		case <-agg.TickerChan:
 			start := time.Now()
-			agg.flush()
+			agg.flush(timeNowNano())
 			addFlushTime("MainFlushTime", int64(time.Since(start)))
 			aggregatorExpvar.Add("NumberOfFlush", 1)
 		case sample := <-agg.dogstatsdIn:
@@ -423,6 +596,10 @@ func (agg *BufferedAggregator) run() {
 		case e := <-agg.eventIn:
 			aggregatorExpvar.Add("Event", 1)
 			agg.addEvent(e)
+		case done := <-agg.stopChan:
+			agg.stop(done)
+			close(agg.stopped)
+			return
 		case h := <-agg.hostnameUpdate:
 			aggregatorExpvar.Add("HostnameUpdate", 1)
 			agg.hostname = h
@@ -8,13 +8,19 @@ package aggregator
 import (
 	// stdlib
 	"testing"
+	"time"
 
 	// 3p
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/serializer"
+	"github.com/DataDog/datadog-agent/pkg/util/cloudtags"
 )
 
 var checkID1 check.ID = "1"
@@ -135,3 +141,114 @@ func TestSetHostname(t *testing.T) {
 	agg.SetHostname("different-hostname")
 	assert.Equal(t, "different-hostname", agg.hostname)
 }
+
+func TestStopFlushesCurrentBucket(t *testing.T) {
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitV1Series", mock.Anything, mock.Anything).Return(nil).Times(1)
+	f.On("SubmitV1CheckRuns", mock.Anything, mock.Anything).Return(nil).Times(1)
+	f.On("SubmitV1Intake", mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	agg := NewBufferedAggregator(&serializer.Serializer{Forwarder: f}, "hostname", time.Hour)
+	go agg.run()
+
+	// the samples are still in the input channels or in the current bucket
+	agg.dogstatsdIn <- &metrics.MetricSample{Name: "my.metric", Value: 1, Mtype: metrics.GaugeType, SampleRate: 1}
+	agg.eventIn <- metrics.Event{Title: "my event"}
+
+	require.Nil(t, agg.Stop(5*time.Second))
+	f.AssertExpectations(t)
+	assert.True(t, agg.IsInputQueueEmpty())
+}
+
+func TestStopTimeout(t *testing.T) {
+	agg := NewBufferedAggregator(nil, "hostname", time.Hour)
+
+	// not running
+	assert.NotNil(t, agg.Stop(10*time.Millisecond))
+}
+
+func TestCloudTagsOnMetrics(t *testing.T) {
+	getCloudTags = func() []string { return []string{"region:us-east-1"} }
+	defer func() { getCloudTags = cloudtags.GetTags }()
+	config.Datadog.Set("cloud_tags_on_metrics", true)
+	defer config.Datadog.Set("cloud_tags_on_metrics", false)
+
+	agg := NewBufferedAggregator(nil, "hostname", time.Hour)
+	agg.updateHostTags()
+	agg.addSample(&metrics.MetricSample{Name: "my.gauge", Value: 1, Mtype: metrics.GaugeType, Tags: []string{"foo:bar"}, SampleRate: 1}, 10)
+	agg.addSample(&metrics.MetricSample{Name: "my.gauge", Value: 1, Mtype: metrics.GaugeType, Host: "other-host", SampleRate: 1}, 10)
+	agg.addSample(&metrics.MetricSample{Name: "my.dist", Value: 1, Mtype: metrics.DistributionType, SampleRate: 1}, 10)
+
+	series := agg.getSeries(30)
+	require.Len(t, series, 2)
+	for _, serie := range series {
+		if serie.Host == "hostname" {
+			assert.Equal(t, []string{"foo:bar", "region:us-east-1"}, serie.Tags)
+		} else {
+			assert.Empty(t, serie.Tags)
+		}
+	}
+
+	sketches := agg.getSketches(30)
+	require.Len(t, sketches, 1)
+	assert.Equal(t, []string{"region:us-east-1"}, sketches[0].Tags)
+}
+
+func TestSendAfterStop(t *testing.T) {
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitV1CheckRuns", mock.Anything, mock.Anything).Return(nil)
+	agg := NewBufferedAggregator(&serializer.Serializer{Forwarder: f}, "hostname", time.Hour)
+	go agg.run()
+	require.Nil(t, agg.Stop(5*time.Second))
+
+	// the senders and the setters don't block once the aggregator is stopped
+	sender := newCheckSender(checkID1, agg.checkMetricIn, agg.serviceCheckIn, agg.eventIn, agg.stopped)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 200; i++ {
+			sender.Gauge("my.metric", 1, "", nil)
+			sender.ServiceCheck("my.check", metrics.ServiceCheckOK, "", nil, "")
+			sender.Event(metrics.Event{Title: "my event"})
+		}
+		sender.Commit()
+		agg.AddAgentStartupEvent("6.0.0")
+		agg.SetHostname("different-hostname")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "sending to a stopped aggregator blocks")
+	}
+}
+
+func TestCloudTagsRefreshedInBackground(t *testing.T) {
+	queried := make(chan struct{}, 1)
+	getCloudTags = func() []string {
+		select {
+		case queried <- struct{}{}:
+		default:
+		}
+		return []string{"region:us-east-1"}
+	}
+	defer func() { getCloudTags = cloudtags.GetTags }()
+	config.Datadog.Set("cloud_tags_on_metrics", true)
+	defer config.Datadog.Set("cloud_tags_on_metrics", false)
+
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitV1CheckRuns", mock.Anything, mock.Anything).Return(nil)
+	agg := NewBufferedAggregator(&serializer.Serializer{Forwarder: f}, "hostname", time.Hour)
+	go agg.run()
+	defer agg.Stop(5 * time.Second)
+
+	select {
+	case <-queried:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the cloud tags are not queried when the aggregator starts")
+	}
+	for i := 0; i < 100 && len(agg.getHostTags()) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, []string{"region:us-east-1"}, agg.getHostTags())
+}
@@ -58,6 +58,7 @@ type checkSender struct {
 	smsOut           chan<- senderMetricSample
 	serviceCheckOut  chan<- metrics.ServiceCheck
 	eventOut         chan<- metrics.Event
+	stopped          <-chan struct{} // closed once the aggregator doesn't read its channels anymore
 }
 
 type senderMetricSample struct {
@@ -77,12 +78,13 @@ func init() {
 	}
 }
 
-func newCheckSender(id check.ID, smsOut chan<- senderMetricSample, serviceCheckOut chan<- metrics.ServiceCheck, eventOut chan<- metrics.Event) *checkSender {
+func newCheckSender(id check.ID, smsOut chan<- senderMetricSample, serviceCheckOut chan<- metrics.ServiceCheck, eventOut chan<- metrics.Event, stopped <-chan struct{}) *checkSender {
 	return &checkSender{
 		id:               id,
 		smsOut:           smsOut,
 		serviceCheckOut:  serviceCheckOut,
 		eventOut:         eventOut,
+		stopped:          stopped,
 		metricStats:      metricStats{},
 		priormetricStats: metricStats{},
 	}
@@ -127,7 +129,7 @@ func GetDefaultSender() (Sender, error) {
 	senderInit.Do(func() {
 		var defaultCheckID check.ID // the default value is the zero value
 		aggregatorInstance.registerSender(defaultCheckID)
-		senderInstance = newCheckSender(defaultCheckID, aggregatorInstance.checkMetricIn, aggregatorInstance.serviceCheckIn, aggregatorInstance.eventIn)
+		senderInstance = newCheckSender(defaultCheckID, aggregatorInstance.checkMetricIn, aggregatorInstance.serviceCheckIn, aggregatorInstance.eventIn, aggregatorInstance.stopped)
 	})
 
 	return senderInstance, nil
@@ -136,7 +138,7 @@ func GetDefaultSender() (Sender, error) {
 // Commit commits the metric samples that were added during a check run
 // Should be called at the end of every check run
 func (s *checkSender) Commit() {
-	s.smsOut <- senderMetricSample{s.id, &metrics.MetricSample{}, true}
+	s.sendSenderMetricSample(senderMetricSample{s.id, &metrics.MetricSample{}, true})
 	s.cyclemetricStats()
 }
 
@@ -165,10 +167,28 @@ func (s *checkSender) cyclemetricStats() {
 	s.priormetricStats.Lock.Unlock()
 }
 
+// sendSenderMetricSample sends the sample to the aggregator, unless it's stopped
+func (s *checkSender) sendSenderMetricSample(sample senderMetricSample) {
+	select {
+	case s.smsOut <- sample:
+	case <-s.stopped:
+		log.Debug("Metric sample dropped, the aggregator is stopped")
+	}
+}
+
+// sendServiceCheck sends the service check to the aggregator, unless it's stopped
+func (s *checkSender) sendServiceCheck(sc metrics.ServiceCheck) {
+	select {
+	case s.serviceCheckOut <- sc:
+	case <-s.stopped:
+		log.Debug("Service check dropped, the aggregator is stopped: ", sc.CheckName)
+	}
+}
+
 // SendRawMetricSample sends the raw sample
 // Useful for testing - submitting precomputed samples.
 func (s *checkSender) SendRawMetricSample(sample *metrics.MetricSample) {
-	s.smsOut <- senderMetricSample{s.id, sample, false}
+	s.sendSenderMetricSample(senderMetricSample{s.id, sample, false})
 }
 
 func (s *checkSender) sendMetricSample(metric string, value float64, hostname string, tags []string, mType metrics.MetricType) {
@@ -184,7 +204,7 @@ func (s *checkSender) sendMetricSample(metric string, value float64, hostname st
 		Timestamp:  timeNowNano(),
 	}
 
-	s.smsOut <- senderMetricSample{s.id, metricSample, false}
+	s.sendSenderMetricSample(senderMetricSample{s.id, metricSample, false})
 
 	s.metricStats.Lock.Lock()
 	s.metricStats.Metrics++
@@ -233,7 +253,7 @@ func (s *checkSender) Historate(metric string, value float64, hostname string, t
 // SendRawServiceCheck sends the raw service check
 // Useful for testing - submitting precomputed service check.
 func (s *checkSender) SendRawServiceCheck(sc *metrics.ServiceCheck) {
-	s.serviceCheckOut <- *sc
+	s.sendServiceCheck(*sc)
 }
 
 // ServiceCheck submits a service check
@@ -248,7 +268,7 @@ func (s *checkSender) ServiceCheck(checkName string, status metrics.ServiceCheck
 		Message:   message,
 	}
 
-	s.serviceCheckOut <- serviceCheck
+	s.sendServiceCheck(serviceCheck)
 
 	s.metricStats.Lock.Lock()
 	s.metricStats.ServiceChecks++
@@ -259,7 +279,11 @@ func (s *checkSender) ServiceCheck(checkName string, status metrics.ServiceCheck
 func (s *checkSender) Event(e metrics.Event) {
 	log.Trace("Event submitted: ", e.Title, " for hostname: ", e.Host, " tags: ", e.Tags)
 
-	s.eventOut <- e
+	select {
+	case s.eventOut <- e:
+	case <-s.stopped:
+		log.Debug("Event dropped, the aggregator is stopped: ", e.Title)
+	}
 
 	s.metricStats.Lock.Lock()
 	s.metricStats.Events++
@@ -281,7 +305,7 @@ func (sp *checkSenderPool) mkSender(id check.ID) (Sender, error) {
 	defer sp.m.Unlock()
 
 	err := aggregatorInstance.registerSender(id)
-	sender := newCheckSender(id, aggregatorInstance.checkMetricIn, aggregatorInstance.serviceCheckIn, aggregatorInstance.eventIn)
+	sender := newCheckSender(id, aggregatorInstance.checkMetricIn, aggregatorInstance.serviceCheckIn, aggregatorInstance.eventIn, aggregatorInstance.stopped)
 	sp.senders[id] = sender
 	return sender, err
 }
@@ -107,7 +107,7 @@ func TestGetAndSetSender(t *testing.T) {
 	senderMetricSampleChan := make(chan senderMetricSample, 10)
 	serviceCheckChan := make(chan metrics.ServiceCheck, 10)
 	eventChan := make(chan metrics.Event, 10)
-	testCheckSender := newCheckSender(checkID1, senderMetricSampleChan, serviceCheckChan, eventChan)
+	testCheckSender := newCheckSender(checkID1, senderMetricSampleChan, serviceCheckChan, eventChan, make(chan struct{}))
 
 	err := SetSender(testCheckSender, checkID1)
 	assert.Nil(t, err)
@@ -122,7 +122,7 @@ func TestCheckSenderInterface(t *testing.T) {
 	senderMetricSampleChan := make(chan senderMetricSample, 10)
 	serviceCheckChan := make(chan metrics.ServiceCheck, 10)
 	eventChan := make(chan metrics.Event, 10)
-	checkSender := newCheckSender(checkID1, senderMetricSampleChan, serviceCheckChan, eventChan)
+	checkSender := newCheckSender(checkID1, senderMetricSampleChan, serviceCheckChan, eventChan, make(chan struct{}))
 	checkSender.Gauge("my.metric", 1.0, "my-hostname", []string{"foo", "bar"})
 	checkSender.Rate("my.rate_metric", 2.0, "my-hostname", []string{"foo", "bar"})
 	checkSender.Count("my.count_metric", 123.0, "my-hostname", []string{"foo", "bar"})
@@ -14,8 +14,10 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/collector"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/isolated"
 	"github.com/DataDog/datadog-agent/pkg/collector/listeners"
 	"github.com/DataDog/datadog-agent/pkg/collector/providers"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
 	log "github.com/cihub/seelog"
 )
 
@@ -62,15 +64,18 @@ type AutoConfig struct {
 	collector         *collector.Collector
 	providers         []*providerDescriptor
 	loaders           []check.Loader
+	isolatedLoader    check.Loader // loads the instances running in worker processes
 	templateCache     *TemplateCache
 	listeners         []listeners.ServiceListener
 	configResolver    *ConfigResolver
 	configsPollTicker *time.Ticker
 	config2checks     map[string][]check.ID       // cache the ID of checks we load for each config
+	loadedConfigs     map[string]check.Config     // config digest -> loaded config that isn't a template
 	name2jmxmetrics   map[string]check.ConfigData // holds the metrics to collect for JMX checks
 	stop              chan bool
 	pollerActive      bool
 	m                 sync.RWMutex
+	loadedM           sync.RWMutex // protects loadedConfigs
 }
 
 // NewAutoConfig creates an AutoConfig instance.
@@ -79,8 +84,10 @@ func NewAutoConfig(collector *collector.Collector) *AutoConfig {
 		collector:       collector,
 		providers:       make([]*providerDescriptor, 0, 5),
 		loaders:         make([]check.Loader, 0, 5),
+		isolatedLoader:  isolated.NewIsolatedCheckLoader(),
 		templateCache:   NewTemplateCache(),
 		config2checks:   make(map[string][]check.ID),
+		loadedConfigs:   make(map[string]check.Config),
 		name2jmxmetrics: make(map[string]check.ConfigData),
 		stop:            make(chan bool),
 	}
@@ -186,6 +193,7 @@ func (ac *AutoConfig) getAllConfigs() []check.Config {
 	rawConfigs := []check.Config{}
 	for _, pd := range ac.providers {
 		cfgs, _ := pd.provider.Collect()
+		setProvider(cfgs, pd.provider)
 
 		if fileConfPd, ok := pd.provider.(*providers.FileConfigProvider); ok {
 
@@ -298,6 +306,10 @@ func (ac *AutoConfig) resolve(config check.Config) ([]check.Config, error) {
 		}
 	} else {
 		configs = append(configs, config)
+
+		ac.loadedM.Lock()
+		ac.loadedConfigs[config.Digest()] = config
+		ac.loadedM.Unlock()
 	}
 
 	return configs, nil
@@ -409,6 +421,11 @@ func (ac *AutoConfig) pollConfigs() {
 						// if the config is a template, remove it from the cache
 						if config.IsTemplate() {
 							ac.templateCache.Del(config)
+							ac.configResolver.forgetTemplate(config)
+						} else {
+							ac.loadedM.Lock()
+							delete(ac.loadedConfigs, digest)
+							ac.loadedM.Unlock()
 						}
 					}
 				}
@@ -429,6 +446,7 @@ func (ac *AutoConfig) collect(pd *providerDescriptor) (new, removed []check.Conf
 		log.Errorf("Unable to collect configurations from provider %s: %s", pd.provider, err)
 		return
 	}
+	setProvider(fetched, pd.provider)
 
 	for _, c := range fetched {
 		if !pd.contains(&c) {
@@ -453,26 +471,88 @@ func (ac *AutoConfig) collect(pd *providerDescriptor) (new, removed []check.Conf
 // GetChecks takes a check configuration and returns a slice of Check instances
 // along with any error it might happen during the process
 func (ac *AutoConfig) GetChecks(config check.Config) ([]check.Check, error) {
+	decrypted, err := decryptConfig(config)
+	if err != nil {
+		errorStats.setLoaderError(config.Name, "secrets", err.Error())
+		return []check.Check{}, fmt.Errorf("unable to decrypt the secrets of config '%s': %s", config.Name, err)
+	}
+	// the IDs of the checks are built from the configuration before its
+	// secrets are decrypted, so that they can't leak through the IDs
+	for i := range decrypted.Instances {
+		defer check.RegisterTemplate(decrypted.Instances[i], decrypted.InitConfig, config.Instances[i], config.InitConfig)()
+	}
+	config = decrypted
+
+	// the instances setting `isolated: true` run in worker processes
+	isolatedConfig, config := isolated.SplitConfig(config)
+	isolatedChecks := []check.Check{}
+	if len(isolatedConfig.Instances) != 0 {
+		res, err := ac.isolatedLoader.Load(isolatedConfig)
+		if err != nil {
+			errorStats.setLoaderError(config.Name, fmt.Sprintf("%v", ac.isolatedLoader), err.Error())
+			log.Debugf("%v: unable to load the check '%s': %s", ac.isolatedLoader, config.Name, err)
+		}
+		isolatedChecks = append(isolatedChecks, res...)
+		if len(config.Instances) == 0 {
+			if len(isolatedChecks) == 0 {
+				return isolatedChecks, fmt.Errorf("unable to load any check from config '%s'", config.Name)
+			}
+			return isolatedChecks, nil
+		}
+	}
+
 	for _, loader := range ac.loaders {
 		res, err := loader.Load(config)
 		if err == nil {
 			log.Debugf("%v: successfully loaded check '%s'", loader, config.Name)
 			errorStats.removeLoaderErrors(config.Name)
-			return res, nil
+			return append(isolatedChecks, res...), nil
 		}
 
 		errorStats.setLoaderError(config.Name, fmt.Sprintf("%v", loader), err.Error())
 
 		// Check if some check instances were loaded correctly (can occur if there's multiple check instances)
 		if len(res) != 0 {
-			return res, nil
+			return append(isolatedChecks, res...), nil
 		}
 		log.Debugf("%v: unable to load the check '%s': %s", loader, config.Name, err)
 	}
 
+	if len(isolatedChecks) != 0 {
+		return isolatedChecks, nil
+	}
 	return []check.Check{}, fmt.Errorf("unable to load any check from config '%s'", config.Name)
 }
 
+// decryptConfig returns a copy of the configuration with the `ENC[<handle>]`
+// values of its instances and init_config replaced by their secrets
+func decryptConfig(config check.Config) (check.Config, error) {
+	initConfig, err := secrets.Decrypt(config.InitConfig)
+	if err != nil {
+		return config, err
+	}
+
+	instances := make([]check.ConfigData, 0, len(config.Instances))
+	for _, instance := range config.Instances {
+		decrypted, err := secrets.Decrypt(instance)
+		if err != nil {
+			return config, err
+		}
+		instances = append(instances, decrypted)
+	}
+
+	config.InitConfig = initConfig
+	config.Instances = instances
+	return config, nil
+}
+
+// setProvider records the provider that returned the configurations
+func setProvider(configs []check.Config, provider providers.ConfigProvider) {
+	for i := range configs {
+		configs[i].Provider = provider.String()
+	}
+}
+
 // check if the descriptor contains the Config passed
 func (pd *providerDescriptor) contains(c *check.Config) bool {
 	for _, config := range pd.configs {
@@ -6,10 +6,16 @@
 package autodiscovery
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/listeners"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -38,6 +44,20 @@ type MockLoader struct{}
 
 func (l *MockLoader) Load(config check.Config) ([]check.Check, error) { return []check.Check{}, nil }
 
+// IDLoader records the IDs the checks would get and their instances
+type IDLoader struct {
+	ids       []check.ID
+	instances []check.ConfigData
+}
+
+func (l *IDLoader) Load(config check.Config) ([]check.Check, error) {
+	for _, instance := range config.Instances {
+		l.ids = append(l.ids, check.BuildID(config.Name, instance, config.InitConfig))
+		l.instances = append(l.instances, instance)
+	}
+	return []check.Check{}, nil
+}
+
 type MockListener struct {
 	ListenCount  int
 	stopReceived bool
@@ -99,3 +119,34 @@ func TestStop(t *testing.T) {
 	assert.True(t, ml.stopReceived)
 	assert.True(t, ml.stopReceived)
 }
+
+func TestGetChecksSecretsIDs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("secret_backend_command isn't supported on Windows")
+	}
+	dir, err := ioutil.TempDir("", "autoconfig")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	command := filepath.Join(dir, "secret_command")
+	script := "#!/bin/sh\necho '{\"db_password\": {\"value\": \"secret\", \"error\": null}}'\n"
+	require.NoError(t, ioutil.WriteFile(command, []byte(script), 0700))
+	secrets.Init(command, nil, 5*time.Second, 1024)
+	defer secrets.Init("", nil, 5*time.Second, 1024)
+
+	loader := &IDLoader{}
+	ac := NewAutoConfig(nil)
+	ac.AddLoader(loader)
+	config := check.Config{
+		Name:       "foo",
+		Instances:  []check.ConfigData{check.ConfigData("password: ENC[db_password]")},
+		InitConfig: check.ConfigData("{}"),
+	}
+	_, err = ac.GetChecks(config)
+	require.NoError(t, err)
+
+	// the check gets the secret, but its ID is the one of the template
+	require.Len(t, loader.instances, 1)
+	assert.Equal(t, "password: secret\n", string(loader.instances[0]))
+	assert.Equal(t, check.BuildID("foo", config.Instances[0], config.InitConfig), loader.ids[0])
+	assert.NotEqual(t, check.BuildID("foo", loader.instances[0], config.InitConfig), loader.ids[0])
+}
@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package autodiscovery
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/listeners"
+)
+
+// ConfigCheckResponse describes the configurations known to AutoConfig, it's
+// what `agent configcheck` displays
+type ConfigCheckResponse struct {
+	Configs      []LoadedConfig       `json:"configs"`
+	Unresolved   []UnresolvedTemplate `json:"unresolved"`
+	Services     []ServiceInfo        `json:"services"`
+	Templates    []check.Config       `json:"templates"`
+	ConfigErrors map[string]string    `json:"config_errors"`
+}
+
+// LoadedConfig is a configuration AutoConfig loaded checks from, ServiceID
+// is set when it was resolved from a template
+type LoadedConfig struct {
+	Config    check.Config `json:"config"`
+	ServiceID string       `json:"service_id,omitempty"`
+}
+
+// UnresolvedTemplate is a template that no service resolved
+type UnresolvedTemplate struct {
+	Template check.Config `json:"template"`
+	Reasons  []string     `json:"reasons"`
+}
+
+// ServiceInfo is a service the listeners reported
+type ServiceInfo struct {
+	ID            string   `json:"id"`
+	ADIdentifiers []string `json:"ad_identifiers"`
+}
+
+// GetConfigCheck returns the configurations loaded by AutoConfig, the
+// templates it couldn't resolve and the services it knows about
+func (ac *AutoConfig) GetConfigCheck() ConfigCheckResponse {
+	response := ConfigCheckResponse{
+		Configs:      []LoadedConfig{},
+		Unresolved:   []UnresolvedTemplate{},
+		ConfigErrors: GetConfigErrors(),
+	}
+
+	ac.loadedM.RLock()
+	for _, config := range ac.loadedConfigs {
+		response.Configs = append(response.Configs, LoadedConfig{Config: config})
+	}
+	ac.loadedM.RUnlock()
+
+	cr := ac.configResolver
+	response.Templates = cr.templates.GetAll()
+	sort.Slice(response.Templates, func(i, j int) bool {
+		return response.Templates[i].Name < response.Templates[j].Name
+	})
+
+	cr.resolvedM.RLock()
+	resolvedTemplates := map[string]bool{}
+	for id, configs := range cr.resolved {
+		for digest, config := range configs {
+			response.Configs = append(response.Configs, LoadedConfig{Config: config, ServiceID: string(id)})
+			resolvedTemplates[digest] = true
+		}
+	}
+	for _, tpl := range response.Templates {
+		digest := tpl.Digest()
+		if resolvedTemplates[digest] {
+			continue
+		}
+		reasons := []string{}
+		for id, err := range cr.resolveErrors[digest] {
+			reasons = append(reasons, fmt.Sprintf("service %s: %s", id, err))
+		}
+		if len(reasons) == 0 {
+			reasons = append(reasons, fmt.Sprintf("no service found with the AD identifiers %s", strings.Join(tpl.ADIdentifiers, ", ")))
+		}
+		sort.Strings(reasons)
+		response.Unresolved = append(response.Unresolved, UnresolvedTemplate{Template: tpl, Reasons: reasons})
+	}
+	cr.resolvedM.RUnlock()
+
+	sort.Slice(response.Configs, func(i, j int) bool {
+		if response.Configs[i].Config.Name != response.Configs[j].Config.Name {
+			return response.Configs[i].Config.Name < response.Configs[j].Config.Name
+		}
+		return response.Configs[i].ServiceID < response.Configs[j].ServiceID
+	})
+
+	response.Services = cr.getServices()
+	return response
+}
+
+// getServices returns the services the listeners reported
+func (cr *ConfigResolver) getServices() []ServiceInfo {
+	cr.m.Lock()
+	services := make([]listeners.Service, 0, len(cr.services))
+	for _, svc := range cr.services {
+		services = append(services, svc)
+	}
+	cr.m.Unlock()
+
+	infos := make([]ServiceInfo, 0, len(services))
+	for _, svc := range services {
+		// the identifiers were already computed when the service was added
+		ids, _ := svc.GetADIdentifiers()
+		infos = append(infos, ServiceInfo{ID: string(svc.GetID()), ADIdentifiers: ids})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
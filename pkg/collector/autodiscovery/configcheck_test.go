@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package autodiscovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/listeners"
+)
+
+type MockService struct {
+	id    listeners.ID
+	adIDs []string
+	hosts map[string]string
+}
+
+func (s *MockService) GetID() listeners.ID                   { return s.id }
+func (s *MockService) GetADIdentifiers() ([]string, error)   { return s.adIDs, nil }
+func (s *MockService) GetHosts() (map[string]string, error)  { return s.hosts, nil }
+func (s *MockService) GetPorts() ([]int, error)              { return nil, listeners.ErrNotSupported }
+func (s *MockService) GetTags() ([]string, error)            { return nil, nil }
+func (s *MockService) GetPid() (int, error)                  { return -1, listeners.ErrNotSupported }
+func (s *MockService) GetEnv() (map[string]string, error)    { return map[string]string{}, nil }
+func (s *MockService) GetLabels() (map[string]string, error) { return map[string]string{}, nil }
+
+func TestGetConfigCheck(t *testing.T) {
+	ac := NewAutoConfig(nil)
+	defer ac.configResolver.Stop()
+
+	config := check.Config{
+		Name:      "cpu",
+		Instances: []check.ConfigData{check.ConfigData("{}")},
+		Provider:  "file",
+	}
+	redisTpl := check.Config{
+		Name:          "redisdb",
+		Instances:     []check.ConfigData{check.ConfigData("host: %%host%%")},
+		ADIdentifiers: []string{"redis"},
+		Provider:      "Kubernetes ConfigMaps",
+	}
+	envTpl := check.Config{
+		Name:          "http_check",
+		Instances:     []check.ConfigData{check.ConfigData("url: %%env_URL%%")},
+		ADIdentifiers: []string{"redis"},
+		Provider:      "Kubernetes ConfigMaps",
+	}
+	nginxTpl := check.Config{
+		Name:          "nginx",
+		Instances:     []check.ConfigData{check.ConfigData("host: %%host%%")},
+		ADIdentifiers: []string{"nginx"},
+	}
+
+	svc := &MockService{id: "redis-container", adIDs: []string{"redis"}, hosts: map[string]string{"bridge": "10.0.0.2"}}
+	ac.configResolver.services[svc.id] = svc
+	ac.configResolver.adIDToServices["redis"] = []listeners.ID{svc.id}
+
+	for _, c := range []check.Config{config, redisTpl, envTpl, nginxTpl} {
+		_, err := ac.resolve(c)
+		require.NoError(t, err)
+	}
+
+	response := ac.GetConfigCheck()
+
+	require.Len(t, response.Configs, 2)
+	assert.Equal(t, config, response.Configs[0].Config)
+	assert.Equal(t, "", response.Configs[0].ServiceID)
+	assert.Equal(t, "redisdb", response.Configs[1].Config.Name)
+	assert.Equal(t, "Kubernetes ConfigMaps", response.Configs[1].Config.Provider)
+	assert.Equal(t, "redis-container", response.Configs[1].ServiceID)
+	assert.Equal(t, "host: 10.0.0.2", string(response.Configs[1].Config.Instances[0]))
+
+	require.Len(t, response.Unresolved, 2)
+	assert.Equal(t, "http_check", response.Unresolved[0].Template.Name)
+	assert.Equal(t, []string{"service redis-container: failed to retrieve envvar URL, skipping service redis-container"}, response.Unresolved[0].Reasons)
+	assert.Equal(t, "nginx", response.Unresolved[1].Template.Name)
+	assert.Equal(t, []string{"no service found with the AD identifiers nginx"}, response.Unresolved[1].Reasons)
+
+	assert.Len(t, response.Templates, 3)
+	assert.Equal(t, []ServiceInfo{{ID: "redis-container", ADIdentifiers: []string{"redis"}}}, response.Services)
+
+	// the resolutions of a service are dropped with it
+	ac.configResolver.processDelService(svc)
+	response = ac.GetConfigCheck()
+	assert.Len(t, response.Configs, 1)
+	assert.Len(t, response.Unresolved, 3)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/listeners"
+	"github.com/DataDog/datadog-agent/pkg/util"
 	log "github.com/cihub/seelog"
 )
 
@@ -27,6 +28,9 @@ var (
 		"pid":            getPid,
 		"port":           getPort,
 		"container-name": getContainerName,
+		"env":            getEnvvar,
+		"hostname":       getHostname,
+		"label":          getLabel,
 	}
 )
 
@@ -37,13 +41,16 @@ type ConfigResolver struct {
 	ac              *AutoConfig
 	collector       *collector.Collector
 	templates       *TemplateCache
-	services        map[listeners.ID]listeners.Service // Service.ID --> []Service
-	serviceToChecks map[listeners.ID][]check.ID        // Service.ID --> []CheckID
-	adIDToServices  map[string][]listeners.ID          // AD id --> services that have it
+	services        map[listeners.ID]listeners.Service       // Service.ID --> []Service
+	serviceToChecks map[listeners.ID][]check.ID              // Service.ID --> []CheckID
+	adIDToServices  map[string][]listeners.ID                // AD id --> services that have it
+	resolved        map[listeners.ID]map[string]check.Config // Service.ID --> template digest --> resolved config
+	resolveErrors   map[string]map[listeners.ID]string       // template digest --> Service.ID --> error
 	newService      chan listeners.Service
 	delService      chan listeners.Service
 	stop            chan bool
 	m               sync.Mutex
+	resolvedM       sync.RWMutex // protects resolved and resolveErrors
 }
 
 // NewConfigResolver returns a config resolver
@@ -55,6 +62,8 @@ func newConfigResolver(coll *collector.Collector, ac *AutoConfig, tc *TemplateCa
 		services:        make(map[listeners.ID]listeners.Service),
 		serviceToChecks: make(map[listeners.ID][]check.ID, 0),
 		adIDToServices:  make(map[string][]listeners.ID),
+		resolved:        make(map[listeners.ID]map[string]check.Config),
+		resolveErrors:   make(map[string]map[listeners.ID]string),
 		newService:      make(chan listeners.Service),
 		delService:      make(chan listeners.Service),
 		stop:            make(chan bool),
@@ -116,9 +125,11 @@ func (cr *ConfigResolver) ResolveTemplate(tpl check.Config) []check.Config {
 			config, err := cr.resolve(tpl, cr.services[serviceID])
 			if err == nil {
 				resolvedSet[config.Digest()] = config
+				errorStats.removeResolveError(tpl.Name, string(serviceID))
 			} else {
 				log.Warnf("Error resolving template %s for service %s: %v",
-					config.Name, serviceID, err)
+					tpl.Name, serviceID, err)
+				errorStats.setResolveError(tpl.Name, string(serviceID), err.Error())
 			}
 		}
 	}
@@ -133,8 +144,32 @@ func (cr *ConfigResolver) ResolveTemplate(tpl check.Config) []check.Config {
 }
 
 // resolve takes a template and a service and generates a config with
-// valid connection info and relevant tags.
+// valid connection info and relevant tags. The outcome is kept for
+// `agent configcheck`.
 func (cr *ConfigResolver) resolve(tpl check.Config, svc listeners.Service) (check.Config, error) {
+	config, err := cr.resolveTemplate(tpl, svc)
+
+	digest := tpl.Digest()
+	cr.resolvedM.Lock()
+	defer cr.resolvedM.Unlock()
+	if err != nil {
+		if cr.resolveErrors[digest] == nil {
+			cr.resolveErrors[digest] = make(map[listeners.ID]string)
+		}
+		cr.resolveErrors[digest][svc.GetID()] = err.Error()
+		return config, err
+	}
+	if cr.resolved[svc.GetID()] == nil {
+		cr.resolved[svc.GetID()] = make(map[string]check.Config)
+	}
+	cr.resolved[svc.GetID()][digest] = config
+	delete(cr.resolveErrors[digest], svc.GetID())
+	return config, nil
+}
+
+// resolveTemplate replaces the template variables of a template with the
+// values of a service
+func (cr *ConfigResolver) resolveTemplate(tpl check.Config, svc listeners.Service) (check.Config, error) {
 	// Copy original template
 	resolvedConfig := check.Config{
 		Name:          tpl.Name,
@@ -142,6 +177,7 @@ func (cr *ConfigResolver) resolve(tpl check.Config, svc listeners.Service) (chec
 		InitConfig:    make(check.ConfigData, len(tpl.InitConfig)),
 		MetricConfig:  tpl.MetricConfig,
 		ADIdentifiers: tpl.ADIdentifiers,
+		Provider:      tpl.Provider,
 	}
 	copy(resolvedConfig.InitConfig, tpl.InitConfig)
 	copy(resolvedConfig.Instances, tpl.Instances)
@@ -155,15 +191,17 @@ func (cr *ConfigResolver) resolve(tpl check.Config, svc listeners.Service) (chec
 		vars := tpl.GetTemplateVariablesForInstance(i)
 		for _, v := range vars {
 			name, key := parseTemplateVar(v)
-			if f, found := templateVariables[string(name)]; found {
-				resolvedVar, err := f(key, svc)
-				if err != nil {
-					return check.Config{}, err
-				}
-				// init config vars are replaced by the first found
-				resolvedConfig.InitConfig = bytes.Replace(resolvedConfig.InitConfig, v, resolvedVar, -1)
-				resolvedConfig.Instances[i] = bytes.Replace(resolvedConfig.Instances[i], v, resolvedVar, -1)
+			f, found := templateVariables[string(name)]
+			if !found {
+				return check.Config{}, fmt.Errorf("unsupported template variable %s in the configuration of %s", v, tpl.Name)
 			}
+			resolvedVar, err := f(key, svc)
+			if err != nil {
+				return check.Config{}, err
+			}
+			// init config vars are replaced by the first found
+			resolvedConfig.InitConfig = bytes.Replace(resolvedConfig.InitConfig, v, resolvedVar, -1)
+			resolvedConfig.Instances[i] = bytes.Replace(resolvedConfig.Instances[i], v, resolvedVar, -1)
 		}
 		err = resolvedConfig.Instances[i].MergeAdditionalTags(tags)
 		if err != nil {
@@ -174,6 +212,18 @@ func (cr *ConfigResolver) resolve(tpl check.Config, svc listeners.Service) (chec
 	return resolvedConfig, nil
 }
 
+// forgetTemplate drops the outcome of the resolutions of a template removed
+// from the cache
+func (cr *ConfigResolver) forgetTemplate(tpl check.Config) {
+	digest := tpl.Digest()
+	cr.resolvedM.Lock()
+	defer cr.resolvedM.Unlock()
+	for _, configs := range cr.resolved {
+		delete(configs, digest)
+	}
+	delete(cr.resolveErrors, digest)
+}
+
 // processNewService takes a service, tries to match it against templates and
 // triggers scheduling events if it finds a valid config for it.
 func (cr *ConfigResolver) processNewService(svc listeners.Service) {
@@ -206,8 +256,10 @@ func (cr *ConfigResolver) processNewService(svc listeners.Service) {
 		config, err := cr.resolve(template, svc)
 		if err != nil {
 			log.Errorf("Unable to resolve configuration template: %v", err)
+			errorStats.setResolveError(template.Name, string(svc.GetID()), err.Error())
 			continue
 		}
+		errorStats.removeResolveError(template.Name, string(svc.GetID()))
 
 		// load the checks for this config using Autoconfig
 		checks, err := cr.ac.GetChecks(config)
@@ -236,6 +288,14 @@ func (cr *ConfigResolver) processDelService(svc listeners.Service) {
 	cr.m.Lock()
 	defer cr.m.Unlock()
 
+	cr.resolvedM.Lock()
+	delete(cr.resolved, svc.GetID())
+	for _, svcErrors := range cr.resolveErrors {
+		delete(svcErrors, svc.GetID())
+	}
+	cr.resolvedM.Unlock()
+	errorStats.removeServiceResolveErrors(string(svc.GetID()))
+
 	if checks, ok := cr.serviceToChecks[svc.GetID()]; ok {
 		stopped := map[check.ID]struct{}{}
 		for _, id := range checks {
@@ -291,9 +351,9 @@ func getHost(tplVar []byte, svc listeners.Service) ([]byte, error) {
 
 // getFallbackHost implements the fallback strategy to get a service's IP address
 // the current strategy is:
-// 		- if there's only one network we use its IP
-// 		- otherwise we look for the bridge net and return its IP address
-// 		- if we can't find it we fail because we shouldn't try and guess the IP address
+//   - if there's only one network we use its IP
+//   - otherwise we look for the bridge net and return its IP address
+//   - if we can't find it we fail because we shouldn't try and guess the IP address
 func getFallbackHost(hosts map[string]string) (string, error) {
 	if len(hosts) == 1 {
 		for _, host := range hosts {
@@ -346,8 +406,49 @@ func getContainerName(tplVar []byte, svc listeners.Service) ([]byte, error) {
 	return []byte("test-container-name"), nil
 }
 
-// parseTemplateVar extracts the name of the var
-// and the key (or index if it can be cast to an int)
+// getEnvvar returns the value of an environment variable of the service
+func getEnvvar(tplVar []byte, svc listeners.Service) ([]byte, error) {
+	if len(tplVar) == 0 {
+		return nil, fmt.Errorf("envvar name is missing, skipping service %s", svc.GetID())
+	}
+	env, err := svc.GetEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the environment of service %s, skipping config - %s", svc.GetID(), err)
+	}
+	value, found := env[string(tplVar)]
+	if !found {
+		return nil, fmt.Errorf("failed to retrieve envvar %s, skipping service %s", tplVar, svc.GetID())
+	}
+	return []byte(value), nil
+}
+
+// getLabel returns the value of a label of the service
+func getLabel(tplVar []byte, svc listeners.Service) ([]byte, error) {
+	if len(tplVar) == 0 {
+		return nil, fmt.Errorf("label name is missing, skipping service %s", svc.GetID())
+	}
+	labels, err := svc.GetLabels()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the labels of service %s, skipping config - %s", svc.GetID(), err)
+	}
+	value, found := labels[string(tplVar)]
+	if !found {
+		return nil, fmt.Errorf("failed to retrieve label %s, skipping service %s", tplVar, svc.GetID())
+	}
+	return []byte(value), nil
+}
+
+// getHostname returns the hostname of the agent host
+func getHostname(tplVar []byte, svc listeners.Service) ([]byte, error) {
+	hostname, err := util.GetHostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the hostname, skipping service %s - %s", svc.GetID(), err)
+	}
+	return []byte(hostname), nil
+}
+
+// parseTemplateVar extracts the name of the var and the key (or index if it
+// can be cast to an int), the key being everything after the first underscore
 func parseTemplateVar(v []byte) (name, key []byte) {
 	stripped := bytes.Map(func(r rune) rune {
 		if unicode.IsSpace(r) || r == '%' {
@@ -355,7 +456,7 @@ func parseTemplateVar(v []byte) (name, key []byte) {
 		}
 		return r
 	}, v)
-	split := bytes.Split(stripped, []byte("_"))
+	split := bytes.SplitN(stripped, []byte("_"), 2)
 	name = split[0]
 	if len(split) == 2 {
 		key = split[1]
@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks"
 	"github.com/DataDog/datadog-agent/pkg/collector/listeners"
+	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/stretchr/testify/assert"
 
 	// we need some valid check in the catalog to run tests
@@ -68,7 +69,11 @@ func TestParseTemplateVar(t *testing.T) {
 
 	name, key = parseTemplateVar([]byte("%%host_0_1%%"))
 	assert.Equal(t, "host", string(name))
-	assert.Equal(t, "", string(key))
+	assert.Equal(t, "0_1", string(key))
+
+	name, key = parseTemplateVar([]byte("%%env_REDIS_PASSWORD%%"))
+	assert.Equal(t, "env", string(name))
+	assert.Equal(t, "REDIS_PASSWORD", string(key))
 }
 
 func TestResolve(t *testing.T) {
@@ -78,6 +83,8 @@ func TestResolve(t *testing.T) {
 		ADIdentifiers: []string{"redis"},
 		Hosts:         map[string]string{"bridge": "127.0.0.1"},
 		Pid:           1337,
+		Env:           map[string]string{"REDIS_PASSWORD": "secret"},
+		Labels:        map[string]string{"com.example.team": "storage"},
 	}
 	cr.processNewService(&service)
 
@@ -98,10 +105,30 @@ func TestResolve(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "pid: 1337\ntags:\n- foo\n", string(config.Instances[0]))
 
+	tpl.Instances = []check.ConfigData{check.ConfigData("password: %%env_REDIS_PASSWORD%%\nteam: %%label_com.example.team%%")}
+	config, err = cr.resolve(tpl, &service)
+	assert.Nil(t, err)
+	assert.Equal(t, "password: secret\nteam: storage", string(config.Instances[0]))
+
+	hostname, err := util.GetHostname()
+	assert.Nil(t, err)
+	tpl.Instances = []check.ConfigData{check.ConfigData("host: %%hostname%%")}
+	config, err = cr.resolve(tpl, &service)
+	assert.Nil(t, err)
+	assert.Equal(t, "host: "+hostname, string(config.Instances[0]))
+
+	// missing envvar and label
+	tpl.Instances = []check.ConfigData{check.ConfigData("password: %%env_FOO%%")}
+	_, err = cr.resolve(tpl, &service)
+	assert.NotNil(t, err)
+	tpl.Instances = []check.ConfigData{check.ConfigData("team: %%label_foo%%")}
+	_, err = cr.resolve(tpl, &service)
+	assert.NotNil(t, err)
+
 	// template variable doesn't exist
 	tpl.Instances = []check.ConfigData{check.ConfigData("host: %%FOO%%")}
 	config, err = cr.resolve(tpl, &service)
-	assert.NotNil(t, err)
+	assert.EqualError(t, err, "unsupported template variable %%FOO%% in the configuration of cpu")
 }
 
 func TestGetFallbackHost(t *testing.T) {
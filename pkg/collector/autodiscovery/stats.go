@@ -6,6 +6,9 @@
 package autodiscovery
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
@@ -16,18 +19,20 @@ type LoaderErrors map[string]string
 
 // loaderErrorStats holds the error objects
 type acErrorStats struct {
-	config map[string]string       // config file name -> error
-	loader map[string]LoaderErrors // check name -> LoaderErrors
-	run    map[check.ID]string     // check ID -> error
-	m      sync.RWMutex
+	config  map[string]string            // config file name -> error
+	resolve map[string]map[string]string // template name -> service ID -> error
+	loader  map[string]LoaderErrors      // check name -> LoaderErrors
+	run     map[check.ID]string          // check ID -> error
+	m       sync.RWMutex
 }
 
 // newAcErrorStats returns an instance holding autoconfig errors stats
 func newAcErrorStats() *acErrorStats {
 	return &acErrorStats{
-		config: make(map[string]string),
-		loader: make(map[string]LoaderErrors),
-		run:    make(map[check.ID]string),
+		config:  make(map[string]string),
+		resolve: make(map[string]map[string]string),
+		loader:  make(map[string]LoaderErrors),
+		run:     make(map[check.ID]string),
 	}
 }
 
@@ -47,7 +52,45 @@ func (es *acErrorStats) removeConfigError(checkName string) {
 	delete(es.config, checkName)
 }
 
-// getConfigErrors will safely get the errors a check config file
+// setResolveError will safely set the error resolving a template for a service
+func (es *acErrorStats) setResolveError(templateName string, serviceID string, err string) {
+	es.m.Lock()
+	defer es.m.Unlock()
+
+	if _, found := es.resolve[templateName]; !found {
+		es.resolve[templateName] = make(map[string]string)
+	}
+	es.resolve[templateName][serviceID] = err
+}
+
+// removeResolveError removes the error resolving a template for a service,
+// the errors of the other services are kept
+func (es *acErrorStats) removeResolveError(templateName string, serviceID string) {
+	es.m.Lock()
+	defer es.m.Unlock()
+
+	delete(es.resolve[templateName], serviceID)
+	if len(es.resolve[templateName]) == 0 {
+		delete(es.resolve, templateName)
+	}
+}
+
+// removeServiceResolveErrors removes the errors resolving any template for a
+// service (usually when it's gone)
+func (es *acErrorStats) removeServiceResolveErrors(serviceID string) {
+	es.m.Lock()
+	defer es.m.Unlock()
+
+	for templateName, serviceErrors := range es.resolve {
+		delete(serviceErrors, serviceID)
+		if len(serviceErrors) == 0 {
+			delete(es.resolve, templateName)
+		}
+	}
+}
+
+// getConfigErrors will safely get the errors a check config file, along with
+// the errors resolving it for each service when it's a template
 func (es *acErrorStats) getConfigErrors() map[string]string {
 	es.m.RLock()
 	defer es.m.RUnlock()
@@ -56,6 +99,17 @@ func (es *acErrorStats) getConfigErrors() map[string]string {
 	for k, v := range es.config {
 		configCopy[k] = v
 	}
+	for templateName, serviceErrors := range es.resolve {
+		errs := []string{}
+		if err, found := configCopy[templateName]; found {
+			errs = append(errs, err)
+		}
+		for serviceID, err := range serviceErrors {
+			errs = append(errs, fmt.Sprintf("service %s: %s", serviceID, err))
+		}
+		sort.Strings(errs)
+		configCopy[templateName] = strings.Join(errs, "\n")
+	}
 
 	return configCopy
 }
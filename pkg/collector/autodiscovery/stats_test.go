@@ -10,6 +10,7 @@ import (
 func TestNewAcErrorStats(t *testing.T) {
 	s := newAcErrorStats()
 	assert.Len(t, s.config, 0)
+	assert.Len(t, s.resolve, 0)
 	assert.Len(t, s.loader, 0)
 	assert.Len(t, s.run, 0)
 }
@@ -41,6 +42,23 @@ func TestGetConfigErrors(t *testing.T) {
 	assert.Len(t, err, 1)
 }
 
+func TestResolveErrors(t *testing.T) {
+	s := newAcErrorStats()
+	s.setConfigError("redis", "anError")
+	s.setResolveError("redis", "a", "aResolveError")
+	s.setResolveError("redis", "b", "anotherResolveError")
+	assert.Equal(t, map[string]string{"redis": "anError\nservice a: aResolveError\nservice b: anotherResolveError"}, s.getConfigErrors())
+
+	// resolving the template for a service keeps the errors of the others
+	s.removeResolveError("redis", "a")
+	assert.Equal(t, map[string]string{"redis": "anError\nservice b: anotherResolveError"}, s.getConfigErrors())
+
+	s.removeConfigError("redis")
+	s.removeServiceResolveErrors("b")
+	assert.Len(t, s.getConfigErrors(), 0)
+	assert.Len(t, s.resolve, 0)
+}
+
 func TestSetLoaderError(t *testing.T) {
 	s := newAcErrorStats()
 	s.setLoaderError("aCheck", "aLoader", "anError")
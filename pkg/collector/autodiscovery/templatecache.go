@@ -74,6 +74,18 @@ func (cache *TemplateCache) Get(adID string) ([]check.Config, error) {
 	return nil, fmt.Errorf("AD id %s not found in cache", adID)
 }
 
+// GetAll returns all the templates of the cache
+func (cache *TemplateCache) GetAll() []check.Config {
+	cache.m.RLock()
+	defer cache.m.RUnlock()
+
+	templates := make([]check.Config, 0, len(cache.digest2template))
+	for _, tpl := range cache.digest2template {
+		templates = append(templates, tpl)
+	}
+	return templates
+}
+
 // Del removes a template from the cache
 func (cache *TemplateCache) Del(tpl check.Config) error {
 	// compute the digest once
@@ -63,6 +63,7 @@ type Config struct {
 	MetricConfig  ConfigData   // the metric config in Yaml (jmx check only)
 	LogsConfig    ConfigData   // the logs config in Yaml (logs-agent only)
 	ADIdentifiers []string     // the list of AutoDiscovery identifiers (optional)
+	Provider      string       // the provider that returned the configuration (optional)
 }
 
 // Check is an interface for types capable to run checks
@@ -84,6 +85,8 @@ type Stats struct {
 	TotalRuns          uint64
 	TotalErrors        uint64
 	TotalWarnings      uint64
+	TotalTimeouts      uint64
+	ConsecutiveErrors  uint64 // errors in a row since the last successful run
 	Metrics            int64
 	Events             int64
 	ServiceChecks      int64
@@ -95,7 +98,29 @@ type Stats struct {
 	LastError          string    // error that occurred in the last run, if any
 	LastWarnings       []string  // warnings that occurred in the last run, if any
 	UpdateTimestamp    int64     // latest update to this instance, unix timestamp in seconds
-	m                  sync.Mutex
+	BackoffInterval    int64     // delay between runs while backing off after consecutive errors, in seconds, 0 if not backing off
+	NextRunTimestamp   int64     // runs are skipped until then while backing off, unix timestamp in seconds
+	// resources consumed by the check, see ResourceUsage
+	LastCPUTime         int64  // CPU time of the most recent run, in milliseconds
+	TotalCPUTime        int64  // in milliseconds
+	LastAllocatedBytes  uint64 // Go heap allocations during the most recent run
+	TotalAllocatedBytes uint64
+	LastRSSDelta        int64 // change of the resident memory of the agent process during the most recent run, in bytes
+	m                   sync.Mutex
+}
+
+// ResourceUsage describes the resources consumed by a check run
+type ResourceUsage struct {
+	CPUTime        time.Duration // CPU time of the thread running the check
+	AllocatedBytes uint64        // Go heap allocations during the run, including the ones of concurrent goroutines
+	RSSDelta       int64         // change of the resident memory of the agent process during the run, reported by RSSCheck
+}
+
+// RSSCheck is implemented by the checks reporting the change of the resident
+// memory of the whole agent process during their last run, like Python checks
+// whose interpreter allocates out of the Go heap. Concurrent runs are included.
+type RSSCheck interface {
+	RSSDelta() int64 // in bytes, can be negative
 }
 
 // NewStats returns a new check stats instance
@@ -118,8 +143,13 @@ func (cs *Stats) Add(t time.Duration, err error, warnings []error, metricStats m
 	cs.TotalRuns++
 	if err != nil {
 		cs.TotalErrors++
+		cs.ConsecutiveErrors++
 		cs.LastError = err.Error()
+		if _, ok := err.(TimeoutError); ok {
+			cs.TotalTimeouts++
+		}
 	} else {
+		cs.ConsecutiveErrors = 0
 		cs.LastError = ""
 	}
 	cs.LastWarnings = []string{}
@@ -151,6 +181,59 @@ func (cs *Stats) Add(t time.Duration, err error, warnings []error, metricStats m
 	}
 }
 
+// AddResourceUsage tracks the resources consumed by a run
+func (cs *Stats) AddResourceUsage(usage ResourceUsage) {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	cs.LastCPUTime = usage.CPUTime.Nanoseconds() / 1e6
+	cs.TotalCPUTime += cs.LastCPUTime
+	cs.LastAllocatedBytes = usage.AllocatedBytes
+	cs.TotalAllocatedBytes += usage.AllocatedBytes
+	cs.LastRSSDelta = usage.RSSDelta
+}
+
+// UpdateBackoff computes the backoff of the check after its last run, started
+// at `lastRun`: once it failed `failures` times in a row, the delay between its
+// runs doubles after every new error, starting from `interval` and up to
+// `maxInterval`. A successful run ends the backoff.
+func (cs *Stats) UpdateBackoff(lastRun time.Time, interval time.Duration, failures uint64, maxInterval time.Duration) {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	if failures == 0 || cs.ConsecutiveErrors < failures || interval <= 0 || interval >= maxInterval {
+		cs.BackoffInterval = 0
+		cs.NextRunTimestamp = 0
+		return
+	}
+
+	delay := maxInterval
+	if shift := cs.ConsecutiveErrors - failures + 1; shift < 32 && interval<<shift < maxInterval {
+		delay = interval << shift
+	}
+	cs.BackoffInterval = int64(delay / time.Second)
+	cs.NextRunTimestamp = lastRun.Add(delay).Unix()
+}
+
+// BackoffStatus returns the delay between the runs of the check while it's
+// backing off, in seconds, and its number of errors in a row
+func (cs *Stats) BackoffStatus() (int64, uint64) {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	return cs.BackoffInterval, cs.ConsecutiveErrors
+}
+
+// IsBackingOff returns whether the check is backing off and its next run is
+// not due yet. The scheduling of a run can be a bit late compared to the
+// tick, so runs due within the next second aren't skipped.
+func (cs *Stats) IsBackingOff(now time.Time) bool {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	return cs.BackoffInterval > 0 && now.Add(time.Second).Unix() < cs.NextRunTimestamp
+}
+
 // Equal determines whether the passed config is the same
 func (c *Config) Equal(config *Config) bool {
 	if config == nil {
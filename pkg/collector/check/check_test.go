@@ -7,8 +7,10 @@ package check
 
 import (
 	"crypto/rand"
+	"errors"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	yaml "gopkg.in/yaml.v2"
@@ -191,3 +193,90 @@ func LoadCheck(name, path string) (Config, error) {
 
 	return config, err
 }
+
+func TestGetInstanceTimeout(t *testing.T) {
+	timeout, err := GetInstanceTimeout(ConfigData("foo: bar"))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), timeout)
+
+	// the timeout of the requests of a check isn't its run timeout
+	timeout, err = GetInstanceTimeout(ConfigData("timeout: 1"))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), timeout)
+
+	timeout, err = GetInstanceTimeout(ConfigData("check_timeout: 30"))
+	assert.Nil(t, err)
+	assert.Equal(t, 30*time.Second, timeout)
+
+	timeout, err = GetInstanceTimeout(ConfigData("check_timeout: 0.5"))
+	assert.Nil(t, err)
+	assert.Equal(t, 500*time.Millisecond, timeout)
+
+	_, err = GetInstanceTimeout(ConfigData("check_timeout: -1"))
+	assert.NotNil(t, err)
+	_, err = GetInstanceTimeout(ConfigData("check_timeout: never"))
+	assert.NotNil(t, err)
+}
+
+func TestStatsTimeouts(t *testing.T) {
+	s := &Stats{}
+	s.Add(time.Second, errors.New("failure"), nil, nil)
+	s.Add(time.Minute, TimeoutError{Timeout: time.Minute}, nil, nil)
+	assert.Equal(t, uint64(2), s.TotalErrors)
+	assert.Equal(t, uint64(1), s.TotalTimeouts)
+	assert.Equal(t, "check run timed out after 1m0s", s.LastError)
+}
+
+func TestStatsBackoff(t *testing.T) {
+	s := &Stats{}
+	lastRun := time.Now()
+	fail := func() {
+		s.Add(time.Millisecond, errors.New("failure"), nil, nil)
+		s.UpdateBackoff(lastRun, 15*time.Second, 3, time.Minute)
+	}
+
+	fail()
+	fail()
+	assert.Equal(t, uint64(2), s.ConsecutiveErrors)
+	assert.Equal(t, int64(0), s.BackoffInterval)
+	assert.False(t, s.IsBackingOff(lastRun))
+
+	// the delay doubles after every new error, up to the max interval
+	fail()
+	assert.Equal(t, int64(30), s.BackoffInterval)
+	assert.Equal(t, lastRun.Add(30*time.Second).Unix(), s.NextRunTimestamp)
+	assert.True(t, s.IsBackingOff(lastRun.Add(15*time.Second)))
+	assert.False(t, s.IsBackingOff(lastRun.Add(30*time.Second)))
+	fail()
+	assert.Equal(t, int64(60), s.BackoffInterval)
+	fail()
+	interval, consecutiveErrors := s.BackoffStatus()
+	assert.Equal(t, int64(60), interval)
+	assert.Equal(t, uint64(5), consecutiveErrors)
+
+	// a successful run ends the backoff
+	s.Add(time.Millisecond, nil, nil, nil)
+	s.UpdateBackoff(lastRun, 15*time.Second, 3, time.Minute)
+	assert.Equal(t, uint64(0), s.ConsecutiveErrors)
+	assert.Equal(t, int64(0), s.BackoffInterval)
+	assert.False(t, s.IsBackingOff(lastRun))
+
+	// disabled
+	for i := 0; i < 5; i++ {
+		s.Add(time.Millisecond, errors.New("failure"), nil, nil)
+	}
+	s.UpdateBackoff(lastRun, 15*time.Second, 0, time.Minute)
+	assert.Equal(t, int64(0), s.BackoffInterval)
+}
+
+func TestStatsResourceUsage(t *testing.T) {
+	s := &Stats{}
+	s.AddResourceUsage(ResourceUsage{CPUTime: 20 * time.Millisecond, AllocatedBytes: 1024, RSSDelta: 4096})
+	s.AddResourceUsage(ResourceUsage{CPUTime: 5 * time.Millisecond, AllocatedBytes: 512, RSSDelta: -1024})
+
+	assert.Equal(t, int64(5), s.LastCPUTime)
+	assert.Equal(t, int64(25), s.TotalCPUTime)
+	assert.Equal(t, uint64(512), s.LastAllocatedBytes)
+	assert.Equal(t, uint64(1536), s.TotalAllocatedBytes)
+	assert.Equal(t, int64(-1024), s.LastRSSDelta)
+}
@@ -8,11 +8,21 @@ package check
 import (
 	"fmt"
 	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // ID is the representation of the unique ID of a Check instance
 type ID string
 
+var (
+	// templateDigests maps the digests of the configurations derived from a
+	// template to the digests of their templates, see RegisterTemplate
+	templateDigests = map[uint64]uint64{}
+	templateMutex   sync.RWMutex
+)
+
 // Identify returns an unique ID for a check and its configuration
 func Identify(check Check, instance ConfigData, initConfig ConfigData) ID {
 	return BuildID(check.String(), instance, initConfig)
@@ -20,10 +30,53 @@ func Identify(check Check, instance ConfigData, initConfig ConfigData) ID {
 
 // BuildID returns an unique ID for a check name and its configuration
 func BuildID(checkName string, instance, initConfig ConfigData) ID {
+	d := digest(instance, initConfig)
+
+	templateMutex.RLock()
+	if template, found := templateDigests[d]; found {
+		d = template
+	}
+	templateMutex.RUnlock()
+
+	id := fmt.Sprintf("%s:%x", checkName, d)
+	return ID(id)
+}
+
+// RegisterTemplate makes the IDs built from a configuration the IDs of the
+// template it was derived from, e.g. so that the IDs of the checks don't
+// depend on their secrets. The returned function unregisters the template.
+func RegisterTemplate(instance, initConfig, templateInstance, templateInitConfig ConfigData) func() {
+	return registerDigest(digest(instance, initConfig), digest(templateInstance, templateInitConfig))
+}
+
+// RegisterID makes the IDs built from a configuration end with the digest of
+// the given ID, for a check loaded from a configuration derived from a
+// template it doesn't know, e.g. in a worker process. The returned function
+// unregisters the ID.
+func RegisterID(instance, initConfig ConfigData, id ID) (func(), error) {
+	s := string(id)
+	d, err := strconv.ParseUint(s[strings.LastIndex(s, ":")+1:], 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid check ID %s: %s", id, err)
+	}
+	return registerDigest(digest(instance, initConfig), d), nil
+}
+
+func registerDigest(d, template uint64) func() {
+	templateMutex.Lock()
+	templateDigests[d] = template
+	templateMutex.Unlock()
+
+	return func() {
+		templateMutex.Lock()
+		delete(templateDigests, d)
+		templateMutex.Unlock()
+	}
+}
+
+func digest(instance, initConfig ConfigData) uint64 {
 	h := fnv.New64()
 	h.Write([]byte(instance))
 	h.Write([]byte(initConfig))
-
-	id := fmt.Sprintf("%s:%x", checkName, h.Sum64())
-	return ID(id)
+	return h.Sum64()
 }
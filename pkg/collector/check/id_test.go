@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // FIXTURE
@@ -37,3 +38,34 @@ func TestIdentify(t *testing.T) {
 	initConfig3 := ConfigData("key:value")
 	assert.NotEqual(t, Identify(testCheck, instance1, initConfig1), Identify(testCheck, instance3, initConfig3))
 }
+
+func TestRegisterTemplate(t *testing.T) {
+	template := ConfigData("password: ENC[db_password]")
+	instance := ConfigData("password: secret")
+	initConfig := ConfigData("key: value")
+	templateID := BuildID("foo", template, initConfig)
+
+	unregister := RegisterTemplate(instance, initConfig, template, initConfig)
+	assert.Equal(t, templateID, BuildID("foo", instance, initConfig))
+	assert.Equal(t, ID("bar"+string(templateID[len("foo"):])), BuildID("bar", instance, initConfig))
+
+	unregister()
+	assert.NotEqual(t, templateID, BuildID("foo", instance, initConfig))
+}
+
+func TestRegisterID(t *testing.T) {
+	template := ConfigData("password: ENC[db_password]")
+	instance := ConfigData("password: secret")
+	initConfig := ConfigData("key: value")
+	templateID := BuildID("foo", template, initConfig)
+
+	unregister, err := RegisterID(instance, initConfig, templateID)
+	require.NoError(t, err)
+	assert.Equal(t, templateID, BuildID("foo", instance, initConfig))
+
+	unregister()
+	assert.NotEqual(t, templateID, BuildID("foo", instance, initConfig))
+
+	_, err = RegisterID(instance, initConfig, ID("foo"))
+	assert.Error(t, err)
+}
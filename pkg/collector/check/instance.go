@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package check
+
+import (
+	"fmt"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cron"
+)
+
+// TimeoutCheck is implemented by checks whose instances can set their own
+// execution timeout. A zero timeout means the agent-wide `check_timeout`
+// applies.
+type TimeoutCheck interface {
+	Timeout() time.Duration
+}
+
+// JitterCheck is implemented by checks whose instances can delay each of
+// their runs by a random duration up to Jitter(), on top of the spreading
+// done by the scheduler
+type JitterCheck interface {
+	Jitter() time.Duration
+}
+
+// ScheduleCheck is implemented by checks whose instances can be run at the
+// times given by a cron expression instead of every Interval(), see the
+// pkg/util/cron package for the supported syntax
+type ScheduleCheck interface {
+	Schedule() string
+}
+
+// TimeoutError is the error reported for a check run exceeding its timeout
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("check run timed out after %v", e.Timeout)
+}
+
+// GetInstanceTimeout returns the `check_timeout` option of an instance
+// configuration, in seconds, or 0 if the instance doesn't set one. The
+// `timeout` option isn't used, many checks read it as the timeout of their
+// requests.
+func GetInstanceTimeout(instance ConfigData) (time.Duration, error) {
+	return getInstanceDuration(instance, "check_timeout")
+}
+
+// GetInstanceJitter returns the `jitter` option of an instance configuration,
+// in seconds, or 0 if the instance doesn't set one
+func GetInstanceJitter(instance ConfigData) (time.Duration, error) {
+	return getInstanceDuration(instance, "jitter")
+}
+
+// GetInstanceSchedule returns the `schedule` option of an instance
+// configuration, an empty string if the instance doesn't set one
+func GetInstanceSchedule(instance ConfigData) (string, error) {
+	rawInstance := ConfigRawMap{}
+	if err := yaml.Unmarshal(instance, &rawInstance); err != nil {
+		return "", err
+	}
+
+	x, ok := rawInstance["schedule"]
+	if !ok {
+		return "", nil
+	}
+	schedule, ok := x.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid schedule %v, expected a cron expression", x)
+	}
+	if _, err := cron.Parse(schedule); err != nil {
+		return "", err
+	}
+	return schedule, nil
+}
+
+// getInstanceDuration reads a positive number of seconds from an instance
+// configuration
+func getInstanceDuration(instance ConfigData, option string) (time.Duration, error) {
+	rawInstance := ConfigRawMap{}
+	if err := yaml.Unmarshal(instance, &rawInstance); err != nil {
+		return 0, err
+	}
+
+	x, ok := rawInstance[option]
+	if !ok {
+		return 0, nil
+	}
+	switch value := x.(type) {
+	case int:
+		if value >= 0 {
+			return time.Duration(value) * time.Second, nil
+		}
+	case float64:
+		if value >= 0 {
+			return time.Duration(value * float64(time.Second)), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid %s %v, expected a positive number of seconds", option, x)
+}
@@ -35,6 +35,7 @@ type Collector struct {
 func NewCollector(paths ...string) *Collector {
 	run := runner.NewRunner()
 	sched := scheduler.NewScheduler(run.GetChan())
+	sched.SkipChecks(run.ShouldSkip)
 	sched.Run()
 
 	c := &Collector{
@@ -175,6 +176,12 @@ func (c *Collector) StopCheck(id check.ID) error {
 	return nil
 }
 
+// GetSchedules returns the next run of the checks scheduled with a cron
+// expression
+func (c *Collector) GetSchedules() map[check.ID]scheduler.ScheduleStats {
+	return c.scheduler.GetSchedules()
+}
+
 // check if the check is on the list
 func (c *Collector) find(id check.ID) bool {
 	c.m.RLock()
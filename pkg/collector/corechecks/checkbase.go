@@ -30,6 +30,9 @@ import (
 type CheckBase struct {
 	checkName      string
 	checkID        check.ID
+	timeout        time.Duration
+	jitter         time.Duration
+	schedule       string
 	latestWarnings []error
 }
 
@@ -73,6 +76,39 @@ func (c *CheckBase) Interval() time.Duration {
 	return check.DefaultCheckInterval
 }
 
+// Timeout returns the execution timeout set by the `check_timeout` option of the
+// instance, 0 if the agent-wide `check_timeout` applies.
+func (c *CheckBase) Timeout() time.Duration {
+	return c.timeout
+}
+
+// Jitter returns the maximum random delay of each run set by the `jitter`
+// option of the instance.
+func (c *CheckBase) Jitter() time.Duration {
+	return c.jitter
+}
+
+// Schedule returns the cron expression set by the `schedule` option of the
+// instance, empty if the check runs every Interval().
+func (c *CheckBase) Schedule() string {
+	return c.schedule
+}
+
+// configureCommon is called by the loader to parse the options supported by
+// every instance, whatever the check. An invalid timeout or jitter is
+// ignored, only an invalid schedule is an error.
+func (c *CheckBase) configureCommon(instance check.ConfigData) error {
+	var err error
+	if c.timeout, err = check.GetInstanceTimeout(instance); err != nil {
+		log.Warnf("ignoring the timeout of check %s: %s", c.checkName, err)
+	}
+	if c.jitter, err = check.GetInstanceJitter(instance); err != nil {
+		log.Warnf("ignoring the jitter of check %s: %s", c.checkName, err)
+	}
+	c.schedule, err = check.GetInstanceSchedule(instance)
+	return err
+}
+
 // String returns the name of the check, the same for every instance
 func (c *CheckBase) String() string {
 	return c.checkName
@@ -20,8 +20,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/util/container"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
 )
 
@@ -61,12 +63,14 @@ func (c *DockerConfig) Parse(data []byte) error {
 	return nil
 }
 
-// DockerCheck grabs docker metrics
+// DockerCheck grabs the metrics of the containers of the runtime set by the
+// container_runtime option, and the docker ones if it's docker
 type DockerCheck struct {
 	core.CheckBase
 	instance       *DockerConfig
 	lastEventTime  time.Time
 	dockerHostname string
+	dockerRuntime  bool
 	cappedSender   *cappedSender
 }
 
@@ -91,7 +95,8 @@ func updateContainerRunningCount(images map[string]*containerPerImage, c *docker
 }
 
 func (d *DockerCheck) countAndWeightImages(sender aggregator.Sender, du *docker.DockerUtil) error {
-	if d.instance.CollectImagesStats == false {
+	// the images are not exposed by the other runtimes
+	if du == nil || d.instance.CollectImagesStats == false {
 		return nil
 	}
 
@@ -129,17 +134,28 @@ func (d *DockerCheck) Run() error {
 		return err
 	}
 
-	du, err := docker.GetDockerUtil()
+	rt, err := container.GetRuntime()
 	if err != nil {
 		sender.ServiceCheck(DockerServiceUp, metrics.ServiceCheckCritical, "", d.instance.Tags, err.Error())
 		return err
 	}
-	containers, err := du.Containers(&docker.ContainerListConfig{IncludeExited: true, FlagExcluded: true})
+	containers, err := rt.ListContainers(&docker.ContainerListConfig{IncludeExited: true, FlagExcluded: true})
 	if err != nil {
 		sender.ServiceCheck(DockerServiceUp, metrics.ServiceCheckCritical, "", d.instance.Tags, err.Error())
 		return err
 	}
 
+	// the images, container sizes, events, disk and volume statistics are
+	// only exposed by docker
+	var du *docker.DockerUtil
+	if d.dockerRuntime {
+		du, err = docker.GetDockerUtil()
+		if err != nil {
+			sender.ServiceCheck(DockerServiceUp, metrics.ServiceCheckCritical, "", d.instance.Tags, err.Error())
+			return err
+		}
+	}
+
 	images := map[string]*containerPerImage{}
 	for _, c := range containers {
 		updateContainerRunningCount(images, c)
@@ -178,6 +194,11 @@ func (d *DockerCheck) Run() error {
 			}
 		}
 
+		// a running container has at least one task, 0 means the count is unknown
+		if c.ThreadCount > 0 {
+			sender.Gauge("docker.thread.count", float64(c.ThreadCount), "", tags)
+		}
+
 		sender.Rate("docker.io.read_bytes", float64(c.IO.ReadBytes), "", tags)
 		sender.Rate("docker.io.write_bytes", float64(c.IO.WriteBytes), "", tags)
 
@@ -193,7 +214,7 @@ func (d *DockerCheck) Run() error {
 			}
 		}
 
-		if d.instance.CollectContainerSize {
+		if du != nil && d.instance.CollectContainerSize {
 			info, err := du.Inspect(c.ID, true)
 			if err != nil {
 				log.Errorf("Failed to inspect container %s - %s", c.ID[:12], err)
@@ -223,7 +244,7 @@ func (d *DockerCheck) Run() error {
 	}
 	sender.ServiceCheck(DockerServiceUp, metrics.ServiceCheckOK, "", d.instance.Tags, "")
 
-	if d.instance.CollectEvent || d.instance.CollectExitCodes {
+	if du != nil && (d.instance.CollectEvent || d.instance.CollectExitCodes) {
 		events, err := d.retrieveEvents(du)
 		if err != nil {
 			log.Warn(err.Error())
@@ -243,7 +264,7 @@ func (d *DockerCheck) Run() error {
 		}
 	}
 
-	if d.instance.CollectDiskStats {
+	if du != nil && d.instance.CollectDiskStats {
 		stats, err := du.GetStorageStats()
 		if err != nil {
 			log.Errorf("Failed to get disk stats: %s", err)
@@ -270,7 +291,7 @@ func (d *DockerCheck) Run() error {
 		}
 	}
 
-	if d.instance.CollectVolumeCount {
+	if du != nil && d.instance.CollectVolumeCount {
 		attached, dangling, err := du.CountVolumes()
 		if err != nil {
 			log.Errorf("failed to get volume stats: %s", err)
@@ -285,13 +306,18 @@ func (d *DockerCheck) Run() error {
 }
 
 // Configure parses the check configuration and init the check
-func (d *DockerCheck) Configure(config, initConfig check.ConfigData) error {
-	d.instance.Parse(config)
+func (d *DockerCheck) Configure(data, initConfig check.ConfigData) error {
+	d.instance.Parse(data)
 
 	if len(d.instance.FilteredEventType) == 0 {
 		d.instance.FilteredEventType = []string{"top", "exec_create", "exec_start"}
 	}
 
+	d.dockerRuntime = config.Datadog.GetString("container_runtime") == "docker"
+	if !d.dockerRuntime {
+		return nil
+	}
+
 	var err error
 	d.dockerHostname, err = docker.HostnameProvider("")
 	if err != nil {
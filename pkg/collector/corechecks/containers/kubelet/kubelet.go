@@ -0,0 +1,279 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubelet
+
+package kubelet
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	log "github.com/cihub/seelog"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+)
+
+const (
+	kubeletCheckName = "kubelet"
+
+	// KubeletServiceCheck is the service check reporting the health of the kubelet
+	KubeletServiceCheck = "kubernetes.kubelet.check"
+)
+
+// For testing purpose
+var tag = tagger.Tag
+
+// resourceMetrics maps the resources of the container specs to the prefix
+// of their requests and limits metrics
+var resourceMetrics = map[string]string{
+	"cpu":               "kubernetes.cpu",
+	"memory":            "kubernetes.memory",
+	"ephemeral-storage": "kubernetes.ephemeral_storage",
+}
+
+type kubeletInstanceConfig struct {
+	Tags []string `yaml:"tags"`
+}
+
+// KubeletCheck reports the resource usage, requests and limits of the pods
+// and containers running on the node, from the kubelet /pods and
+// /stats/summary endpoints
+type KubeletCheck struct {
+	core.CheckBase
+	tags []string
+}
+
+// Configure parses the check configuration
+func (c *KubeletCheck) Configure(data check.ConfigData, initConfig check.ConfigData) error {
+	var conf kubeletInstanceConfig
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+	c.tags = conf.Tags
+
+	c.BuildID(data, initConfig)
+	return nil
+}
+
+// Run executes the check
+func (c *KubeletCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	ku, err := kubelet.GetKubeUtil()
+	if err != nil {
+		sender.ServiceCheck(KubeletServiceCheck, metrics.ServiceCheckCritical, "", c.tags, err.Error())
+		sender.Commit()
+		return err
+	}
+	c.checkHealth(sender, ku)
+
+	pods, err := ku.GetLocalPodList()
+	if err != nil {
+		sender.Commit()
+		return err
+	}
+	c.submitPodMetrics(sender, pods)
+
+	summary, err := ku.GetStatsSummary()
+	if err != nil {
+		// the spec metrics are still worth sending
+		log.Warnf("could not get the kubelet stats summary: %s", err)
+	} else {
+		c.submitSummaryMetrics(sender, summary, pods)
+	}
+
+	sender.Commit()
+	return nil
+}
+
+// checkHealth sends the service check reporting whether the kubelet
+// /healthz endpoint answers "ok"
+func (c *KubeletCheck) checkHealth(sender aggregator.Sender, ku *kubelet.KubeUtil) {
+	body, code, err := ku.QueryKubelet("/healthz")
+	status := metrics.ServiceCheckOK
+	message := ""
+	switch {
+	case err != nil:
+		status = metrics.ServiceCheckCritical
+		message = err.Error()
+	case code != http.StatusOK:
+		status = metrics.ServiceCheckCritical
+		message = fmt.Sprintf("unexpected status code %d on /healthz: %s", code, strings.TrimSpace(string(body)))
+	case strings.TrimSpace(string(body)) != "ok":
+		status = metrics.ServiceCheckCritical
+		message = fmt.Sprintf("kubelet is unhealthy: %s", strings.TrimSpace(string(body)))
+	}
+	sender.ServiceCheck(KubeletServiceCheck, status, "", c.tags, message)
+}
+
+// submitPodMetrics reports the number of running pods and containers per
+// namespace, and the restarts, requests and limits of every container
+func (c *KubeletCheck) submitPodMetrics(sender aggregator.Sender, pods []*kubelet.Pod) {
+	runningPods := map[string]int{}
+	runningContainers := map[string]int{}
+
+	for _, pod := range pods {
+		namespace := pod.Metadata.Namespace
+		if pod.Status.Phase == "Running" {
+			runningPods[namespace]++
+		}
+
+		for _, status := range pod.Status.Containers {
+			if status.State.Running != nil {
+				runningContainers[namespace]++
+			}
+			sender.Gauge("kubernetes.containers.restarts", float64(status.RestartCount), "", c.containerTags(pod, status.Name))
+		}
+
+		for _, container := range pod.Spec.Containers {
+			tags := c.containerTags(pod, container.Name)
+			c.submitResources(sender, container.Resources.Requests, "requests", tags)
+			c.submitResources(sender, container.Resources.Limits, "limits", tags)
+		}
+	}
+
+	for namespace, count := range runningPods {
+		sender.Gauge("kubernetes.pods.running", float64(count), "", append(c.copyTags(), "kube_namespace:"+namespace))
+	}
+	for namespace, count := range runningContainers {
+		sender.Gauge("kubernetes.containers.running", float64(count), "", append(c.copyTags(), "kube_namespace:"+namespace))
+	}
+}
+
+// submitResources reports the requests or limits of a container, the CPU
+// being expressed in cores and the memory and storage in bytes
+func (c *KubeletCheck) submitResources(sender aggregator.Sender, resources map[string]string, kind string, tags []string) {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prefix, found := resourceMetrics[name]
+		if !found {
+			continue
+		}
+		v, err := parseQuantity(resources[name])
+		if err != nil {
+			log.Debugf("could not parse the %s %s: %s", name, kind, err)
+			continue
+		}
+		sender.Gauge(prefix+"."+kind, v, "", tags)
+	}
+}
+
+// submitSummaryMetrics reports the network and storage usage of the pods
+// and the CPU, memory and filesystem usage of their containers
+func (c *KubeletCheck) submitSummaryMetrics(sender aggregator.Sender, summary *kubelet.Summary, pods []*kubelet.Pod) {
+	podsByUID := make(map[string]*kubelet.Pod, len(pods))
+	for _, pod := range pods {
+		podsByUID[pod.Metadata.UID] = pod
+	}
+
+	for _, podStats := range summary.Pods {
+		pod, found := podsByUID[podStats.PodRef.UID]
+		if !found {
+			// the pod is not known yet or has been deleted since the pod list
+			pod = &kubelet.Pod{Metadata: kubelet.PodMetadata{
+				Name:      podStats.PodRef.Name,
+				Namespace: podStats.PodRef.Namespace,
+				UID:       podStats.PodRef.UID,
+			}}
+		}
+
+		podTags := c.podTags(pod)
+		if network := podStats.Network; network != nil {
+			submitUint(sender.Rate, "kubernetes.network.rx_bytes", network.RxBytes, podTags)
+			submitUint(sender.Rate, "kubernetes.network.tx_bytes", network.TxBytes, podTags)
+			submitUint(sender.Rate, "kubernetes.network.rx_errors", network.RxErrors, podTags)
+			submitUint(sender.Rate, "kubernetes.network.tx_errors", network.TxErrors, podTags)
+		}
+		if storage := podStats.EphemeralStorage; storage != nil {
+			submitUint(sender.Gauge, "kubernetes.ephemeral_storage.usage", storage.UsedBytes, podTags)
+		}
+
+		for _, containerStats := range podStats.Containers {
+			tags := c.containerTags(pod, containerStats.Name)
+			if cpu := containerStats.CPU; cpu != nil {
+				submitUint(sender.Gauge, "kubernetes.cpu.usage.total", cpu.UsageNanoCores, tags)
+			}
+			if mem := containerStats.Memory; mem != nil {
+				submitUint(sender.Gauge, "kubernetes.memory.usage", mem.UsageBytes, tags)
+				submitUint(sender.Gauge, "kubernetes.memory.working_set", mem.WorkingSetBytes, tags)
+				submitUint(sender.Gauge, "kubernetes.memory.rss", mem.RSSBytes, tags)
+			}
+			if fs := containerStats.Rootfs; fs != nil {
+				submitUint(sender.Gauge, "kubernetes.filesystem.usage", fs.UsedBytes, tags)
+				if fs.UsedBytes != nil && fs.CapacityBytes != nil && *fs.CapacityBytes > 0 {
+					sender.Gauge("kubernetes.filesystem.usage_pct", float64(*fs.UsedBytes)/float64(*fs.CapacityBytes), "", tags)
+				}
+			}
+		}
+	}
+}
+
+// submitUint sends the value with the submission function when it is
+// reported by the kubelet
+func submitUint(submit func(string, float64, string, []string), metric string, value *uint64, tags []string) {
+	if value == nil {
+		return
+	}
+	submit(metric, float64(*value), "", tags)
+}
+
+// podTags returns the tags of the pod level metrics. The tagger only knows
+// about containers, so they are limited to the pod name and namespace.
+func (c *KubeletCheck) podTags(pod *kubelet.Pod) []string {
+	return append(c.copyTags(), "pod_name:"+pod.Metadata.Name, "kube_namespace:"+pod.Metadata.Namespace)
+}
+
+// containerTags returns the tagger tags of a container of the pod, falling
+// back to the pod tags when it has no ID yet or the tagger knows nothing
+// about it
+func (c *KubeletCheck) containerTags(pod *kubelet.Pod, name string) []string {
+	for _, status := range pod.Status.Containers {
+		if status.Name != name || status.ID == "" {
+			continue
+		}
+		entityTags, err := tag(status.ID, true)
+		if err != nil {
+			log.Debugf("could not collect tags for container %s: %s", status.ID, err)
+			break
+		}
+		if len(entityTags) == 0 {
+			break
+		}
+		return append(c.copyTags(), entityTags...)
+	}
+	return append(c.podTags(pod), "kube_container_name:"+name)
+}
+
+// copyTags returns a copy of the instance tags, safe to append to
+func (c *KubeletCheck) copyTags() []string {
+	return append([]string{}, c.tags...)
+}
+
+func kubeletFactory() check.Check {
+	return &KubeletCheck{
+		CheckBase: core.NewCheckBase(kubeletCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(kubeletCheckName, kubeletFactory)
+}
@@ -0,0 +1,159 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubelet
+
+package kubelet
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+)
+
+const redisContainerID = "docker://3e13513f94b41d23429804243820438fb9a214238bf2d4f384741a48b575670a"
+
+// dummyKubelet serves the fixtures of the testdata folder
+type dummyKubelet struct {
+	health string
+}
+
+func (d *dummyKubelet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/", "/healthz":
+		w.Write([]byte(d.health))
+	case "/pods":
+		serveFixture(w, "pods.json")
+	case "/stats/summary":
+		serveFixture(w, "summary.json")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func serveFixture(w http.ResponseWriter, name string) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
+func runKubeletCheck(t *testing.T, health string) *mocksender.MockSender {
+	ts := httptest.NewServer(&dummyKubelet{health: health})
+	defer ts.Close()
+	kubeletURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(kubeletURL.Port())
+	require.NoError(t, err)
+
+	kubelet.ResetGlobalKubeUtil()
+	config.Datadog.Set("kubernetes_kubelet_host", "127.0.0.1")
+	config.Datadog.Set("kubernetes_http_kubelet_port", port)
+	config.Datadog.Set("kubernetes_https_kubelet_port", port)
+	config.Datadog.Set("kubelet_tls_verify", false)
+	config.Datadog.Set("kubelet_auth_token_path", "")
+	defer kubelet.ResetGlobalKubeUtil()
+
+	defer func(tagFunc func(string, bool) ([]string, error)) { tag = tagFunc }(tag)
+	tag = func(entity string, highCard bool) ([]string, error) {
+		if entity == redisContainerID {
+			return []string{"pod_name:redis-75586d7d7c-jrm7j", "kube_namespace:default", "kube_container_name:redis"}, nil
+		}
+		return nil, nil
+	}
+
+	kubeletCheck := kubeletFactory().(*KubeletCheck)
+	require.NoError(t, kubeletCheck.Configure([]byte("tags: [\"cluster:test\"]"), nil))
+
+	mockSender := mocksender.NewMockSender(kubeletCheck.ID())
+	mockSender.SetupAcceptAll()
+	require.NoError(t, kubeletCheck.Run())
+	return mockSender
+}
+
+func TestKubeletCheck(t *testing.T) {
+	mockSender := runKubeletCheck(t, "ok")
+
+	mockSender.AssertServiceCheck(t, KubeletServiceCheck, metrics.ServiceCheckOK, "", []string{"cluster:test"}, "")
+
+	redisTags := []string{"cluster:test", "pod_name:redis-75586d7d7c-jrm7j", "kube_namespace:default", "kube_container_name:redis"}
+	// resources of the pod list
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.pods.running", 1, "", []string{"cluster:test", "kube_namespace:default"})
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.containers.running", 1, "", []string{"cluster:test", "kube_namespace:default"})
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.containers.restarts", 3, "", redisTags)
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.cpu.requests", 0.1, "", redisTags)
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.cpu.limits", 1, "", redisTags)
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.memory.requests", 64*1024*1024, "", redisTags)
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.memory.limits", 128*1024*1024, "", redisTags)
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.ephemeral_storage.limits", 2*1024*1024*1024, "", redisTags)
+
+	// the pending container has no ID, so no tagger tags
+	workerTags := []string{"cluster:test", "pod_name:batch-job-xk2lp", "kube_namespace:jobs", "kube_container_name:worker"}
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.cpu.requests", 0.25, "", workerTags)
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.containers.restarts", 0, "", workerTags)
+
+	// usage of the stats summary
+	podTags := []string{"cluster:test", "pod_name:redis-75586d7d7c-jrm7j", "kube_namespace:default"}
+	mockSender.AssertMetric(t, "Rate", "kubernetes.network.rx_bytes", 1261549, "", podTags)
+	mockSender.AssertMetric(t, "Rate", "kubernetes.network.tx_bytes", 498211, "", podTags)
+	mockSender.AssertMetric(t, "Rate", "kubernetes.network.tx_errors", 2, "", podTags)
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.ephemeral_storage.usage", 69632, "", podTags)
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.cpu.usage.total", 2431876, "", redisTags)
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.memory.usage", 12693504, "", redisTags)
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.memory.working_set", 8339456, "", redisTags)
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.memory.rss", 7413760, "", redisTags)
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.filesystem.usage", 40960, "", redisTags)
+	mockSender.AssertMetricInRange(t, "Gauge", "kubernetes.filesystem.usage_pct", 4.04e-7, 4.05e-7, "", redisTags)
+	mockSender.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestKubeletCheckUnhealthy(t *testing.T) {
+	mockSender := runKubeletCheck(t, "[-]syncloop failed")
+
+	mockSender.AssertServiceCheck(t, KubeletServiceCheck, metrics.ServiceCheckCritical, "", []string{"cluster:test"}, "kubelet is unhealthy: [-]syncloop failed")
+	// the metrics are still collected
+	mockSender.AssertMetric(t, "Gauge", "kubernetes.pods.running", 1, "", []string{"cluster:test", "kube_namespace:default"})
+}
+
+func TestParseQuantity(t *testing.T) {
+	for _, tc := range []struct {
+		quantity string
+		expected float64
+	}{
+		{"2", 2},
+		{"0.5", 0.5},
+		{"100m", 0.1},
+		{"1500u", 0.0015},
+		{"1k", 1000},
+		{"1.5Gi", 1.5 * 1024 * 1024 * 1024},
+		{"64Mi", 64 * 1024 * 1024},
+		{"1M", 1e6},
+		{"2E", 2e18},
+		{"1e3", 1000},
+	} {
+		v, err := parseQuantity(tc.quantity)
+		assert.NoError(t, err, tc.quantity)
+		assert.InDelta(t, tc.expected, v, tc.expected*1e-9, tc.quantity)
+	}
+
+	for _, quantity := range []string{"", "abc", "12Qi", "Mi"} {
+		_, err := parseQuantity(quantity)
+		assert.Error(t, err, quantity)
+	}
+}
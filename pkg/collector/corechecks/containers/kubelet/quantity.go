@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubelet
+
+package kubelet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	binarySuffixes = map[string]float64{
+		"Ki": 1 << 10,
+		"Mi": 1 << 20,
+		"Gi": 1 << 30,
+		"Ti": 1 << 40,
+		"Pi": 1 << 50,
+		"Ei": 1 << 60,
+	}
+
+	decimalSuffixes = map[string]float64{
+		"n": 1e-9,
+		"u": 1e-6,
+		"m": 1e-3,
+		"k": 1e3,
+		"M": 1e6,
+		"G": 1e9,
+		"T": 1e12,
+		"P": 1e15,
+		"E": 1e18,
+	}
+)
+
+// parseQuantity converts a kubernetes resource quantity, such as "100m",
+// "1.5Gi" or "2e3", to a float
+func parseQuantity(quantity string) (float64, error) {
+	quantity = strings.TrimSpace(quantity)
+	if v, err := strconv.ParseFloat(quantity, 64); err == nil {
+		return v, nil
+	}
+
+	number, multiplier := quantity, 1.0
+	if len(quantity) > 2 {
+		if m, found := binarySuffixes[quantity[len(quantity)-2:]]; found {
+			number, multiplier = quantity[:len(quantity)-2], m
+		}
+	}
+	if multiplier == 1 && len(quantity) > 1 {
+		if m, found := decimalSuffixes[quantity[len(quantity)-1:]]; found {
+			number, multiplier = quantity[:len(quantity)-1], m
+		}
+	}
+
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q", quantity)
+	}
+	return v * multiplier, nil
+}
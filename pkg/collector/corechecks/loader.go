@@ -34,6 +34,11 @@ func GetCheckFactory(name string) CheckFactory {
 	return f
 }
 
+// commonConfigurer is implemented by the checks embedding CheckBase
+type commonConfigurer interface {
+	configureCommon(instance check.ConfigData) error
+}
+
 // GoCheckLoader is a specific loader for checks living in this package
 type GoCheckLoader struct{}
 
@@ -60,7 +65,11 @@ func (gl *GoCheckLoader) Load(config check.Config) ([]check.Check, error) {
 	errors := []string{}
 	for _, instance := range config.Instances {
 		newCheck := factory()
-		if err := newCheck.Configure(instance, config.InitConfig); err != nil {
+		err := newCheck.Configure(instance, config.InitConfig)
+		if c, ok := newCheck.(commonConfigurer); ok && err == nil {
+			err = c.configureCommon(instance)
+		}
+		if err != nil {
 			errors = append(errors, fmt.Sprintf("Could not configure check %s: %s", newCheck, err))
 			log.Errorf("core.loader: could not configure check %s: %s", newCheck, err)
 			continue
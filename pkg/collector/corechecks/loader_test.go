@@ -109,3 +109,39 @@ func TestLoad(t *testing.T) {
 		t.Fatalf("Expected 0 checks, found: %d", len(lst))
 	}
 }
+
+type BaseCheck struct {
+	CheckBase
+}
+
+func (c *BaseCheck) Run() error                                                       { return nil }
+func (c *BaseCheck) Configure(data check.ConfigData, initData check.ConfigData) error { return nil }
+
+func TestLoadCommonOptions(t *testing.T) {
+	RegisterCheck("base", func() check.Check { return &BaseCheck{NewCheckBase("base")} })
+	l, _ := NewGoCheckLoader()
+
+	i := []check.ConfigData{
+		check.ConfigData("check_timeout: 30\njitter: 5\nschedule: \"0 3 * * *\""),
+		check.ConfigData("check_timeout: soon"),
+		check.ConfigData("schedule: \"0 25 * * *\""),
+	}
+	lst, err := l.Load(check.Config{Name: "base", Instances: i})
+	if err == nil {
+		t.Fatalf("Expected error, found: nil")
+	}
+	if len(lst) != 2 {
+		t.Fatalf("Expected 2 checks, found: %d", len(lst))
+	}
+
+	c := lst[0].(*BaseCheck)
+	if c.Timeout() != 30*time.Second || c.Jitter() != 5*time.Second || c.Schedule() != "0 3 * * *" {
+		t.Fatalf("Unexpected options: timeout %v, jitter %v, schedule %q", c.Timeout(), c.Jitter(), c.Schedule())
+	}
+
+	// an invalid timeout is ignored
+	c = lst[1].(*BaseCheck)
+	if c.Timeout() != 0 {
+		t.Fatalf("Expected no timeout, found: %v", c.Timeout())
+	}
+}
@@ -0,0 +1,229 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+const (
+	dnsCheckName = "dns_check"
+
+	defaultDNSTimeout    = 5
+	defaultDNSRecordType = "A"
+)
+
+type dnsInstanceConfig struct {
+	Name           string   `yaml:"name"`
+	Hostname       string   `yaml:"hostname"`
+	Nameserver     string   `yaml:"nameserver"`
+	NameserverPort int      `yaml:"nameserver_port"`
+	RecordType     string   `yaml:"record_type"`
+	ResolvesAs     []string `yaml:"resolves_as"`
+	Timeout        int      `yaml:"timeout"`
+	Tags           []string `yaml:"tags"`
+}
+
+// DNSCheck resolves a hostname, with the system resolver or a given
+// nameserver, and reports the resolution time and whether the answers are
+// the expected ones
+type DNSCheck struct {
+	core.CheckBase
+	hostname   string
+	recordType string
+	resolvesAs []string
+	resolver   *net.Resolver
+	timeout    time.Duration
+	tags       []string
+}
+
+// Configure parses the check configuration
+func (c *DNSCheck) Configure(data check.ConfigData, initConfig check.ConfigData) error {
+	var conf dnsInstanceConfig
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+	if conf.Hostname == "" {
+		return fmt.Errorf("the hostname of the instance is missing")
+	}
+
+	c.hostname = conf.Hostname
+	c.recordType = strings.ToUpper(conf.RecordType)
+	if c.recordType == "" {
+		c.recordType = defaultDNSRecordType
+	}
+	switch c.recordType {
+	case "A", "AAAA", "CNAME", "MX", "NS", "TXT":
+	default:
+		return fmt.Errorf("unsupported record_type %s", conf.RecordType)
+	}
+
+	c.resolvesAs = make([]string, 0, len(conf.ResolvesAs))
+	for _, answer := range conf.ResolvesAs {
+		c.resolvesAs = append(c.resolvesAs, normalizeDNSAnswer(answer))
+	}
+	sort.Strings(c.resolvesAs)
+
+	timeout := conf.Timeout
+	if timeout == 0 {
+		timeout = defaultDNSTimeout
+	}
+	c.timeout = time.Duration(timeout) * time.Second
+
+	name := conf.Name
+	if name == "" {
+		name = c.hostname
+	}
+	c.tags = append(conf.Tags,
+		"instance:"+name,
+		"resolved_hostname:"+c.hostname,
+		"record_type:"+c.recordType,
+	)
+
+	c.resolver = net.DefaultResolver
+	if conf.Nameserver != "" {
+		port := conf.NameserverPort
+		if port == 0 {
+			port = 53
+		}
+		nameserver := net.JoinHostPort(conf.Nameserver, strconv.Itoa(port))
+		c.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, nameserver)
+			},
+		}
+		c.tags = append(c.tags, "nameserver:"+conf.Nameserver)
+	}
+
+	c.BuildID(data, initConfig)
+	return nil
+}
+
+// Run executes the check
+func (c *DNSCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	answers, err := c.lookup()
+	if err != nil {
+		sender.ServiceCheck("dns.can_resolve", metrics.ServiceCheckCritical, "", c.tags, err.Error())
+	} else {
+		sender.Gauge("dns.response_time", time.Since(start).Seconds(), "", c.tags)
+		if len(c.resolvesAs) > 0 && !equalAnswers(answers, c.resolvesAs) {
+			sender.ServiceCheck("dns.can_resolve", metrics.ServiceCheckCritical, "", c.tags,
+				fmt.Sprintf("%s resolved as %s, expected %s", c.hostname, strings.Join(answers, ","), strings.Join(c.resolvesAs, ",")))
+		} else {
+			sender.ServiceCheck("dns.can_resolve", metrics.ServiceCheckOK, "", c.tags, "")
+		}
+	}
+
+	sender.Commit()
+	return nil
+}
+
+// lookup returns the sorted answers of the records of the hostname
+func (c *DNSCheck) lookup() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	var answers []string
+	switch c.recordType {
+	case "A", "AAAA":
+		addrs, err := c.resolver.LookupIPAddr(ctx, c.hostname)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			if (addr.IP.To4() != nil) == (c.recordType == "A") {
+				answers = append(answers, addr.IP.String())
+			}
+		}
+	case "CNAME":
+		cname, err := c.resolver.LookupCNAME(ctx, c.hostname)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, cname)
+	case "MX":
+		mxs, err := c.resolver.LookupMX(ctx, c.hostname)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			answers = append(answers, mx.Host)
+		}
+	case "NS":
+		nss, err := c.resolver.LookupNS(ctx, c.hostname)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			answers = append(answers, ns.Host)
+		}
+	case "TXT":
+		txts, err := c.resolver.LookupTXT(ctx, c.hostname)
+		if err != nil {
+			return nil, err
+		}
+		answers = txts
+	}
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("no %s record found for %s", c.recordType, c.hostname)
+	}
+
+	for i, answer := range answers {
+		answers[i] = normalizeDNSAnswer(answer)
+	}
+	sort.Strings(answers)
+	return answers, nil
+}
+
+// normalizeDNSAnswer trims the root label of the domain names so that the
+// answers can be compared with the configured ones
+func normalizeDNSAnswer(answer string) string {
+	return strings.TrimSuffix(strings.TrimSpace(answer), ".")
+}
+
+// equalAnswers returns whether two sorted lists of answers are the same
+func equalAnswers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func dnsFactory() check.Check {
+	return &DNSCheck{
+		CheckBase: core.NewCheckBase(dnsCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(dnsCheckName, dnsFactory)
+}
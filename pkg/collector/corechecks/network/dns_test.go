@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package network
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// startDNSServer answers the A and CNAME queries of www.example.com and the
+// MX queries of example.com, every other name being unknown
+func startDNSServer(t *testing.T) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) != 1 {
+				continue
+			}
+			q := query.Questions[0]
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true},
+				Questions: query.Questions,
+			}
+			header := dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: dnsmessage.ClassINET, TTL: 60}
+			switch {
+			case q.Name.String() == "www.example.com." && q.Type == dnsmessage.TypeA:
+				for _, ip := range [][4]byte{{192, 0, 2, 1}, {192, 0, 2, 2}} {
+					resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.AResource{A: ip}})
+				}
+			case q.Name.String() == "alias.example.com." && q.Type == dnsmessage.TypeCNAME:
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.CNAMEResource{
+					CNAME: dnsmessage.MustNewName("www.example.com."),
+				}})
+			case q.Name.String() == "example.com." && q.Type == dnsmessage.TypeMX:
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.MXResource{
+					Pref: 10,
+					MX:   dnsmessage.MustNewName("mail.example.com."),
+				}})
+			case strings.HasSuffix(q.Name.String(), "example.com."):
+				// known name without record of this type
+			default:
+				resp.RCode = dnsmessage.RCodeNameError
+			}
+			packed, err := resp.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteToUDP(packed, addr)
+		}
+	}()
+	return conn
+}
+
+func runDNSCheck(t *testing.T, config string) *mocksender.MockSender {
+	dnsCheck := dnsFactory()
+	require.NoError(t, dnsCheck.Configure([]byte(config), nil))
+
+	mock := mocksender.NewMockSender(dnsCheck.ID())
+	mock.SetupAcceptAll()
+	require.NoError(t, dnsCheck.Run())
+	mock.AssertNumberOfCalls(t, "Commit", 1)
+	return mock
+}
+
+func TestDNSCheck(t *testing.T) {
+	conn := startDNSServer(t)
+	defer conn.Close()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	tags := []string{
+		"role:web",
+		"instance:www",
+		"resolved_hostname:www.example.com.",
+		"record_type:A",
+		"nameserver:127.0.0.1",
+	}
+	mock := runDNSCheck(t, fmt.Sprintf(`
+name: www
+hostname: www.example.com.
+nameserver: 127.0.0.1
+nameserver_port: %d
+resolves_as:
+  - 192.0.2.2
+  - 192.0.2.1
+tags:
+  - role:web
+`, port))
+	mock.AssertServiceCheck(t, "dns.can_resolve", metrics.ServiceCheckOK, "", tags, "")
+	mock.AssertMetricInRange(t, "Gauge", "dns.response_time", 0, 5, "", tags)
+
+	mock = runDNSCheck(t, fmt.Sprintf(`
+hostname: www.example.com.
+nameserver: 127.0.0.1
+nameserver_port: %d
+resolves_as: [192.0.2.1]
+`, port))
+	mock.AssertServiceCheck(t, "dns.can_resolve", metrics.ServiceCheckCritical, "", nil,
+		"www.example.com. resolved as 192.0.2.1,192.0.2.2, expected 192.0.2.1")
+
+	mock = runDNSCheck(t, fmt.Sprintf(`
+hostname: alias.example.com.
+record_type: cname
+nameserver: 127.0.0.1
+nameserver_port: %d
+resolves_as: [www.example.com.]
+`, port))
+	mock.AssertServiceCheck(t, "dns.can_resolve", metrics.ServiceCheckOK, "", []string{"record_type:CNAME"}, "")
+
+	mock = runDNSCheck(t, fmt.Sprintf(`
+hostname: example.com.
+record_type: MX
+nameserver: 127.0.0.1
+nameserver_port: %d
+resolves_as: [mail.example.com]
+`, port))
+	mock.AssertServiceCheck(t, "dns.can_resolve", metrics.ServiceCheckOK, "", nil, "")
+
+	// the name exists but has no AAAA record
+	mock = runDNSCheck(t, fmt.Sprintf(`
+hostname: www.example.com.
+record_type: AAAA
+nameserver: 127.0.0.1
+nameserver_port: %d
+`, port))
+	mock.AssertServiceCheck(t, "dns.can_resolve", metrics.ServiceCheckCritical, "", nil, "no AAAA record found for www.example.com.")
+	mock.AssertNumberOfCalls(t, "Gauge", 0)
+
+	mock = runDNSCheck(t, fmt.Sprintf(`
+hostname: unknown.test.
+nameserver: 127.0.0.1
+nameserver_port: %d
+timeout: 1
+`, port))
+	mock.AssertCalled(t, "ServiceCheck", "dns.can_resolve", metrics.ServiceCheckCritical, "", mocksender.MatchTagsContains(nil), mockAnyMessage)
+	mock.AssertNumberOfCalls(t, "Gauge", 0)
+}
+
+func TestDNSCheckConfigure(t *testing.T) {
+	assert.Error(t, dnsFactory().Configure([]byte(`nameserver: 8.8.8.8`), nil))
+	assert.Error(t, dnsFactory().Configure([]byte(`{hostname: example.com, record_type: SOA}`), nil))
+}
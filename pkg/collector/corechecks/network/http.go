@@ -0,0 +1,243 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package network
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+const (
+	httpCheckName = "http_check"
+
+	defaultHTTPTimeout      = 10
+	defaultHTTPStatusCode   = `(1|2|3)\d\d`
+	defaultHTTPDaysWarning  = 14
+	defaultHTTPDaysCritical = 7
+
+	// maxHTTPContentLength is the size of the beginning of the response
+	// searched for the content_match pattern
+	maxHTTPContentLength = 1 << 20
+)
+
+type httpInstanceConfig struct {
+	Name                       string            `yaml:"name"`
+	URL                        string            `yaml:"url"`
+	Method                     string            `yaml:"method"`
+	Data                       string            `yaml:"data"`
+	Headers                    map[string]string `yaml:"headers"`
+	Timeout                    int               `yaml:"timeout"`
+	HTTPResponseStatusCode     string            `yaml:"http_response_status_code"`
+	ContentMatch               string            `yaml:"content_match"`
+	ReverseContentMatch        bool              `yaml:"reverse_content_match"`
+	DisableSSLValidation       bool              `yaml:"disable_ssl_validation"`
+	CheckCertificateExpiration *bool             `yaml:"check_certificate_expiration"`
+	DaysWarning                int               `yaml:"days_warning"`
+	DaysCritical               int               `yaml:"days_critical"`
+	Tags                       []string          `yaml:"tags"`
+}
+
+// HTTPCheck requests an URL and reports whether it answers with the expected
+// status code and content, its response time and the expiration of its
+// certificate
+type HTTPCheck struct {
+	core.CheckBase
+	url              string
+	method           string
+	data             string
+	headers          map[string]string
+	client           *http.Client
+	expectedStatus   string
+	statusCode       *regexp.Regexp
+	contentMatch     *regexp.Regexp
+	reverseMatch     bool
+	checkCertificate bool
+	daysWarning      int
+	daysCritical     int
+	tags             []string
+}
+
+// Configure parses the check configuration
+func (c *HTTPCheck) Configure(data check.ConfigData, initConfig check.ConfigData) error {
+	var conf httpInstanceConfig
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+	if conf.URL == "" {
+		return fmt.Errorf("the url of the instance is missing")
+	}
+	if !strings.HasPrefix(conf.URL, "http://") && !strings.HasPrefix(conf.URL, "https://") {
+		return fmt.Errorf("unsupported url %s, only http and https are supported", conf.URL)
+	}
+
+	c.url = conf.URL
+	c.method = strings.ToUpper(conf.Method)
+	if c.method == "" {
+		c.method = http.MethodGet
+	}
+	c.data = conf.Data
+	c.headers = conf.Headers
+
+	c.expectedStatus = conf.HTTPResponseStatusCode
+	if c.expectedStatus == "" {
+		c.expectedStatus = defaultHTTPStatusCode
+	}
+	var err error
+	if c.statusCode, err = regexp.Compile("^(" + c.expectedStatus + ")$"); err != nil {
+		return fmt.Errorf("invalid http_response_status_code: %s", err)
+	}
+	if conf.ContentMatch != "" {
+		if c.contentMatch, err = regexp.Compile(conf.ContentMatch); err != nil {
+			return fmt.Errorf("invalid content_match: %s", err)
+		}
+	}
+	c.reverseMatch = conf.ReverseContentMatch
+
+	c.checkCertificate = strings.HasPrefix(c.url, "https://")
+	if conf.CheckCertificateExpiration != nil {
+		c.checkCertificate = c.checkCertificate && *conf.CheckCertificateExpiration
+	}
+	c.daysWarning = conf.DaysWarning
+	if c.daysWarning == 0 {
+		c.daysWarning = defaultHTTPDaysWarning
+	}
+	c.daysCritical = conf.DaysCritical
+	if c.daysCritical == 0 {
+		c.daysCritical = defaultHTTPDaysCritical
+	}
+
+	timeout := conf.Timeout
+	if timeout == 0 {
+		timeout = defaultHTTPTimeout
+	}
+	c.client = &http.Client{
+		Timeout: time.Duration(timeout) * time.Second,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: conf.DisableSSLValidation},
+			// every run measures the time to open a new connection
+			DisableKeepAlives: true,
+		},
+	}
+
+	name := conf.Name
+	if name == "" {
+		name = c.url
+	}
+	c.tags = append(conf.Tags, "url:"+c.url, "instance:"+name)
+
+	c.BuildID(data, initConfig)
+	return nil
+}
+
+// Run executes the check
+func (c *HTTPCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	status, message, tlsState := c.request(sender)
+	sender.ServiceCheck("http.can_connect", status, "", c.tags, message)
+
+	if c.checkCertificate && tlsState != nil && len(tlsState.PeerCertificates) > 0 {
+		c.submitCertificateExpiration(sender, tlsState.PeerCertificates[0].NotAfter)
+	}
+
+	sender.Commit()
+	return nil
+}
+
+// request sends the request and checks the response, returning the status
+// of the http.can_connect service check
+func (c *HTTPCheck) request(sender aggregator.Sender) (metrics.ServiceCheckStatus, string, *tls.ConnectionState) {
+	req, err := http.NewRequest(c.method, c.url, strings.NewReader(c.data))
+	if err != nil {
+		return metrics.ServiceCheckCritical, err.Error(), nil
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	if h, found := c.headers["Host"]; found {
+		req.Host = h
+	}
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return metrics.ServiceCheckCritical, err.Error(), nil
+	}
+	defer resp.Body.Close()
+	sender.Gauge("network.http.response_time", time.Since(start).Seconds(), "", c.tags)
+
+	if !c.statusCode.MatchString(strconv.Itoa(resp.StatusCode)) {
+		return metrics.ServiceCheckCritical, fmt.Sprintf("Incorrect HTTP return code for url %s. Expected %s, got %d.", c.url, c.expectedStatus, resp.StatusCode), resp.TLS
+	}
+
+	if c.contentMatch != nil {
+		// the body is only read to match its content, up to a limit for the
+		// servers streaming endless responses
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxHTTPContentLength))
+		if err != nil {
+			return metrics.ServiceCheckCritical, err.Error(), resp.TLS
+		}
+		found := c.contentMatch.Match(body)
+		if found && c.reverseMatch {
+			return metrics.ServiceCheckCritical, fmt.Sprintf("Content %q found in response.", c.contentMatch), resp.TLS
+		}
+		if !found && !c.reverseMatch {
+			return metrics.ServiceCheckCritical, fmt.Sprintf("Content %q not found in response.", c.contentMatch), resp.TLS
+		}
+	}
+
+	return metrics.ServiceCheckOK, "", resp.TLS
+}
+
+// submitCertificateExpiration reports the number of days left before the
+// expiration of the certificate of the server
+func (c *HTTPCheck) submitCertificateExpiration(sender aggregator.Sender, notAfter time.Time) {
+	daysLeft := notAfter.Sub(time.Now()).Hours() / 24
+	sender.Gauge("http.ssl.days_left", daysLeft, "", c.tags)
+
+	status := metrics.ServiceCheckOK
+	message := fmt.Sprintf("Days left: %d", int(daysLeft))
+	switch {
+	case daysLeft < 0:
+		status = metrics.ServiceCheckCritical
+		message = fmt.Sprintf("Expired by %d days", -int(daysLeft))
+	case daysLeft < float64(c.daysCritical):
+		status = metrics.ServiceCheckCritical
+		message = fmt.Sprintf("This cert TTL is critical: only %d days before it expires", int(daysLeft))
+	case daysLeft < float64(c.daysWarning):
+		status = metrics.ServiceCheckWarning
+		message = fmt.Sprintf("This cert is almost expired, only %d days left", int(daysLeft))
+	}
+	sender.ServiceCheck("http.ssl_cert", status, "", c.tags, message)
+}
+
+func httpFactory() check.Check {
+	return &HTTPCheck{
+		CheckBase: core.NewCheckBase(httpCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(httpCheckName, httpFactory)
+}
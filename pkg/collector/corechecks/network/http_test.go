@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package network
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// mockAnyMessage matches the error messages of the service checks
+var mockAnyMessage = mock.AnythingOfType("string")
+
+func runHTTPCheck(t *testing.T, config string) *mocksender.MockSender {
+	httpCheck := httpFactory()
+	require.NoError(t, httpCheck.Configure([]byte(config), nil))
+
+	mock := mocksender.NewMockSender(httpCheck.ID())
+	mock.SetupAcceptAll()
+	require.NoError(t, httpCheck.Run())
+	mock.AssertNumberOfCalls(t, "Commit", 1)
+	return mock
+}
+
+func TestHTTPCheck(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/private" && r.Header.Get("User-Agent") != "agent":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			fmt.Fprint(w, "status: green")
+		}
+	}))
+	defer ts.Close()
+
+	tags := []string{"role:web", "url:" + ts.URL + "/private", "instance:web"}
+	mock := runHTTPCheck(t, fmt.Sprintf(`
+name: web
+url: %s/private
+headers:
+  User-Agent: agent
+content_match: "status: (green|yellow)"
+tags:
+  - role:web
+`, ts.URL))
+	mock.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckOK, "", tags, "")
+	mock.AssertMetricInRange(t, "Gauge", "network.http.response_time", 0, 5, "", tags)
+	mock.AssertNotCalled(t, "ServiceCheck", "http.ssl_cert", metrics.ServiceCheckOK, "", tags, "")
+
+	// reverse_content_match fails when the content is found
+	mock = runHTTPCheck(t, fmt.Sprintf(`
+url: %s
+content_match: green
+reverse_content_match: true
+`, ts.URL))
+	mock.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckCritical, "", []string{"instance:" + ts.URL}, `Content "green" found in response.`)
+
+	mock = runHTTPCheck(t, fmt.Sprintf(`
+url: %s
+content_match: red
+`, ts.URL))
+	mock.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckCritical, "", nil, `Content "red" not found in response.`)
+
+	mock = runHTTPCheck(t, fmt.Sprintf(`url: %s/missing`, ts.URL))
+	mock.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckCritical, "", nil,
+		fmt.Sprintf(`Incorrect HTTP return code for url %s/missing. Expected (1|2|3)\d\d, got 404.`, ts.URL))
+
+	mock = runHTTPCheck(t, fmt.Sprintf(`
+url: %s/missing
+http_response_status_code: 404
+`, ts.URL))
+	mock.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckOK, "", nil, "")
+}
+
+func TestHTTPCheckEndlessContent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunk := []byte(strings.Repeat("a", 1024))
+		for {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+	defer ts.Close()
+
+	// the content is only searched in the beginning of the response
+	mock := runHTTPCheck(t, fmt.Sprintf(`
+url: %s
+content_match: b
+`, ts.URL))
+	mock.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckCritical, "", nil, `Content "b" not found in response.`)
+
+	mock = runHTTPCheck(t, fmt.Sprintf(`url: %s`, ts.URL))
+	mock.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckOK, "", nil, "")
+}
+
+func TestHTTPCheckConnectionError(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	url := ts.URL
+	ts.Close()
+
+	mock := runHTTPCheck(t, fmt.Sprintf(`url: %s`, url))
+	mock.AssertCalled(t, "ServiceCheck", "http.can_connect", metrics.ServiceCheckCritical, "", mocksender.MatchTagsContains(nil), mockAnyMessage)
+	mock.AssertNumberOfCalls(t, "Gauge", 0)
+}
+
+func TestHTTPCheckCertificate(t *testing.T) {
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	// the certificate of the test server is self-signed
+	mock := runHTTPCheck(t, fmt.Sprintf(`url: %s`, ts.URL))
+	mock.AssertCalled(t, "ServiceCheck", "http.can_connect", metrics.ServiceCheckCritical, "", mocksender.MatchTagsContains(nil), mockAnyMessage)
+	mock.AssertNumberOfCalls(t, "ServiceCheck", 1)
+
+	mock = runHTTPCheck(t, fmt.Sprintf(`
+url: %s
+disable_ssl_validation: true
+http_response_status_code: 404
+`, ts.URL))
+	mock.AssertServiceCheck(t, "http.can_connect", metrics.ServiceCheckOK, "", nil, "")
+	mock.AssertCalled(t, "ServiceCheck", "http.ssl_cert", metrics.ServiceCheckOK, "", mocksender.MatchTagsContains(nil), mockAnyMessage)
+	mock.AssertMetricTaggedWith(t, "Gauge", "http.ssl.days_left", []string{"url:" + ts.URL})
+
+	mock = runHTTPCheck(t, fmt.Sprintf(`
+url: %s
+disable_ssl_validation: true
+days_warning: 100000
+days_critical: 1
+`, ts.URL))
+	mock.AssertCalled(t, "ServiceCheck", "http.ssl_cert", metrics.ServiceCheckWarning, "", mocksender.MatchTagsContains(nil), mockAnyMessage)
+
+	mock = runHTTPCheck(t, fmt.Sprintf(`
+url: %s
+disable_ssl_validation: true
+days_critical: 100000
+`, ts.URL))
+	mock.AssertCalled(t, "ServiceCheck", "http.ssl_cert", metrics.ServiceCheckCritical, "", mocksender.MatchTagsContains(nil), mockAnyMessage)
+
+	mock = runHTTPCheck(t, fmt.Sprintf(`
+url: %s
+disable_ssl_validation: true
+check_certificate_expiration: false
+`, ts.URL))
+	mock.AssertNumberOfCalls(t, "ServiceCheck", 1)
+}
+
+func TestHTTPCheckConfigure(t *testing.T) {
+	assert.Error(t, httpFactory().Configure([]byte(`name: missing url`), nil))
+	assert.Error(t, httpFactory().Configure([]byte(`url: ftp://example.com`), nil))
+	assert.Error(t, httpFactory().Configure([]byte(`{url: "http://example.com", content_match: "["}`), nil))
+}
@@ -0,0 +1,344 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build linux
+
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/cihub/seelog"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const networkCheckName = "network"
+
+var (
+	// netDevMetrics maps the columns of /proc/net/dev to metrics
+	netDevMetrics = map[int]string{
+		0:  "system.net.bytes_rcvd",
+		1:  "system.net.packets_in.count",
+		2:  "system.net.packets_in.error",
+		3:  "system.net.packets_in.drop",
+		8:  "system.net.bytes_sent",
+		9:  "system.net.packets_out.count",
+		10: "system.net.packets_out.error",
+		11: "system.net.packets_out.drop",
+	}
+
+	// snmpMetrics maps the counters of /proc/net/snmp to metrics
+	snmpMetrics = map[string]map[string]string{
+		"Tcp": {
+			"RetransSegs": "system.net.tcp.retrans_segs",
+			"InSegs":      "system.net.tcp.in_segs",
+			"OutSegs":     "system.net.tcp.out_segs",
+		},
+		"Udp": {
+			"InDatagrams":  "system.net.udp.in_datagrams",
+			"NoPorts":      "system.net.udp.no_ports",
+			"InErrors":     "system.net.udp.in_errors",
+			"OutDatagrams": "system.net.udp.out_datagrams",
+			"RcvbufErrors": "system.net.udp.rcv_buf_errors",
+			"SndbufErrors": "system.net.udp.snd_buf_errors",
+		},
+	}
+
+	// netstatMetrics maps the counters of /proc/net/netstat to metrics
+	netstatMetrics = map[string]map[string]string{
+		"TcpExt": {
+			"ListenOverflows": "system.net.tcp.listen_overflows",
+			"ListenDrops":     "system.net.tcp.listen_drops",
+			"TCPBacklogDrop":  "system.net.tcp.backlog_drops",
+			"TCPRetransFail":  "system.net.tcp.failed_retransmits",
+		},
+	}
+
+	// tcpStates maps the hexadecimal states of /proc/net/tcp to the
+	// suffix of the socket state metrics
+	tcpStates = map[string]string{
+		"01": "established",
+		"02": "opening",
+		"03": "opening",
+		"04": "closing",
+		"05": "closing",
+		"06": "time_wait",
+		"07": "closing",
+		"08": "closing",
+		"09": "closing",
+		"0A": "listening",
+		"0B": "closing",
+	}
+)
+
+type networkInstanceConfig struct {
+	ProcRoot               string   `yaml:"proc_root"`
+	ExcludedInterfaces     []string `yaml:"excluded_interfaces"`
+	ExcludedInterfaceRe    string   `yaml:"excluded_interface_re"`
+	IncludedInterfaceRe    string   `yaml:"included_interface_re"`
+	CollectConnectionState bool     `yaml:"collect_connection_state"`
+}
+
+// NetworkCheck reports the network interface and TCP/UDP stack metrics
+// exposed in procfs
+type NetworkCheck struct {
+	core.CheckBase
+	procRoot               string
+	excludedInterfaces     map[string]bool
+	blacklist              *regexp.Regexp
+	whitelist              *regexp.Regexp
+	collectConnectionState bool
+}
+
+// Configure parses the check configuration
+func (c *NetworkCheck) Configure(data check.ConfigData, initConfig check.ConfigData) error {
+	var conf networkInstanceConfig
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+
+	c.procRoot = conf.ProcRoot
+	if c.procRoot == "" {
+		c.procRoot = config.Datadog.GetString("procfs_path")
+	}
+	if c.procRoot == "" {
+		c.procRoot = "/proc"
+	}
+
+	c.excludedInterfaces = make(map[string]bool, len(conf.ExcludedInterfaces))
+	for _, iface := range conf.ExcludedInterfaces {
+		c.excludedInterfaces[iface] = true
+	}
+
+	var err error
+	if conf.ExcludedInterfaceRe != "" {
+		if c.blacklist, err = regexp.Compile(conf.ExcludedInterfaceRe); err != nil {
+			return fmt.Errorf("invalid excluded_interface_re: %s", err)
+		}
+	}
+	if conf.IncludedInterfaceRe != "" {
+		if c.whitelist, err = regexp.Compile(conf.IncludedInterfaceRe); err != nil {
+			return fmt.Errorf("invalid included_interface_re: %s", err)
+		}
+	}
+	c.collectConnectionState = conf.CollectConnectionState
+
+	c.BuildID(data, initConfig)
+	return nil
+}
+
+// Run executes the check
+func (c *NetworkCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	if err := c.submitNetDev(sender); err != nil {
+		return err
+	}
+	if err := c.submitCounters(sender, "snmp", snmpMetrics); err != nil {
+		log.Debugf("could not collect the snmp counters: %s", err)
+	}
+	if err := c.submitCounters(sender, "netstat", netstatMetrics); err != nil {
+		log.Debugf("could not collect the netstat counters: %s", err)
+	}
+	c.submitConntrack(sender)
+	if c.collectConnectionState {
+		c.submitConnectionStates(sender)
+	}
+
+	sender.Commit()
+	return nil
+}
+
+// isExcluded returns whether the metrics of the interface are dropped
+func (c *NetworkCheck) isExcluded(iface string) bool {
+	if c.excludedInterfaces[iface] {
+		return true
+	}
+	if c.blacklist != nil && c.blacklist.MatchString(iface) {
+		return true
+	}
+	return c.whitelist != nil && !c.whitelist.MatchString(iface)
+}
+
+// submitNetDev reports the per-interface counters of /proc/net/dev:
+//
+// Inter-|   Receive                                                |  Transmit
+//  face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+//   eth0: 1111111   2222    0    0    0     0          0         0  3333333   4444    0    0    0     0       0          0
+//
+func (c *NetworkCheck) submitNetDev(sender aggregator.Sender) error {
+	lines, err := readProcLines(filepath.Join(c.procRoot, "net", "dev"))
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		sp := strings.SplitN(line, ":", 2)
+		if len(sp) != 2 {
+			// header
+			continue
+		}
+		iface := strings.TrimSpace(sp[0])
+		if c.isExcluded(iface) {
+			continue
+		}
+		fields := strings.Fields(sp[1])
+		if len(fields) < 16 {
+			log.Debugf("unexpected format for interface %s in /proc/net/dev", iface)
+			continue
+		}
+
+		tags := []string{"device:" + iface}
+		for column, metric := range netDevMetrics {
+			v, err := strconv.ParseUint(fields[column], 10, 64)
+			if err != nil {
+				continue
+			}
+			sender.Rate(metric, float64(v), "", tags)
+		}
+	}
+	return nil
+}
+
+// submitCounters reports the counters of /proc/net/snmp or /proc/net/netstat,
+// where every protocol comes as a header line and a value line:
+//
+// Tcp: RtoAlgorithm RtoMin RtoMax MaxConn ActiveOpens PassiveOpens ...
+// Tcp: 1 200 120000 -1 120 30 ...
+//
+func (c *NetworkCheck) submitCounters(sender aggregator.Sender, file string, metrics map[string]map[string]string) error {
+	lines, err := readProcLines(filepath.Join(c.procRoot, "net", file))
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i+1 < len(lines); i += 2 {
+		names := strings.Fields(lines[i])
+		values := strings.Fields(lines[i+1])
+		if len(names) != len(values) || len(names) == 0 || names[0] != values[0] {
+			return fmt.Errorf("unexpected format for /proc/net/%s", file)
+		}
+		protoMetrics, found := metrics[strings.TrimSuffix(names[0], ":")]
+		if !found {
+			continue
+		}
+		for j := 1; j < len(names); j++ {
+			metric, found := protoMetrics[names[j]]
+			if !found {
+				continue
+			}
+			v, err := strconv.ParseInt(values[j], 10, 64)
+			if err != nil {
+				continue
+			}
+			sender.Rate(metric, float64(v), "", nil)
+		}
+	}
+	return nil
+}
+
+// submitConntrack reports the number of connections tracked by netfilter and
+// the size of its table, when the module is loaded
+func (c *NetworkCheck) submitConntrack(sender aggregator.Sender) {
+	for file, metric := range map[string]string{
+		"nf_conntrack_count": "system.net.conntrack.count",
+		"nf_conntrack_max":   "system.net.conntrack.max",
+	} {
+		lines, err := readProcLines(filepath.Join(c.procRoot, "sys", "net", "netfilter", file))
+		if err != nil || len(lines) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(strings.TrimSpace(lines[0]), 10, 64)
+		if err != nil {
+			continue
+		}
+		sender.Gauge(metric, float64(v), "", nil)
+	}
+}
+
+// submitConnectionStates reports the number of sockets in every TCP state
+// and the number of UDP sockets, for both IPv4 and IPv6
+func (c *NetworkCheck) submitConnectionStates(sender aggregator.Sender) {
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		lines, err := readProcLines(filepath.Join(c.procRoot, "net", proto))
+		if err != nil {
+			log.Debugf("could not read the %s sockets: %s", proto, err)
+			continue
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		prefix := "system.net." + proto
+		if !strings.HasSuffix(proto, "6") {
+			prefix += "4"
+		}
+
+		if strings.HasPrefix(proto, "udp") {
+			// the first line is a header
+			sender.Gauge(prefix+".connections", float64(len(lines)-1), "", nil)
+			continue
+		}
+
+		counts := map[string]int{}
+		for _, state := range tcpStates {
+			counts[state] = 0
+		}
+		for _, line := range lines[1:] {
+			// sl local_address rem_address st ...
+			fields := strings.Fields(line)
+			if len(fields) < 4 {
+				continue
+			}
+			if state, found := tcpStates[fields[3]]; found {
+				counts[state]++
+			}
+		}
+		for state, count := range counts {
+			sender.Gauge(prefix+"."+state, float64(count), "", nil)
+		}
+	}
+}
+
+// readProcLines returns the non-empty lines of a procfs file
+func readProcLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func networkFactory() check.Check {
+	return &NetworkCheck{
+		CheckBase: core.NewCheckBase(networkCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(networkCheckName, networkFactory)
+}
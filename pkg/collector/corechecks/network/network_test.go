@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build linux
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+)
+
+func TestNetworkCheck(t *testing.T) {
+	networkCheck := networkFactory()
+	err := networkCheck.Configure([]byte(`
+proc_root: testdata/proc
+excluded_interfaces:
+  - lo
+excluded_interface_re: ^docker
+collect_connection_state: true
+`), nil)
+	require.NoError(t, err)
+
+	mock := mocksender.NewMockSender(networkCheck.ID())
+	mock.SetupAcceptAll()
+
+	require.NoError(t, networkCheck.Run())
+
+	eth0 := []string{"device:eth0"}
+	mock.AssertMetric(t, "Rate", "system.net.bytes_rcvd", 1111111, "", eth0)
+	mock.AssertMetric(t, "Rate", "system.net.packets_in.count", 2222, "", eth0)
+	mock.AssertMetric(t, "Rate", "system.net.packets_in.error", 3, "", eth0)
+	mock.AssertMetric(t, "Rate", "system.net.packets_in.drop", 4, "", eth0)
+	mock.AssertMetric(t, "Rate", "system.net.bytes_sent", 3333333, "", eth0)
+	mock.AssertMetric(t, "Rate", "system.net.packets_out.count", 4444, "", eth0)
+	mock.AssertMetric(t, "Rate", "system.net.packets_out.error", 5, "", eth0)
+	mock.AssertMetric(t, "Rate", "system.net.packets_out.drop", 6, "", eth0)
+	mock.AssertNotCalled(t, "Rate", "system.net.bytes_rcvd", float64(45890), "", []string{"device:lo"})
+	mock.AssertNotCalled(t, "Rate", "system.net.bytes_rcvd", float64(555555), "", []string{"device:docker0"})
+
+	mock.AssertMetric(t, "Rate", "system.net.tcp.retrans_segs", 17, "", nil)
+	mock.AssertMetric(t, "Rate", "system.net.tcp.in_segs", 2400, "", nil)
+	mock.AssertMetric(t, "Rate", "system.net.tcp.out_segs", 2380, "", nil)
+	mock.AssertMetric(t, "Rate", "system.net.udp.in_datagrams", 56, "", nil)
+	mock.AssertMetric(t, "Rate", "system.net.udp.no_ports", 3, "", nil)
+	mock.AssertMetric(t, "Rate", "system.net.udp.in_errors", 1, "", nil)
+	mock.AssertMetric(t, "Rate", "system.net.udp.out_datagrams", 60, "", nil)
+	mock.AssertMetric(t, "Rate", "system.net.udp.rcv_buf_errors", 7, "", nil)
+	mock.AssertMetric(t, "Rate", "system.net.udp.snd_buf_errors", 8, "", nil)
+	mock.AssertMetric(t, "Rate", "system.net.tcp.listen_overflows", 9, "", nil)
+	mock.AssertMetric(t, "Rate", "system.net.tcp.listen_drops", 10, "", nil)
+	mock.AssertMetric(t, "Rate", "system.net.tcp.backlog_drops", 11, "", nil)
+	mock.AssertMetric(t, "Rate", "system.net.tcp.failed_retransmits", 12, "", nil)
+
+	mock.AssertMetric(t, "Gauge", "system.net.conntrack.count", 42, "", nil)
+	mock.AssertMetric(t, "Gauge", "system.net.conntrack.max", 65536, "", nil)
+
+	mock.AssertMetric(t, "Gauge", "system.net.tcp4.listening", 2, "", nil)
+	mock.AssertMetric(t, "Gauge", "system.net.tcp4.established", 1, "", nil)
+	mock.AssertMetric(t, "Gauge", "system.net.tcp4.time_wait", 1, "", nil)
+	mock.AssertMetric(t, "Gauge", "system.net.tcp4.opening", 0, "", nil)
+	mock.AssertMetric(t, "Gauge", "system.net.tcp4.closing", 0, "", nil)
+	mock.AssertMetric(t, "Gauge", "system.net.tcp6.listening", 1, "", nil)
+	mock.AssertMetric(t, "Gauge", "system.net.udp4.connections", 2, "", nil)
+	mock.AssertMetric(t, "Gauge", "system.net.udp6.connections", 0, "", nil)
+
+	// 8 per interface and 13 stack counters
+	mock.AssertNumberOfCalls(t, "Rate", 21)
+	mock.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestNetworkCheckInterfaceFilters(t *testing.T) {
+	networkCheck := networkFactory().(*NetworkCheck)
+	err := networkCheck.Configure([]byte(`included_interface_re: ^(eth|docker)`), nil)
+	require.NoError(t, err)
+
+	assert.True(t, networkCheck.isExcluded("lo"))
+	assert.False(t, networkCheck.isExcluded("eth0"))
+	assert.False(t, networkCheck.isExcluded("docker0"))
+
+	assert.Error(t, networkCheck.Configure([]byte(`excluded_interface_re: "["`), nil))
+}
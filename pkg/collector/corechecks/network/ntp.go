@@ -6,9 +6,15 @@
 package network
 
 import (
+	"errors"
 	"expvar"
 	"fmt"
-	"math/rand"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
 	"github.com/beevik/ntp"
@@ -22,10 +28,32 @@ import (
 
 const ntpCheckName = "ntp"
 
+// Selections of the offset of the remote servers
+const (
+	medianSelection   = "median"
+	majoritySelection = "majority"
+)
+
+// Local NTP daemons
+const (
+	chronySource = "chrony"
+	ntpdSource   = "ntpd"
+)
+
 var (
 	ntpExpVar = expvar.NewFloat("ntpOffset")
 	// for testing purpose
-	ntpQuery = ntp.Query
+	ntpQuery = ntp.QueryWithOptions
+
+	// defaultNTPHosts are the servers queried when none is configured
+	defaultNTPHosts = []string{
+		"0.datadog.pool.ntp.org",
+		"1.datadog.pool.ntp.org",
+		"2.datadog.pool.ntp.org",
+		"3.datadog.pool.ntp.org",
+	}
+
+	errNoMajority = errors.New("no majority of the ntp hosts agree on the time")
 )
 
 // NTPCheck only has sender and config
@@ -35,11 +63,15 @@ type NTPCheck struct {
 }
 
 type ntpInstanceConfig struct {
-	OffsetThreshold int    `yaml:"offset_threshold"`
-	Host            string `yaml:"host"`
-	Port            string `yaml:"port"`
-	Timeout         int    `yaml:"timeout"`
-	Version         int    `yaml:"version"`
+	OffsetThreshold int      `yaml:"offset_threshold"`
+	Host            string   `yaml:"host"`
+	Hosts           []string `yaml:"hosts"`
+	Port            string   `yaml:"port"`
+	Timeout         int      `yaml:"timeout"`
+	Version         int      `yaml:"version"`
+	Selection       string   `yaml:"selection"`
+	LocalSource     string   `yaml:"local_source"`
+	LocalAddress    string   `yaml:"local_address"`
 }
 
 type ntpInitConfig struct{}
@@ -47,6 +79,15 @@ type ntpInitConfig struct{}
 type ntpConfig struct {
 	instance ntpInstanceConfig
 	initConf ntpInitConfig
+	hosts    []string
+	port     int
+	timeout  time.Duration
+}
+
+// ntpServerResponse is the answer of one of the remote servers
+type ntpServerResponse struct {
+	host     string
+	response *ntp.Response
 }
 
 func (c *NTPCheck) String() string {
@@ -70,8 +111,12 @@ func (c *ntpConfig) parse(data []byte, initData []byte) error {
 	}
 
 	c.instance = instance
-	if c.instance.Host == "" {
-		c.instance.Host = fmt.Sprintf("%d.datadog.pool.ntp.org", rand.Intn(3))
+	c.hosts = c.instance.Hosts
+	if c.instance.Host != "" {
+		c.hosts = append([]string{c.instance.Host}, c.hosts...)
+	}
+	if len(c.hosts) == 0 {
+		c.hosts = defaultNTPHosts
 	}
 	if c.instance.Port == "" {
 		c.instance.Port = defaultPort
@@ -85,8 +130,42 @@ func (c *ntpConfig) parse(data []byte, initData []byte) error {
 	if c.instance.OffsetThreshold == 0 {
 		c.instance.OffsetThreshold = defaultOffsetThreshold
 	}
+	if c.instance.Selection == "" {
+		c.instance.Selection = medianSelection
+	}
 	c.initConf = initConf
 
+	switch c.instance.Selection {
+	case medianSelection, majoritySelection:
+	default:
+		return fmt.Errorf("unknown selection %s, expected %s or %s", c.instance.Selection, medianSelection, majoritySelection)
+	}
+
+	switch c.instance.LocalSource {
+	case "":
+	case chronySource:
+		if c.instance.LocalAddress == "" {
+			c.instance.LocalAddress = defaultChronyAddress
+		}
+	case ntpdSource:
+		if c.instance.LocalAddress == "" {
+			c.instance.LocalAddress = defaultNtpdAddress
+		}
+	default:
+		return fmt.Errorf("unknown local_source %s, expected %s or %s", c.instance.LocalSource, chronySource, ntpdSource)
+	}
+
+	// the port of the ntp service is resolved here as it may not be
+	// listed in /etc/services
+	if c.instance.Port == defaultPort {
+		c.port = 123
+	} else if port, err := strconv.Atoi(c.instance.Port); err == nil {
+		c.port = port
+	} else if c.port, err = net.LookupPort("udp", c.instance.Port); err != nil {
+		return fmt.Errorf("invalid port %s: %s", c.instance.Port, err)
+	}
+	c.timeout = time.Duration(c.instance.Timeout) * time.Second
+
 	return nil
 }
 
@@ -113,25 +192,35 @@ func (c *NTPCheck) Run() error {
 	}
 
 	var serviceCheckStatus metrics.ServiceCheckStatus
-	var clockOffset int
 	serviceCheckMessage := ""
 	offsetThreshold := c.cfg.instance.OffsetThreshold
 
-	response, err := ntpQuery(c.cfg.instance.Host, c.cfg.instance.Version)
-	if err != nil {
+	var offset float64
+	if c.cfg.instance.LocalSource != "" {
+		offset, err = c.queryLocalSource(sender)
+	} else {
+		offset, err = c.queryServers(sender)
+	}
+
+	switch {
+	case err == errNotSynchronized:
+		serviceCheckStatus = metrics.ServiceCheckCritical
+		serviceCheckMessage = fmt.Sprintf("The %s daemon is not synchronized", c.cfg.instance.LocalSource)
+	case err != nil:
 		log.Infof("There was an error querying the ntp host: %s", err)
 		serviceCheckStatus = metrics.ServiceCheckUnknown
-	} else {
-		clockOffset = int(response.ClockOffset.Seconds())
-		if clockOffset > offsetThreshold {
+		serviceCheckMessage = err.Error()
+	default:
+		clockOffset := int(offset)
+		if math.Abs(offset) > float64(offsetThreshold) {
 			serviceCheckStatus = metrics.ServiceCheckCritical
 			serviceCheckMessage = fmt.Sprintf("Offset %v secs higher than offset threshold (%v secs)", clockOffset, offsetThreshold)
 		} else {
 			serviceCheckStatus = metrics.ServiceCheckOK
 		}
 
-		sender.Gauge("ntp.offset", response.ClockOffset.Seconds(), "", nil)
-		ntpExpVar.Set(response.ClockOffset.Seconds())
+		sender.Gauge("ntp.offset", offset, "", nil)
+		ntpExpVar.Set(offset)
 	}
 
 	sender.ServiceCheck("ntp.in_sync", serviceCheckStatus, "", nil, serviceCheckMessage)
@@ -141,6 +230,138 @@ func (c *NTPCheck) Run() error {
 	return nil
 }
 
+// queryServers queries the remote servers concurrently, reports the
+// stratum, root delay and root dispersion of each of them and the jitter of
+// their offsets, and returns the offset selected among them
+func (c *NTPCheck) queryServers(sender aggregator.Sender) (float64, error) {
+	options := ntp.QueryOptions{
+		Version: c.cfg.instance.Version,
+		Port:    c.cfg.port,
+		Timeout: c.cfg.timeout,
+	}
+
+	results := make([]*ntp.Response, len(c.cfg.hosts))
+	var wg sync.WaitGroup
+	for i, host := range c.cfg.hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			response, err := ntpQuery(host, options)
+			if err == nil {
+				err = response.Validate()
+			}
+			if err != nil {
+				log.Infof("There was an error querying the ntp host %s: %s", host, err)
+				return
+			}
+			results[i] = response
+		}(i, host)
+	}
+	wg.Wait()
+
+	var responses []ntpServerResponse
+	for i, response := range results {
+		if response == nil {
+			continue
+		}
+		responses = append(responses, ntpServerResponse{host: c.cfg.hosts[i], response: response})
+
+		tags := []string{"ntp_server:" + c.cfg.hosts[i]}
+		sender.Gauge("ntp.stratum", float64(response.Stratum), "", tags)
+		sender.Gauge("ntp.root_delay", response.RootDelay.Seconds(), "", tags)
+		sender.Gauge("ntp.root_dispersion", response.RootDispersion.Seconds(), "", tags)
+	}
+	if len(responses) == 0 {
+		return 0, fmt.Errorf("none of the %d ntp hosts answered", len(c.cfg.hosts))
+	}
+
+	if c.cfg.instance.Selection == majoritySelection {
+		truechimers := selectMajority(responses)
+		if truechimers == nil {
+			return 0, errNoMajority
+		}
+		responses = truechimers
+	}
+
+	offsets := make([]float64, 0, len(responses))
+	for _, r := range responses {
+		offsets = append(offsets, r.response.ClockOffset.Seconds())
+	}
+	offset := median(offsets)
+	sender.Gauge("ntp.jitter", jitter(offsets, offset), "", nil)
+	return offset, nil
+}
+
+// selectMajority returns the servers agreeing with the majority of the
+// servers, a server agreeing with another when the intervals of their offset
+// plus or minus their root distance intersect (Marzullo's algorithm), or nil
+// if no interval is shared by more than half of them
+func selectMajority(responses []ntpServerResponse) []ntpServerResponse {
+	type edge struct {
+		offset time.Duration
+		start  int
+	}
+	edges := make([]edge, 0, 2*len(responses))
+	for _, r := range responses {
+		edges = append(edges,
+			edge{r.response.ClockOffset - r.response.RootDistance, 1},
+			edge{r.response.ClockOffset + r.response.RootDistance, -1},
+		)
+	}
+	// the intervals sharing a bound intersect, so the starts come first
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].offset == edges[j].offset {
+			return edges[i].start > edges[j].start
+		}
+		return edges[i].offset < edges[j].offset
+	})
+
+	best, count := 0, 0
+	var low, high time.Duration
+	for i, e := range edges {
+		count += e.start
+		if count > best {
+			best = count
+			low, high = e.offset, edges[i+1].offset
+		}
+	}
+	if 2*best <= len(responses) {
+		return nil
+	}
+
+	var truechimers []ntpServerResponse
+	for _, r := range responses {
+		if r.response.ClockOffset-r.response.RootDistance <= high && r.response.ClockOffset+r.response.RootDistance >= low {
+			truechimers = append(truechimers, r)
+		} else {
+			log.Debugf("Ignoring the ntp host %s, its offset of %s disagrees with the majority", r.host, r.response.ClockOffset)
+		}
+	}
+	return truechimers
+}
+
+// median returns the median of the values, the mean of the two middle ones
+// for an even number of values
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// jitter returns the root mean square of the differences between the
+// offsets and the selected one
+func jitter(offsets []float64, selected float64) float64 {
+	var sum float64
+	for _, offset := range offsets {
+		sum += (offset - selected) * (offset - selected)
+	}
+	return math.Sqrt(sum / float64(len(offsets)))
+}
+
 func ntpFactory() check.Check {
 	return &NTPCheck{
 		CheckBase: core.NewCheckBase(ntpCheckName),
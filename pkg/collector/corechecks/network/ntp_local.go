@@ -0,0 +1,327 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package network
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+)
+
+const (
+	defaultChronyAddress = "127.0.0.1:323"
+	defaultNtpdAddress   = "127.0.0.1:123"
+)
+
+// chronyd command protocol, see candm.h in the chrony sources
+const (
+	chronyProtocolVersion    = 6
+	chronyRequest            = 1
+	chronyReply              = 2
+	chronyTrackingCommand    = 33
+	chronyTrackingReply      = 5
+	chronyStatusSuccess      = 0
+	chronyReplyHeaderLength  = 28
+	chronyTrackingLength     = 76
+	chronyLeapUnsynchronised = 3
+)
+
+// ntpd mode 6 control protocol, see RFC 1305 appendix B
+const (
+	ntpControlHeaderLength = 12
+	ntpControlMode         = 6
+	ntpControlReadVars     = 2
+	ntpControlResponse     = 0x80
+	ntpControlError        = 0x40
+	ntpControlMore         = 0x20
+	ntpLeapNotInSync       = 3
+	ntpMaxStratum          = 16
+)
+
+var errNotSynchronized = errors.New("the local ntp daemon is not synchronized")
+
+// localSourceStatus is the synchronization status of the local daemon, the
+// durations being in seconds
+type localSourceStatus struct {
+	offset         float64
+	jitter         float64
+	stratum        int
+	rootDelay      float64
+	rootDispersion float64
+	synchronized   bool
+}
+
+// queryLocalSource reports the status of the local daemon and returns its
+// offset
+func (c *NTPCheck) queryLocalSource(sender aggregator.Sender) (float64, error) {
+	var status *localSourceStatus
+	var err error
+	switch c.cfg.instance.LocalSource {
+	case chronySource:
+		status, err = queryChrony(c.cfg.instance.LocalAddress, c.cfg.timeout)
+	case ntpdSource:
+		status, err = queryNtpd(c.cfg.instance.LocalAddress, c.cfg.timeout)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !status.synchronized {
+		return 0, errNotSynchronized
+	}
+
+	sender.Gauge("ntp.stratum", float64(status.stratum), "", nil)
+	sender.Gauge("ntp.root_delay", status.rootDelay, "", nil)
+	sender.Gauge("ntp.root_dispersion", status.rootDispersion, "", nil)
+	sender.Gauge("ntp.jitter", status.jitter, "", nil)
+	return status.offset, nil
+}
+
+// queryChrony sends a tracking request to chronyd, on its UDP command port
+// or on its unix socket when the address is a path
+func queryChrony(address string, timeout time.Duration) (*localSourceStatus, error) {
+	var conn net.Conn
+	var err error
+	if strings.HasPrefix(address, "/") {
+		// chronyd answers to the socket the request is sent from
+		local := filepath.Join(os.TempDir(), fmt.Sprintf("datadog-agent-chrony.%d.sock", os.Getpid()))
+		os.Remove(local)
+		defer os.Remove(local)
+		conn, err = net.DialUnix("unixgram", &net.UnixAddr{Name: local, Net: "unixgram"}, &net.UnixAddr{Name: address, Net: "unixgram"})
+		if err == nil {
+			os.Chmod(local, 0666)
+		}
+	} else {
+		conn, err = net.Dial("udp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// the request is padded to the length of the reply
+	request := make([]byte, chronyReplyHeaderLength+chronyTrackingLength)
+	request[0] = chronyProtocolVersion
+	request[1] = chronyRequest
+	binary.BigEndian.PutUint16(request[4:], chronyTrackingCommand)
+	if _, err := rand.Read(request[8:12]); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	reply := make([]byte, 512)
+	n, err := conn.Read(reply)
+	if err != nil {
+		return nil, err
+	}
+	return parseChronyTracking(reply[:n], request[8:12])
+}
+
+// parseChronyTracking parses the reply of chronyd to a tracking request
+func parseChronyTracking(reply []byte, sequence []byte) (*localSourceStatus, error) {
+	if len(reply) < chronyReplyHeaderLength {
+		return nil, fmt.Errorf("invalid chronyd reply of %d bytes", len(reply))
+	}
+	if reply[0] != chronyProtocolVersion || reply[1] != chronyReply {
+		return nil, fmt.Errorf("unsupported chronyd reply version %d", reply[0])
+	}
+	if status := binary.BigEndian.Uint16(reply[8:]); status != chronyStatusSuccess {
+		return nil, fmt.Errorf("chronyd replied with the status %d", status)
+	}
+	if binary.BigEndian.Uint16(reply[4:]) != chronyTrackingCommand || binary.BigEndian.Uint16(reply[6:]) != chronyTrackingReply {
+		return nil, errors.New("unexpected chronyd reply")
+	}
+	if string(reply[16:20]) != string(sequence) {
+		return nil, errors.New("chronyd reply mismatch")
+	}
+	if len(reply) < chronyReplyHeaderLength+chronyTrackingLength {
+		return nil, fmt.Errorf("invalid chronyd tracking reply of %d bytes", len(reply))
+	}
+
+	tracking := reply[chronyReplyHeaderLength:]
+	stratum := int(binary.BigEndian.Uint16(tracking[24:]))
+	leap := binary.BigEndian.Uint16(tracking[26:])
+	return &localSourceStatus{
+		offset:         chronyFloat(tracking[40:]),
+		jitter:         chronyFloat(tracking[48:]),
+		stratum:        stratum,
+		rootDelay:      chronyFloat(tracking[64:]),
+		rootDispersion: chronyFloat(tracking[68:]),
+		synchronized:   leap != chronyLeapUnsynchronised,
+	}, nil
+}
+
+// chronyFloat decodes the floats of chronyd: a 7-bit signed exponent
+// followed by a 25-bit signed coefficient
+func chronyFloat(b []byte) float64 {
+	x := binary.BigEndian.Uint32(b)
+	exp := int32(x >> 25)
+	if exp >= 1<<6 {
+		exp -= 1 << 7
+	}
+	exp -= 25
+	coef := int32(x % (1 << 25))
+	if coef >= 1<<24 {
+		coef -= 1 << 25
+	}
+	return float64(coef) * math.Pow(2, float64(exp))
+}
+
+// queryNtpd reads the system variables of ntpd with a mode 6 request
+func queryNtpd(address string, timeout time.Duration) (*localSourceStatus, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	request := make([]byte, ntpControlHeaderLength)
+	request[0] = 2<<3 | ntpControlMode
+	request[1] = ntpControlReadVars
+	if _, err := rand.Read(request[2:4]); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	// the variables may be split in several fragments
+	fragments := make(map[int][]byte)
+	length := -1
+	buf := make([]byte, 512)
+	for length < 0 || !ntpdFragmentsComplete(fragments, length) {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		packet := buf[:n]
+		if n < ntpControlHeaderLength || packet[0]&0x7 != ntpControlMode || packet[1]&ntpControlResponse == 0 ||
+			packet[1]&0x1f != ntpControlReadVars || packet[2] != request[2] || packet[3] != request[3] {
+			continue
+		}
+		if packet[1]&ntpControlError != 0 {
+			return nil, fmt.Errorf("ntpd replied with the error %d", packet[4])
+		}
+		offset := int(binary.BigEndian.Uint16(packet[8:]))
+		count := int(binary.BigEndian.Uint16(packet[10:]))
+		if ntpControlHeaderLength+count > n {
+			return nil, errors.New("truncated ntpd reply")
+		}
+		fragments[offset] = append([]byte(nil), packet[ntpControlHeaderLength:ntpControlHeaderLength+count]...)
+		if packet[1]&ntpControlMore == 0 {
+			length = offset + count
+		}
+	}
+
+	data, err := ntpdFragmentsData(fragments, length)
+	if err != nil {
+		return nil, err
+	}
+	return parseNtpdVariables(string(data))
+}
+
+// ntpdFragmentsData joins the fragments, rejecting the ones going beyond
+// the length given by the last fragment
+func ntpdFragmentsData(fragments map[int][]byte, length int) ([]byte, error) {
+	data := make([]byte, length)
+	for offset, fragment := range fragments {
+		if offset+len(fragment) > length {
+			return nil, fmt.Errorf("invalid ntpd reply: the fragment at offset %d goes beyond the length %d", offset, length)
+		}
+		copy(data[offset:], fragment)
+	}
+	return data, nil
+}
+
+// ntpdFragmentsComplete returns whether the fragments cover the data
+func ntpdFragmentsComplete(fragments map[int][]byte, length int) bool {
+	for offset := 0; offset < length; {
+		fragment, found := fragments[offset]
+		if !found || len(fragment) == 0 {
+			return false
+		}
+		offset += len(fragment)
+	}
+	return true
+}
+
+// parseNtpdVariables parses the comma separated system variables of ntpd:
+//
+// version="ntpd 4.2.8p10", leap=0, stratum=2, rootdelay=1.521,
+// rootdisp=22.376, offset=-0.250, sys_jitter=0.372
+//
+// whose durations are in milliseconds
+func parseNtpdVariables(data string) (*localSourceStatus, error) {
+	variables := make(map[string]string)
+	for _, field := range splitNtpdVariables(data) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) == 2 {
+			variables[strings.TrimSpace(kv[0])] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		}
+	}
+
+	leap, err := strconv.Atoi(variables["leap"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid leap variable: %s", err)
+	}
+	stratum, err := strconv.Atoi(variables["stratum"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid stratum variable: %s", err)
+	}
+	status := &localSourceStatus{
+		stratum:      stratum,
+		synchronized: leap != ntpLeapNotInSync && stratum < ntpMaxStratum,
+	}
+
+	jitter := "sys_jitter"
+	if _, found := variables[jitter]; !found {
+		// ntpd < 4.2.6
+		jitter = "jitter"
+	}
+	for name, value := range map[string]*float64{
+		"offset":    &status.offset,
+		jitter:      &status.jitter,
+		"rootdelay": &status.rootDelay,
+		"rootdisp":  &status.rootDispersion,
+	} {
+		ms, err := strconv.ParseFloat(variables[name], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s variable: %s", name, err)
+		}
+		*value = ms / 1000
+	}
+	return status, nil
+}
+
+// splitNtpdVariables splits the variables on the commas which aren't quoted
+func splitNtpdVariables(data string) []string {
+	var fields []string
+	quoted := false
+	start := 0
+	for i, r := range data {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			fields = append(fields, data[start:i])
+			start = i + 1
+		}
+	}
+	return append(fields, data[start:])
+}
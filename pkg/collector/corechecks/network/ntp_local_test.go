@@ -0,0 +1,192 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// toChronyFloat encodes a float like UTI_FloatHostToNetwork in chrony
+func toChronyFloat(x float64) uint32 {
+	if x == 0 {
+		return 0
+	}
+	exp := int32(math.Log2(math.Abs(x))) + 1
+	coef := int32(math.Abs(x)*math.Pow(2, float64(25-exp)) + 0.5)
+	for coef > 1<<24-1 {
+		coef >>= 1
+		exp++
+	}
+	if x < 0 {
+		coef = -coef
+	}
+	return uint32(exp)<<25 | uint32(coef)&(1<<25-1)
+}
+
+// startChronyServer answers the tracking requests like chronyd
+func startChronyServer(t *testing.T, leap uint16) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+
+	go func() {
+		request := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFromUDP(request)
+			if err != nil {
+				return
+			}
+			if n != chronyReplyHeaderLength+chronyTrackingLength || binary.BigEndian.Uint16(request[4:]) != chronyTrackingCommand {
+				continue
+			}
+			reply := make([]byte, chronyReplyHeaderLength+chronyTrackingLength)
+			reply[0] = chronyProtocolVersion
+			reply[1] = chronyReply
+			binary.BigEndian.PutUint16(reply[4:], chronyTrackingCommand)
+			binary.BigEndian.PutUint16(reply[6:], chronyTrackingReply)
+			copy(reply[16:20], request[8:12])
+
+			tracking := reply[chronyReplyHeaderLength:]
+			binary.BigEndian.PutUint16(tracking[24:], 3)
+			binary.BigEndian.PutUint16(tracking[26:], leap)
+			binary.BigEndian.PutUint32(tracking[40:], toChronyFloat(-0.125))
+			binary.BigEndian.PutUint32(tracking[48:], toChronyFloat(0.0009765625))
+			binary.BigEndian.PutUint32(tracking[64:], toChronyFloat(0.015625))
+			binary.BigEndian.PutUint32(tracking[68:], toChronyFloat(0.03125))
+			conn.WriteToUDP(reply, addr)
+		}
+	}()
+	return conn
+}
+
+// startNtpdServer answers the mode 6 read variables requests like ntpd, in
+// two fragments received in the reverse order
+func startNtpdServer(t *testing.T, variables string) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+
+	go func() {
+		request := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFromUDP(request)
+			if err != nil {
+				return
+			}
+			if n < ntpControlHeaderLength || request[0]&0x7 != ntpControlMode || request[1] != ntpControlReadVars {
+				continue
+			}
+			middle := len(variables) / 2
+			fragment := func(offset int, data string, more bool) []byte {
+				packet := make([]byte, ntpControlHeaderLength+len(data))
+				packet[0] = request[0]
+				packet[1] = ntpControlResponse | ntpControlReadVars
+				if more {
+					packet[1] |= ntpControlMore
+				}
+				copy(packet[2:4], request[2:4])
+				binary.BigEndian.PutUint16(packet[8:], uint16(offset))
+				binary.BigEndian.PutUint16(packet[10:], uint16(len(data)))
+				copy(packet[ntpControlHeaderLength:], data)
+				return packet
+			}
+			conn.WriteToUDP(fragment(middle, variables[middle:], false), addr)
+			conn.WriteToUDP(fragment(0, variables[:middle], true), addr)
+		}
+	}()
+	return conn
+}
+
+func runLocalNTPCheck(t *testing.T, source string, conn *net.UDPConn) *mocksender.MockSender {
+	ntpCheck := new(NTPCheck)
+	require.NoError(t, ntpCheck.Configure([]byte(fmt.Sprintf(`
+local_source: %s
+local_address: %s
+`, source, conn.LocalAddr())), nil))
+
+	mockSender := mocksender.NewMockSender(ntpCheck.ID())
+	mockSender.SetupAcceptAll()
+	ntpCheck.Run()
+	mockSender.AssertNumberOfCalls(t, "ServiceCheck", 1)
+	mockSender.AssertNumberOfCalls(t, "Commit", 1)
+	return mockSender
+}
+
+func TestNTPChrony(t *testing.T) {
+	conn := startChronyServer(t, 0)
+	defer conn.Close()
+
+	mockSender := runLocalNTPCheck(t, chronySource, conn)
+	mockSender.AssertMetric(t, "Gauge", "ntp.offset", -0.125, "", nil)
+	mockSender.AssertMetric(t, "Gauge", "ntp.jitter", 0.0009765625, "", nil)
+	mockSender.AssertMetric(t, "Gauge", "ntp.stratum", 3, "", nil)
+	mockSender.AssertMetric(t, "Gauge", "ntp.root_delay", 0.015625, "", nil)
+	mockSender.AssertMetric(t, "Gauge", "ntp.root_dispersion", 0.03125, "", nil)
+	mockSender.AssertServiceCheck(t, "ntp.in_sync", metrics.ServiceCheckOK, "", nil, "")
+
+	unsynchronized := startChronyServer(t, chronyLeapUnsynchronised)
+	defer unsynchronized.Close()
+	mockSender = runLocalNTPCheck(t, chronySource, unsynchronized)
+	mockSender.AssertServiceCheck(t, "ntp.in_sync", metrics.ServiceCheckCritical, "", nil, "The chrony daemon is not synchronized")
+	mockSender.AssertNumberOfCalls(t, "Gauge", 0)
+}
+
+func TestNTPNtpd(t *testing.T) {
+	conn := startNtpdServer(t, `version="ntpd 4.2.8p10@1.3728-o, Debian", processor="x86_64",
+leap=0, stratum=2, precision=-23, rootdelay=1.500, rootdisp=22.250,
+refid=192.0.2.1, offset=-250.000, frequency=-7.102, sys_jitter=0.375`)
+	defer conn.Close()
+
+	mockSender := runLocalNTPCheck(t, ntpdSource, conn)
+	mockSender.AssertMetric(t, "Gauge", "ntp.offset", -0.25, "", nil)
+	mockSender.AssertMetric(t, "Gauge", "ntp.jitter", 0.000375, "", nil)
+	mockSender.AssertMetric(t, "Gauge", "ntp.stratum", 2, "", nil)
+	mockSender.AssertMetric(t, "Gauge", "ntp.root_delay", 0.0015, "", nil)
+	mockSender.AssertMetric(t, "Gauge", "ntp.root_dispersion", 0.02225, "", nil)
+	mockSender.AssertServiceCheck(t, "ntp.in_sync", metrics.ServiceCheckOK, "", nil, "")
+
+	unsynchronized := startNtpdServer(t, `leap=3, stratum=16, rootdelay=0.000, rootdisp=0.000, offset=0.000, sys_jitter=0.000`)
+	defer unsynchronized.Close()
+	mockSender = runLocalNTPCheck(t, ntpdSource, unsynchronized)
+	mockSender.AssertServiceCheck(t, "ntp.in_sync", metrics.ServiceCheckCritical, "", nil, "The ntpd daemon is not synchronized")
+}
+
+func TestNtpdFragmentsData(t *testing.T) {
+	data, err := ntpdFragmentsData(map[int][]byte{4: []byte("=0"), 0: []byte("leap")}, 6)
+	require.NoError(t, err)
+	assert.Equal(t, "leap=0", string(data))
+
+	// a fragment beyond the length given by the last fragment
+	_, err = ntpdFragmentsData(map[int][]byte{0: []byte("leap=0"), 100: []byte("stratum=2")}, 6)
+	assert.Error(t, err)
+	_, err = ntpdFragmentsData(map[int][]byte{0: []byte("leap"), 4: []byte("=0, stratum=2")}, 6)
+	assert.Error(t, err)
+}
+
+func TestParseNtpdVariables(t *testing.T) {
+	// ntpd < 4.2.6 reports jitter instead of sys_jitter
+	status, err := parseNtpdVariables(`leap=1, stratum=3, rootdelay=2, rootdisp=4, offset=8, jitter=16`)
+	require.NoError(t, err)
+	assert.Equal(t, &localSourceStatus{
+		offset:         0.008,
+		jitter:         0.016,
+		stratum:        3,
+		rootDelay:      0.002,
+		rootDispersion: 0.004,
+		synchronized:   true,
+	}, status)
+
+	_, err = parseNtpdVariables(`leap=0, stratum=3`)
+	assert.Error(t, err)
+}
@@ -6,12 +6,16 @@
 package network
 
 import (
+	"encoding/binary"
 	"fmt"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/beevik/ntp"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
@@ -27,42 +31,56 @@ timeout: 5
 	offset = 10
 )
 
-func testNTPQueryError(host string, version int) (*ntp.Response, error) {
+func testNTPQueryError(host string, opt ntp.QueryOptions) (*ntp.Response, error) {
 	return nil, fmt.Errorf("test error from NTP")
 }
 
-func testNTPQuery(host string, version int) (*ntp.Response, error) {
+func testNTPQuery(host string, opt ntp.QueryOptions) (*ntp.Response, error) {
 	return &ntp.Response{
 		ClockOffset: time.Duration(offset) * time.Second,
+		Stratum:     2,
 	}, nil
 }
 
+// testNTPQueryOffsets returns a query answering with the offsets of the
+// hosts, the other hosts being unreachable
+func testNTPQueryOffsets(offsets map[string]time.Duration) func(string, ntp.QueryOptions) (*ntp.Response, error) {
+	return func(host string, opt ntp.QueryOptions) (*ntp.Response, error) {
+		offset, found := offsets[host]
+		if !found {
+			return nil, fmt.Errorf("unreachable host %s", host)
+		}
+		return &ntp.Response{
+			ClockOffset:    offset,
+			Stratum:        2,
+			RootDelay:      20 * time.Millisecond,
+			RootDispersion: 40 * time.Millisecond,
+			RootDistance:   50 * time.Millisecond,
+		}, nil
+	}
+}
+
 func TestNTPOK(t *testing.T) {
 	var ntpCfg = []byte(ntpCfgString)
 	var ntpInitCfg = []byte("")
 
 	offset = 21
 	ntpQuery = testNTPQuery
-	defer func() { ntpQuery = ntp.Query }()
+	defer func() { ntpQuery = ntp.QueryWithOptions }()
 
 	ntpCheck := new(NTPCheck)
 	ntpCheck.Configure(ntpCfg, ntpInitCfg)
 
 	mockSender := mocksender.NewMockSender(ntpCheck.ID())
-
-	mockSender.On("Gauge", "ntp.offset", float64(21), "", []string(nil)).Return().Times(1)
-	mockSender.On("ServiceCheck",
-		"ntp.in_sync",
-		metrics.ServiceCheckOK,
-		"",
-		[]string(nil),
-		"").Return().Times(1)
-
-	mockSender.On("Commit").Return().Times(1)
+	mockSender.SetupAcceptAll()
 	ntpCheck.Run()
 
-	mockSender.AssertExpectations(t)
-	mockSender.AssertNumberOfCalls(t, "Gauge", 1)
+	mockSender.AssertMetric(t, "Gauge", "ntp.offset", 21, "", nil)
+	mockSender.AssertMetric(t, "Gauge", "ntp.jitter", 0, "", nil)
+	for _, host := range defaultNTPHosts {
+		mockSender.AssertMetric(t, "Gauge", "ntp.stratum", 2, "", []string{"ntp_server:" + host})
+	}
+	mockSender.AssertServiceCheck(t, "ntp.in_sync", metrics.ServiceCheckOK, "", nil, "")
 	mockSender.AssertNumberOfCalls(t, "ServiceCheck", 1)
 	mockSender.AssertNumberOfCalls(t, "Commit", 1)
 }
@@ -73,28 +91,25 @@ func TestNTPCritical(t *testing.T) {
 
 	offset = 100
 	ntpQuery = testNTPQuery
-	defer func() { ntpQuery = ntp.Query }()
+	defer func() { ntpQuery = ntp.QueryWithOptions }()
 
 	ntpCheck := new(NTPCheck)
 	ntpCheck.Configure(ntpCfg, ntpInitCfg)
 
 	mockSender := mocksender.NewMockSender(ntpCheck.ID())
-
-	mockSender.On("Gauge", "ntp.offset", float64(100), "", []string(nil)).Return().Times(1)
-	mockSender.On("ServiceCheck",
-		"ntp.in_sync",
-		metrics.ServiceCheckCritical,
-		"",
-		[]string(nil),
-		"Offset 100 secs higher than offset threshold (60 secs)").Return().Times(1)
-
-	mockSender.On("Commit").Return().Times(1)
+	mockSender.SetupAcceptAll()
 	ntpCheck.Run()
 
-	mockSender.AssertExpectations(t)
-	mockSender.AssertNumberOfCalls(t, "Gauge", 1)
+	mockSender.AssertMetric(t, "Gauge", "ntp.offset", 100, "", nil)
+	mockSender.AssertServiceCheck(t, "ntp.in_sync", metrics.ServiceCheckCritical, "", nil, "Offset 100 secs higher than offset threshold (60 secs)")
 	mockSender.AssertNumberOfCalls(t, "ServiceCheck", 1)
 	mockSender.AssertNumberOfCalls(t, "Commit", 1)
+
+	// clocks ahead of the servers are critical too
+	offset = -100
+	mockSender.ResetCalls()
+	ntpCheck.Run()
+	mockSender.AssertServiceCheck(t, "ntp.in_sync", metrics.ServiceCheckCritical, "", nil, "Offset -100 secs higher than offset threshold (60 secs)")
 }
 
 func TestNTPError(t *testing.T) {
@@ -102,7 +117,7 @@ func TestNTPError(t *testing.T) {
 	var ntpInitCfg = []byte("")
 
 	ntpQuery = testNTPQueryError
-	defer func() { ntpQuery = ntp.Query }()
+	defer func() { ntpQuery = ntp.QueryWithOptions }()
 
 	ntpCheck := new(NTPCheck)
 	ntpCheck.Configure(ntpCfg, ntpInitCfg)
@@ -124,3 +139,172 @@ func TestNTPError(t *testing.T) {
 	mockSender.AssertNumberOfCalls(t, "ServiceCheck", 1)
 	mockSender.AssertNumberOfCalls(t, "Commit", 1)
 }
+
+func TestNTPMedianSelection(t *testing.T) {
+	// a single bad server doesn't change the offset
+	ntpQuery = testNTPQueryOffsets(map[string]time.Duration{
+		"0.pool": 1 * time.Second,
+		"1.pool": 2 * time.Second,
+		"2.pool": 500 * time.Second,
+	})
+	defer func() { ntpQuery = ntp.QueryWithOptions }()
+
+	ntpCheck := new(NTPCheck)
+	require.NoError(t, ntpCheck.Configure([]byte(`
+hosts: [0.pool, 1.pool, 2.pool, 3.pool]
+`), nil))
+
+	mockSender := mocksender.NewMockSender(ntpCheck.ID())
+	mockSender.SetupAcceptAll()
+	ntpCheck.Run()
+
+	mockSender.AssertMetric(t, "Gauge", "ntp.offset", 2, "", nil)
+	mockSender.AssertMetricInRange(t, "Gauge", "ntp.jitter", 287.5, 287.6, "", nil)
+	mockSender.AssertMetric(t, "Gauge", "ntp.root_delay", 0.02, "", []string{"ntp_server:2.pool"})
+	mockSender.AssertMetric(t, "Gauge", "ntp.root_dispersion", 0.04, "", []string{"ntp_server:2.pool"})
+	mockSender.AssertNotCalled(t, "Gauge", "ntp.stratum", mock.Anything, "", []string{"ntp_server:3.pool"})
+	mockSender.AssertServiceCheck(t, "ntp.in_sync", metrics.ServiceCheckOK, "", nil, "")
+
+	// the two middle offsets are averaged
+	ntpQuery = testNTPQueryOffsets(map[string]time.Duration{
+		"0.pool": 1 * time.Second,
+		"1.pool": 2 * time.Second,
+	})
+	mockSender.ResetCalls()
+	ntpCheck.Run()
+	mockSender.AssertMetric(t, "Gauge", "ntp.offset", 1.5, "", nil)
+}
+
+func TestNTPMajoritySelection(t *testing.T) {
+	ntpQuery = testNTPQueryOffsets(map[string]time.Duration{
+		"0.pool": 1000 * time.Millisecond,
+		"1.pool": 1080 * time.Millisecond,
+		"2.pool": 100 * time.Second,
+	})
+	defer func() { ntpQuery = ntp.QueryWithOptions }()
+
+	ntpCheck := new(NTPCheck)
+	require.NoError(t, ntpCheck.Configure([]byte(`
+hosts: [0.pool, 1.pool, 2.pool]
+selection: majority
+offset_threshold: 1
+`), nil))
+
+	mockSender := mocksender.NewMockSender(ntpCheck.ID())
+	mockSender.SetupAcceptAll()
+	ntpCheck.Run()
+
+	// the offset of 2.pool disagrees with the others
+	mockSender.AssertMetricInRange(t, "Gauge", "ntp.offset", 1.0399, 1.0401, "", nil)
+	mockSender.AssertMetricInRange(t, "Gauge", "ntp.jitter", 0.0399, 0.0401, "", nil)
+	mockSender.AssertServiceCheck(t, "ntp.in_sync", metrics.ServiceCheckCritical, "", nil, "Offset 1 secs higher than offset threshold (1 secs)")
+
+	ntpQuery = testNTPQueryOffsets(map[string]time.Duration{
+		"0.pool": 1 * time.Second,
+		"1.pool": 50 * time.Second,
+		"2.pool": 100 * time.Second,
+	})
+	mockSender.ResetCalls()
+	ntpCheck.Run()
+	mockSender.AssertServiceCheck(t, "ntp.in_sync", metrics.ServiceCheckUnknown, "", nil, errNoMajority.Error())
+	mockSender.AssertNotCalled(t, "Gauge", "ntp.offset", mock.Anything, "", mock.Anything)
+}
+
+func TestSelectMajority(t *testing.T) {
+	response := func(host string, offset, distance time.Duration) ntpServerResponse {
+		return ntpServerResponse{host: host, response: &ntp.Response{ClockOffset: offset, RootDistance: distance}}
+	}
+
+	// the intervals sharing a bound intersect
+	responses := []ntpServerResponse{
+		response("a", 0, time.Second),
+		response("b", 2*time.Second, time.Second),
+		response("c", 10*time.Second, time.Second),
+	}
+	assert.Equal(t, responses[:2], selectMajority(responses))
+
+	// half of the servers isn't a majority
+	assert.Nil(t, selectMajority(responses[1:]))
+	assert.Equal(t, responses[2:], selectMajority(responses[2:]))
+}
+
+func TestNTPConfigure(t *testing.T) {
+	cfg := new(ntpConfig)
+	require.NoError(t, cfg.parse([]byte(`{host: a.pool, hosts: [b.pool], port: "1123"}`), nil))
+	assert.Equal(t, []string{"a.pool", "b.pool"}, cfg.hosts)
+	assert.Equal(t, 1123, cfg.port)
+	assert.Equal(t, time.Second, cfg.timeout)
+	assert.Equal(t, medianSelection, cfg.instance.Selection)
+
+	require.NoError(t, cfg.parse([]byte(`local_source: chrony`), nil))
+	assert.Equal(t, defaultChronyAddress, cfg.instance.LocalAddress)
+
+	assert.Error(t, cfg.parse([]byte(`selection: mean`), nil))
+	assert.Error(t, cfg.parse([]byte(`local_source: openntpd`), nil))
+}
+
+// toNTPTime converts a time to the NTP timestamp format
+func toNTPTime(t time.Time) uint64 {
+	secs := uint64(t.Unix() + 2208988800)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	return secs<<32 | frac
+}
+
+// startNTPServer starts a stratum 2 NTP server whose clock is ahead of the
+// local one by the offset
+func startNTPServer(t *testing.T, offset time.Duration) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+
+	go func() {
+		request := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFromUDP(request)
+			if err != nil {
+				return
+			}
+			if n < 48 || request[0]&0x7 != 3 {
+				continue
+			}
+			now := time.Now().Add(offset)
+			response := make([]byte, 48)
+			response[0] = request[0]&0x38 | 4                    // version of the request, server mode
+			response[1] = 2                                      // stratum
+			response[3] = 0xec                                   // precision
+			binary.BigEndian.PutUint32(response[4:], 0x00001000) // root delay of 62.5ms
+			binary.BigEndian.PutUint32(response[8:], 0x00000800) // root dispersion of 31.25ms
+			copy(response[12:], "GPS")                           // reference id
+			binary.BigEndian.PutUint64(response[16:], toNTPTime(now.Add(-time.Minute)))
+			copy(response[24:32], request[40:48])
+			binary.BigEndian.PutUint64(response[32:], toNTPTime(now))
+			binary.BigEndian.PutUint64(response[40:], toNTPTime(now))
+			conn.WriteToUDP(response, addr)
+		}
+	}()
+	return conn
+}
+
+func TestNTPServer(t *testing.T) {
+	// the offset is reported truncated, half a second of margin keeps the
+	// latency of the test server from changing it
+	conn := startNTPServer(t, 5500*time.Millisecond)
+	defer conn.Close()
+
+	ntpCheck := new(NTPCheck)
+	require.NoError(t, ntpCheck.Configure([]byte(fmt.Sprintf(`
+host: 127.0.0.1
+port: "%d"
+offset_threshold: 3
+`, conn.LocalAddr().(*net.UDPAddr).Port)), nil))
+
+	mockSender := mocksender.NewMockSender(ntpCheck.ID())
+	mockSender.SetupAcceptAll()
+	ntpCheck.Run()
+
+	tags := []string{"ntp_server:127.0.0.1"}
+	mockSender.AssertMetricInRange(t, "Gauge", "ntp.offset", 5.4, 5.6, "", nil)
+	mockSender.AssertMetric(t, "Gauge", "ntp.stratum", 2, "", tags)
+	mockSender.AssertMetric(t, "Gauge", "ntp.root_delay", 0.0625, "", tags)
+	mockSender.AssertMetric(t, "Gauge", "ntp.root_dispersion", 0.03125, "", tags)
+	mockSender.AssertServiceCheck(t, "ntp.in_sync", metrics.ServiceCheckCritical, "", nil, "Offset 5 secs higher than offset threshold (3 secs)")
+}
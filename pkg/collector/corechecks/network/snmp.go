@@ -3,724 +3,754 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2018 Datadog, Inc.
 
-// +build !windows
 // +build snmp
 
 package network
 
-/*
-#cgo pkg-config: net-snmp-5.7.3
-
-#include <stdlib.h>
-#include <net-snmp/net-snmp-config.h>
-#include <net-snmp/net-snmp-includes.h>
-#include <net-snmp/mib_api.h>
-*/
-import "C"
-
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
 	"net"
-	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
-	"unsafe"
-
-	"github.com/DataDog/datadog-agent/pkg/aggregator"
-	"github.com/DataDog/datadog-agent/pkg/collector/check"
-	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
-	"github.com/DataDog/datadog-agent/pkg/util"
 
 	log "github.com/cihub/seelog"
 	"github.com/k-sone/snmpgo"
 	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
 )
 
 const (
-	maxOIDLen      = 128
-	defaultPort    = 161
-	nonRepeaters   = 0
-	maxRepetitions = 10
-	snmpCheckName  = "snmp"
+	snmpCheckName = "snmp"
+
+	// SNMPServiceCheck is the service check reporting whether the device answers
+	SNMPServiceCheck = "snmp.can_check"
+
+	defaultPort           = 161
+	defaultTimeout        = 1
+	defaultRetries        = 5
+	defaultMaxRepetitions = 10
+	// maxOIDsPerRequest caps the number of OIDs of a GET request, devices
+	// rejecting big requests with a tooBig error
+	maxOIDsPerRequest = 32
+
+	sysObjectIDOID = "1.3.6.1.2.1.1.2.0"
 )
 
-var once sync.Once
+// symbolConfig is an OID and the name it is reported as
+type symbolConfig struct {
+	OID  string `yaml:"OID"`
+	Name string `yaml:"name"`
+}
+
+// UnmarshalYAML accepts the symbols given by their MIB name only, their
+// OID being resolved from the known MIB objects
+func (s *symbolConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		s.Name = name
+		return nil
+	}
+	type plain symbolConfig
+	return unmarshal((*plain)(s))
+}
+
+// resolve sets the OID of a symbol given by its MIB name
+func (s *symbolConfig) resolve(mib string) error {
+	if s.OID != "" || s.Name == "" {
+		return nil
+	}
+	oid, name, err := lookupMIBObject(mib, s.Name, false)
+	if err != nil {
+		return err
+	}
+	s.OID, s.Name = oid, name
+	return nil
+}
 
-type metricTag struct {
-	Tag    string `yaml:"tag"`
-	ColOID string `yaml:"tag_oid,omitempty"`
-	Column string `yaml:"column,omitempty"`
-	Index  int    `yaml:"index,omitempty"`
+type metricTagConfig struct {
+	Tag string `yaml:"tag"`
+	// Column is the column of the table holding the tag value
+	Column symbolConfig `yaml:"column,omitempty"`
+	// Index is the position, starting at 1, of the row index part holding
+	// the tag value. Mapping translates its value if set.
+	Index   int            `yaml:"index,omitempty"`
+	Mapping map[int]string `yaml:"mapping,omitempty"`
 }
 
-type metric struct {
-	MIB        string      `yaml:"MIB,omitempty"`
-	OID        string      `yaml:"OID,omitempty"`
-	Symbol     string      `yaml:"symbol,omitempty"`
-	Name       string      `yaml:"name,omitempty"`
-	Table      string      `yaml:"table,omitempty"`
-	ForcedType string      `yaml:"forced_type,omitempty"`
-	Symbols    []string    `yaml:"symbols,omitempty"`
-	Tags       []metricTag `yaml:"metric_tags,omitempty"`
+// metricConfig is either a scalar, set with OID and name, or the symbols
+// of a table, walked and tagged with the metric_tags of each row
+type metricConfig struct {
+	MIB        string            `yaml:"MIB,omitempty"`
+	Symbol     string            `yaml:"symbol,omitempty"`
+	OID        string            `yaml:"OID,omitempty"`
+	Name       string            `yaml:"name,omitempty"`
+	Table      string            `yaml:"table,omitempty"`
+	Symbols    []symbolConfig    `yaml:"symbols,omitempty"`
+	ForcedType string            `yaml:"forced_type,omitempty"`
+	Tags       []metricTagConfig `yaml:"metric_tags,omitempty"`
 }
 
-type snmpInstanceCfg struct {
-	Host            string                  `yaml:"ip_address"`
-	Port            uint                    `yaml:"port"`
-	User            string                  `yaml:"user,omitempty"`
-	Community       string                  `yaml:"community_string,omitempty"`
-	Version         int                     `yaml:"snmp_version,omitempty"`
-	AuthKey         string                  `yaml:"authKey,omitempty"`
-	PrivKey         string                  `yaml:"privKey,omitempty"`
-	AuthProtocol    string                  `yaml:"authProtocol,omitempty"`
-	PrivProtocol    string                  `yaml:"privProtocol,omitempty"`
-	ContextEngineId string                  `yaml:"context_engine_id,omitempty"`
-	ContextName     string                  `yaml:"context_name,omitempty"`
-	Timeout         uint                    `yaml:"timeout,omitempty"`
-	Retries         uint                    `yaml:"retries,omitempty"`
-	Metrics         []metric                `yaml:"metrics,omitempty"`
-	Tags            []string                `yaml:"tags,omitempty"`
-	OIDTranslator   *util.BiMap             `yaml:",omitempty"` //will not be in yaml
-	NameLookup      map[string]string       `yaml:",omitempty"` //will not be in yaml
-	MetricMap       map[string]*metric      `yaml:",omitempty"` //will not be in yaml
-	TagMap          map[string][]*metricTag `yaml:",omitempty"` //will not be in yaml
-	snmp            *snmpgo.SNMP
+type snmpInstanceConfig struct {
+	Host            string         `yaml:"ip_address"`
+	Port            uint           `yaml:"port"`
+	Community       string         `yaml:"community_string"`
+	Version         int            `yaml:"snmp_version"`
+	User            string         `yaml:"user"`
+	AuthKey         string         `yaml:"authKey"`
+	PrivKey         string         `yaml:"privKey"`
+	AuthProtocol    string         `yaml:"authProtocol"`
+	PrivProtocol    string         `yaml:"privProtocol"`
+	ContextEngineID string         `yaml:"context_engine_id"`
+	ContextName     string         `yaml:"context_name"`
+	Timeout         uint           `yaml:"timeout"`
+	Retries         uint           `yaml:"retries"`
+	MaxRepetitions  int            `yaml:"bulk_max_repetitions"`
+	Profile         string         `yaml:"profile"`
+	Metrics         []metricConfig `yaml:"metrics"`
+	Tags            []string       `yaml:"tags"`
 }
 
-type snmpInitCfg struct {
-	MibsDir         string `yaml:"mibs_folder,omitempty"`
-	IgnoreNonIncOID string `yaml:"ignore_nonincreasing_oid,omitempty"`
+type snmpInitConfig struct {
+	ProfilesFolder string `yaml:"profiles_folder"`
+	// MibsFolder is the folder of the MIB files of the net-snmp based
+	// check, no longer read
+	MibsFolder string `yaml:"mibs_folder"`
 }
 
 type snmpConfig struct {
-	instance snmpInstanceCfg
-	initConf snmpInitCfg
+	instance snmpInstanceConfig
+	initConf snmpInitConfig
 }
 
-// SNMPCheck grabs SNMP metrics
+// SNMPCheck collects the metrics of a device, queried with SNMP v1, v2c or
+// v3, from the OIDs of the configuration and of the profile of the device
 type SNMPCheck struct {
 	core.CheckBase
-	cfg *snmpConfig
+	cfg      *snmpConfig
+	snmp     *snmpgo.SNMP
+	profiles profileDefinitions
+	// metrics are the metrics of the instance and of its profile, the
+	// profile being detected on the first run when not configured
+	metrics         []metricConfig
+	profileResolved bool
+	tags            []string
 }
 
-func initCNetSnmpLib(cfg *snmpInitCfg) (err error) {
-	err = nil
+func (c *snmpConfig) parse(data []byte, initData []byte) error {
+	var instance snmpInstanceConfig
+	var initConf snmpInitConfig
 
-	defer func() {
-		if r := recover(); r != nil {
-			log.Debugf("error initializing SNMP libraries: %v", r)
-			log.Debug("are all dependencies available?")
-			err = errors.New("Unable to initialize SNMP")
-		}
-	}()
+	if err := yaml.Unmarshal(data, &instance); err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(initData, &initConf); err != nil {
+		return err
+	}
 
-	once.Do(func() {
-		C.netsnmp_init_mib()
+	if instance.Host == "" {
+		return errors.New("invalid SNMP instance configuration: ip_address is missing")
+	}
+	if instance.Port == 0 {
+		instance.Port = defaultPort
+	}
+	if instance.Timeout == 0 {
+		instance.Timeout = defaultTimeout
+	}
+	if instance.Retries == 0 {
+		instance.Retries = defaultRetries
+	}
+	if instance.MaxRepetitions <= 0 {
+		instance.MaxRepetitions = defaultMaxRepetitions
+	}
+	if initConf.ProfilesFolder == "" {
+		initConf.ProfilesFolder = filepath.Join(config.Datadog.GetString("confd_path"), "snmp.d", "profiles")
+	}
 
-		if cfg != nil && cfg.MibsDir != "" {
-			_, e := os.Stat(cfg.MibsDir)
-			if e == nil || !os.IsNotExist(e) {
-				mibdir := C.CString(cfg.MibsDir)
-				defer C.free(unsafe.Pointer(mibdir))
-				C.add_mibdir(mibdir)
-			}
+	// if version not set explicitly - infer from params.
+	if instance.Version == 0 {
+		if instance.User != "" {
+			instance.Version = 3
+		} else {
+			instance.Version = 2
 		}
-	})
-
-	return
-}
-
-type tagRegistryEntry struct {
-	Varbinds snmpgo.VarBinds
-	Tag      *metricTag
-}
-
-type tagRegistry map[string]tagRegistryEntry
+	}
 
-func namespaceMetric(name string) string {
-	var buffer bytes.Buffer
+	if initConf.MibsFolder != "" {
+		log.Warnf("The mibs_folder option of the snmp check is no longer supported, only the objects of the standard MIBs are resolved by name")
+	}
 
-	//build correct metric name
-	buffer.WriteString("snmp.")
+	// the metrics whose MIB names cannot be resolved are skipped instead of
+	// failing the instance, to migrate them to OIDs one at a time
+	metrics := make([]metricConfig, 0, len(instance.Metrics))
+	for _, metric := range instance.Metrics {
+		if err := metric.resolve(); err != nil {
+			log.Warnf("Skipping a metric of the SNMP instance %s: %s, give its OID and name instead", instance.Host, err)
+			continue
+		}
+		if err := metric.validate(); err != nil {
+			return err
+		}
+		metrics = append(metrics, metric)
+	}
+	instance.Metrics = metrics
 
-	tokenized := strings.Split(name, "::")
-	buffer.WriteString(tokenized[len(tokenized)-1])
-	return buffer.String()
+	c.instance = instance
+	c.initConf = initConf
+	return nil
 }
 
-func buildTextOID(oids []string) string {
-	var buffer bytes.Buffer
-	for idx, oid := range oids {
-		buffer.WriteString(oid)
-		if idx < len(oids)-1 {
-			buffer.WriteString("::")
+// resolve sets the OIDs of the scalar, symbols and tag columns given by
+// their MIB name, the metric name defaulting to the name of the scalar
+func (m *metricConfig) resolve() error {
+	if len(m.Symbols) == 0 {
+		if m.OID != "" || m.Symbol == "" {
+			return nil
+		}
+		oid, name, err := lookupMIBObject(m.MIB, m.Symbol, true)
+		if err != nil {
+			return err
+		}
+		m.OID = oid
+		if m.Name == "" {
+			m.Name = name
 		}
+		return nil
 	}
-	return buffer.String()
-}
 
-func buildOID(holder []C.oid, idLen int) string {
-	var buffer bytes.Buffer
-	for i := 0; i < idLen; i++ {
-		buffer.WriteString(fmt.Sprintf("%v", holder[i]))
-		if i < (idLen - 1) {
-			buffer.WriteString(".")
+	for i := range m.Symbols {
+		if err := m.Symbols[i].resolve(m.MIB); err != nil {
+			return err
+		}
+	}
+	for i := range m.Tags {
+		if err := m.Tags[i].Column.resolve(m.MIB); err != nil {
+			return err
 		}
 	}
-	return buffer.String()
+	return nil
 }
 
-func getTextualOID(oid string) (string, error) {
-	var cbuff [512]C.char
-	var oidHolder [maxOIDLen]C.oid
-
-	//zero out buffer
-	for i := range cbuff {
-		cbuff[i] = 0
-	}
-	_oid := strings.Split(oid, ".")
-	if _oid[0] == "." {
-		_oid = _oid[1:]
+// validate checks that the metric has the OIDs to collect it
+func (m *metricConfig) validate() error {
+	if len(m.Symbols) == 0 {
+		if m.OID == "" || m.Name == "" {
+			return fmt.Errorf("metric %q needs both an OID and a name", m.OID+m.Name)
+		}
+		if _, err := snmpgo.NewOid(m.OID); err != nil {
+			return fmt.Errorf("metric %s has an invalid OID %s: %s", m.Name, m.OID, err)
+		}
+		return nil
 	}
 
-	for i := range _oid {
-		id, err := strconv.Atoi(_oid[i])
-		if err != nil {
-			return "", err
+	for _, symbol := range m.Symbols {
+		if symbol.OID == "" || symbol.Name == "" {
+			return fmt.Errorf("symbol %q of table %s needs both an OID and a name", symbol.OID+symbol.Name, m.Table)
+		}
+		if _, err := snmpgo.NewOid(symbol.OID); err != nil {
+			return fmt.Errorf("symbol %s has an invalid OID %s: %s", symbol.Name, symbol.OID, err)
 		}
-		oidHolder[i] = (C.oid)(id)
 	}
-	idLen := len(_oid)
-	cbuffLen := 511
-
-	C.snprint_objid(
-		&cbuff[0],
-		(C.size_t)(cbuffLen),
-		(*C.oid)(unsafe.Pointer(&oidHolder[0])),
-		(C.size_t)(idLen))
-
-	return C.GoString((*C.char)(unsafe.Pointer(&cbuff[0]))), nil
-
+	for _, tag := range m.Tags {
+		switch {
+		case tag.Tag == "":
+			return fmt.Errorf("a metric tag of table %s has no name", m.Table)
+		case tag.Column.OID == "" && tag.Index <= 0:
+			return fmt.Errorf("metric tag %s of table %s needs a column or an index", tag.Tag, m.Table)
+		case tag.Column.OID != "":
+			if _, err := snmpgo.NewOid(tag.Column.OID); err != nil {
+				return fmt.Errorf("metric tag %s has an invalid OID %s: %s", tag.Tag, tag.Column.OID, err)
+			}
+		}
+	}
+	return nil
 }
 
-func getIndexTag(baseOID, OID string, idx int) (string, error) {
+// snmpArguments builds the session arguments, translating the protocol
+// names of the pysnmp based check
+func (c *snmpConfig) snmpArguments() (snmpgo.SNMPArguments, error) {
+	instance := c.instance
+	args := snmpgo.SNMPArguments{
+		Address:         net.JoinHostPort(instance.Host, strconv.Itoa(int(instance.Port))),
+		Timeout:         time.Duration(instance.Timeout) * time.Second,
+		Retries:         instance.Retries,
+		Community:       instance.Community,
+		UserName:        instance.User,
+		AuthPassword:    instance.AuthKey,
+		PrivPassword:    instance.PrivKey,
+		ContextEngineId: instance.ContextEngineID,
+		ContextName:     instance.ContextName,
+	}
+
+	switch instance.Version {
+	case 1:
+		args.Version = snmpgo.V1
+	case 2:
+		args.Version = snmpgo.V2c
+	case 3:
+		args.Version = snmpgo.V3
+	default:
+		return args, fmt.Errorf("unsupported SNMP version %d", instance.Version)
+	}
+	if args.Version != snmpgo.V3 {
+		return args, nil
+	}
+
+	args.SecurityLevel = snmpgo.NoAuthNoPriv
+	if instance.AuthKey == "" {
+		return args, nil
+	}
+	switch instance.AuthProtocol {
+	case "", "MD5", "usmHMACMD5AuthProtocol":
+		args.AuthProtocol = snmpgo.Md5
+	case "SHA", "usmHMACSHAAuthProtocol":
+		args.AuthProtocol = snmpgo.Sha
+	default:
+		return args, fmt.Errorf("unsupported authentication protocol %s", instance.AuthProtocol)
+	}
+
+	args.SecurityLevel = snmpgo.AuthNoPriv
+	if instance.PrivKey == "" {
+		return args, nil
+	}
+	switch instance.PrivProtocol {
+	case "", "DES", "usmDESPrivProtocol":
+		args.PrivProtocol = snmpgo.Des
+	case "AES", "usmAesCfb128Protocol":
+		args.PrivProtocol = snmpgo.Aes
+	default:
+		return args, fmt.Errorf("unsupported privacy protocol %s", instance.PrivProtocol)
+	}
+	args.SecurityLevel = snmpgo.AuthPriv
+
+	return args, nil
+}
 
-	_base := strings.Split(baseOID, ".")
-	if _base[0] == "." {
-		_base = _base[1:]
+// Configure the check from YAML data
+func (c *SNMPCheck) Configure(data check.ConfigData, initConfig check.ConfigData) error {
+	cfg := new(snmpConfig)
+	if err := cfg.parse(data, initConfig); err != nil {
+		log.Criticalf("Error parsing configuration file: %s ", err)
+		return err
 	}
+	c.BuildID(data, initConfig)
+	c.cfg = cfg
 
-	_oid := strings.Split(OID, ".")
-	if _oid[0] == "." {
-		_oid = _oid[1:]
-	}
-	if len(_oid) < len(_base)+idx {
-		return "", errors.New("index provided unavailable in target OID")
+	args, err := cfg.snmpArguments()
+	if err != nil {
+		return err
 	}
-	_oid = _oid[:len(_base)+idx]
-
-	textOID, err := getTextualOID(strings.Join(_oid, "."))
+	c.snmp, err = snmpgo.NewSNMP(args)
 	if err != nil {
-		return "", err
+		log.Warnf("Error creating SNMP instance for: %s (%v) - skipping", cfg.instance.Host, err)
+		return err
 	}
-	sliceOID := strings.Split(textOID, ".")
-	tag := sliceOID[len(sliceOID)-1]
-
-	return tag, nil
-}
 
-func (cfg *snmpInstanceCfg) generateOIDs() error {
-
-	var textualOID string
-	var err error
-	cfg.OIDTranslator = util.NewBiMap((string)(""), (string)(""))
-	cfg.MetricMap = make(map[string]*metric)
-	cfg.NameLookup = make(map[string]string)
-
-	for i, metric := range cfg.Metrics {
-		oidHolder := make([]C.oid, maxOIDLen)
-		idLen := maxOIDLen
-
-		log.Debugf("Mapping metric: %v", metric)
-		if metric.OID != "" {
-			log.Debugf("No translation necessary for OID: %s", metric.OID)
-			err = cfg.OIDTranslator.AddKV(metric.OID, metric.OID)
-			if err != nil {
-				log.Warnf("Unable to add OID %s to translation map", metric.OID)
-			}
-			cfg.MetricMap[metric.OID] = &cfg.Metrics[i]
-		} else if metric.Table != "" {
-			for _, symbol := range metric.Symbols {
-				textualOID = buildTextOID([]string{metric.MIB, symbol})
-				ctextualOID := C.CString(textualOID)
-				log.Debugf("Translating Table OID: %s", textualOID)
-
-				C.read_objid(
-					ctextualOID,
-					(*C.oid)(unsafe.Pointer(&oidHolder[0])),
-					(*C.size_t)(unsafe.Pointer(&idLen)))
-
-				symOID := buildOID(oidHolder, idLen)
-				err = cfg.OIDTranslator.AddKV(textualOID, symOID)
-				if err != nil {
-					log.Warnf("Unable to add OID %s to translation map", textualOID)
-				}
-				cfg.MetricMap[symOID] = &cfg.Metrics[i]
-				cfg.NameLookup[textualOID] = namespaceMetric(textualOID)
-				C.free(unsafe.Pointer(ctextualOID))
-			}
-		} else {
-			textualOID = buildTextOID([]string{metric.MIB, metric.Symbol})
-			ctextualOID := C.CString(textualOID)
-			log.Debugf("Translating Symbol: %s", textualOID)
+	c.tags = append(append([]string{}, cfg.instance.Tags...), fmt.Sprintf("snmp_device:%s:%d", cfg.instance.Host, cfg.instance.Port))
+	c.metrics = append([]metricConfig{}, cfg.instance.Metrics...)
 
-			C.read_objid(
-				ctextualOID,
-				(*C.oid)(unsafe.Pointer(&oidHolder[0])),
-				(*C.size_t)(unsafe.Pointer(&idLen)))
-
-			symOID := buildOID(oidHolder, idLen)
-			err = cfg.OIDTranslator.AddKV(textualOID, symOID)
-			if err != nil {
-				log.Warnf("Unable to add OID %s to translation map", textualOID)
-			}
-			cfg.MetricMap[symOID] = &cfg.Metrics[i]
-			cfg.NameLookup[textualOID] = namespaceMetric(textualOID)
-			C.free(unsafe.Pointer(ctextualOID))
-		}
-
-		//grab tags too
-		for _, tag := range metric.Tags {
-			if tag.Column != "" {
-				textualTagOID := buildTextOID([]string{metric.MIB, tag.Column})
-				ctextualOID := C.CString(textualTagOID)
-				log.Debugf("Translating Tag OID: %s", textualTagOID)
-
-				C.read_objid(
-					ctextualOID,
-					(*C.oid)(unsafe.Pointer(&oidHolder[0])),
-					(*C.size_t)(unsafe.Pointer(&idLen)))
-
-				err = cfg.OIDTranslator.AddKV(textualTagOID, buildOID(oidHolder, idLen))
-				if err != nil {
-					log.Warnf("Unable to add OID %s to translation map", textualTagOID)
-					C.free(unsafe.Pointer(ctextualOID))
-					continue // or bail out?
-				}
-				C.free(unsafe.Pointer(ctextualOID))
-			}
+	c.profiles, err = loadProfiles(cfg.initConf.ProfilesFolder)
+	if err != nil {
+		log.Debugf("Could not load the SNMP profiles: %s", err)
+	}
+	if cfg.instance.Profile != "" {
+		if err := c.useProfile(cfg.instance.Profile); err != nil {
+			return err
 		}
 	}
 
+	if len(c.metrics) == 0 && len(c.profiles) == 0 {
+		return errors.New("no metrics to collect: the instance has no metrics and no profile is available")
+	}
 	return nil
 }
 
-func (cfg *snmpInstanceCfg) generateTagMap() error {
-
-	cfg.TagMap = make(map[string][]*metricTag)
-
-	for _, metric := range cfg.Metrics {
-
-		var ok bool
-		var textOID, symOID string
-
-		for idx, tag := range metric.Tags {
-			textOID = ""
-			symOID = ""
-
-			//Set column ID for lookup if it applies
-			if tag.Column != "" {
-				textOID = buildTextOID([]string{metric.MIB, tag.Column})
-				OID, err := cfg.OIDTranslator.GetKV(textOID)
-				if err != nil {
-					continue
-				}
-				symOID, ok = OID.(string)
-				if !ok {
-					continue
-				}
-
-				metric.Tags[idx].ColOID = string(symOID)
-			}
-
-			if metric.OID != "" {
-				if _, ok := cfg.TagMap[metric.OID]; ok {
-					cfg.TagMap[metric.OID] = append(cfg.TagMap[metric.OID], &metric.Tags[idx])
-				} else {
-					cfg.TagMap[metric.OID] = []*metricTag{&metric.Tags[idx]}
-				}
-			} else if metric.Table != "" {
-				for _, symbol := range metric.Symbols {
-					metricOID := buildTextOID([]string{metric.MIB, symbol})
-
-					OID, err := cfg.OIDTranslator.GetKV(metricOID)
-					if err != nil {
-						continue
-					}
-					symMetricOID, ok := OID.(string)
-					if !ok {
-						continue
-					}
-					if _, ok := cfg.TagMap[symMetricOID]; ok {
-						cfg.TagMap[symMetricOID] = append(cfg.TagMap[symMetricOID], &metric.Tags[idx])
-					} else {
-						cfg.TagMap[symMetricOID] = []*metricTag{&metric.Tags[idx]}
-					}
-				}
-			} else {
-				metricOID := buildTextOID([]string{metric.MIB, metric.Symbol})
-
-				OID, err := cfg.OIDTranslator.GetKV(metricOID)
-				if err != nil {
-					continue
-				}
-				symMetricOID, ok := OID.(string)
-				if !ok {
-					continue
-				}
-				if _, ok := cfg.TagMap[symMetricOID]; ok {
-					cfg.TagMap[symMetricOID] = append(cfg.TagMap[symMetricOID], &metric.Tags[idx])
-				} else {
-					cfg.TagMap[symMetricOID] = []*metricTag{&metric.Tags[idx]}
-				}
-			}
-		}
+// useProfile adds the metrics of the profile to the metrics of the instance
+func (c *SNMPCheck) useProfile(name string) error {
+	profile, found := c.profiles[name]
+	if !found {
+		return fmt.Errorf("unknown profile %s", name)
 	}
-
+	c.metrics = append(c.metrics, profile.Metrics...)
+	c.tags = append(c.tags, "snmp_profile:"+name)
+	c.profileResolved = true
 	return nil
 }
 
-func (c *snmpConfig) parse(data []byte, initData []byte) error {
-	var tagbuff bytes.Buffer
-	var instance snmpInstanceCfg
-	var initConf snmpInitCfg
-
-	if err := yaml.Unmarshal(data, &instance); err != nil {
-		return err
-	}
-	if err := yaml.Unmarshal(initData, &initConf); err != nil {
+// Run runs the check
+func (c *SNMPCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
 		return err
 	}
 
-	c.instance = instance
-	c.initConf = initConf
-
-	if c.instance.Port == 0 {
-		c.instance.Port = defaultPort
+	if err = c.collect(sender); err != nil {
+		sender.ServiceCheck(SNMPServiceCheck, metrics.ServiceCheckCritical, "", c.tags, err.Error())
+	} else {
+		sender.ServiceCheck(SNMPServiceCheck, metrics.ServiceCheckOK, "", c.tags, "")
 	}
+	sender.Commit()
+	return err
+}
 
-	if c.instance.Host == "" {
-		return errors.New("error parsing configuration - invalid SNMP instance configuration")
+func (c *SNMPCheck) collect(sender aggregator.Sender) error {
+	if err := c.snmp.Open(); err != nil {
+		return fmt.Errorf("could not connect to %s: %s", c.cfg.instance.Host, err)
 	}
+	defer c.snmp.Close()
 
-	//build instance tag
-	tagbuff.Reset()
-	tagbuff.WriteString("snmp_device:")
-	tagbuff.WriteString(c.instance.Host)
-	tagbuff.WriteString(":")
-	tagbuff.WriteString(fmt.Sprintf("%d", c.instance.Port))
+	if !c.profileResolved {
+		if err := c.detectProfile(); err != nil {
+			return err
+		}
+	}
 
-	c.instance.Tags = append(c.instance.Tags, tagbuff.String())
+	var scalars []metricConfig
+	var tables []metricConfig
+	for _, metric := range c.metrics {
+		if len(metric.Symbols) > 0 {
+			tables = append(tables, metric)
+		} else {
+			scalars = append(scalars, metric)
+		}
+	}
 
-	//security - make sure we're backward compatible
-	switch c.instance.AuthProtocol {
-	case "usmHMACMD5AuthProtocol":
-		c.instance.AuthProtocol = string(snmpgo.Md5)
-	case "usmHMACSHAAuthProtocol":
-		c.instance.AuthProtocol = string(snmpgo.Sha)
+	if err := c.collectScalars(sender, scalars); err != nil {
+		return err
 	}
+	return c.collectTables(sender, tables)
+}
 
-	switch c.instance.PrivProtocol {
-	case "usmDESPrivProtocol", "usm3DESEDEPrivProtocol":
-		c.instance.PrivProtocol = string(snmpgo.Des)
-	case "usmAesCfb128Protocol", "usmAesCfb192Protocol", "usmAesCfb256Protocol":
-		c.instance.PrivProtocol = string(snmpgo.Aes)
+// detectProfile matches the sysObjectID of the device against the profiles
+// and uses the most specific one
+func (c *SNMPCheck) detectProfile() error {
+	if len(c.profiles) == 0 {
+		c.profileResolved = true
+		return nil
 	}
 
-	// if version not set explicitly - infer from params.
-	if c.instance.Version == 0 && c.instance.User != "" {
-		c.instance.Version = 3
+	vbs, err := c.get([]string{sysObjectIDOID})
+	if err != nil {
+		return fmt.Errorf("could not get the sysObjectID: %s", err)
+	}
+	c.profileResolved = true
+	if len(vbs) == 0 || !isValue(vbs[0].Variable) {
+		log.Debugf("%s reports no sysObjectID, no profile will be used", c.cfg.instance.Host)
+		return nil
 	}
 
-	return nil
+	sysObjectID := vbs[0].Variable.String()
+	name, found := c.profiles.match(sysObjectID)
+	if !found {
+		log.Debugf("no profile matches the sysObjectID %s of %s", sysObjectID, c.cfg.instance.Host)
+		return nil
+	}
+	log.Debugf("using the profile %s for %s (sysObjectID %s)", name, c.cfg.instance.Host, sysObjectID)
+	return c.useProfile(name)
 }
 
-func (c *SNMPCheck) submitSNMP(oids snmpgo.Oids, vbs snmpgo.VarBinds) error {
-	sender, err := aggregator.GetSender(c.ID())
+// collectScalars gets the scalar OIDs, the ones the device does not know
+// being walked for compatibility with configurations omitting the
+// trailing .0 of the OID
+func (c *SNMPCheck) collectScalars(sender aggregator.Sender, scalars []metricConfig) error {
+	if len(scalars) == 0 {
+		return nil
+	}
+
+	oids := make([]string, 0, len(scalars))
+	for _, metric := range scalars {
+		oids = append(oids, metric.OID)
+	}
+	vbs, err := c.get(oids)
 	if err != nil {
 		return err
 	}
 
-	for _, oid := range oids {
-		varbinds := vbs.MatchBaseOids(oid)
+	values := make(map[string]snmpgo.Variable, len(vbs))
+	for _, vb := range vbs {
+		if isValue(vb.Variable) {
+			values[vb.Oid.String()] = vb.Variable
+		}
+	}
 
-		registry := make(tagRegistry)
-		if tags, ok := c.cfg.instance.TagMap[oid.String()]; ok {
-			for _, tag := range tags {
-				if tag.Column != "" {
-					if tagOID, err := snmpgo.NewOid(tag.ColOID); err == nil {
-						registry[tag.Tag] = tagRegistryEntry{Tag: tag, Varbinds: vbs.MatchBaseOids(tagOID)}
-					}
-				} else if tag.Index > 0 {
-					registry[tag.Tag] = tagRegistryEntry{Tag: tag, Varbinds: nil}
-				}
-			}
+	for _, metric := range scalars {
+		// the OIDs are validated by the configuration parsing
+		oid, _ := snmpgo.NewOid(metric.OID)
+		if value, found := values[oid.String()]; found {
+			c.submit(sender, metric.Name, metric.ForcedType, value, c.tags)
+			continue
 		}
+		walked, err := c.walk(metric.OID)
+		if err != nil {
+			return err
+		}
+		if len(walked) == 0 {
+			log.Debugf("%s has no value for the OID %s", c.cfg.instance.Host, metric.OID)
+		}
+		for _, vb := range walked {
+			c.submit(sender, metric.Name, metric.ForcedType, vb.Variable, c.tags)
+		}
+	}
+	return nil
+}
 
-		symbolicOID, err := c.cfg.instance.OIDTranslator.GetKVReverse(oid.String())
+// collectTables walks the columns of the table symbols and of their tags,
+// and submits the symbols of every row with the tags of the same row
+func (c *SNMPCheck) collectTables(sender aggregator.Sender, tables []metricConfig) error {
+	// the rows of each column, by index
+	columns := map[string]map[string]snmpgo.Variable{}
+	walkColumn := func(column string) error {
+		if _, done := columns[column]; done {
+			return nil
+		}
+		vbs, err := c.walk(column)
 		if err != nil {
-			log.Warnf("unable to report OID: %s - error retrieving value.", oid.String())
-			continue
+			return err
+		}
+		base, _ := snmpgo.NewOid(column)
+		rows := make(map[string]snmpgo.Variable, len(vbs))
+		for _, vb := range vbs {
+			rows[rowIndex(base, vb.Oid)] = vb.Variable
 		}
+		columns[column] = rows
+		return nil
+	}
 
-		//forced type?
-		metricName := ""
-		metricType := ""
-		if m, ok := c.cfg.instance.MetricMap[oid.String()]; ok {
-			if m.ForcedType != "" {
-				log.Warnf("Detected forced type: %s - for %v.", m.ForcedType, *m)
-				metricType = m.ForcedType
+	for _, table := range tables {
+		for _, tag := range table.Tags {
+			if tag.Column.OID == "" {
+				continue
 			}
-
-			if m.Name != "" {
-				metricName = namespaceMetric(m.Name)
-				log.Debugf("Overriding name: %s - for %v.", metricName, *m)
+			if err := walkColumn(tag.Column.OID); err != nil {
+				return err
 			}
 		}
 
-		if metric, ok := symbolicOID.(string); ok {
-			for idx, collected := range varbinds {
-				var tag string
-				var tagbuff bytes.Buffer
-				var value *big.Int
-				var err error
-
-				value, err = collected.Variable.BigInt()
-				if err != nil || value == nil {
-					continue
-				}
-
-				//set tag
-				tagbundle := append([]string(nil), c.cfg.instance.Tags...)
-				for _, entry := range registry {
-					tagbuff.Reset()
-					if entry.Tag.Column != "" && len(entry.Varbinds) > 0 {
-						tagbuff.WriteString(entry.Tag.Tag)
-						tagbuff.WriteString(":")
-						tagbuff.WriteString(entry.Varbinds[idx].Variable.String())
-						tagbundle = append(tagbundle, tagbuff.String())
-					} else if entry.Tag.Index > 0 {
-						tag, _ = getIndexTag(oid.String(), collected.Oid.String(), entry.Tag.Index)
-						tagbuff.WriteString(entry.Tag.Tag)
-						tagbuff.WriteString(":")
-						tagbuff.WriteString(tag)
-						tagbundle = append(tagbundle, tagbuff.String())
-					}
-				}
-
-				//TODO: add more types
-				log.Debugf("Variable fetched has type: %v = %v", metric, collected.Variable.Type())
-				if metricType == "" {
-					metricType = collected.Variable.Type()
-				}
-				if metricName == "" {
-					if ddMetric, ok := c.cfg.instance.NameLookup[metric]; ok {
-						metricName = ddMetric
-					} else {
-						metricName = namespaceMetric(metric)
+		for _, symbol := range table.Symbols {
+			if err := walkColumn(symbol.OID); err != nil {
+				return err
+			}
+			for index, value := range columns[symbol.OID] {
+				tags := append([]string{}, c.tags...)
+				for _, tag := range table.Tags {
+					if tagValue, found := rowTag(tag, index, columns); found {
+						tags = append(tags, tag.Tag+":"+tagValue)
 					}
 				}
-
-				switch metricType {
-				case "Gauge32", "Integer", "gauge":
-					log.Debugf("Submitting gauge: %v = %v tagged with: %v", metricName, value, tagbundle)
-					//should report instance has hostname
-					sender.Gauge(metricName, float64(value.Int64()), "", tagbundle)
-				case "Counter64", "Counter32":
-					log.Debugf("Submitting rate: %v = %v tagged with: %v", metricName, value, tagbundle)
-					//should report instance has hostname
-					sender.Rate(metricName, float64(value.Int64()), "", tagbundle)
-				case "OctetString":
-				default:
-					continue
-				}
+				c.submit(sender, symbol.Name, table.ForcedType, value, tags)
 			}
 		}
 	}
-
-	sender.Commit()
-
 	return nil
 }
 
-func (c *SNMPCheck) getSNMP() error {
-
-	// get OIDList
-	oidvalues := c.cfg.instance.OIDTranslator.Values()
-	oidList := make([]string, len(oidvalues))
-	for i, v := range oidvalues {
-		//should be true for each v
-		if vstr, ok := v.(string); ok {
-			oidList[i] = vstr
+// rowTag returns the value of the tag for the row of the given index
+func rowTag(tag metricTagConfig, index string, columns map[string]map[string]snmpgo.Variable) (string, bool) {
+	if tag.Column.OID != "" {
+		value, found := columns[tag.Column.OID][index]
+		if !found {
+			return "", false
 		}
+		return value.String(), true
 	}
 
-	oids, err := snmpgo.NewOids(oidList)
-	if err != nil {
-		// Failed creating Native OID list.
-		log.Warnf("Error creating Native OID list: %v", err)
-		return err
+	parts := strings.Split(index, ".")
+	if tag.Index > len(parts) {
+		log.Debugf("the index %s has no part %d for the tag %s", index, tag.Index, tag.Tag)
+		return "", false
 	}
-
-	log.Debugf("Connecting to SNMP host...")
-	if err = c.cfg.instance.snmp.Open(); err != nil {
-		// Failed to open connection
-		log.Warnf("Error connecting to host: %v", err)
-		return err
+	value := parts[tag.Index-1]
+	if tag.Mapping != nil {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return "", false
+		}
+		mapped, found := tag.Mapping[i]
+		if !found {
+			return "", false
+		}
+		return mapped, true
 	}
+	return value, true
+}
 
-	log.Debugf("SNMP Getting... OIDS: %v", oids)
-	pdu, err := c.cfg.instance.snmp.GetRequest(oids)
+// submit sends the value with the type of its SNMP variable, counters
+// being sent as rates unless a type is forced
+func (c *SNMPCheck) submit(sender aggregator.Sender, name, forcedType string, variable snmpgo.Variable, tags []string) {
+	value, err := variableValue(variable)
 	if err != nil {
-		// Failed to request
-		log.Warnf("Error performing snmpget: %v", err)
-		return err
-	}
-	if pdu.ErrorStatus() != snmpgo.NoError {
-		// Received an error from the agent
-		log.Warnf("Received error from SNMP agent: %v - %v", pdu.ErrorStatus(), pdu.ErrorIndex())
+		log.Debugf("could not report %s: %s", name, err)
+		return
 	}
 
-	var collectedVars []*snmpgo.VarBind
-	var missingOIDs []*snmpgo.Oid
-	for _, oid := range oids {
-		collected := pdu.VarBinds().MatchOid(oid)
-		if collected != nil && collected.Variable.Type() != "NoSucheInstance" {
-			log.Debugf("Collected OID: %v", oid)
-			collectedVars = append(collectedVars, collected)
-		} else {
-			log.Debugf("Missing OID: %v", oid)
-			missingOIDs = append(missingOIDs, oid)
+	metricType := forcedType
+	if metricType == "" {
+		switch variable.Type() {
+		case "Counter32", "Counter64":
+			metricType = "rate"
+		default:
+			metricType = "gauge"
 		}
 	}
 
-	if len(missingOIDs) > 0 {
-		log.Debugf("SNMP Walking...")
-		// See: https://www.snmpsharpnet.com/?page_id=30
-		pdu, err := c.cfg.instance.snmp.GetBulkWalk(missingOIDs, nonRepeaters, maxRepetitions)
-		if err != nil {
-			// Failed to request
-			log.Warnf("Error performing snmpget: %v", err)
-			return err
+	metricName := "snmp." + name
+	switch metricType {
+	case "gauge":
+		sender.Gauge(metricName, value, "", tags)
+	case "rate", "counter":
+		sender.Rate(metricName, value, "", tags)
+	case "monotonic_count":
+		sender.MonotonicCount(metricName, value, "", tags)
+	default:
+		log.Warnf("unsupported forced type %s for %s", forcedType, metricName)
+	}
+}
+
+// get sends GET requests for the OIDs, by batches
+func (c *SNMPCheck) get(oids []string) (snmpgo.VarBinds, error) {
+	var result snmpgo.VarBinds
+	for start := 0; start < len(oids); start += maxOIDsPerRequest {
+		end := start + maxOIDsPerRequest
+		if end > len(oids) {
+			end = len(oids)
 		}
-		if pdu.ErrorStatus() != snmpgo.NoError {
-			// Received an error from the agent
-			log.Warnf("Received error from SNMP agent: %v - %v", pdu.ErrorStatus(), pdu.ErrorIndex())
+		batch, err := snmpgo.NewOids(oids[start:end])
+		if err != nil {
+			return nil, err
 		}
-
-		for _, oid := range oids {
-			varbinds := pdu.VarBinds().MatchBaseOids(oid)
-			for _, collected := range varbinds {
-				if collected != nil && collected.Variable.Type() != "NoSucheInstance" {
-					log.Debugf("Collected OID: %v", collected.Oid.String())
-					collectedVars = append(collectedVars, collected)
-				}
+		for len(batch) > 0 {
+			pdu, err := c.snmp.GetRequest(batch)
+			if err != nil {
+				return nil, err
+			}
+			if pdu.ErrorStatus() == snmpgo.NoError {
+				result = append(result, pdu.VarBinds()...)
+				break
 			}
+			// v1 agents fail the whole request on an unknown OID, it is
+			// left to be walked and the others are requested again
+			index := pdu.ErrorIndex()
+			if pdu.ErrorStatus() != snmpgo.NoSuchName || index < 1 || index > len(batch) {
+				log.Debugf("Received error from SNMP agent: %v - %v", pdu.ErrorStatus(), pdu.ErrorIndex())
+				break
+			}
+			batch = append(batch[:index-1:index-1], batch[index:]...)
 		}
 	}
-
-	log.Debugf("Submitting metrics...")
-	c.submitSNMP(oids, collectedVars)
-
-	//TODO: send service checks
-	return nil
+	return result, nil
 }
 
-// Configure the check from YAML data
-func (c *SNMPCheck) Configure(data check.ConfigData, initConfig check.ConfigData) error {
-
-	cfg := new(snmpConfig)
-	err := cfg.parse(data, initConfig)
+// walk returns the variables under the base OID, with GETBULK requests of
+// bulk_max_repetitions variables, or GETNEXT requests with SNMP v1
+func (c *SNMPCheck) walk(base string) (snmpgo.VarBinds, error) {
+	baseOID, err := snmpgo.NewOid(base)
 	if err != nil {
-		log.Criticalf("Error parsing configuration file: %s ", err)
-		return err
+		return nil, err
 	}
-	c.BuildID(data, initConfig)
-	c.cfg = cfg
 
-	//init SNMP - will fail if missing snmp libs.
-	if err = initCNetSnmpLib(&c.cfg.initConf); err != nil {
-		log.Criticalf("Unable to configure check: %s ", err)
-		return err
-	}
+	var result snmpgo.VarBinds
+	current := baseOID
+	for {
+		var pdu snmpgo.Pdu
+		if c.cfg.instance.Version == 1 {
+			pdu, err = c.snmp.GetNextRequest(snmpgo.Oids{current})
+		} else {
+			pdu, err = c.snmp.GetBulkRequest(snmpgo.Oids{current}, 0, c.cfg.instance.MaxRepetitions)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if pdu.ErrorStatus() != snmpgo.NoError {
+			// the end of the MIB view of v1 agents
+			if pdu.ErrorStatus() == snmpgo.NoSuchName {
+				return result, nil
+			}
+			return nil, fmt.Errorf("error walking %s: %v", base, pdu.ErrorStatus())
+		}
 
-	//create snmp object for instance
-	snmpver := snmpgo.V2c
-	if c.cfg.instance.Version == 3 {
-		snmpver = snmpgo.V3
+		vbs := pdu.VarBinds()
+		if len(vbs) == 0 {
+			return result, nil
+		}
+		for _, vb := range vbs {
+			if !isValue(vb.Variable) || !isUnder(baseOID, vb.Oid) {
+				return result, nil
+			}
+			if compareOIDs(vb.Oid.Value, current.Value) <= 0 {
+				return nil, fmt.Errorf("error walking %s: the agent returned the non increasing OID %s", base, vb.Oid)
+			}
+			result = append(result, vb)
+			current = vb.Oid
+		}
 	}
+}
 
-	//sec level
-	seclevel := snmpgo.NoAuthNoPriv
-	if snmpver == snmpgo.V3 && c.cfg.instance.AuthKey != "" {
-		if c.cfg.instance.PrivKey != "" {
-			seclevel = snmpgo.AuthPriv
-		} else {
-			seclevel = snmpgo.AuthNoPriv
-		}
-	}
-
-	c.cfg.instance.snmp, err = snmpgo.NewSNMP(snmpgo.SNMPArguments{
-		Version:         snmpver,
-		Address:         net.JoinHostPort(c.cfg.instance.Host, strconv.Itoa(int(c.cfg.instance.Port))),
-		Retries:         c.cfg.instance.Retries,
-		Timeout:         time.Duration(c.cfg.instance.Timeout) * time.Second,
-		UserName:        c.cfg.instance.User,
-		Community:       c.cfg.instance.Community,
-		AuthPassword:    c.cfg.instance.AuthKey,
-		AuthProtocol:    snmpgo.AuthProtocol(c.cfg.instance.AuthProtocol),
-		PrivPassword:    c.cfg.instance.PrivKey,
-		PrivProtocol:    snmpgo.PrivProtocol(c.cfg.instance.PrivProtocol),
-		ContextEngineId: c.cfg.instance.ContextEngineId,
-		ContextName:     c.cfg.instance.ContextName,
-		SecurityLevel:   seclevel,
-	})
-	if err != nil {
-		// Failed to create snmpgo.SNMP object
-		log.Warnf("Error creating SNMP instance for: %s (%v) - skipping", c.cfg.instance.Host, err)
-		return err
+// isValue returns false for the exceptions reported instead of a value
+func isValue(variable snmpgo.Variable) bool {
+	switch variable.Type() {
+	case "NoSucheObject", "NoSucheInstance", "EndOfMibView", "Null":
+		return false
 	}
+	return true
+}
 
-	// genereate OID Translator and TagMap - one time thing
-	if c.cfg.instance.OIDTranslator == nil {
-		log.Debugf("Generating OIDs...")
-		if err := c.cfg.instance.generateOIDs(); err != nil {
-			return err
-		}
+// isUnder returns whether the OID is in the subtree of the base OID
+func isUnder(base, oid *snmpgo.Oid) bool {
+	if len(oid.Value) <= len(base.Value) {
+		return false
 	}
-	if c.cfg.instance.TagMap == nil {
-		log.Debugf("Generating TagMap...")
-		if err := c.cfg.instance.generateTagMap(); err != nil {
-			return err
+	for i, id := range base.Value {
+		if oid.Value[i] != id {
+			return false
 		}
 	}
+	return true
+}
 
-	return nil
+func compareOIDs(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
 }
 
-// Run runs the check
-func (c *SNMPCheck) Run() error {
-	log.Debugf("Grabbing SNMP variables...")
-	if err := c.getSNMP(); err != nil {
-		return err
+// rowIndex returns the index of the row, the OID parts after the column
+func rowIndex(column, oid *snmpgo.Oid) string {
+	parts := make([]string, 0, len(oid.Value)-len(column.Value))
+	for _, id := range oid.Value[len(column.Value):] {
+		parts = append(parts, strconv.Itoa(id))
 	}
+	return strings.Join(parts, ".")
+}
 
-	return nil
+// variableValue converts the SNMP variable to a metric value, octet
+// strings being parsed as some devices report numbers as strings
+func variableValue(variable snmpgo.Variable) (float64, error) {
+	if variable.Type() == "OctetString" {
+		return strconv.ParseFloat(strings.TrimSpace(variable.String()), 64)
+	}
+	value, err := variable.BigInt()
+	if err != nil {
+		return 0, err
+	}
+	if value == nil {
+		return 0, fmt.Errorf("%s has no numeric value", variable.Type())
+	}
+	f, _ := new(big.Float).SetInt(value).Float64()
+	return f, nil
 }
 
 func snmpFactory() check.Check {
@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build snmp
+
+package network
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mibObject is an object of the MIBs known by the check, scalars being
+// requested with their .0 instance
+type mibObject struct {
+	mib    string
+	oid    string
+	scalar bool
+}
+
+func mibScalar(mib, oid string) mibObject { return mibObject{mib: mib, oid: oid, scalar: true} }
+func mibColumn(mib, oid string) mibObject { return mibObject{mib: mib, oid: oid} }
+
+// mibObjects are the objects of the standard MIBs the check resolves by
+// name, the other symbols must be given by OID
+var mibObjects = map[string]mibObject{
+	// SNMPv2-MIB
+	"sysDescr":                mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.1.1"),
+	"sysObjectID":             mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.1.2"),
+	"sysUpTime":               mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.1.3"),
+	"sysContact":              mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.1.4"),
+	"sysName":                 mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.1.5"),
+	"sysLocation":             mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.1.6"),
+	"sysServices":             mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.1.7"),
+	"snmpInPkts":              mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.11.1"),
+	"snmpOutPkts":             mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.11.2"),
+	"snmpInBadVersions":       mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.11.3"),
+	"snmpInBadCommunityNames": mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.11.4"),
+	"snmpInBadCommunityUses":  mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.11.5"),
+	"snmpInASNParseErrs":      mibScalar("SNMPv2-MIB", "1.3.6.1.2.1.11.6"),
+
+	// IF-MIB
+	"ifNumber":             mibScalar("IF-MIB", "1.3.6.1.2.1.2.1"),
+	"ifIndex":              mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.1"),
+	"ifDescr":              mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.2"),
+	"ifType":               mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.3"),
+	"ifMtu":                mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.4"),
+	"ifSpeed":              mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.5"),
+	"ifPhysAddress":        mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.6"),
+	"ifAdminStatus":        mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.7"),
+	"ifOperStatus":         mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.8"),
+	"ifLastChange":         mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.9"),
+	"ifInOctets":           mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.10"),
+	"ifInUcastPkts":        mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.11"),
+	"ifInNUcastPkts":       mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.12"),
+	"ifInDiscards":         mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.13"),
+	"ifInErrors":           mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.14"),
+	"ifInUnknownProtos":    mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.15"),
+	"ifOutOctets":          mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.16"),
+	"ifOutUcastPkts":       mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.17"),
+	"ifOutNUcastPkts":      mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.18"),
+	"ifOutDiscards":        mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.19"),
+	"ifOutErrors":          mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.20"),
+	"ifOutQLen":            mibColumn("IF-MIB", "1.3.6.1.2.1.2.2.1.21"),
+	"ifName":               mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.1"),
+	"ifInMulticastPkts":    mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.2"),
+	"ifInBroadcastPkts":    mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.3"),
+	"ifOutMulticastPkts":   mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.4"),
+	"ifOutBroadcastPkts":   mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.5"),
+	"ifHCInOctets":         mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.6"),
+	"ifHCInUcastPkts":      mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.7"),
+	"ifHCInMulticastPkts":  mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.8"),
+	"ifHCInBroadcastPkts":  mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.9"),
+	"ifHCOutOctets":        mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.10"),
+	"ifHCOutUcastPkts":     mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.11"),
+	"ifHCOutMulticastPkts": mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.12"),
+	"ifHCOutBroadcastPkts": mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.13"),
+	"ifHighSpeed":          mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.15"),
+	"ifAlias":              mibColumn("IF-MIB", "1.3.6.1.2.1.31.1.1.1.18"),
+
+	// IP-MIB
+	"ipForwarding":                   mibScalar("IP-MIB", "1.3.6.1.2.1.4.1"),
+	"ipDefaultTTL":                   mibScalar("IP-MIB", "1.3.6.1.2.1.4.2"),
+	"ipInReceives":                   mibScalar("IP-MIB", "1.3.6.1.2.1.4.3"),
+	"ipInHdrErrors":                  mibScalar("IP-MIB", "1.3.6.1.2.1.4.4"),
+	"ipInAddrErrors":                 mibScalar("IP-MIB", "1.3.6.1.2.1.4.5"),
+	"ipForwDatagrams":                mibScalar("IP-MIB", "1.3.6.1.2.1.4.6"),
+	"ipInUnknownProtos":              mibScalar("IP-MIB", "1.3.6.1.2.1.4.7"),
+	"ipInDiscards":                   mibScalar("IP-MIB", "1.3.6.1.2.1.4.8"),
+	"ipInDelivers":                   mibScalar("IP-MIB", "1.3.6.1.2.1.4.9"),
+	"ipOutRequests":                  mibScalar("IP-MIB", "1.3.6.1.2.1.4.10"),
+	"ipOutDiscards":                  mibScalar("IP-MIB", "1.3.6.1.2.1.4.11"),
+	"ipOutNoRoutes":                  mibScalar("IP-MIB", "1.3.6.1.2.1.4.12"),
+	"ipReasmTimeout":                 mibScalar("IP-MIB", "1.3.6.1.2.1.4.13"),
+	"ipReasmReqds":                   mibScalar("IP-MIB", "1.3.6.1.2.1.4.14"),
+	"ipReasmOKs":                     mibScalar("IP-MIB", "1.3.6.1.2.1.4.15"),
+	"ipReasmFails":                   mibScalar("IP-MIB", "1.3.6.1.2.1.4.16"),
+	"ipFragOKs":                      mibScalar("IP-MIB", "1.3.6.1.2.1.4.17"),
+	"ipFragFails":                    mibScalar("IP-MIB", "1.3.6.1.2.1.4.18"),
+	"ipFragCreates":                  mibScalar("IP-MIB", "1.3.6.1.2.1.4.19"),
+	"ipSystemStatsInReceives":        mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.3"),
+	"ipSystemStatsHCInReceives":      mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.4"),
+	"ipSystemStatsInOctets":          mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.5"),
+	"ipSystemStatsHCInOctets":        mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.6"),
+	"ipSystemStatsInHdrErrors":       mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.7"),
+	"ipSystemStatsInNoRoutes":        mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.8"),
+	"ipSystemStatsInAddrErrors":      mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.9"),
+	"ipSystemStatsInUnknownProtos":   mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.10"),
+	"ipSystemStatsInTruncatedPkts":   mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.11"),
+	"ipSystemStatsInForwDatagrams":   mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.12"),
+	"ipSystemStatsHCInForwDatagrams": mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.13"),
+	"ipSystemStatsReasmReqds":        mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.14"),
+	"ipSystemStatsReasmOKs":          mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.15"),
+	"ipSystemStatsReasmFails":        mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.16"),
+	"ipSystemStatsInDiscards":        mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.17"),
+	"ipSystemStatsInDelivers":        mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.18"),
+	"ipSystemStatsHCInDelivers":      mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.19"),
+	"ipSystemStatsOutRequests":       mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.20"),
+	"ipSystemStatsHCOutRequests":     mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.21"),
+	"ipSystemStatsOutNoRoutes":       mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.22"),
+	"ipSystemStatsOutDiscards":       mibColumn("IP-MIB", "1.3.6.1.2.1.4.31.1.1.25"),
+
+	// TCP-MIB
+	"tcpRtoAlgorithm": mibScalar("TCP-MIB", "1.3.6.1.2.1.6.1"),
+	"tcpRtoMin":       mibScalar("TCP-MIB", "1.3.6.1.2.1.6.2"),
+	"tcpRtoMax":       mibScalar("TCP-MIB", "1.3.6.1.2.1.6.3"),
+	"tcpMaxConn":      mibScalar("TCP-MIB", "1.3.6.1.2.1.6.4"),
+	"tcpActiveOpens":  mibScalar("TCP-MIB", "1.3.6.1.2.1.6.5"),
+	"tcpPassiveOpens": mibScalar("TCP-MIB", "1.3.6.1.2.1.6.6"),
+	"tcpAttemptFails": mibScalar("TCP-MIB", "1.3.6.1.2.1.6.7"),
+	"tcpEstabResets":  mibScalar("TCP-MIB", "1.3.6.1.2.1.6.8"),
+	"tcpCurrEstab":    mibScalar("TCP-MIB", "1.3.6.1.2.1.6.9"),
+	"tcpInSegs":       mibScalar("TCP-MIB", "1.3.6.1.2.1.6.10"),
+	"tcpOutSegs":      mibScalar("TCP-MIB", "1.3.6.1.2.1.6.11"),
+	"tcpRetransSegs":  mibScalar("TCP-MIB", "1.3.6.1.2.1.6.12"),
+	"tcpInErrs":       mibScalar("TCP-MIB", "1.3.6.1.2.1.6.14"),
+	"tcpOutRsts":      mibScalar("TCP-MIB", "1.3.6.1.2.1.6.15"),
+	"tcpHCInSegs":     mibScalar("TCP-MIB", "1.3.6.1.2.1.6.17"),
+	"tcpHCOutSegs":    mibScalar("TCP-MIB", "1.3.6.1.2.1.6.18"),
+
+	// UDP-MIB
+	"udpInDatagrams":    mibScalar("UDP-MIB", "1.3.6.1.2.1.7.1"),
+	"udpNoPorts":        mibScalar("UDP-MIB", "1.3.6.1.2.1.7.2"),
+	"udpInErrors":       mibScalar("UDP-MIB", "1.3.6.1.2.1.7.3"),
+	"udpOutDatagrams":   mibScalar("UDP-MIB", "1.3.6.1.2.1.7.4"),
+	"udpHCInDatagrams":  mibScalar("UDP-MIB", "1.3.6.1.2.1.7.8"),
+	"udpHCOutDatagrams": mibScalar("UDP-MIB", "1.3.6.1.2.1.7.9"),
+
+	// HOST-RESOURCES-MIB
+	"hrSystemUptime":              mibScalar("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.1.1"),
+	"hrSystemNumUsers":            mibScalar("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.1.5"),
+	"hrSystemProcesses":           mibScalar("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.1.6"),
+	"hrSystemMaxProcesses":        mibScalar("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.1.7"),
+	"hrMemorySize":                mibScalar("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.2.2"),
+	"hrStorageIndex":              mibColumn("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.2.3.1.1"),
+	"hrStorageType":               mibColumn("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.2.3.1.2"),
+	"hrStorageDescr":              mibColumn("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.2.3.1.3"),
+	"hrStorageAllocationUnits":    mibColumn("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.2.3.1.4"),
+	"hrStorageSize":               mibColumn("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.2.3.1.5"),
+	"hrStorageUsed":               mibColumn("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.2.3.1.6"),
+	"hrStorageAllocationFailures": mibColumn("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.2.3.1.7"),
+	"hrProcessorLoad":             mibColumn("HOST-RESOURCES-MIB", "1.3.6.1.2.1.25.3.3.1.2"),
+}
+
+// rfc1213Groups are the MIBs that took over the groups of RFC1213-MIB,
+// whose names are accepted for their objects
+var rfc1213Groups = map[string]bool{
+	"SNMPv2-MIB": true,
+	"IF-MIB":     true,
+	"IP-MIB":     true,
+	"TCP-MIB":    true,
+	"UDP-MIB":    true,
+}
+
+// lookupMIBObject resolves a symbol of a MIB, the symbol being a name or a
+// MIB::name couple. It returns the OID of the object, with the .0 instance
+// of scalars when asked, and the name of the symbol without its MIB.
+func lookupMIBObject(mib, symbol string, instance bool) (string, string, error) {
+	if parts := strings.SplitN(symbol, "::", 2); len(parts) == 2 {
+		mib, symbol = parts[0], parts[1]
+	}
+	object, found := mibObjects[symbol]
+	if !found {
+		return "", symbol, fmt.Errorf("symbol %s of %s is not a known MIB object", symbol, mibOrUnknown(mib))
+	}
+	if mib != "" && mib != object.mib && !(mib == "RFC1213-MIB" && rfc1213Groups[object.mib]) {
+		return "", symbol, fmt.Errorf("symbol %s is not an object of %s but of %s", symbol, mib, object.mib)
+	}
+	if instance && object.scalar {
+		return object.oid + ".0", symbol, nil
+	}
+	return object.oid, symbol, nil
+}
+
+func mibOrUnknown(mib string) string {
+	if mib == "" {
+		return "an unknown MIB"
+	}
+	return mib
+}
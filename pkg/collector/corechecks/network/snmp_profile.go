@@ -0,0 +1,129 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build snmp
+
+package network
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// profileDefinition is the metrics of a kind of device, used for the
+// devices whose sysObjectID matches its sysobjectid pattern
+type profileDefinition struct {
+	Extends     []string       `yaml:"extends"`
+	SysObjectID string         `yaml:"sysobjectid"`
+	Metrics     []metricConfig `yaml:"metrics"`
+}
+
+// profileDefinitions are the profiles by name, the name of their file
+// without extension
+type profileDefinitions map[string]*profileDefinition
+
+// loadProfiles reads the profiles of the folder. The files starting with
+// an underscore are only meant to be extended and are not profiles.
+func loadProfiles(folder string) (profileDefinitions, error) {
+	files, err := filepath.Glob(filepath.Join(folder, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := profileDefinitions{}
+	for _, file := range files {
+		base := filepath.Base(file)
+		if strings.HasPrefix(base, "_") {
+			continue
+		}
+		profile, err := readProfile(folder, base, nil)
+		if err != nil {
+			return nil, err
+		}
+		profiles[strings.TrimSuffix(base, filepath.Ext(base))] = profile
+	}
+	return profiles, nil
+}
+
+// readProfile reads a profile file of the folder and adds the metrics of
+// the files it extends, seen holding the files being read to detect cycles
+func readProfile(folder, name string, seen []string) (*profileDefinition, error) {
+	for _, s := range seen {
+		if s == name {
+			return nil, fmt.Errorf("profile %s extends itself through %s", name, strings.Join(seen, ", "))
+		}
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(folder, name))
+	if err != nil {
+		return nil, err
+	}
+	var profile profileDefinition
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %s", name, err)
+	}
+	for i := range profile.Metrics {
+		metric := &profile.Metrics[i]
+		if err := metric.resolve(); err != nil {
+			return nil, fmt.Errorf("invalid profile %s: %s", name, err)
+		}
+		if err := metric.validate(); err != nil {
+			return nil, fmt.Errorf("invalid profile %s: %s", name, err)
+		}
+	}
+
+	for _, parentName := range profile.Extends {
+		parent, err := readProfile(folder, parentName, append(seen, name))
+		if err != nil {
+			return nil, err
+		}
+		profile.Metrics = append(profile.Metrics, parent.Metrics...)
+	}
+	return &profile, nil
+}
+
+// match returns the name of the profile whose pattern matches the
+// sysObjectID. An exact OID wins over the patterns, the longest pattern
+// winning between them.
+func (p profileDefinitions) match(sysObjectID string) (string, bool) {
+	sysObjectID = strings.TrimPrefix(sysObjectID, ".")
+
+	matched := ""
+	matchedPattern := ""
+	for name, profile := range p {
+		pattern := strings.TrimPrefix(profile.SysObjectID, ".")
+		if pattern == "" {
+			continue
+		}
+		if ok, _ := path.Match(pattern, sysObjectID); !ok {
+			continue
+		}
+		if matched == "" || morePrecise(pattern, name, matchedPattern, matched) {
+			matched = name
+			matchedPattern = pattern
+		}
+	}
+	return matched, matched != ""
+}
+
+// morePrecise returns whether the first pattern is more precise than the
+// second one, the profile names breaking ties so that the match does not
+// depend on the map order
+func morePrecise(pattern, name, otherPattern, otherName string) bool {
+	exact := !strings.ContainsAny(pattern, "*?[")
+	otherExact := !strings.ContainsAny(otherPattern, "*?[")
+	switch {
+	case exact != otherExact:
+		return exact
+	case len(pattern) != len(otherPattern):
+		return len(pattern) > len(otherPattern)
+	}
+	return name < otherName
+}
@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build snmp
+
+package network
+
+import (
+	"encoding/asn1"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/k-sone/snmpgo"
+	"github.com/stretchr/testify/require"
+)
+
+// snmpMessage is a SNMP v1 or v2c message, the PDU being decoded by snmpgo
+type snmpMessage struct {
+	Version   int
+	Community []byte
+	Pdu       asn1.RawValue
+}
+
+// snmpSimulator is an in-process SNMP v1 and v2c agent answering the GET,
+// GETNEXT and GETBULK requests from a fixed set of variables
+type snmpSimulator struct {
+	community string
+	conn      *net.UDPConn
+	oids      []*snmpgo.Oid
+	values    map[string]snmpgo.Variable
+}
+
+func newSNMPSimulator(t *testing.T, community string, values map[string]snmpgo.Variable) *snmpSimulator {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+
+	s := &snmpSimulator{
+		community: community,
+		conn:      conn,
+		values:    make(map[string]snmpgo.Variable, len(values)),
+	}
+	for oid, value := range values {
+		o, err := snmpgo.NewOid(oid)
+		require.NoError(t, err)
+		s.oids = append(s.oids, o)
+		s.values[o.String()] = value
+	}
+	sort.Slice(s.oids, func(i, j int) bool { return compareOIDs(s.oids[i].Value, s.oids[j].Value) < 0 })
+
+	go s.serve()
+	return s
+}
+
+func (s *snmpSimulator) port() int {
+	return s.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func (s *snmpSimulator) close() {
+	s.conn.Close()
+}
+
+func (s *snmpSimulator) serve() {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		response, err := s.handle(buf[:n])
+		if err != nil {
+			// like real agents, the invalid requests are not answered
+			continue
+		}
+		s.conn.WriteToUDP(response, addr)
+	}
+}
+
+func (s *snmpSimulator) handle(b []byte) ([]byte, error) {
+	var msg snmpMessage
+	if _, err := asn1.Unmarshal(b, &msg); err != nil {
+		return nil, err
+	}
+	if string(msg.Community) != s.community {
+		return nil, asn1.StructuralError{Msg: "wrong community"}
+	}
+
+	version := snmpgo.SNMPVersion(msg.Version)
+	request := snmpgo.NewPdu(version, snmpgo.GetRequest)
+	if _, err := request.Unmarshal(msg.Pdu.FullBytes); err != nil {
+		return nil, err
+	}
+
+	response := snmpgo.NewPdu(version, snmpgo.GetResponse)
+	response.SetRequestId(request.RequestId())
+	vbs := request.VarBinds()
+
+	switch request.PduType() {
+	case snmpgo.GetRequest:
+		for i, vb := range vbs {
+			value, found := s.values[vb.Oid.String()]
+			if !found {
+				if version == snmpgo.V1 {
+					return s.v1Error(msg, request, i)
+				}
+				value = snmpgo.NewNoSucheInstance()
+			}
+			response.AppendVarBind(vb.Oid, value)
+		}
+	case snmpgo.GetNextRequest:
+		for i, vb := range vbs {
+			next := s.next(vb.Oid)
+			if next == nil {
+				if version == snmpgo.V1 {
+					return s.v1Error(msg, request, i)
+				}
+				response.AppendVarBind(vb.Oid, snmpgo.NewEndOfMibView())
+				continue
+			}
+			response.AppendVarBind(next, s.values[next.String()])
+		}
+	case snmpgo.GetBulkRequest:
+		// the error fields of the bulk requests hold their parameters
+		nonRepeaters := int(request.ErrorStatus())
+		maxRepetitions := request.ErrorIndex()
+		for i, vb := range vbs {
+			repetitions := maxRepetitions
+			if i < nonRepeaters {
+				repetitions = 1
+			}
+			current := vb.Oid
+			for r := 0; r < repetitions; r++ {
+				next := s.next(current)
+				if next == nil {
+					response.AppendVarBind(current, snmpgo.NewEndOfMibView())
+					break
+				}
+				response.AppendVarBind(next, s.values[next.String()])
+				current = next
+			}
+		}
+	}
+
+	return s.marshal(msg, response)
+}
+
+// v1Error answers with the noSuchName error v1 agents send for the unknown
+// OIDs, echoing the variables of the request
+func (s *snmpSimulator) v1Error(msg snmpMessage, request snmpgo.Pdu, index int) ([]byte, error) {
+	response := snmpgo.NewPduWithVarBinds(snmpgo.V1, snmpgo.GetResponse, request.VarBinds())
+	response.SetRequestId(request.RequestId())
+	response.SetErrorStatus(snmpgo.NoSuchName)
+	response.SetErrorIndex(index + 1)
+	return s.marshal(msg, response)
+}
+
+func (s *snmpSimulator) marshal(msg snmpMessage, response snmpgo.Pdu) ([]byte, error) {
+	pdu, err := response.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	msg.Pdu = asn1.RawValue{FullBytes: pdu}
+	return asn1.Marshal(msg)
+}
+
+// next returns the first OID of the agent following the given one
+func (s *snmpSimulator) next(oid *snmpgo.Oid) *snmpgo.Oid {
+	i := sort.Search(len(s.oids), func(i int) bool { return compareOIDs(s.oids[i].Value, oid.Value) > 0 })
+	if i == len(s.oids) {
+		return nil
+	}
+	return s.oids[i]
+}
@@ -3,293 +3,374 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2018 Datadog, Inc.
 
-// +build !windows
 // +build snmp
 
 package network
 
 import (
-	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
-	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
 	"github.com/k-sone/snmpgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
 )
 
+// the profiles shipped with the agent
+var shippedProfiles = filepath.Join("..", "..", "..", "..", "cmd", "agent", "dist", "conf.d", "snmp.d", "profiles")
+
 const goodV2Cfg = `
 ip_address: localhost
 port: 161
 community_string: public
-snmp_version: 2 # Only required for snmp v1, will default to 2
-timeout: 1 # second, by default
-retries: 5
+snmp_version: 2
+timeout: 2
+retries: 3
 tags:
   - optional_tag_1
   - optional_tag_2
 metrics:
-  - MIB: UDP-MIB
-    symbol: udpInDatagrams
-  - MIB: TCP-MIB
-    symbol: tcpActiveOpens
-  - OID: 1.3.6.1.2.1.6.6
+  - OID: 1.3.6.1.2.1.6.6.0
     name: tcpPassiveOpens
   - OID: 1.3.6.1.4.1.3375.2.1.1.2.1.8.0
     name: F5_TotalCurrentConnections
     forced_type: gauge
-  - MIB: IF-MIB
-    table: ifTable
+  - table: ifTable
     symbols:
-      - ifInOctets
-      - ifOutOctets
+      - OID: 1.3.6.1.2.1.2.2.1.10
+        name: ifInOctets
     metric_tags:
       - tag: interface
-        column: ifDescr  # specify which column to read the tag value from
-  - MIB: IP-MIB
-    table: ipSystemStatsTable
-    symbols:
-      - ipSystemStatsInReceives
-    metric_tags:
-      - tag: ipversion
-        index: 1
+        column:
+          OID: 1.3.6.1.2.1.2.2.1.2
+          name: ifDescr
 `
 
 const goodV3Cfg = `
 ip_address: 192.168.34.10
-# port: 161 # default value
 user: user
 authKey: password
 privKey: private_key
-authProtocol: authProtocol
-privProtocol: privProtocol
-timeout: 1 # second, by default
-retries: 5
-tags:
-  - optional_tag_1
-  - optional_tag_2
-metrics:
-  - MIB: UDP-MIB
-    symbol: udpInDatagrams
-  - MIB: TCP-MIB
-    symbol: tcpActiveOpens
-`
-
-const basicCfg = `
-ip_address: localhost
-port: 161
-community_string: public
-snmp_version: 2 # Only required for snmp v1, will default to 2
-timeout: 1 # second, by default
-retries: 5
-tags:
-  - optional_tag_1
-  - optional_tag_2
+authProtocol: usmHMACSHAAuthProtocol
+privProtocol: AES
+context_name: context
 metrics:
-  - MIB: UDP-MIB
-    symbol: udpInDatagrams
-  - MIB: TCP-MIB
-    symbol: tcpActiveOpens
-  - OID: 1.3.6.1.2.1.6.6
-    name: tcpPassiveOpens
-  - OID: 1.3.6.1.4.1.3375.2.1.1.2.1.8.0
-    name: F5_TotalCurrentConnections
-    forced_type: gauge
+  - OID: 1.3.6.1.2.1.6.5.0
+    name: tcpActiveOpens
 `
 
-func TestTextualOIDConversion(t *testing.T) {
-
-	oid := "1.3.6.1.2.1.4.31.1.1.3"
-
-	initCNetSnmpLib(nil)
-
-	textOID, err := getTextualOID(oid)
-	if err != nil {
-		t.Fatalf("Unable to get index tag: %v", err)
-	}
-
-	if textOID != "IP-MIB::ipSystemStatsInReceives" {
-		t.Fatalf("Incorrect tag retrieved. Expected IP-MIB::ipSystemStatsInReceives got: %v", textOID)
-	}
+// netSNMPDevice is a linux host matching the net-snmp profile
+var netSNMPDevice = map[string]snmpgo.Variable{
+	"1.3.6.1.2.1.1.2.0": snmpgo.MustNewOid("1.3.6.1.4.1.8072.3.2.10"),
+	// ifTable
+	"1.3.6.1.2.1.2.2.1.2.1":  snmpgo.NewOctetString([]byte("eth0")),
+	"1.3.6.1.2.1.2.2.1.2.2":  snmpgo.NewOctetString([]byte("lo")),
+	"1.3.6.1.2.1.2.2.1.14.1": snmpgo.NewCounter32(3),
+	"1.3.6.1.2.1.2.2.1.14.2": snmpgo.NewCounter32(0),
+	// ifXTable
+	"1.3.6.1.2.1.31.1.1.1.1.1":  snmpgo.NewOctetString([]byte("eth0")),
+	"1.3.6.1.2.1.31.1.1.1.6.1":  snmpgo.NewCounter64(1234567890123),
+	"1.3.6.1.2.1.31.1.1.1.15.1": snmpgo.NewGauge32(1000),
+	// ipSystemStatsTable
+	"1.3.6.1.2.1.4.31.1.1.3.1": snmpgo.NewCounter64(100),
+	"1.3.6.1.2.1.4.31.1.1.3.2": snmpgo.NewCounter64(200),
+	// TCP-MIB
+	"1.3.6.1.2.1.6.5.0": snmpgo.NewCounter32(42),
+	"1.3.6.1.2.1.6.9.0": snmpgo.NewGauge32(7),
+	// UCD-SNMP-MIB
+	"1.3.6.1.4.1.2021.4.5.0":     snmpgo.NewInteger(2048000),
+	"1.3.6.1.4.1.2021.10.1.2.1":  snmpgo.NewOctetString([]byte("Load-1")),
+	"1.3.6.1.4.1.2021.10.1.3.1":  snmpgo.NewOctetString([]byte("0.15")),
+	"1.3.6.1.4.1.2021.11.50.0":   snmpgo.NewCounter32(9000),
+	"1.3.6.1.4.1.2021.100.1.0":   snmpgo.NewOctetString([]byte("not a number")),
+	"1.3.6.1.4.1.8072.1.3.2.1.0": snmpgo.NewInteger(1),
 }
 
-func TestIndexTagExtraction(t *testing.T) {
-	base := "1.3.6.1.2.1.4.31.1.1.3"
-	oid := "1.3.6.1.2.1.4.31.1.1.3.1.4"
-	oidv6 := "1.3.6.1.2.1.4.31.1.1.3.2.4.9.1"
-
-	initCNetSnmpLib(nil)
-
-	tag, err := getIndexTag(base, oid, 1)
-	if err != nil {
-		t.Fatalf("Unable to get index tag: %v", err)
-	}
+func runSNMPCheck(t *testing.T, instance, initConfig string) (*SNMPCheck, *mocksender.MockSender, error) {
+	snmpCheck := snmpFactory().(*SNMPCheck)
+	require.NoError(t, snmpCheck.Configure([]byte(instance), []byte(initConfig)))
 
-	if tag != "ipv4" {
-		t.Fatalf("Incorrect tag retrieved. Expected ipv4 got: %v", tag)
-	}
-
-	tag, err = getIndexTag(base, oidv6, 1)
-	if err != nil {
-		t.Fatalf("Unable to get index tag: %v", err)
-	}
-
-	if tag != "ipv6" {
-		t.Fatalf("Incorrect tag retrieved. Expected ipv6 got: %v", tag)
-	}
+	mockSender := mocksender.NewMockSender(snmpCheck.ID())
+	mockSender.SetupAcceptAll()
+	err := snmpCheck.Run()
+	return snmpCheck, mockSender, err
 }
 
 func TestConfigureV2(t *testing.T) {
 	cfg := new(snmpConfig)
-
-	err := cfg.parse(bytes.NewBufferString(goodV2Cfg).Bytes(), []byte{})
-	if err != nil {
-		t.Fatalf("Unable to parse configuration: %v", err)
-	}
-
-	if cfg.instance.Host != "localhost" {
-		t.Fatalf("Failed hostname: expected 'localhost' got '%v'", cfg.instance.Host)
-	}
-	if cfg.instance.Port != 161 {
-		t.Fatalf("Failed port: expected '161' got '%v'", cfg.instance.Port)
-	}
-	if cfg.instance.Community != "public" {
-		t.Fatalf("Failed community: expected 'public' got '%v'", cfg.instance.Community)
-	}
-	if cfg.instance.Version != 2 {
-		t.Fatalf("Failed snmp version: expected '2' got '%v'", cfg.instance.Version)
-	}
-	if cfg.instance.Timeout != 1 {
-		t.Fatalf("Failed retries: expected '5' got '%v'", cfg.instance.Retries)
-	}
-	if cfg.instance.Retries != 5 {
-		t.Fatalf("Failed retries: expected '5' got '%v'", cfg.instance.Retries)
-	}
-
-	tag1Found := false
-	tag2Found := false
-
-	for _, tag := range cfg.instance.Tags {
-		if tag == "optional_tag_1" {
-			tag1Found = true
-		}
-		if tag == "optional_tag_2" {
-			tag2Found = true
-		}
-	}
-
-	if !tag1Found || !tag2Found {
-		t.Fatalf("Instance tags not properly unmarshalled.")
-	}
+	require.NoError(t, cfg.parse([]byte(goodV2Cfg), []byte{}))
+
+	assert.Equal(t, "localhost", cfg.instance.Host)
+	assert.Equal(t, uint(161), cfg.instance.Port)
+	assert.Equal(t, "public", cfg.instance.Community)
+	assert.Equal(t, 2, cfg.instance.Version)
+	assert.Equal(t, uint(2), cfg.instance.Timeout)
+	assert.Equal(t, uint(3), cfg.instance.Retries)
+	assert.Equal(t, defaultMaxRepetitions, cfg.instance.MaxRepetitions)
+	assert.Equal(t, []string{"optional_tag_1", "optional_tag_2"}, cfg.instance.Tags)
+	require.Len(t, cfg.instance.Metrics, 3)
+	assert.Equal(t, "ifDescr", cfg.instance.Metrics[2].Tags[0].Column.Name)
+
+	args, err := cfg.snmpArguments()
+	require.NoError(t, err)
+	assert.Equal(t, snmpgo.V2c, args.Version)
+	assert.Equal(t, "localhost:161", args.Address)
+	assert.Equal(t, "public", args.Community)
 }
 
 func TestConfigureV3(t *testing.T) {
 	cfg := new(snmpConfig)
+	require.NoError(t, cfg.parse([]byte(goodV3Cfg), []byte{}))
+	assert.Equal(t, 3, cfg.instance.Version)
+	assert.Equal(t, uint(defaultTimeout), cfg.instance.Timeout)
+	assert.Equal(t, uint(defaultRetries), cfg.instance.Retries)
+
+	args, err := cfg.snmpArguments()
+	require.NoError(t, err)
+	assert.Equal(t, snmpgo.V3, args.Version)
+	assert.Equal(t, snmpgo.AuthPriv, args.SecurityLevel)
+	assert.Equal(t, snmpgo.Sha, args.AuthProtocol)
+	assert.Equal(t, snmpgo.Aes, args.PrivProtocol)
+	assert.Equal(t, "context", args.ContextName)
+
+	// the protocols default to MD5 and DES
+	cfg.instance.AuthProtocol = ""
+	cfg.instance.PrivProtocol = ""
+	args, err = cfg.snmpArguments()
+	require.NoError(t, err)
+	assert.Equal(t, snmpgo.Md5, args.AuthProtocol)
+	assert.Equal(t, snmpgo.Des, args.PrivProtocol)
+
+	cfg.instance.PrivKey = ""
+	args, err = cfg.snmpArguments()
+	require.NoError(t, err)
+	assert.Equal(t, snmpgo.AuthNoPriv, args.SecurityLevel)
+
+	cfg.instance.PrivKey = "private_key"
+	cfg.instance.PrivProtocol = "usmAesCfb256Protocol"
+	_, err = cfg.snmpArguments()
+	assert.Error(t, err)
+}
 
-	err := cfg.parse(bytes.NewBufferString(goodV3Cfg).Bytes(), []byte{})
-	if err != nil {
-		t.Fatalf("Unable to parse configuration: %v", err)
+func TestConfigureErrors(t *testing.T) {
+	for name, instance := range map[string]string{
+		"no host":          "community_string: public",
+		"no name":          "ip_address: localhost\nmetrics:\n  - OID: 1.3.6.1.2.1.6.5.0",
+		"invalid OID":      "ip_address: localhost\nmetrics:\n  - OID: 1.3.foo\n    name: foo",
+		"tag without data": "ip_address: localhost\nmetrics:\n  - table: t\n    symbols:\n      - {OID: 1.3.6.1.2.1.2.2.1.10, name: ifInOctets}\n    metric_tags:\n      - tag: interface",
+	} {
+		cfg := new(snmpConfig)
+		assert.Error(t, cfg.parse([]byte(instance), []byte{}), name)
 	}
 }
 
-func TestSubmitSNMP(t *testing.T) {
-	snmpCheck := new(SNMPCheck)
+func TestConfigureMIBNames(t *testing.T) {
+	instance := `
+ip_address: localhost
+metrics:
+  - MIB: UDP-MIB
+    symbol: udpInDatagrams
+  - MIB: RFC1213-MIB
+    symbol: tcpCurrEstab
+    name: currentConnections
+  - MIB: IF-MIB
+    table: ifTable
+    symbols:
+      - ifInOctets
+      - IF-MIB::ifOutOctets
+    metric_tags:
+      - tag: interface
+        column: ifDescr
+  - MIB: CISCO-PROCESS-MIB
+    symbol: cpmCPUTotal1minRev
+  - MIB: TCP-MIB
+    symbol: udpNoPorts
+  - OID: 1.3.6.1.2.1.6.5.0
+    name: tcpActiveOpens
+`
 	cfg := new(snmpConfig)
+	require.NoError(t, cfg.parse([]byte(instance), []byte("mibs_folder: /usr/share/snmp/mibs")))
+
+	// the unknown symbols are skipped
+	metrics := cfg.instance.Metrics
+	require.Len(t, metrics, 4)
+	assert.Equal(t, "1.3.6.1.2.1.7.1.0", metrics[0].OID)
+	assert.Equal(t, "udpInDatagrams", metrics[0].Name)
+	assert.Equal(t, "1.3.6.1.2.1.6.9.0", metrics[1].OID)
+	assert.Equal(t, "currentConnections", metrics[1].Name)
+	assert.Equal(t, []symbolConfig{
+		{OID: "1.3.6.1.2.1.2.2.1.10", Name: "ifInOctets"},
+		{OID: "1.3.6.1.2.1.2.2.1.16", Name: "ifOutOctets"},
+	}, metrics[2].Symbols)
+	assert.Equal(t, symbolConfig{OID: "1.3.6.1.2.1.2.2.1.2", Name: "ifDescr"}, metrics[2].Tags[0].Column)
+	assert.Equal(t, "tcpActiveOpens", metrics[3].Name)
+}
 
-	initCNetSnmpLib(nil)
-
-	mock := mocksender.NewMockSender(snmpCheck.ID())
+func TestProfiles(t *testing.T) {
+	profiles, err := loadProfiles(shippedProfiles)
+	require.NoError(t, err)
+	assert.Contains(t, profiles, "cisco")
+	assert.Contains(t, profiles, "net-snmp")
+	assert.NotContains(t, profiles, "_generic-if")
+
+	// the metrics of the extended files are added
+	names := map[string]bool{}
+	for _, metric := range profiles["cisco"].Metrics {
+		names[metric.Name] = true
+		for _, symbol := range metric.Symbols {
+			names[symbol.Name] = true
+		}
+	}
+	for _, name := range []string{"cpmCPUTotal1minRev", "ifHCInOctets", "tcpActiveOpens"} {
+		assert.True(t, names[name], name)
+	}
 
-	if err := cfg.parse(bytes.NewBufferString(basicCfg).Bytes(), []byte{}); err != nil {
-		t.Fatalf("Unable to parse configuration: %v", err)
+	for sysObjectID, expected := range map[string]string{
+		"1.3.6.1.4.1.9.1.1745":       "cisco",
+		".1.3.6.1.4.1.9.1.516":       "cisco",
+		"1.3.6.1.4.1.8072.3.2.10":    "net-snmp",
+		"1.3.6.1.4.1.3375.2.1.3.4.1": "f5-big-ip",
+		"1.3.6.1.4.1.8072.3.2.255":   "",
+	} {
+		name, found := profiles.match(sysObjectID)
+		assert.Equal(t, expected, name, sysObjectID)
+		assert.Equal(t, expected != "", found, sysObjectID)
 	}
-	snmpCheck.cfg = cfg
+}
 
-	if err := snmpCheck.cfg.instance.generateOIDs(); err != nil {
-		t.Fatalf("Unable to generate OIDs: %v", err)
+func TestProfilesPrecedence(t *testing.T) {
+	profiles := profileDefinitions{
+		"generic":  {SysObjectID: "1.3.6.1.4.1.9.*"},
+		"specific": {SysObjectID: "1.3.6.1.4.1.9.1.*"},
+		"exact":    {SysObjectID: "1.3.6.1.4.1.9.1.42"},
 	}
-	if err := snmpCheck.cfg.instance.generateTagMap(); err != nil {
-		t.Fatalf("Unable to generate OIDs: %v", err)
+	for sysObjectID, expected := range map[string]string{
+		"1.3.6.1.4.1.9.1.42": "exact",
+		"1.3.6.1.4.1.9.1.43": "specific",
+		"1.3.6.1.4.1.9.2.1":  "generic",
+	} {
+		name, _ := profiles.match(sysObjectID)
+		assert.Equal(t, expected, name, sysObjectID)
 	}
+}
 
-	//Check OIDs...
-	oidvalues := snmpCheck.cfg.instance.OIDTranslator.Values()
-	oidList := make([]string, len(oidvalues))
-	for i, v := range oidvalues {
-		//should be true for each v
-		if vstr, ok := v.(string); ok {
-			oidList[i] = vstr
-		}
-	}
+func TestProfilesExtendsCycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snmp-profiles")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.yaml"), []byte("extends: [_b.yaml]"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "_b.yaml"), []byte("extends: [a.yaml]"), 0644))
 
-	oids, err := snmpgo.NewOids(oidList)
-	if err != nil {
-		// Failed creating Native OID list.
-		t.Fatalf("Unable to create Native OID list: %v", err)
-	}
+	_, err = loadProfiles(dir)
+	assert.Error(t, err)
+}
 
-	//Mocked VarBinds
-	binds := []*snmpgo.VarBind{}
-	mtype := make(map[string]string)
-	cnt := 0
-	for _, oid := range oids {
-		v := snmpgo.VarBind{Oid: oid}
-		if oid.String() == "1.3.6.1.4.1.3375.2.1.1.2.1.8.0" {
-			// forced type - gauge despite being counter
-			v.Variable = snmpgo.NewCounter32(uint32(42949670))
-			mtype[oid.String()] = "Gauge"
-		} else {
-			switch cnt { // just different acceptable types.
-			case 0:
-				v.Variable = snmpgo.NewInteger(int32(42949670))
-				mtype[oid.String()] = "Gauge"
-			case 1:
-				v.Variable = snmpgo.NewGauge32(uint32(42949670))
-				mtype[oid.String()] = "Gauge"
-			case 2:
-				v.Variable = snmpgo.NewCounter64(uint64(42949670))
-				mtype[oid.String()] = "Rate"
-			}
-			cnt++
-		}
-		binds = append(binds, &v)
-	}
+func TestSNMPCheck(t *testing.T) {
+	sim := newSNMPSimulator(t, "public", netSNMPDevice)
+	defer sim.close()
 
-	expectedTags := []string{
-		"optional_tag_1",
-		"optional_tag_2",
-		"snmp_device:localhost:161"}
+	instance := fmt.Sprintf(`
+ip_address: 127.0.0.1
+port: %d
+community_string: public
+bulk_max_repetitions: 1
+tags: ["optional_tag_1"]
+metrics:
+  - OID: 1.3.6.1.4.1.8072.1.3.2.1.0
+    name: nsExtendNumEntries
+  - OID: 1.3.6.1.4.1.2021.100.1.0
+    name: notANumber
+  - table: ipSystemStatsTable
+    symbols:
+      - OID: 1.3.6.1.2.1.4.31.1.1.3
+        name: ipSystemStatsInReceives
+    metric_tags:
+      - tag: ipversion
+        index: 1
+        mapping:
+          1: ipv4
+          2: ipv6
+`, sim.port())
+	snmpCheck, mockSender, err := runSNMPCheck(t, instance, fmt.Sprintf("profiles_folder: %s", shippedProfiles))
+	require.NoError(t, err)
+
+	tags := []string{"optional_tag_1", fmt.Sprintf("snmp_device:127.0.0.1:%d", sim.port()), "snmp_profile:net-snmp"}
+	mockSender.AssertServiceCheck(t, SNMPServiceCheck, metrics.ServiceCheckOK, "", tags, "")
+
+	// the instance metrics
+	mockSender.AssertMetric(t, "Gauge", "snmp.nsExtendNumEntries", 1, "", tags)
+	mockSender.AssertMetric(t, "Rate", "snmp.ipSystemStatsInReceives", 100, "", append(tags, "ipversion:ipv4"))
+	mockSender.AssertMetric(t, "Rate", "snmp.ipSystemStatsInReceives", 200, "", append(tags, "ipversion:ipv6"))
+	mockSender.AssertNotCalled(t, "Gauge", "snmp.notANumber", mock.Anything, "", mock.Anything)
+
+	// the profile metrics
+	mockSender.AssertMetric(t, "Rate", "snmp.tcpActiveOpens", 42, "", tags)
+	mockSender.AssertMetric(t, "Gauge", "snmp.tcpCurrEstab", 7, "", tags)
+	mockSender.AssertMetric(t, "Gauge", "snmp.memTotalReal", 2048000, "", tags)
+	mockSender.AssertMetric(t, "Rate", "snmp.ssCpuRawUser", 9000, "", tags)
+	mockSender.AssertMetric(t, "Gauge", "snmp.laLoad", 0.15, "", append(tags, "load:Load-1"))
+	mockSender.AssertMetric(t, "Rate", "snmp.ifInErrors", 3, "", append(tags, "interface:eth0"))
+	mockSender.AssertMetric(t, "Rate", "snmp.ifInErrors", 0, "", append(tags, "interface:lo"))
+	mockSender.AssertMetric(t, "Rate", "snmp.ifHCInOctets", 1234567890123, "", append(tags, "interface:eth0"))
+	mockSender.AssertMetric(t, "Gauge", "snmp.ifHighSpeed", 1000, "", append(tags, "interface:eth0"))
+	mockSender.AssertNumberOfCalls(t, "Commit", 1)
+
+	// the profile is detected once
+	assert.True(t, snmpCheck.profileResolved)
+	require.NoError(t, snmpCheck.Run())
+	assert.Equal(t, tags, snmpCheck.tags)
+}
 
-	for _, oid := range oids {
-		symbolicOID, err := snmpCheck.cfg.instance.OIDTranslator.GetKVReverse(oid.String())
-		if err != nil {
-			t.Fatalf("Unable to get symbolic OID for assertion: %v", err)
-		}
+func TestSNMPCheckV1(t *testing.T) {
+	sim := newSNMPSimulator(t, "public", netSNMPDevice)
+	defer sim.close()
 
-		name := ""
-		if m, ok := snmpCheck.cfg.instance.MetricMap[oid.String()]; ok {
-			if m.Name != "" {
-				name = "snmp." + m.Name
-			}
-		}
-		if m, ok := symbolicOID.(string); ok {
-			if name == "" {
-				if metric, ok := snmpCheck.cfg.instance.NameLookup[m]; ok {
-					name = metric
-				} else {
-					name = "snmp." + m
-				}
-			}
-			mt, ok := mtype[oid.String()]
-			if !ok {
-				t.FailNow()
-			}
-			mock.On(mt, name, float64(42949670), "", expectedTags).Return().Times(1)
-		}
-	}
-	mock.On("Commit").Return().Times(1)
-	snmpCheck.submitSNMP(oids, binds)
+	// the profile is forced, and the scalar without its trailing .0 is walked
+	instance := fmt.Sprintf(`
+ip_address: 127.0.0.1
+port: %d
+community_string: public
+snmp_version: 1
+profile: net-snmp
+metrics:
+  - OID: 1.3.6.1.2.1.6.9
+    name: tcpCurrEstabWalked
+`, sim.port())
+	_, mockSender, err := runSNMPCheck(t, instance, fmt.Sprintf("profiles_folder: %s", shippedProfiles))
+	require.NoError(t, err)
+
+	tags := []string{fmt.Sprintf("snmp_device:127.0.0.1:%d", sim.port()), "snmp_profile:net-snmp"}
+	mockSender.AssertServiceCheck(t, SNMPServiceCheck, metrics.ServiceCheckOK, "", tags, "")
+	mockSender.AssertMetric(t, "Gauge", "snmp.tcpCurrEstabWalked", 7, "", tags)
+	mockSender.AssertMetric(t, "Gauge", "snmp.memTotalReal", 2048000, "", tags)
+	mockSender.AssertMetric(t, "Rate", "snmp.ifInErrors", 3, "", append(tags, "interface:eth0"))
+}
+
+func TestSNMPCheckUnreachable(t *testing.T) {
+	sim := newSNMPSimulator(t, "public", netSNMPDevice)
+	defer sim.close()
 
-	mock.AssertExpectations(t)
-	mock.AssertNumberOfCalls(t, "Gauge", 3)
-	mock.AssertNumberOfCalls(t, "Rate", 1)
-	mock.AssertNumberOfCalls(t, "Commit", 1)
+	// the simulator does not answer the requests of another community
+	instance := fmt.Sprintf(`
+ip_address: 127.0.0.1
+port: %d
+community_string: private
+retries: 1
+metrics:
+  - OID: 1.3.6.1.2.1.6.5.0
+    name: tcpActiveOpens
+`, sim.port())
+	_, mockSender, err := runSNMPCheck(t, instance, "profiles_folder: /does/not/exist")
+	require.Error(t, err)
+
+	tags := []string{fmt.Sprintf("snmp_device:127.0.0.1:%d", sim.port())}
+	mockSender.AssertServiceCheck(t, SNMPServiceCheck, metrics.ServiceCheckCritical, "", tags, err.Error())
+	mockSender.AssertNotCalled(t, "Rate", "snmp.tcpActiveOpens", mock.Anything, "", mock.Anything)
 }
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package network
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+const (
+	tcpCheckName = "tcp_check"
+
+	defaultTCPTimeout = 10
+)
+
+type tcpInstanceConfig struct {
+	Name    string   `yaml:"name"`
+	Host    string   `yaml:"host"`
+	Port    int      `yaml:"port"`
+	Timeout int      `yaml:"timeout"`
+	Tags    []string `yaml:"tags"`
+}
+
+// TCPCheck opens a TCP connection to an endpoint and reports whether it is
+// reachable and how long the connection took
+type TCPCheck struct {
+	core.CheckBase
+	address string
+	timeout time.Duration
+	tags    []string
+}
+
+// Configure parses the check configuration
+func (c *TCPCheck) Configure(data check.ConfigData, initConfig check.ConfigData) error {
+	var conf tcpInstanceConfig
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+	if conf.Host == "" {
+		return fmt.Errorf("the host of the instance is missing")
+	}
+	if conf.Port <= 0 || conf.Port > 65535 {
+		return fmt.Errorf("invalid port %d", conf.Port)
+	}
+
+	c.address = net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port))
+	timeout := conf.Timeout
+	if timeout == 0 {
+		timeout = defaultTCPTimeout
+	}
+	c.timeout = time.Duration(timeout) * time.Second
+
+	name := conf.Name
+	if name == "" {
+		name = c.address
+	}
+	c.tags = append(conf.Tags,
+		"instance:"+name,
+		"target_host:"+conf.Host,
+		"port:"+strconv.Itoa(conf.Port),
+		"url:"+c.address,
+	)
+
+	c.BuildID(data, initConfig)
+	return nil
+}
+
+// Run executes the check
+func (c *TCPCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", c.address, c.timeout)
+	if err != nil {
+		sender.ServiceCheck("tcp.can_connect", metrics.ServiceCheckCritical, "", c.tags, err.Error())
+	} else {
+		sender.Gauge("network.tcp.response_time", time.Since(start).Seconds(), "", c.tags)
+		sender.ServiceCheck("tcp.can_connect", metrics.ServiceCheckOK, "", c.tags, "")
+		conn.Close()
+	}
+
+	sender.Commit()
+	return nil
+}
+
+func tcpFactory() check.Check {
+	return &TCPCheck{
+		CheckBase: core.NewCheckBase(tcpCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(tcpCheckName, tcpFactory)
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package network
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func runTCPCheck(t *testing.T, config string) *mocksender.MockSender {
+	tcpCheck := tcpFactory()
+	require.NoError(t, tcpCheck.Configure([]byte(config), nil))
+
+	mock := mocksender.NewMockSender(tcpCheck.ID())
+	mock.SetupAcceptAll()
+	require.NoError(t, tcpCheck.Run())
+	mock.AssertNumberOfCalls(t, "Commit", 1)
+	return mock
+}
+
+func TestTCPCheck(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	tags := []string{
+		"role:db",
+		"instance:db",
+		"target_host:127.0.0.1",
+		fmt.Sprintf("port:%d", port),
+		fmt.Sprintf("url:127.0.0.1:%d", port),
+	}
+	config := fmt.Sprintf(`
+name: db
+host: 127.0.0.1
+port: %d
+timeout: 1
+tags:
+  - role:db
+`, port)
+	mock := runTCPCheck(t, config)
+	mock.AssertServiceCheck(t, "tcp.can_connect", metrics.ServiceCheckOK, "", tags, "")
+	mock.AssertMetricInRange(t, "Gauge", "network.tcp.response_time", 0, 1, "", tags)
+
+	l.Close()
+	mock = runTCPCheck(t, config)
+	mock.AssertCalled(t, "ServiceCheck", "tcp.can_connect", metrics.ServiceCheckCritical, "", mocksender.MatchTagsContains(tags), mockAnyMessage)
+	mock.AssertNumberOfCalls(t, "Gauge", 0)
+}
+
+func TestTCPCheckConfigure(t *testing.T) {
+	assert.Error(t, tcpFactory().Configure([]byte(`port: 80`), nil))
+	assert.Error(t, tcpFactory().Configure([]byte(`host: localhost`), nil))
+	assert.Error(t, tcpFactory().Configure([]byte(`{host: localhost, port: 70000}`), nil))
+}
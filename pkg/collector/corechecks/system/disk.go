@@ -0,0 +1,399 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+// +build linux
+
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+
+	log "github.com/cihub/seelog"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+const diskCheckName = "disk"
+
+// For testing purpose
+var statfs = syscall.Statfs
+
+// mount is an entry of a mounts file
+type mount struct {
+	device     string
+	mountPoint string
+	fsType     string
+	readOnly   bool
+}
+
+// diskUsage holds the usage of a filesystem, in bytes and inodes
+type diskUsage struct {
+	total      uint64
+	used       uint64
+	free       uint64
+	inodes     uint64
+	inodesFree uint64
+}
+
+type diskInstanceConfig struct {
+	AllPartitions       bool              `yaml:"all_partitions"`
+	DeviceIncludeRe     string            `yaml:"device_include_re"`
+	DeviceExcludeRe     string            `yaml:"device_exclude_re"`
+	MountPointIncludeRe string            `yaml:"mount_point_include_re"`
+	MountPointExcludeRe string            `yaml:"mount_point_exclude_re"`
+	FileSystemIncludeRe string            `yaml:"file_system_include_re"`
+	FileSystemExcludeRe string            `yaml:"file_system_exclude_re"`
+	TagByFileSystem     bool              `yaml:"tag_by_filesystem"`
+	MountPointTagRe     map[string]string `yaml:"mount_point_tag_re"`
+	ServiceCheckRw      bool              `yaml:"service_check_rw"`
+	Tags                []string          `yaml:"tags"`
+
+	// options of the Python check
+	ExcludedFileSystems  []string `yaml:"excluded_filesystems"`
+	ExcludedMountPointRe string   `yaml:"excluded_mountpoint_re"`
+	UseMount             bool     `yaml:"use_mount"`
+}
+
+// diskFilter includes or excludes the values matching its regexes, both
+// being optional
+type diskFilter struct {
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+func newDiskFilter(include, exclude string) (diskFilter, error) {
+	var f diskFilter
+	var err error
+	if include != "" {
+		if f.include, err = regexp.Compile(include); err != nil {
+			return f, fmt.Errorf("invalid include regex %q: %s", include, err)
+		}
+	}
+	if exclude != "" {
+		if f.exclude, err = regexp.Compile(exclude); err != nil {
+			return f, fmt.Errorf("invalid exclude regex %q: %s", exclude, err)
+		}
+	}
+	return f, nil
+}
+
+// joinRegexes returns a regex matching any of the non-empty regexes
+func joinRegexes(regexes ...string) string {
+	var parts []string
+	for _, re := range regexes {
+		if re != "" {
+			parts = append(parts, "(?:"+re+")")
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+// literalsRegex returns a regex matching exactly any of the values
+func literalsRegex(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, regexp.QuoteMeta(v))
+	}
+	return "^(?:" + strings.Join(quoted, "|") + ")$"
+}
+
+func (f diskFilter) match(value string) bool {
+	if f.include != nil && !f.include.MatchString(value) {
+		return false
+	}
+	return f.exclude == nil || !f.exclude.MatchString(value)
+}
+
+// mountPointTags are the tags added to the mount points matching a regex
+type mountPointTags struct {
+	re   *regexp.Regexp
+	tags []string
+}
+
+// DiskCheck reports the usage of the mounted filesystems
+type DiskCheck struct {
+	core.CheckBase
+	allPartitions   bool
+	tagByFileSystem bool
+	useMount        bool
+	serviceCheckRw  bool
+	devices         diskFilter
+	mountPoints     diskFilter
+	fileSystems     diskFilter
+	mountPointTags  []mountPointTags
+	tags            []string
+	// procPath is where the mounts and the filesystem types are read from
+	procPath string
+	// rootPath is prepended to the mount points to reach them from a container
+	rootPath string
+}
+
+// Configure parses the check configuration
+func (c *DiskCheck) Configure(data check.ConfigData, initConfig check.ConfigData) error {
+	var conf diskInstanceConfig
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+
+	var err error
+	if c.devices, err = newDiskFilter(conf.DeviceIncludeRe, conf.DeviceExcludeRe); err != nil {
+		return err
+	}
+	mountPointExcludeRe := joinRegexes(conf.MountPointExcludeRe, conf.ExcludedMountPointRe)
+	if c.mountPoints, err = newDiskFilter(conf.MountPointIncludeRe, mountPointExcludeRe); err != nil {
+		return err
+	}
+	fileSystemExcludeRe := joinRegexes(conf.FileSystemExcludeRe, literalsRegex(conf.ExcludedFileSystems))
+	if c.fileSystems, err = newDiskFilter(conf.FileSystemIncludeRe, fileSystemExcludeRe); err != nil {
+		return err
+	}
+
+	// sort the patterns so that the tags are added in a stable order
+	patterns := make([]string, 0, len(conf.MountPointTagRe))
+	for pattern := range conf.MountPointTagRe {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	c.mountPointTags = nil
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid mount_point_tag_re regex %q: %s", pattern, err)
+		}
+		var tags []string
+		for _, tag := range strings.Split(conf.MountPointTagRe[pattern], ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		c.mountPointTags = append(c.mountPointTags, mountPointTags{re: re, tags: tags})
+	}
+
+	c.allPartitions = conf.AllPartitions
+	c.tagByFileSystem = conf.TagByFileSystem
+	c.useMount = conf.UseMount
+	c.serviceCheckRw = conf.ServiceCheckRw
+	c.tags = conf.Tags
+	c.procPath, c.rootPath = diskPaths()
+
+	c.BuildID(data, initConfig)
+	return nil
+}
+
+// diskPaths returns the procfs to read the mounts from and the path the
+// mount points are relative to. From a container, the host mounts are read
+// from the procfs of its init process and reached through its root.
+func diskPaths() (string, string) {
+	procPath := config.Datadog.GetString("procfs_path")
+	if procPath == "" {
+		procPath = "/proc"
+	}
+	if !config.IsContainerized() {
+		return procPath, ""
+	}
+	return procPath, filepath.Join(config.Datadog.GetString("container_proc_root"), "1", "root")
+}
+
+// Run executes the check
+func (c *DiskCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	mounts, err := readMounts(c.mountsFile())
+	if err != nil {
+		return err
+	}
+	var nodev map[string]bool
+	if !c.allPartitions {
+		nodev, err = readNodevFileSystems(filepath.Join(c.procPath, "filesystems"))
+		if err != nil {
+			log.Debugf("could not read the filesystems, pseudo filesystems won't be excluded: %s", err)
+		}
+	}
+
+	for _, m := range mounts {
+		if nodev[m.fsType] {
+			continue
+		}
+		if !c.devices.match(m.device) || !c.mountPoints.match(m.mountPoint) || !c.fileSystems.match(m.fsType) {
+			continue
+		}
+
+		usage, err := getDiskUsage(filepath.Join(c.rootPath, m.mountPoint))
+		if err != nil {
+			log.Debugf("could not get the usage of %s: %s", m.mountPoint, err)
+			continue
+		}
+		// pseudo filesystems report no size
+		if usage.total == 0 && !c.allPartitions {
+			continue
+		}
+
+		tags := c.mountTags(m)
+		sender.Gauge("system.disk.total", float64(usage.total)/kB, "", tags)
+		sender.Gauge("system.disk.used", float64(usage.used)/kB, "", tags)
+		sender.Gauge("system.disk.free", float64(usage.free)/kB, "", tags)
+		if usage.used+usage.free > 0 {
+			sender.Gauge("system.disk.in_use", float64(usage.used)/float64(usage.used+usage.free), "", tags)
+		}
+		if usage.inodes > 0 {
+			inodesUsed := usage.inodes - usage.inodesFree
+			sender.Gauge("system.fs.inodes.total", float64(usage.inodes), "", tags)
+			sender.Gauge("system.fs.inodes.used", float64(inodesUsed), "", tags)
+			sender.Gauge("system.fs.inodes.free", float64(usage.inodesFree), "", tags)
+			sender.Gauge("system.fs.inodes.in_use", float64(inodesUsed)/float64(usage.inodes), "", tags)
+		}
+
+		if c.serviceCheckRw {
+			status := metrics.ServiceCheckOK
+			message := ""
+			if m.readOnly {
+				status = metrics.ServiceCheckCritical
+				message = fmt.Sprintf("%s is mounted read-only", m.mountPoint)
+			}
+			sender.ServiceCheck("disk.read_write", status, "", tags, message)
+		}
+	}
+
+	sender.Commit()
+	return nil
+}
+
+// mountsFile returns the file listing the mounted filesystems
+func (c *DiskCheck) mountsFile() string {
+	if c.rootPath != "" {
+		return filepath.Join(c.procPath, "1", "mounts")
+	}
+	return filepath.Join(c.procPath, "self", "mounts")
+}
+
+// mountTags returns the tags of the metrics of a mount
+func (c *DiskCheck) mountTags(m mount) []string {
+	tags := append([]string{}, c.tags...)
+	// like the Python check, use_mount sets the device tag to the mount point
+	if c.useMount {
+		tags = append(tags, "device:"+m.mountPoint, "mount_point:"+m.mountPoint)
+	} else {
+		tags = append(tags, "device:"+m.device, "mount_point:"+m.mountPoint)
+	}
+	if c.tagByFileSystem {
+		tags = append(tags, "filesystem:"+m.fsType)
+	}
+	for _, t := range c.mountPointTags {
+		if t.re.MatchString(m.mountPoint) {
+			tags = append(tags, t.tags...)
+		}
+	}
+	return tags
+}
+
+// readMounts parses a mounts file, formatted as:
+//
+//	/dev/sda1 / ext4 rw,relatime,errors=remount-ro 0 0
+//
+func readMounts(path string) ([]mount, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []mount
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		m := mount{
+			device:     fields[0],
+			mountPoint: unescapeMountPoint(fields[1]),
+			fsType:     fields[2],
+		}
+		for _, option := range strings.Split(fields[3], ",") {
+			if option == "ro" {
+				m.readOnly = true
+			}
+		}
+		mounts = append(mounts, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", path, err)
+	}
+	return mounts, nil
+}
+
+// unescapeMountPoint replaces the octal escapes of the spaces, tabs and
+// backslashes of the mount points
+func unescapeMountPoint(mountPoint string) string {
+	return strings.NewReplacer(`\040`, " ", `\011`, "\t", `\134`, `\`).Replace(mountPoint)
+}
+
+// readNodevFileSystems returns the filesystems backed by no device, as
+// listed in /proc/filesystems:
+//
+//	nodev	sysfs
+//		ext4
+//
+func readNodevFileSystems(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	nodev := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "nodev" {
+			nodev[fields[1]] = true
+		}
+	}
+	return nodev, scanner.Err()
+}
+
+// getDiskUsage returns the usage of the filesystem mounted on path, computed
+// the same way as df
+func getDiskUsage(path string) (*diskUsage, error) {
+	var stat syscall.Statfs_t
+	if err := statfs(path, &stat); err != nil {
+		return nil, err
+	}
+	bsize := uint64(stat.Frsize)
+	return &diskUsage{
+		total:      stat.Blocks * bsize,
+		used:       (stat.Blocks - stat.Bfree) * bsize,
+		free:       stat.Bavail * bsize,
+		inodes:     stat.Files,
+		inodesFree: stat.Ffree,
+	}, nil
+}
+
+func diskFactory() check.Check {
+	return &DiskCheck{
+		CheckBase: core.NewCheckBase(diskCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(diskCheckName, diskFactory)
+}
@@ -0,0 +1,149 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+// +build linux
+
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+var sampleMounts = `sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+/dev/sda1 / ext4 rw,relatime,errors=remount-ro 0 0
+/dev/sdb1 /mnt/data\040disk xfs ro,relatime 0 0
+/dev/sdc1 /var/lib/docker ext4 rw,relatime 0 0
+`
+
+var sampleFilesystems = "nodev\tsysfs\nnodev\tproc\n\text4\n\txfs\n"
+
+func statfsSampler(path string, stat *syscall.Statfs_t) error {
+	stat.Frsize = 4096
+	stat.Blocks = 1000
+	stat.Bfree = 400
+	stat.Bavail = 300
+	stat.Files = 200
+	stat.Ffree = 150
+	return nil
+}
+
+func setupDiskProc(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "disk-check")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "self"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "self", "mounts"), []byte(sampleMounts), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "filesystems"), []byte(sampleFilesystems), 0644))
+	return dir
+}
+
+func TestReadMounts(t *testing.T) {
+	dir := setupDiskProc(t)
+	defer os.RemoveAll(dir)
+
+	mounts, err := readMounts(filepath.Join(dir, "self", "mounts"))
+	require.NoError(t, err)
+	require.Len(t, mounts, 5)
+	assert.Equal(t, mount{device: "/dev/sda1", mountPoint: "/", fsType: "ext4"}, mounts[2])
+	assert.Equal(t, mount{device: "/dev/sdb1", mountPoint: "/mnt/data disk", fsType: "xfs", readOnly: true}, mounts[3])
+
+	nodev, err := readNodevFileSystems(filepath.Join(dir, "filesystems"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"sysfs": true, "proc": true}, nodev)
+}
+
+func TestDiskCheck(t *testing.T) {
+	statfs = statfsSampler
+	defer func() { statfs = syscall.Statfs }()
+	dir := setupDiskProc(t)
+	defer os.RemoveAll(dir)
+
+	diskCheck := diskFactory().(*DiskCheck)
+	err := diskCheck.Configure([]byte(`
+mount_point_exclude_re: ^/var/lib/docker
+tag_by_filesystem: true
+service_check_rw: true
+mount_point_tag_re:
+  ^/mnt/.*: role:data, tier:hot
+tags:
+  - env:test
+`), nil)
+	require.NoError(t, err)
+	diskCheck.procPath = dir
+	diskCheck.rootPath = ""
+
+	mock := mocksender.NewMockSender(diskCheck.ID())
+	mock.SetupAcceptAll()
+
+	require.NoError(t, diskCheck.Run())
+
+	rootTags := []string{"env:test", "device:/dev/sda1", "mount_point:/", "filesystem:ext4"}
+	dataTags := []string{"env:test", "device:/dev/sdb1", "mount_point:/mnt/data disk", "filesystem:xfs", "role:data", "tier:hot"}
+	for _, tags := range [][]string{rootTags, dataTags} {
+		mock.AssertMetric(t, "Gauge", "system.disk.total", 4000, "", tags)
+		mock.AssertMetric(t, "Gauge", "system.disk.used", 2400, "", tags)
+		mock.AssertMetric(t, "Gauge", "system.disk.free", 1200, "", tags)
+		mock.AssertMetric(t, "Gauge", "system.disk.in_use", 600.0/900.0, "", tags)
+		mock.AssertMetric(t, "Gauge", "system.fs.inodes.total", 200, "", tags)
+		mock.AssertMetric(t, "Gauge", "system.fs.inodes.used", 50, "", tags)
+		mock.AssertMetric(t, "Gauge", "system.fs.inodes.free", 150, "", tags)
+		mock.AssertMetric(t, "Gauge", "system.fs.inodes.in_use", 0.25, "", tags)
+	}
+	mock.AssertServiceCheck(t, "disk.read_write", metrics.ServiceCheckOK, "", rootTags, "")
+	mock.AssertServiceCheck(t, "disk.read_write", metrics.ServiceCheckCritical, "", dataTags, "/mnt/data disk is mounted read-only")
+	// the pseudo filesystems and the excluded mount point are skipped
+	mock.AssertNumberOfCalls(t, "Gauge", 16)
+	mock.AssertNumberOfCalls(t, "ServiceCheck", 2)
+	mock.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestDiskCheckInvalidRegex(t *testing.T) {
+	diskCheck := diskFactory()
+	assert.Error(t, diskCheck.Configure([]byte("device_include_re: '['"), nil))
+}
+
+func TestDiskCheckPythonOptions(t *testing.T) {
+	statfs = statfsSampler
+	defer func() { statfs = syscall.Statfs }()
+	dir := setupDiskProc(t)
+	defer os.RemoveAll(dir)
+
+	diskCheck := diskFactory().(*DiskCheck)
+	err := diskCheck.Configure([]byte(`
+excluded_filesystems:
+  - xfs
+excluded_mountpoint_re: ^/var/lib/docker
+use_mount: true
+`), nil)
+	require.NoError(t, err)
+	diskCheck.procPath = dir
+	diskCheck.rootPath = ""
+
+	mock := mocksender.NewMockSender(diskCheck.ID())
+	mock.SetupAcceptAll()
+
+	require.NoError(t, diskCheck.Run())
+
+	// only the root filesystem is left, and disk.read_write isn't sent
+	// without service_check_rw
+	mock.AssertMetric(t, "Gauge", "system.disk.total", 4000, "", []string{"device:/", "mount_point:/"})
+	mock.AssertNumberOfCalls(t, "Gauge", 8)
+	mock.AssertNumberOfCalls(t, "ServiceCheck", 0)
+}
+
+func TestJoinRegexes(t *testing.T) {
+	assert.Equal(t, "", joinRegexes("", ""))
+	assert.Equal(t, "(?:^/a)", joinRegexes("^/a", ""))
+	assert.Equal(t, "(?:^/a)|(?:^(?:b\\.c|d)$)", joinRegexes("^/a", literalsRegex([]string{"b.c", "d"})))
+}
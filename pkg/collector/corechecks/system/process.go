@@ -0,0 +1,288 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+// +build linux
+
+package system
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/cihub/seelog"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/pidfile"
+)
+
+const processCheckName = "process"
+
+// For testing purpose
+var pageSize = uint64(os.Getpagesize())
+
+type processInstanceConfig struct {
+	Name      string   `yaml:"name"`
+	Names     []string `yaml:"names"`
+	CmdlineRe string   `yaml:"cmdline_re"`
+	PidFile   string   `yaml:"pid_file"`
+	MinCount  *int     `yaml:"min_count"`
+	MaxCount  int      `yaml:"max_count"`
+	Tags      []string `yaml:"tags"`
+}
+
+// procStat holds the stats of a process read from procfs
+type procStat struct {
+	cpuTicks uint64
+	rss      uint64
+	threads  uint64
+	fds      uint64
+}
+
+// ProcessCheck reports the resources used by the processes matching its
+// instance, and whether their number is in the expected range
+type ProcessCheck struct {
+	core.CheckBase
+	name      string
+	names     map[string]bool
+	cmdlineRe *regexp.Regexp
+	pidFile   string
+	minCount  int
+	maxCount  int
+	tags      []string
+	procPath  string
+	// CPU ticks of the processes matched by the previous run
+	lastTicks map[int]uint64
+	lastRun   time.Time
+}
+
+// Configure parses the check configuration
+func (c *ProcessCheck) Configure(data check.ConfigData, initConfig check.ConfigData) error {
+	var conf processInstanceConfig
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+
+	if conf.Name == "" {
+		return fmt.Errorf("the process check requires a name")
+	}
+	if len(conf.Names) == 0 && conf.CmdlineRe == "" && conf.PidFile == "" {
+		return fmt.Errorf("%s: one of names, cmdline_re or pid_file is required", conf.Name)
+	}
+	c.name = conf.Name
+	c.names = make(map[string]bool, len(conf.Names))
+	for _, name := range conf.Names {
+		c.names[name] = true
+	}
+	if conf.CmdlineRe != "" {
+		var err error
+		if c.cmdlineRe, err = regexp.Compile(conf.CmdlineRe); err != nil {
+			return fmt.Errorf("%s: invalid cmdline_re: %s", conf.Name, err)
+		}
+	}
+	c.pidFile = conf.PidFile
+
+	// at least one process is expected by default
+	c.minCount = 1
+	if conf.MinCount != nil {
+		c.minCount = *conf.MinCount
+	}
+	c.maxCount = conf.MaxCount
+	if c.maxCount > 0 && c.maxCount < c.minCount {
+		return fmt.Errorf("%s: max_count can't be lower than min_count", conf.Name)
+	}
+
+	c.tags = append([]string{"process_name:" + conf.Name}, conf.Tags...)
+	c.procPath = config.Datadog.GetString("procfs_path")
+	if c.procPath == "" {
+		c.procPath = "/proc"
+	}
+
+	c.BuildID(data, initConfig)
+	return nil
+}
+
+// Run executes the check
+func (c *ProcessCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	pids, err := c.matchingPids()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var total procStat
+	var cpuDelta uint64
+	ticks := make(map[int]uint64, len(pids))
+	for _, pid := range pids {
+		stat, err := c.readProcStat(pid)
+		if err != nil {
+			// the process may have exited since it was listed
+			log.Debugf("could not read the stats of process %d: %s", pid, err)
+			continue
+		}
+		total.rss += stat.rss
+		total.threads += stat.threads
+		total.fds += stat.fds
+		ticks[pid] = stat.cpuTicks
+		if last, found := c.lastTicks[pid]; found && stat.cpuTicks >= last {
+			cpuDelta += stat.cpuTicks - last
+		}
+	}
+	count := len(ticks)
+
+	sender.Gauge("system.processes.number", float64(count), "", c.tags)
+	if count > 0 {
+		sender.Gauge("system.processes.mem.rss", float64(total.rss), "", c.tags)
+		sender.Gauge("system.processes.threads", float64(total.threads), "", c.tags)
+		sender.Gauge("system.processes.open_file_descriptors", float64(total.fds), "", c.tags)
+		// the CPU usage needs two runs
+		if hz > 0 && !c.lastRun.IsZero() {
+			elapsed := now.Sub(c.lastRun).Seconds()
+			if elapsed > 0 {
+				sender.Gauge("system.processes.cpu.pct", float64(cpuDelta)/float64(hz)/elapsed*100, "", c.tags)
+			}
+		}
+	}
+	c.lastTicks = ticks
+	c.lastRun = now
+
+	status := metrics.ServiceCheckOK
+	message := ""
+	if count < c.minCount || (c.maxCount > 0 && count > c.maxCount) {
+		status = metrics.ServiceCheckCritical
+		message = fmt.Sprintf("%d processes found for %s, expected between %d and %s", count, c.name, c.minCount, c.maxCountString())
+	}
+	sender.ServiceCheck("process.up", status, "", c.tags, message)
+
+	sender.Commit()
+	return nil
+}
+
+func (c *ProcessCheck) maxCountString() string {
+	if c.maxCount > 0 {
+		return strconv.Itoa(c.maxCount)
+	}
+	return "unlimited"
+}
+
+// matchingPids returns the processes of the pidfile, or those matching the
+// names or the cmdline regex
+func (c *ProcessCheck) matchingPids() ([]int, error) {
+	if c.pidFile != "" {
+		pid, err := pidfile.ReadPID(c.pidFile)
+		if err != nil {
+			// no pidfile means no process
+			log.Debugf("%s: could not read the pidfile: %s", c.name, err)
+			return nil, nil
+		}
+		if _, err := os.Stat(filepath.Join(c.procPath, strconv.Itoa(pid))); err != nil {
+			return nil, nil
+		}
+		return []int{pid}, nil
+	}
+
+	entries, err := ioutil.ReadDir(c.procPath)
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		if c.matches(pid) {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// matches returns whether the name or the command line of the process
+// match the instance
+func (c *ProcessCheck) matches(pid int) bool {
+	procDir := filepath.Join(c.procPath, strconv.Itoa(pid))
+	raw, err := ioutil.ReadFile(filepath.Join(procDir, "cmdline"))
+	if err != nil {
+		return false
+	}
+	args := strings.Split(strings.TrimRight(string(raw), "\x00"), "\x00")
+
+	if len(c.names) > 0 {
+		if comm, err := ioutil.ReadFile(filepath.Join(procDir, "comm")); err == nil && c.names[strings.TrimSpace(string(comm))] {
+			return true
+		}
+		// comm is truncated to 15 characters
+		if args[0] != "" && c.names[filepath.Base(args[0])] {
+			return true
+		}
+	}
+	return c.cmdlineRe != nil && c.cmdlineRe.MatchString(strings.Join(args, " "))
+}
+
+// readProcStat reads the CPU time, the memory and the number of threads of
+// a process from /proc/<pid>/stat and counts its open file descriptors.
+// The stat file is formatted as:
+//
+//	1234 (nginx) S 1 1234 1234 0 -1 4194560 1543 0 0 0 12 7 0 0 20 0 1 0 4316 22220800 1203 ...
+//
+func (c *ProcessCheck) readProcStat(pid int) (*procStat, error) {
+	procDir := filepath.Join(c.procPath, strconv.Itoa(pid))
+	raw, err := ioutil.ReadFile(filepath.Join(procDir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	// the command name can hold spaces and parentheses
+	content := string(raw)
+	fields := strings.Fields(content[strings.LastIndex(content, ")")+1:])
+	if len(fields) < 22 {
+		return nil, fmt.Errorf("unexpected format for %s/stat", procDir)
+	}
+
+	var values [4]uint64
+	// utime, stime, num_threads and rss are the fields 14, 15, 20 and 24
+	for i, index := range []int{11, 12, 17, 21} {
+		if values[i], err = strconv.ParseUint(fields[index], 10, 64); err != nil {
+			return nil, fmt.Errorf("unexpected format for %s/stat: %s", procDir, err)
+		}
+	}
+	stat := &procStat{
+		cpuTicks: values[0] + values[1],
+		threads:  values[2],
+		rss:      values[3] * pageSize,
+	}
+
+	// reading the file descriptors of processes of other users requires privileges
+	if fds, err := ioutil.ReadDir(filepath.Join(procDir, "fd")); err == nil {
+		stat.fds = uint64(len(fds))
+	} else {
+		log.Debugf("could not count the file descriptors of process %d: %s", pid, err)
+	}
+	return stat, nil
+}
+
+func processFactory() check.Check {
+	return &ProcessCheck{
+		CheckBase: core.NewCheckBase(processCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(processCheckName, processFactory)
+}
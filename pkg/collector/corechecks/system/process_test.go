@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+// +build linux
+
+package system
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// writeProcess adds a process to a fake procfs
+func writeProcess(t *testing.T, procPath string, pid int, comm string, args []string, utime, stime, threads, rss, fds int) {
+	dir := filepath.Join(procPath, fmt.Sprint(pid))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "fd"), 0755))
+	for i := 0; i < fds; i++ {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "fd", fmt.Sprint(i)), nil, 0644))
+	}
+	stat := fmt.Sprintf("%d (%s) S 1 %d %d 0 -1 4194560 1543 0 0 0 %d %d 0 0 20 0 %d 0 4316 22220800 %d 18446744073709551615\n",
+		pid, comm, pid, pid, utime, stime, threads, rss)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "comm"), []byte(comm+"\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cmdline"), []byte(strings.Join(args, "\x00")+"\x00"), 0644))
+}
+
+func setupProcFixtures(t *testing.T) string {
+	procPath, err := ioutil.TempDir("", "process-check")
+	require.NoError(t, err)
+	writeProcess(t, procPath, 100, "nginx", []string{"nginx: master process /usr/sbin/nginx"}, 10, 5, 1, 100, 3)
+	writeProcess(t, procPath, 101, "nginx", []string{"nginx: worker process"}, 20, 10, 2, 200, 4)
+	writeProcess(t, procPath, 200, "python (worker)", []string{"/usr/bin/python", "-m", "http.server"}, 1, 1, 1, 50, 1)
+	return procPath
+}
+
+func runProcessCheck(t *testing.T, procPath, conf string) *mocksender.MockSender {
+	processCheck := processFactory().(*ProcessCheck)
+	require.NoError(t, processCheck.Configure([]byte(conf), nil))
+	processCheck.procPath = procPath
+
+	mock := mocksender.NewMockSender(processCheck.ID())
+	mock.SetupAcceptAll()
+	require.NoError(t, processCheck.Run())
+	return mock
+}
+
+func TestProcessCheckByName(t *testing.T) {
+	procPath := setupProcFixtures(t)
+	defer os.RemoveAll(procPath)
+
+	mockSender := runProcessCheck(t, procPath, "name: nginx\nnames: [nginx]\ntags: [role:web]")
+
+	tags := []string{"process_name:nginx", "role:web"}
+	mockSender.AssertMetric(t, "Gauge", "system.processes.number", 2, "", tags)
+	mockSender.AssertMetric(t, "Gauge", "system.processes.mem.rss", float64(300*pageSize), "", tags)
+	mockSender.AssertMetric(t, "Gauge", "system.processes.threads", 3, "", tags)
+	mockSender.AssertMetric(t, "Gauge", "system.processes.open_file_descriptors", 7, "", tags)
+	mockSender.AssertServiceCheck(t, "process.up", metrics.ServiceCheckOK, "", tags, "")
+	// the CPU usage needs two runs
+	mockSender.AssertNotCalled(t, "Gauge", "system.processes.cpu.pct", mock.Anything, "", tags)
+}
+
+func TestProcessCheckByCmdline(t *testing.T) {
+	procPath := setupProcFixtures(t)
+	defer os.RemoveAll(procPath)
+
+	mock := runProcessCheck(t, procPath, "name: http\ncmdline_re: -m http\\.server")
+
+	tags := []string{"process_name:http"}
+	mock.AssertMetric(t, "Gauge", "system.processes.number", 1, "", tags)
+	mock.AssertMetric(t, "Gauge", "system.processes.mem.rss", float64(50*pageSize), "", tags)
+}
+
+func TestProcessCheckByPidFile(t *testing.T) {
+	procPath := setupProcFixtures(t)
+	defer os.RemoveAll(procPath)
+
+	pidFile := filepath.Join(procPath, "nginx.pid")
+	require.NoError(t, ioutil.WriteFile(pidFile, []byte("100\n"), 0644))
+	mock := runProcessCheck(t, procPath, "name: nginx\npid_file: "+pidFile)
+	mock.AssertMetric(t, "Gauge", "system.processes.number", 1, "", []string{"process_name:nginx"})
+
+	// a stale pidfile
+	require.NoError(t, ioutil.WriteFile(pidFile, []byte("300\n"), 0644))
+	mock = runProcessCheck(t, procPath, "name: nginx\npid_file: "+pidFile)
+	mock.AssertMetric(t, "Gauge", "system.processes.number", 0, "", []string{"process_name:nginx"})
+	mock.AssertServiceCheck(t, "process.up", metrics.ServiceCheckCritical, "", []string{"process_name:nginx"},
+		"0 processes found for nginx, expected between 1 and unlimited")
+}
+
+func TestProcessCheckCountRange(t *testing.T) {
+	procPath := setupProcFixtures(t)
+	defer os.RemoveAll(procPath)
+
+	mock := runProcessCheck(t, procPath, "name: nginx\nnames: [nginx]\nmax_count: 1")
+	mock.AssertServiceCheck(t, "process.up", metrics.ServiceCheckCritical, "", []string{"process_name:nginx"},
+		"2 processes found for nginx, expected between 1 and 1")
+
+	mock = runProcessCheck(t, procPath, "name: apache\nnames: [apache2]\nmin_count: 0")
+	mock.AssertServiceCheck(t, "process.up", metrics.ServiceCheckOK, "", []string{"process_name:apache"}, "")
+}
+
+func TestProcessCheckCPU(t *testing.T) {
+	if hz <= 0 {
+		t.Skip("HZ is unavailable")
+	}
+	procPath := setupProcFixtures(t)
+	defer os.RemoveAll(procPath)
+
+	processCheck := processFactory().(*ProcessCheck)
+	require.NoError(t, processCheck.Configure([]byte("name: nginx\nnames: [nginx]"), nil))
+	processCheck.procPath = procPath
+	mock := mocksender.NewMockSender(processCheck.ID())
+	mock.SetupAcceptAll()
+	require.NoError(t, processCheck.Run())
+
+	// pretend 10 seconds elapsed and the workers used one second of CPU time
+	processCheck.lastRun = processCheck.lastRun.Add(-10e9)
+	writeProcess(t, procPath, 101, "nginx", []string{"nginx: worker process"}, 20+int(hz), 10, 2, 200, 4)
+	require.NoError(t, processCheck.Run())
+	mock.AssertMetricInRange(t, "Gauge", "system.processes.cpu.pct", 9.9, 10, "", []string{"process_name:nginx"})
+}
+
+func TestProcessCheckConfigure(t *testing.T) {
+	for _, conf := range []string{
+		"names: [nginx]",
+		"name: nginx",
+		"name: nginx\ncmdline_re: '['",
+		"name: nginx\nnames: [nginx]\nmin_count: 3\nmax_count: 2",
+	} {
+		assert.Error(t, processFactory().Configure([]byte(conf), nil), conf)
+	}
+}
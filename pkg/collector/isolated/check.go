@@ -0,0 +1,299 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package isolated runs check instances in worker processes, so that a crash
+// or a leak in a check doesn't affect the agent and Python checks don't share
+// the interpreter lock of the agent.
+package isolated
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"net/rpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+var (
+	isolatedStats = expvar.NewMap("isolated")
+
+	// how long Stop waits for the worker to stop the check before killing it
+	stopTimeout = 5 * time.Second
+)
+
+// workerCommand returns the command starting a worker process, declared as
+// var to ease testing
+var workerCommand = func() (*exec.Cmd, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"check-worker"}
+	if cfg := config.Datadog.ConfigFileUsed(); cfg != "" {
+		args = append(args, "--cfgpath", filepath.Dir(cfg))
+	}
+	return exec.Command(executable, args...), nil
+}
+
+// IsolatedCheck implements check.Check, it runs a check instance in a worker
+// process and restarts the process when it crashes
+type IsolatedCheck struct {
+	name       string
+	id         check.ID
+	initConfig check.ConfigData
+	instance   check.ConfigData
+	options    ConfigureReply
+	runTimeout time.Duration // the worker is killed when a run lasts longer, 0 to never kill it
+	worker     *worker
+	stopped    bool
+	m          sync.Mutex // to protect worker and stopped
+}
+
+// NewIsolatedCheck returns a check running in a worker process
+func NewIsolatedCheck(name string) *IsolatedCheck {
+	return &IsolatedCheck{name: name}
+}
+
+// String returns the name of the check
+func (c *IsolatedCheck) String() string {
+	return c.name
+}
+
+// Configure starts the worker process and configures the check in it
+func (c *IsolatedCheck) Configure(data check.ConfigData, initConfig check.ConfigData) error {
+	c.id = check.BuildID(c.name, data, initConfig)
+	c.instance = data
+	c.initConfig = initConfig
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	options, err := c.start()
+	if err != nil {
+		return err
+	}
+	c.options = options
+
+	// same timeout as the runner: when it expires the run is abandoned, the
+	// worker is killed and restarted at the next run
+	if options.Interval > 0 {
+		c.runTimeout = options.Timeout
+		if c.runTimeout == 0 {
+			c.runTimeout = config.Datadog.GetDuration("check_timeout") * time.Second
+		}
+	}
+	return nil
+}
+
+// start starts a worker process and configures the check in it
+func (c *IsolatedCheck) start() (ConfigureReply, error) {
+	var reply ConfigureReply
+	w, err := startWorker(c.name)
+	if err != nil {
+		return reply, fmt.Errorf("unable to start a worker process: %s", err)
+	}
+
+	args := &ConfigureArgs{Name: c.name, ID: c.id, InitConfig: c.initConfig, Instance: c.instance}
+	if err := w.client.Call("Worker.Configure", args, &reply); err != nil {
+		w.kill()
+		return reply, fmt.Errorf("unable to configure the check in its worker process: %s", err)
+	}
+	c.worker = w
+	return reply, nil
+}
+
+// Interval returns the scheduling interval of the check
+func (c *IsolatedCheck) Interval() time.Duration {
+	return c.options.Interval
+}
+
+// Timeout returns the execution timeout of the check
+func (c *IsolatedCheck) Timeout() time.Duration {
+	return c.options.Timeout
+}
+
+// Jitter returns the scheduling jitter of the check
+func (c *IsolatedCheck) Jitter() time.Duration {
+	return c.options.Jitter
+}
+
+// Schedule returns the cron expression of the check
+func (c *IsolatedCheck) Schedule() string {
+	return c.options.Schedule
+}
+
+// ID returns the ID of the check
+func (c *IsolatedCheck) ID() check.ID {
+	return c.id
+}
+
+// getWorker returns the worker process, restarting it if it has exited or was
+// killed
+func (c *IsolatedCheck) getWorker() (*worker, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.stopped {
+		return nil, errors.New("the check was stopped")
+	}
+	if c.worker != nil && c.worker.hasExited() {
+		log.Warnf("The worker process of check %s has exited, restarting it", c)
+		c.worker.kill()
+		c.worker = nil
+	}
+	if c.worker == nil {
+		// the scheduling options of the check can't change
+		if _, err := c.start(); err != nil {
+			return nil, err
+		}
+		isolatedStats.Add("Restarts", 1)
+	}
+	return c.worker, nil
+}
+
+// Run runs the check in its worker process and sends the metrics it
+// collected to the aggregator
+func (c *IsolatedCheck) Run() error {
+	w, err := c.getWorker()
+	if err != nil {
+		return err
+	}
+
+	var timeout <-chan time.Time
+	if c.runTimeout > 0 {
+		timer := time.NewTimer(c.runTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var reply RunReply
+	call := w.client.Go("Worker.Run", &Empty{}, &reply, nil)
+	select {
+	case <-call.Done:
+	case <-timeout:
+		// the worker is still running the check, it's restarted at the next run
+		log.Warnf("Check %s didn't return after %v, killing its worker process", c, c.runTimeout)
+		w.kill()
+		isolatedStats.Add("Timeouts", 1)
+		return check.TimeoutError{Timeout: c.runTimeout}
+	}
+	if err := call.Error; err != nil {
+		if _, ok := err.(rpc.ServerError); !ok {
+			// the worker crashed, it's restarted at the next run
+			w.kill()
+		}
+		return fmt.Errorf("the check failed to run in its worker process: %s", err)
+	}
+
+	sender, err := aggregator.GetSender(c.id)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve a sender: %v", err)
+	}
+	for _, call := range reply.Calls {
+		if err := call.replay(sender); err != nil {
+			log.Warnf("Dropping a call of check %s to its sender: %s", c, err)
+		}
+	}
+	sender.Commit()
+
+	if reply.Error != "" {
+		return errors.New(reply.Error)
+	}
+	return nil
+}
+
+// Stop stops the check and its worker process
+func (c *IsolatedCheck) Stop() {
+	c.m.Lock()
+	w := c.worker
+	c.worker = nil
+	c.stopped = true
+	c.m.Unlock()
+
+	if w != nil {
+		w.stop()
+	}
+}
+
+// GetWarnings returns the warnings of the last run of the check
+func (c *IsolatedCheck) GetWarnings() []error {
+	c.m.Lock()
+	w := c.worker
+	c.m.Unlock()
+	if w == nil {
+		return []error{}
+	}
+
+	var reply WarningsReply
+	if err := w.client.Call("Worker.GetWarnings", &Empty{}, &reply); err != nil {
+		log.Debugf("Unable to get the warnings of check %s: %s", c, err)
+		return []error{}
+	}
+	warnings := make([]error, 0, len(reply.Warnings))
+	for _, warning := range reply.Warnings {
+		warnings = append(warnings, errors.New(warning))
+	}
+	return warnings
+}
+
+// GetMetricStats returns the stats from the last run of the check
+func (c *IsolatedCheck) GetMetricStats() (map[string]int64, error) {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve a sender: %v", err)
+	}
+	return sender.GetMetricStats(), nil
+}
+
+// worker is a worker process and the RPC client to talk to it
+type worker struct {
+	cmd    *exec.Cmd
+	client *rpc.Client
+	exited chan struct{}
+}
+
+// hasExited returns whether the worker process has exited
+func (w *worker) hasExited() bool {
+	select {
+	case <-w.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop asks the worker to stop its check, then closes the connection so the
+// process exits. The process is killed if it doesn't exit in time.
+func (w *worker) stop() {
+	call := w.client.Go("Worker.Stop", &Empty{}, &Empty{}, nil)
+	select {
+	case <-call.Done:
+	case <-w.exited:
+	case <-time.After(stopTimeout):
+	}
+	w.client.Close()
+
+	select {
+	case <-w.exited:
+	case <-time.After(stopTimeout):
+		w.kill()
+	}
+}
+
+// kill kills the worker process and waits for it to exit
+func (w *worker) kill() {
+	w.cmd.Process.Kill()
+	w.client.Close()
+	<-w.exited
+}
@@ -0,0 +1,230 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !windows
+
+package isolated
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// FIXTURE
+type TestCheck struct {
+	id       check.ID
+	interval time.Duration
+	timeout  time.Duration
+	sleep    time.Duration
+	fail     bool
+	crash    bool
+}
+
+func (c *TestCheck) String() string          { return "testcheck" }
+func (c *TestCheck) Interval() time.Duration { return c.interval }
+func (c *TestCheck) Timeout() time.Duration  { return c.timeout }
+func (c *TestCheck) Stop()                   {}
+func (c *TestCheck) ID() check.ID            { return c.id }
+func (c *TestCheck) GetWarnings() []error    { return []error{errors.New("a warning")} }
+func (c *TestCheck) GetMetricStats() (map[string]int64, error) {
+	return make(map[string]int64), nil
+}
+func (c *TestCheck) Configure(data check.ConfigData, initConfig check.ConfigData) error {
+	opts := struct {
+		Interval int     `yaml:"min_collection_interval"`
+		Timeout  float64 `yaml:"check_timeout"`
+		Sleep    float64 `yaml:"sleep"`
+		Fail     bool    `yaml:"fail"`
+		Crash    bool    `yaml:"crash"`
+	}{}
+	if err := yaml.Unmarshal(data, &opts); err != nil {
+		return err
+	}
+	c.id = check.Identify(c, data, initConfig)
+	c.interval = time.Duration(opts.Interval) * time.Second
+	c.timeout = time.Duration(opts.Timeout * float64(time.Second))
+	c.sleep = time.Duration(opts.Sleep * float64(time.Second))
+	c.fail = opts.Fail
+	c.crash = opts.Crash
+	return nil
+}
+func (c *TestCheck) Run() error {
+	if c.crash {
+		os.Exit(2)
+	}
+	time.Sleep(c.sleep)
+	sender, err := aggregator.GetSender(c.id)
+	if err != nil {
+		return err
+	}
+	sender.Gauge("test.gauge", 42, "", []string{"pid:" + fmt.Sprint(os.Getpid())})
+	sender.ServiceCheck("test.can_connect", metrics.ServiceCheckOK, "", nil, "")
+	sender.Commit()
+	if c.fail {
+		return errors.New("failure")
+	}
+	return nil
+}
+
+type TestLoader struct{}
+
+func (l *TestLoader) Load(config check.Config) ([]check.Check, error) {
+	c := &TestCheck{}
+	if err := c.Configure(config.Instances[0], config.InitConfig); err != nil {
+		return nil, err
+	}
+	return []check.Check{c}, nil
+}
+
+// the test binary is also the worker process when this variable is set
+const workerEnv = "ISOLATED_TEST_WORKER"
+
+// workerLoaders returns the loaders of the worker process, declared as var so
+// that the tests of other loaders can add theirs
+var workerLoaders = func() []check.Loader {
+	return []check.Loader{&TestLoader{}}
+}
+
+func TestMain(m *testing.M) {
+	if os.Getenv(workerEnv) != "" {
+		err := Serve(os.NewFile(3, "requests"), os.NewFile(4, "replies"), workerLoaders())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	workerCommand = func() (*exec.Cmd, error) {
+		cmd := exec.Command(os.Args[0])
+		cmd.Env = append(os.Environ(), workerEnv+"=1")
+		return cmd, nil
+	}
+	os.Exit(m.Run())
+}
+
+func newCheck(t *testing.T, instance string) (*IsolatedCheck, *mocksender.MockSender) {
+	c := NewIsolatedCheck("testcheck")
+	require.NoError(t, c.Configure(check.ConfigData(instance), check.ConfigData("")))
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	return c, sender
+}
+
+func TestRun(t *testing.T) {
+	c, sender := newCheck(t, "min_collection_interval: 30\nisolated: true")
+	defer c.Stop()
+	assert.Equal(t, "testcheck", c.String())
+	assert.Equal(t, check.BuildID("testcheck", check.ConfigData("min_collection_interval: 30\nisolated: true"), nil), c.ID())
+	assert.Equal(t, 30*time.Second, c.Interval())
+
+	require.NoError(t, c.Run())
+	sender.AssertCalled(t, "Gauge", "test.gauge", 42.0, "", []string{fmt.Sprintf("pid:%d", c.worker.cmd.Process.Pid)})
+	sender.AssertCalled(t, "ServiceCheck", "test.can_connect", metrics.ServiceCheckOK, "", []string(nil), "")
+	sender.AssertNumberOfCalls(t, "Commit", 1)
+	assert.Equal(t, []error{errors.New("a warning")}, c.GetWarnings())
+}
+
+func TestRunFromTemplate(t *testing.T) {
+	// the ID is built from the template by the agent, the worker process
+	// must build the same one for the check to send to the right sender
+	instance := check.ConfigData("password: secret")
+	template := check.ConfigData("password: ENC[db_password]")
+	unregister := check.RegisterTemplate(instance, nil, template, nil)
+	c := NewIsolatedCheck("testcheck")
+	err := c.Configure(instance, check.ConfigData(""))
+	unregister()
+	require.NoError(t, err)
+	defer c.Stop()
+	assert.Equal(t, check.BuildID("testcheck", template, nil), c.ID())
+
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	require.NoError(t, c.Run())
+	sender.AssertNumberOfCalls(t, "Gauge", 1)
+	sender.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestRunError(t *testing.T) {
+	c, sender := newCheck(t, "fail: true")
+	defer c.Stop()
+
+	assert.EqualError(t, c.Run(), "failure")
+	// the metrics sent before the error are kept
+	sender.AssertNumberOfCalls(t, "Gauge", 1)
+}
+
+func TestRestartCrashedWorker(t *testing.T) {
+	c, _ := newCheck(t, "crash: true")
+	defer c.Stop()
+
+	pid := c.worker.cmd.Process.Pid
+	assert.Error(t, c.Run())
+	assert.True(t, c.worker.hasExited())
+
+	// the next run starts a new worker
+	assert.Error(t, c.Run())
+	assert.NotEqual(t, pid, c.worker.cmd.Process.Pid)
+}
+
+func TestKillTimedOutWorker(t *testing.T) {
+	c, sender := newCheck(t, "min_collection_interval: 30\ncheck_timeout: 0.1\nsleep: 5")
+	defer c.Stop()
+	assert.Equal(t, 100*time.Millisecond, c.Timeout())
+
+	w := c.worker
+	start := time.Now()
+	assert.Equal(t, check.TimeoutError{Timeout: 100 * time.Millisecond}, c.Run())
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.True(t, w.hasExited())
+	sender.AssertNotCalled(t, "Commit")
+
+	// the next run starts a new worker
+	assert.Error(t, c.Run())
+	assert.NotEqual(t, w.cmd.Process.Pid, c.worker.cmd.Process.Pid)
+}
+
+func TestStop(t *testing.T) {
+	c, _ := newCheck(t, "{}")
+	w := c.worker
+
+	c.Stop()
+	assert.True(t, w.hasExited())
+	assert.EqualError(t, c.Run(), "the check was stopped")
+	assert.Equal(t, []error{}, c.GetWarnings())
+}
+
+func TestSplitConfig(t *testing.T) {
+	config := check.Config{
+		Name:       "testcheck",
+		InitConfig: check.ConfigData("foo: bar"),
+		Instances: []check.ConfigData{
+			check.ConfigData("isolated: true"),
+			check.ConfigData("isolated: false"),
+			check.ConfigData("{}"),
+		},
+	}
+	isolated, others := SplitConfig(config)
+	assert.Equal(t, "testcheck", isolated.Name)
+	assert.Equal(t, config.InitConfig, isolated.InitConfig)
+	assert.Equal(t, []check.ConfigData{config.Instances[0]}, isolated.Instances)
+	assert.Equal(t, config.InitConfig, others.InitConfig)
+	assert.Equal(t, config.Instances[1:], others.Instances)
+	// the config itself is left untouched
+	assert.Len(t, config.Instances, 3)
+}
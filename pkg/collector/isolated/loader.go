@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package isolated
+
+import (
+	"fmt"
+
+	log "github.com/cihub/seelog"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+// IsolatedCheckLoader loads the instances of a check into worker processes
+type IsolatedCheckLoader struct{}
+
+// NewIsolatedCheckLoader creates a loader for isolated checks
+func NewIsolatedCheckLoader() *IsolatedCheckLoader {
+	return &IsolatedCheckLoader{}
+}
+
+// Load returns an IsolatedCheck for every instance of the config
+func (l *IsolatedCheckLoader) Load(config check.Config) ([]check.Check, error) {
+	checks := []check.Check{}
+	var lastErr error
+	for _, instance := range config.Instances {
+		c := NewIsolatedCheck(config.Name)
+		if err := c.Configure(instance, config.InitConfig); err != nil {
+			log.Errorf("isolated.loader: could not configure check '%s': %s", config.Name, err)
+			lastErr = err
+			continue
+		}
+		checks = append(checks, c)
+	}
+	if lastErr != nil {
+		return checks, fmt.Errorf("Could not configure isolated check %s: %s", config.Name, lastErr)
+	}
+	return checks, nil
+}
+
+func (l *IsolatedCheckLoader) String() string {
+	return "Isolated Check Loader"
+}
+
+// SplitConfig separates the instances of a config that must run in a worker
+// process, the ones setting `isolated: true`, from the others
+func SplitConfig(config check.Config) (isolated check.Config, others check.Config) {
+	isolated, others = config, config
+	isolated.Instances, others.Instances = nil, nil
+	for _, instance := range config.Instances {
+		if isIsolated(instance) {
+			isolated.Instances = append(isolated.Instances, instance)
+		} else {
+			others.Instances = append(others.Instances, instance)
+		}
+	}
+	return isolated, others
+}
+
+func isIsolated(instance check.ConfigData) bool {
+	opts := struct {
+		Isolated bool `yaml:"isolated"`
+	}{}
+	if err := yaml.Unmarshal(instance, &opts); err != nil {
+		return false
+	}
+	return opts.Isolated
+}
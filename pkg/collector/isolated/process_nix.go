@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !windows
+
+package isolated
+
+import (
+	"bufio"
+	"net/rpc"
+	"os"
+
+	log "github.com/cihub/seelog"
+)
+
+// startWorker starts a worker process. The agent sends its requests on the
+// file descriptor 3 of the worker, which replies on the file descriptor 4:
+// the standard output stays available to the check and is logged.
+func startWorker(name string) (*worker, error) {
+	cmd, err := workerCommand()
+	if err != nil {
+		return nil, err
+	}
+
+	requestsIn, requestsOut, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	repliesIn, repliesOut, err := os.Pipe()
+	if err != nil {
+		requestsIn.Close()
+		requestsOut.Close()
+		return nil, err
+	}
+	logsIn, logsOut, err := os.Pipe()
+	if err != nil {
+		requestsIn.Close()
+		requestsOut.Close()
+		repliesIn.Close()
+		repliesOut.Close()
+		return nil, err
+	}
+
+	cmd.ExtraFiles = []*os.File{requestsIn, repliesOut}
+	cmd.Stdout = logsOut
+	cmd.Stderr = logsOut
+	err = cmd.Start()
+	// the worker has its own copies of these
+	requestsIn.Close()
+	repliesOut.Close()
+	logsOut.Close()
+	if err != nil {
+		requestsOut.Close()
+		repliesIn.Close()
+		logsIn.Close()
+		return nil, err
+	}
+	log.Infof("Started worker process %d for check %s", cmd.Process.Pid, name)
+	isolatedStats.Add("Workers", 1)
+
+	go func() {
+		defer logsIn.Close()
+		scanner := bufio.NewScanner(logsIn)
+		for scanner.Scan() {
+			log.Infof("(%s worker) %s", name, scanner.Text())
+		}
+	}()
+
+	w := &worker{
+		cmd:    cmd,
+		client: rpc.NewClient(&pipe{repliesIn, requestsOut}),
+		exited: make(chan struct{}),
+	}
+	go func() {
+		err := cmd.Wait()
+		log.Infof("Worker process %d of check %s exited: %v", cmd.Process.Pid, name, err)
+		isolatedStats.Add("Workers", -1)
+		close(w.exited)
+	}()
+	return w, nil
+}
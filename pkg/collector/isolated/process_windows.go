@@ -0,0 +1,16 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package isolated
+
+import (
+	"errors"
+)
+
+// startWorker fails on Windows: the agent talks to its workers on inherited
+// file descriptors, which os/exec doesn't support there
+func startWorker(name string) (*worker, error) {
+	return nil, errors.New("isolated checks aren't supported on Windows")
+}
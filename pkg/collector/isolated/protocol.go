@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package isolated
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// ConfigureArgs are the arguments of the Worker.Configure call
+type ConfigureArgs struct {
+	Name       string
+	ID         check.ID
+	InitConfig check.ConfigData
+	Instance   check.ConfigData
+}
+
+// RunReply is the reply of the Worker.Run call
+type RunReply struct {
+	Error string
+	Calls []SenderCall // the sender calls made by the check during the run
+}
+
+// WarningsReply is the reply of the Worker.GetWarnings call
+type WarningsReply struct {
+	Warnings []string
+}
+
+// Empty is used for the calls without arguments or reply
+type Empty struct{}
+
+// SenderCall is a call made by a check to its sender in the worker process,
+// replayed by the agent on the actual sender of the check
+type SenderCall struct {
+	Method   string
+	Metric   string
+	Value    float64
+	Hostname string
+	Tags     []string
+	Status   metrics.ServiceCheckStatus
+	Message  string
+	Event    *metrics.Event
+}
+
+// replay sends the call to a sender
+func (c *SenderCall) replay(sender aggregator.Sender) error {
+	switch c.Method {
+	case "Gauge":
+		sender.Gauge(c.Metric, c.Value, c.Hostname, c.Tags)
+	case "Rate":
+		sender.Rate(c.Metric, c.Value, c.Hostname, c.Tags)
+	case "Count":
+		sender.Count(c.Metric, c.Value, c.Hostname, c.Tags)
+	case "MonotonicCount":
+		sender.MonotonicCount(c.Metric, c.Value, c.Hostname, c.Tags)
+	case "Counter":
+		sender.Counter(c.Metric, c.Value, c.Hostname, c.Tags)
+	case "Histogram":
+		sender.Histogram(c.Metric, c.Value, c.Hostname, c.Tags)
+	case "Historate":
+		sender.Historate(c.Metric, c.Value, c.Hostname, c.Tags)
+	case "ServiceCheck":
+		sender.ServiceCheck(c.Metric, c.Status, c.Hostname, c.Tags, c.Message)
+	case "Event":
+		if c.Event == nil {
+			return fmt.Errorf("missing event")
+		}
+		sender.Event(*c.Event)
+	default:
+		return fmt.Errorf("unknown sender method %q", c.Method)
+	}
+	return nil
+}
+
+// recordingSender implements aggregator.Sender in the worker process, it
+// keeps the calls of the check until they're sent back to the agent
+type recordingSender struct {
+	calls []SenderCall
+	m     sync.Mutex
+}
+
+func (s *recordingSender) record(call SenderCall) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.calls = append(s.calls, call)
+}
+
+// flush returns the recorded calls and forgets them
+func (s *recordingSender) flush() []SenderCall {
+	s.m.Lock()
+	defer s.m.Unlock()
+	calls := s.calls
+	s.calls = nil
+	return calls
+}
+
+func (s *recordingSender) sample(method, metric string, value float64, hostname string, tags []string) {
+	s.record(SenderCall{Method: method, Metric: metric, Value: value, Hostname: hostname, Tags: tags})
+}
+
+// Commit is a noop, the agent commits once the calls are replayed
+func (s *recordingSender) Commit() {}
+
+// Gauge records a gauge
+func (s *recordingSender) Gauge(metric string, value float64, hostname string, tags []string) {
+	s.sample("Gauge", metric, value, hostname, tags)
+}
+
+// Rate records a rate
+func (s *recordingSender) Rate(metric string, value float64, hostname string, tags []string) {
+	s.sample("Rate", metric, value, hostname, tags)
+}
+
+// Count records a count
+func (s *recordingSender) Count(metric string, value float64, hostname string, tags []string) {
+	s.sample("Count", metric, value, hostname, tags)
+}
+
+// MonotonicCount records a monotonic count
+func (s *recordingSender) MonotonicCount(metric string, value float64, hostname string, tags []string) {
+	s.sample("MonotonicCount", metric, value, hostname, tags)
+}
+
+// Counter records a counter
+func (s *recordingSender) Counter(metric string, value float64, hostname string, tags []string) {
+	s.sample("Counter", metric, value, hostname, tags)
+}
+
+// Histogram records a histogram
+func (s *recordingSender) Histogram(metric string, value float64, hostname string, tags []string) {
+	s.sample("Histogram", metric, value, hostname, tags)
+}
+
+// Historate records a historate
+func (s *recordingSender) Historate(metric string, value float64, hostname string, tags []string) {
+	s.sample("Historate", metric, value, hostname, tags)
+}
+
+// ServiceCheck records a service check
+func (s *recordingSender) ServiceCheck(checkName string, status metrics.ServiceCheckStatus, hostname string, tags []string, message string) {
+	s.record(SenderCall{Method: "ServiceCheck", Metric: checkName, Status: status, Hostname: hostname, Tags: tags, Message: message})
+}
+
+// Event records an event
+func (s *recordingSender) Event(e metrics.Event) {
+	s.record(SenderCall{Method: "Event", Event: &e})
+}
+
+// GetMetricStats returns empty stats, the agent counts the metrics when
+// replaying the calls
+func (s *recordingSender) GetMetricStats() map[string]int64 {
+	return make(map[string]int64)
+}
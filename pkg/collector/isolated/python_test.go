@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cpython,!windows
+
+package isolated
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/py"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// the worker process loads the Python checks used as fixtures by the py package
+func init() {
+	loaders := workerLoaders
+	workerLoaders = func() []check.Loader {
+		testsDir, _ := filepath.Abs(filepath.Join("..", "py", "tests"))
+		distDir, _ := filepath.Abs(filepath.Join("..", "..", "..", "cmd", "agent", "dist"))
+		py.Initialize(testsDir, distDir)
+
+		loader, err := py.NewPythonCheckLoader()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		// the test loader loads any check, the Python one must come first
+		return append([]check.Loader{loader}, loaders()...)
+	}
+}
+
+func TestRunPythonCheck(t *testing.T) {
+	c := NewIsolatedCheck("testaggregator")
+	require.NoError(t, c.Configure(check.ConfigData("foo: bar\nisolated: true"), check.ConfigData("")))
+	defer c.Stop()
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+
+	require.NoError(t, c.Run())
+	sender.AssertCalled(t, "Gauge", "testmetric", 0.0, "", []string(nil))
+	sender.AssertCalled(t, "Gauge", "testmetricstringvalue", 2.0, "", []string(nil))
+	sender.AssertNotCalled(t, "Gauge", "testmetricnonevalue", 0.0, "", []string(nil))
+	sender.AssertCalled(t, "Counter", "test.increment", 1.0, "", []string{"foo", "bar"})
+	sender.AssertCalled(t, "ServiceCheck", "testservicecheckwithhostname", metrics.ServiceCheckOK, "testhostname", []string{"foo", "bar"}, "a message")
+	sender.AssertNumberOfCalls(t, "Event", 1)
+	sender.AssertNumberOfCalls(t, "Commit", 1)
+	assert.Empty(t, c.GetWarnings())
+}
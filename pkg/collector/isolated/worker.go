@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package isolated
+
+import (
+	"fmt"
+	"io"
+	"net/rpc"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+// Worker is the RPC service run by a worker process, it runs a single check
+// instance on behalf of the agent
+type Worker struct {
+	loaders []check.Loader
+	check   check.Check
+	sender  *recordingSender
+}
+
+// ConfigureReply is the reply of the Worker.Configure call, it describes how
+// the check must be scheduled
+type ConfigureReply struct {
+	Name     string
+	Interval time.Duration
+	Timeout  time.Duration
+	Jitter   time.Duration
+	Schedule string
+}
+
+// Serve runs the Worker RPC service, reading the requests of the agent from
+// `in` and writing the replies to `out`, until the agent closes the pipes.
+// The checks are loaded with the given loaders.
+func Serve(in io.ReadCloser, out io.WriteCloser, loaders []check.Loader) error {
+	// the samples are sent to the agent, this aggregator is only needed to
+	// register the senders of the check and never flushes
+	agg := aggregator.NewBufferedAggregator(nil, "", time.Hour)
+	agg.TickerChan = make(chan time.Time)
+	aggregator.SetDefaultAggregator(agg)
+
+	server := rpc.NewServer()
+	err := server.RegisterName("Worker", &Worker{loaders: loaders, sender: &recordingSender{}})
+	if err != nil {
+		return err
+	}
+	server.ServeConn(&pipe{in, out})
+	return nil
+}
+
+// Configure loads the check instance with the first loader able to do it
+func (w *Worker) Configure(args *ConfigureArgs, reply *ConfigureReply) error {
+	if w.check != nil {
+		return fmt.Errorf("check %s is already configured", w.check)
+	}
+
+	// the check gets this sender from the aggregator by its ID
+	if err := aggregator.SetSender(w.sender, args.ID); err != nil {
+		return err
+	}
+
+	// the ID was built by the agent from the configuration before its secrets
+	// were decrypted, the check must get the same one to find its sender
+	unregister, err := check.RegisterID(args.Instance, args.InitConfig, args.ID)
+	if err != nil {
+		return err
+	}
+	defer unregister()
+
+	config := check.Config{
+		Name:       args.Name,
+		InitConfig: args.InitConfig,
+		Instances:  []check.ConfigData{args.Instance},
+	}
+	for _, loader := range w.loaders {
+		checks, err := loader.Load(config)
+		if err != nil || len(checks) == 0 {
+			log.Debugf("%v: unable to load the check '%s': %v", loader, args.Name, err)
+			continue
+		}
+		if checks[0].ID() != args.ID {
+			return fmt.Errorf("check %s was loaded with the ID %s instead of %s", args.Name, checks[0].ID(), args.ID)
+		}
+		w.check = checks[0]
+		break
+	}
+	if w.check == nil {
+		return fmt.Errorf("unable to load the check '%s'", args.Name)
+	}
+
+	reply.Name = w.check.String()
+	reply.Interval = w.check.Interval()
+	if c, ok := w.check.(check.TimeoutCheck); ok {
+		reply.Timeout = c.Timeout()
+	}
+	if c, ok := w.check.(check.JitterCheck); ok {
+		reply.Jitter = c.Jitter()
+	}
+	if c, ok := w.check.(check.ScheduleCheck); ok {
+		reply.Schedule = c.Schedule()
+	}
+	return nil
+}
+
+// Run runs the check and returns the calls it made to its sender
+func (w *Worker) Run(args *Empty, reply *RunReply) error {
+	if w.check == nil {
+		return fmt.Errorf("no check configured")
+	}
+
+	err := w.check.Run()
+	reply.Calls = w.sender.flush()
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	return nil
+}
+
+// Stop stops the check
+func (w *Worker) Stop(args *Empty, reply *Empty) error {
+	if w.check != nil {
+		w.check.Stop()
+	}
+	return nil
+}
+
+// GetWarnings returns the warnings of the last run of the check
+func (w *Worker) GetWarnings(args *Empty, reply *WarningsReply) error {
+	if w.check == nil {
+		return nil
+	}
+	for _, warning := range w.check.GetWarnings() {
+		reply.Warnings = append(reply.Warnings, warning.Error())
+	}
+	return nil
+}
+
+// pipe makes a single connection out of the two pipes between the agent and
+// a worker process
+type pipe struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+// Close closes both pipes
+func (p *pipe) Close() error {
+	werr := p.WriteCloser.Close()
+	if err := p.ReadCloser.Close(); err != nil {
+		return err
+	}
+	return werr
+}
@@ -14,9 +14,9 @@ const (
 	identifierLabel string = "io.datadog.check.id"
 )
 
-// ComputeContainerServiceIDs takes a container ID, an image (resolved to an actual name) and labels
+// ComputeContainerServiceIDs takes a container entity name, an image (resolved to an actual name) and labels
 // and computes the service IDs for this container service.
-func ComputeContainerServiceIDs(cid string, image string, labels map[string]string) []string {
+func ComputeContainerServiceIDs(entity string, image string, labels map[string]string) []string {
 	ids := []string{}
 
 	// check for an identifier label
@@ -28,8 +28,8 @@ func ComputeContainerServiceIDs(cid string, image string, labels map[string]stri
 		}
 	}
 
-	// add the container ID for templates in labels/annotations
-	ids = append(ids, docker.ContainerIDToEntityName(cid))
+	// add the container entity for templates in labels/annotations
+	ids = append(ids, entity)
 
 	// add the image names (long then short if different)
 	long, short, _, err := docker.SplitImageName(image)
@@ -8,28 +8,27 @@
 package listeners
 
 import (
-	"context"
 	"fmt"
 	"io"
-	"sort"
 	"strings"
 	"sync"
 
 	log "github.com/cihub/seelog"
-	"github.com/docker/docker/api/types"
-	"github.com/docker/go-connections/nat"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/common/signals"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/util/container"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
 )
 
 // DockerListener implements the ServiceListener interface.
-// It listens for Docker events and reports container updates to Auto Discovery
+// It listens for the container events of the Runtime set by the
+// container_runtime option, docker by default, and reports container
+// updates to Auto Discovery.
 // It also holds a cache of services that the ConfigResolver can query to
 // match templates against.
 type DockerListener struct {
-	dockerUtil *docker.DockerUtil
+	runtime    container.Runtime
 	services   map[ID]Service
 	newService chan<- Service
 	delService chan<- Service
@@ -44,27 +43,31 @@ type DockerService struct {
 	Hosts         map[string]string
 	Ports         []int
 	Pid           int
+	Env           map[string]string
+	Labels        map[string]string
+	entity        string     // tagger entity, docker:// prefixed if unset
+	m             sync.Mutex // to protect Env and Labels, filled on demand
 }
 
 func init() {
 	Register("docker", NewDockerListener)
 }
 
-// NewDockerListener creates a client connection to Docker and instantiate a DockerListener with it
+// NewDockerListener connects to the container runtime and instantiate a DockerListener with it
 // TODO: TLS support
 func NewDockerListener() (ServiceListener, error) {
-	d, err := docker.GetDockerUtil()
+	rt, err := container.GetRuntime()
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Docker, auto discovery will not work: %s", err)
+		return nil, fmt.Errorf("failed to connect to the container runtime, auto discovery will not work: %s", err)
 	}
 	return &DockerListener{
-		dockerUtil: d,
-		services:   make(map[ID]Service),
-		stop:       make(chan bool),
+		runtime:  rt,
+		services: make(map[ID]Service),
+		stop:     make(chan bool),
 	}, nil
 }
 
-// Listen streams container-related events from Docker and report said containers as Services.
+// Listen streams container-related events from the runtime and report said containers as Services.
 func (l *DockerListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
 	// setup the I/O channels
 	l.newService = newSvc
@@ -73,9 +76,9 @@ func (l *DockerListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
 	// process containers that might be already running
 	l.init()
 
-	messages, errs, err := l.dockerUtil.SubscribeToContainerEvents("DockerListener")
+	messages, errs, err := l.runtime.SubscribeToEvents("DockerListener")
 	if err != nil {
-		log.Errorf("can't listen to docker events: %v", err)
+		log.Errorf("can't listen to container events: %v", err)
 		signals.ErrorStopper <- true
 		return
 	}
@@ -84,7 +87,7 @@ func (l *DockerListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
 		for {
 			select {
 			case <-l.stop:
-				l.dockerUtil.UnsubscribeFromContainerEvents("DockerListener")
+				l.runtime.UnsubscribeFromEvents("DockerListener")
 				return
 			case msg := <-messages:
 				l.processEvent(msg)
@@ -104,14 +107,14 @@ func (l *DockerListener) Stop() {
 	l.stop <- true
 }
 
-// init looks at currently running Docker containers,
+// init looks at currently running containers,
 // creates services for them, and pass them to the ConfigResolver.
 // It is typically called at start up.
 func (l *DockerListener) init() {
 	l.m.Lock()
 	defer l.m.Unlock()
 
-	containers, err := l.dockerUtil.ContainerList(context.Background(), types.ContainerListOptions{})
+	containers, err := l.runtime.ListContainers(&docker.ContainerListConfig{FlagExcluded: true})
 	if err != nil {
 		log.Errorf("Couldn't retrieve container list - %s", err)
 	}
@@ -124,16 +127,18 @@ func (l *DockerListener) init() {
 			svc = &DockerKubeletService{
 				DockerService: DockerService{
 					ID:            id,
-					ADIdentifiers: l.getConfigIDFromPs(co),
+					ADIdentifiers: getConfigIDFromPs(co),
+					entity:        co.EntityID,
 					// Host and Ports will be looked up when needed
 				},
 			}
 		} else {
 			svc = &DockerService{
 				ID:            id,
-				ADIdentifiers: l.getConfigIDFromPs(co),
-				Hosts:         l.getHostsFromPs(co),
-				Ports:         l.getPortsFromPs(co),
+				ADIdentifiers: getConfigIDFromPs(co),
+				Hosts:         co.Hosts,
+				Ports:         co.Ports,
+				entity:        co.EntityID,
 			}
 		}
 		l.newService <- svc
@@ -186,19 +191,22 @@ func (l *DockerListener) createService(cID ID) {
 	var svc Service
 
 	// Detect whether that container is managed by Kubernetes
-	cInspect, err := l.dockerUtil.Inspect(string(cID), false)
+	ctr, err := l.runtime.InspectContainer(string(cID))
 	if err != nil {
 		log.Errorf("Failed to inspect container %s - %s", cID[:12], err)
+		ctr = &docker.Container{}
 	}
-	if findKubernetesInLabels(cInspect.Config.Labels) {
+	if findKubernetesInLabels(ctr.Labels) {
 		svc = &DockerKubeletService{
 			DockerService: DockerService{
-				ID: cID,
+				ID:     cID,
+				entity: ctr.EntityID,
 			},
 		}
 	} else {
 		svc = &DockerService{
-			ID: cID,
+			ID:     cID,
+			entity: ctr.EntityID,
 		}
 	}
 
@@ -215,7 +223,7 @@ func (l *DockerListener) createService(cID ID) {
 		log.Errorf("Failed to inspect container %s - %s", cID[:12], err)
 	}
 	_, err = svc.GetPid()
-	if err != nil {
+	if err != nil && err != ErrNotSupported {
 		log.Errorf("Failed to inspect container %s - %s", cID[:12], err)
 	}
 	_, err = svc.GetTags()
@@ -259,42 +267,8 @@ func (l *DockerListener) removeService(cID ID) {
 // If the special label was not set, the priority order is the following:
 //   1. Long image name
 //   2. Short image name
-func (l *DockerListener) getConfigIDFromPs(co types.Container) []string {
-	image, err := l.dockerUtil.ResolveImageName(co.Image)
-	if err != nil {
-		log.Warnf("error while resolving image name: %s", err)
-	}
-	return ComputeContainerServiceIDs(co.ID, image, co.Labels)
-}
-
-// getHostsFromPs gets the addresss (for now IP address only) of a container on all its networks.
-func (l *DockerListener) getHostsFromPs(co types.Container) map[string]string {
-	ips := make(map[string]string)
-	if co.NetworkSettings != nil {
-		for net, settings := range co.NetworkSettings.Networks {
-			if len(settings.IPAddress) > 0 {
-				ips[net] = settings.IPAddress
-			}
-		}
-	}
-
-	rancherIP, found := docker.FindRancherIPInLabels(co.Labels)
-	if found {
-		ips["rancher"] = rancherIP
-	}
-	return ips
-}
-
-// getPortsFromPs gets the service ports of a container.
-func (l *DockerListener) getPortsFromPs(co types.Container) []int {
-	// Nil array by default, we'll need to inspect the container
-	// later if we don't find any port in the PS
-	var ports []int
-
-	for _, p := range co.Ports {
-		ports = append(ports, int(p.PrivatePort))
-	}
-	return ports
+func getConfigIDFromPs(co *docker.Container) []string {
+	return ComputeContainerServiceIDs(co.EntityID, co.Image, co.Labels)
 }
 
 // GetID returns the service ID
@@ -302,6 +276,14 @@ func (s *DockerService) GetID() ID {
 	return s.ID
 }
 
+// getEntity returns the tagger entity of the container
+func (s *DockerService) getEntity() string {
+	if s.entity != "" {
+		return s.entity
+	}
+	return docker.ContainerIDToEntityName(string(s.ID))
+}
+
 // GetADIdentifiers returns a set of AD identifiers for a container.
 // These id are sorted to reflect the priority we want the ConfigResolver to
 // use when matching a template.
@@ -315,19 +297,11 @@ func (s *DockerService) GetID() ID {
 //   2. Short image name
 func (s *DockerService) GetADIdentifiers() ([]string, error) {
 	if len(s.ADIdentifiers) == 0 {
-		du, err := docker.GetDockerUtil()
+		ctr, err := inspectContainer(s.ID)
 		if err != nil {
 			return []string{}, err
 		}
-		cj, err := du.Inspect(string(s.ID), false)
-		if err != nil {
-			return []string{}, err
-		}
-		image, err := du.ResolveImageName(cj.Image)
-		if err != nil {
-			log.Warnf("error while resolving image name: %s", err)
-		}
-		s.ADIdentifiers = ComputeContainerServiceIDs(string(s.ID), image, cj.Config.Labels)
+		s.ADIdentifiers = ComputeContainerServiceIDs(s.getEntity(), ctr.Image, ctr.Labels)
 	}
 
 	return s.ADIdentifiers, nil
@@ -339,28 +313,12 @@ func (s *DockerService) GetHosts() (map[string]string, error) {
 		return s.Hosts, nil
 	}
 
-	ips := make(map[string]string)
-	du, err := docker.GetDockerUtil()
+	ctr, err := inspectContainer(s.ID)
 	if err != nil {
 		return nil, err
 	}
-	cInspect, err := du.Inspect(string(s.ID), false)
-	if err != nil {
-		return nil, fmt.Errorf("failed to inspect container %s", string(s.ID)[:12])
-	}
-	for net, settings := range cInspect.NetworkSettings.Networks {
-		if len(settings.IPAddress) > 0 {
-			ips[net] = settings.IPAddress
-		}
-	}
-
-	rancherIP, found := docker.FindRancherIPInLabels(cInspect.Config.Labels)
-	if found {
-		ips["rancher"] = rancherIP
-	}
-
-	s.Hosts = ips
-	return ips, nil
+	s.Hosts = ctr.Hosts
+	return s.Hosts, nil
 }
 
 // GetPorts returns the container's ports
@@ -369,71 +327,19 @@ func (s *DockerService) GetPorts() ([]int, error) {
 		return s.Ports, nil
 	}
 
-	// Make a non-nil array to avoid re-running if we find zero port
-	ports := []int{}
-
-	du, err := docker.GetDockerUtil()
-	if err != nil {
-		return ports, err
-	}
-	cInspect, err := du.Inspect(string(s.ID), false)
+	ctr, err := inspectContainer(s.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to inspect container %s", string(s.ID)[:12])
-	}
-
-	switch {
-	case cInspect.NetworkSettings != nil && len(cInspect.NetworkSettings.Ports) > 0:
-		for p := range cInspect.NetworkSettings.Ports {
-			out, err := parseDockerPort(p)
-			if err != nil {
-				log.Warn(err.Error())
-				continue
-			}
-			ports = append(ports, out...)
-		}
-	case cInspect.Config != nil && len(cInspect.Config.ExposedPorts) > 0:
-		log.Infof("using ExposedPorts for container %s as no port bindings are listed", string(s.ID)[:12])
-		for p := range cInspect.Config.ExposedPorts {
-			out, err := parseDockerPort(p)
-			if err != nil {
-				log.Warn(err.Error())
-				continue
-			}
-			ports = append(ports, out...)
-		}
-	}
-
-	sort.Ints(ports)
-	s.Ports = ports
-	return ports, nil
-}
-
-func parseDockerPort(port nat.Port) ([]int, error) {
-	var output []int
-
-	// Try to parse a port range, eg. 22-25
-	first, last, err := port.Range()
-	if err == nil && last > first {
-		for p := first; p <= last; p++ {
-			output = append(output, p)
-		}
-		return output, nil
-	}
-
-	// Try to parse a single port (most common case)
-	p := port.Int()
-	if p > 0 {
-		output = append(output, p)
-		return output, nil
+		return nil, err
 	}
-
-	return output, fmt.Errorf("failed to extract port from: %v", port)
+	// Make a non-nil array to avoid re-running if we find zero port
+	ports := []int{}
+	s.Ports = append(ports, ctr.Ports...)
+	return s.Ports, nil
 }
 
 // GetTags retrieves tags using the Tagger
 func (s *DockerService) GetTags() ([]string, error) {
-	entity := docker.ContainerIDToEntityName(string(s.ID))
-	tags, err := tagger.Tag(entity, false)
+	tags, err := tagger.Tag(s.getEntity(), false)
 	if err != nil {
 		return []string{}, err
 	}
@@ -445,20 +351,84 @@ func (s *DockerService) GetTags() ([]string, error) {
 func (s *DockerService) GetPid() (int, error) {
 	// Try to inspect container to get the pid if not defined
 	if s.Pid <= 0 {
-		du, err := docker.GetDockerUtil()
+		ctr, err := inspectContainer(s.ID)
 		if err != nil {
 			return -1, err
 		}
-		cj, err := du.Inspect(string(s.ID), false)
-		if err != nil {
-			return -1, err
+		// the CRI runtimes don't expose the pids of the containers
+		if len(ctr.Pids) == 0 {
+			return -1, ErrNotSupported
 		}
-		s.Pid = cj.State.Pid
+		s.Pid = int(ctr.Pids[0])
 	}
 
 	return s.Pid, nil
 }
 
+// GetEnv inspects the container and returns its environment variables
+func (s *DockerService) GetEnv() (map[string]string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.Env != nil {
+		return s.Env, nil
+	}
+	env, err := inspectEnv(s.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.Env = env
+	return env, nil
+}
+
+// GetLabels inspects the container and returns its labels
+func (s *DockerService) GetLabels() (map[string]string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.Labels != nil {
+		return s.Labels, nil
+	}
+
+	ctr, err := inspectContainer(s.ID)
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[string]string)
+	for k, v := range ctr.Labels {
+		labels[k] = v
+	}
+
+	s.Labels = labels
+	return labels, nil
+}
+
+// inspectContainer inspects a container through the container runtime
+func inspectContainer(id ID) (*docker.Container, error) {
+	rt, err := container.GetRuntime()
+	if err != nil {
+		return nil, err
+	}
+	ctr, err := rt.InspectContainer(string(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %s", string(id), err)
+	}
+	return ctr, nil
+}
+
+// inspectEnv inspects a container and returns its environment variables
+func inspectEnv(id ID) (map[string]string, error) {
+	ctr, err := inspectContainer(id)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string)
+	for k, v := range ctr.Env {
+		env[k] = v
+	}
+	return env, nil
+}
+
 // findKubernetesInLabels traverses a map of container labels and
 // returns true if a kubernetes label is detected
 func findKubernetesInLabels(labels map[string]string) bool {
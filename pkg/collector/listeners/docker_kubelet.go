@@ -15,7 +15,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/DataDog/datadog-agent/pkg/util/docker"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 )
 
@@ -37,8 +36,7 @@ func (s *DockerKubeletService) getPod() (*kubelet.Pod, error) {
 			return nil, err
 		}
 	}
-	searchedId := docker.ContainerIDToEntityName(string(s.GetID()))
-	return s.kubeUtil.GetPodForContainerID(searchedId)
+	return s.kubeUtil.GetPodForContainerID(s.getEntity())
 }
 
 // GetHosts returns the container's hosts
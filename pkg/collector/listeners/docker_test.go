@@ -9,8 +9,9 @@ package listeners
 
 import (
 	"errors"
-	"fmt"
+	"io"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -21,7 +22,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	containerutil "github.com/DataDog/datadog-agent/pkg/util/container"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
 )
 
@@ -34,111 +37,33 @@ func TestMain(m *testing.M) {
 }
 
 func TestGetConfigIDFromPs(t *testing.T) {
-	co := types.Container{
-		ID:    "deadbeef",
-		Image: "test",
+	co := &docker.Container{
+		ID:       "deadbeef",
+		EntityID: "docker://deadbeef",
+		Image:    "test",
 	}
-	dl := DockerListener{}
 
-	ids := dl.getConfigIDFromPs(co)
+	ids := getConfigIDFromPs(co)
 	assert.Equal(t, []string{"docker://deadbeef", "test"}, ids)
 
-	prefixCo := types.Container{
-		ID:    "deadbeef",
-		Image: "org/test",
+	prefixCo := &docker.Container{
+		ID:       "deadbeef",
+		EntityID: "containerd://deadbeef",
+		Image:    "org/test",
 	}
-	ids = dl.getConfigIDFromPs(prefixCo)
-	assert.Equal(t, []string{"docker://deadbeef", "org/test", "test"}, ids)
+	ids = getConfigIDFromPs(prefixCo)
+	assert.Equal(t, []string{"containerd://deadbeef", "org/test", "test"}, ids)
 
-	labeledCo := types.Container{
-		ID:     "deadbeef",
-		Image:  "test",
-		Labels: map[string]string{"io.datadog.check.id": "w00tw00t"},
+	labeledCo := &docker.Container{
+		ID:       "deadbeef",
+		EntityID: "docker://deadbeef",
+		Image:    "test",
+		Labels:   map[string]string{"io.datadog.check.id": "w00tw00t"},
 	}
-	ids = dl.getConfigIDFromPs(labeledCo)
+	ids = getConfigIDFromPs(labeledCo)
 	assert.Equal(t, []string{"w00tw00t"}, ids)
 }
 
-func TestGetHostsFromPs(t *testing.T) {
-	dl := DockerListener{}
-
-	co := types.Container{
-		ID:    "foo",
-		Image: "test",
-	}
-
-	assert.Empty(t, dl.getHostsFromPs(co))
-
-	nets := make(map[string]*network.EndpointSettings)
-	nets["bridge"] = &network.EndpointSettings{IPAddress: "172.17.0.2"}
-	nets["foo"] = &network.EndpointSettings{IPAddress: "172.17.0.3"}
-	networkSettings := types.SummaryNetworkSettings{
-		Networks: nets}
-
-	co = types.Container{
-		ID:              "deadbeef",
-		Image:           "test",
-		NetworkSettings: &networkSettings,
-		Ports:           []types.Port{{PrivatePort: 1337}, {PrivatePort: 42}},
-	}
-	hosts := dl.getHostsFromPs(co)
-
-	assert.Equal(t, "172.17.0.2", hosts["bridge"])
-	assert.Equal(t, "172.17.0.3", hosts["foo"])
-	assert.Equal(t, 2, len(hosts))
-}
-
-func TestGetRancherIPFromPs(t *testing.T) {
-	dl := DockerListener{}
-
-	co := types.Container{
-		ID:    "foo",
-		Image: "test",
-	}
-
-	assert.Empty(t, dl.getHostsFromPs(co))
-
-	nets := make(map[string]*network.EndpointSettings)
-	nets["none"] = &network.EndpointSettings{}
-	networkSettings := types.SummaryNetworkSettings{
-		Networks: nets}
-
-	co = types.Container{
-		ID:              "deadbeef",
-		Image:           "test",
-		NetworkSettings: &networkSettings,
-		Ports:           []types.Port{{PrivatePort: 1337}, {PrivatePort: 42}},
-		Labels: map[string]string{
-			"io.rancher.container.ip": "10.42.90.224/16",
-		},
-	}
-	hosts := dl.getHostsFromPs(co)
-
-	assert.Equal(t, "10.42.90.224", hosts["rancher"])
-	assert.Equal(t, 1, len(hosts))
-}
-
-func TestGetPortsFromPs(t *testing.T) {
-	dl := DockerListener{}
-
-	co := types.Container{
-		ID:    "foo",
-		Image: "test",
-	}
-	assert.Empty(t, dl.getPortsFromPs(co))
-	assert.Nil(t, dl.getPortsFromPs(co)) // return must be nil to trigger GetPorts on resolution
-
-	co.Ports = make([]types.Port, 0)
-	assert.Empty(t, dl.getPortsFromPs(co))
-
-	co.Ports = append(co.Ports, types.Port{PrivatePort: 1234})
-	co.Ports = append(co.Ports, types.Port{PrivatePort: 4321})
-	ports := dl.getPortsFromPs(co)
-	assert.Equal(t, 2, len(ports))
-	assert.Contains(t, ports, 1234)
-	assert.Contains(t, ports, 4321)
-}
-
 func TestGetADIdentifiers(t *testing.T) {
 	s := DockerService{ID: ID("deadbeef")}
 
@@ -402,47 +327,135 @@ func TestGetPid(t *testing.T) {
 	assert.Nil(t, err)
 }
 
-func TestParseDockerPort(t *testing.T) {
-	testCases := []struct {
-		proto         string
-		port          string
-		expectedPorts []int
-		expectedError error
-	}{
-		{
-			proto:         "tcp",
-			port:          "42",
-			expectedPorts: []int{42},
-			expectedError: nil,
-		},
-		{
-			proto:         "udp",
-			port:          "500-503",
-			expectedPorts: []int{500, 501, 502, 503},
-			expectedError: nil,
-		},
-		{
-			proto:         "tcp",
-			port:          "0",
-			expectedPorts: nil,
-			expectedError: errors.New("failed to extract port from: 0/tcp"),
+func TestGetEnvAndLabels(t *testing.T) {
+	s := &DockerService{ID: ID("envlabels")}
+	ecsService := &ECSService{ID: ID("envlabels")}
+
+	// Setting mocked data in cache
+	co := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: "envlabels", Image: "test"},
+		Config: &container.Config{
+			Env:    []string{"FOO=bar", "URL=http://host/?a=b", "EMPTY="},
+			Labels: map[string]string{"team": "metrics"},
 		},
 	}
+	cacheKey := docker.GetInspectCacheKey("envlabels", false)
+	cache.Cache.Set(cacheKey, co, 10*time.Second)
 
-	for i, test := range testCases {
-		t.Run(fmt.Sprintf("case %d: %s/%s", i, test.port, test.proto), func(t *testing.T) {
-			p, err := nat.NewPort(test.proto, test.port)
-			assert.Nil(t, err)
+	// the caches are filled by the first of concurrent calls
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.GetEnv()
+			s.GetLabels()
+			ecsService.GetEnv()
+		}()
+	}
+	wg.Wait()
+
+	expectedEnv := map[string]string{"FOO": "bar", "URL": "http://host/?a=b", "EMPTY": ""}
+	env, err := s.GetEnv()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedEnv, env)
+	labels, err := s.GetLabels()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"team": "metrics"}, labels)
+	env, err = ecsService.GetEnv()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedEnv, env)
+}
+
+// fakeRuntime is a container runtime serving a list of containers, without
+// events nor logs
+type fakeRuntime struct {
+	containers []*docker.Container
+}
 
-			ports, err := parseDockerPort(p)
-			if test.expectedError == nil {
-				assert.Nil(t, err)
-			} else {
-				require.NotNil(t, err)
-				assert.Equal(t, test.expectedError.Error(), err.Error())
-			}
+func (r *fakeRuntime) ListContainers(cfg *docker.ContainerListConfig) ([]*docker.Container, error) {
+	return r.containers, nil
+}
 
-			assert.Equal(t, test.expectedPorts, ports)
-		})
+func (r *fakeRuntime) InspectContainer(id string) (*docker.Container, error) {
+	for _, ctr := range r.containers {
+		if ctr.ID == id {
+			return ctr, nil
+		}
 	}
+	return nil, errors.New("container not found")
+}
+
+func (r *fakeRuntime) SubscribeToEvents(name string) (<-chan *docker.ContainerEvent, <-chan error, error) {
+	return nil, nil, errors.New("not supported")
+}
+
+func (r *fakeRuntime) UnsubscribeFromEvents(name string) error {
+	return errors.New("not supported")
+}
+
+func (r *fakeRuntime) ContainerLogs(id string, since string) (io.ReadCloser, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestRuntimeServices(t *testing.T) {
+	redis := &docker.Container{
+		ID:       "3e13513f94b41d23429804243820438f",
+		EntityID: "containerd://3e13513f94b41d23429804243820438f",
+		Image:    "docker.io/library/redis:4.0",
+		Labels:   map[string]string{"team": "metrics"},
+		Hosts:    map[string]string{},
+		Ports:    []int{6379},
+	}
+	nginx := &docker.Container{
+		ID:       "5b0d7a43c31e8e3b1a6b1d3e9ab2d1c0",
+		EntityID: "containerd://5b0d7a43c31e8e3b1a6b1d3e9ab2d1c0",
+		Image:    "docker.io/library/nginx:1.13",
+		Labels:   map[string]string{"io.datadog.check.id": "web"},
+		Env:      map[string]string{"FOO": "bar"},
+		Hosts:    map[string]string{},
+	}
+	rt := &fakeRuntime{containers: []*docker.Container{redis}}
+	containerutil.RegisterRuntime("fake", func() (containerutil.Runtime, error) { return rt, nil })
+	config.Datadog.Set("container_runtime", "fake")
+	defer config.Datadog.Set("container_runtime", "docker")
+
+	listener, err := NewDockerListener()
+	require.NoError(t, err)
+	l := listener.(*DockerListener)
+	newSvc := make(chan Service, 2)
+	l.newService = newSvc
+
+	// the running containers are listed at startup
+	l.init()
+	svc := <-newSvc
+	assert.Equal(t, ID(redis.ID), svc.GetID())
+	ids, err := svc.GetADIdentifiers()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"containerd://3e13513f94b41d23429804243820438f", "docker.io/library/redis", "redis"}, ids)
+	ports, err := svc.GetPorts()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{6379}, ports)
+
+	// the started ones are inspected
+	rt.containers = append(rt.containers, nginx)
+	l.processEvent(&docker.ContainerEvent{ContainerID: nginx.ID, Action: "start"})
+	svc = <-newSvc
+	assert.Equal(t, ID(nginx.ID), svc.GetID())
+	assert.Equal(t, "containerd://5b0d7a43c31e8e3b1a6b1d3e9ab2d1c0", svc.(*DockerService).getEntity())
+	ids, err = svc.GetADIdentifiers()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"web"}, ids)
+	ports, err = svc.GetPorts()
+	assert.NoError(t, err)
+	assert.NotNil(t, ports)
+	assert.Empty(t, ports)
+	_, err = svc.GetPid()
+	assert.Equal(t, ErrNotSupported, err)
+	env, err := svc.GetEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"FOO": "bar"}, env)
+	labels, err := svc.GetLabels()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"io.datadog.check.id": "web"}, labels)
 }
@@ -38,6 +38,9 @@ type ECSService struct {
 	Ports         []int
 	Pid           int
 	Tags          []string
+	Labels        map[string]string
+	env           map[string]string
+	m             sync.Mutex // to protect env, filled on demand
 	clusterName   string
 	taskFamily    string
 	taskVersion   string
@@ -115,9 +118,9 @@ func (l *ECSListener) refreshServices() {
 			continue
 		}
 		l.m.Lock()
-		l.services[c.DockerID] = &s
+		l.services[c.DockerID] = s
 		l.m.Unlock()
-		l.newService <- &s
+		l.newService <- s
 		delete(notSeen, c.DockerID)
 	}
 
@@ -131,9 +134,9 @@ func (l *ECSListener) refreshServices() {
 	}
 }
 
-func (l *ECSListener) createService(c ecs.Container) (ECSService, error) {
+func (l *ECSListener) createService(c ecs.Container) (*ECSService, error) {
 	cID := ID(c.DockerID)
-	svc := ECSService{
+	svc := &ECSService{
 		ID:          cID,
 		clusterName: l.task.ClusterName,
 		taskFamily:  l.task.Family,
@@ -143,7 +146,10 @@ func (l *ECSListener) createService(c ecs.Container) (ECSService, error) {
 	// ADIdentifiers
 	image := c.Image
 	labels := c.Labels
-	svc.ADIdentifiers = ComputeContainerServiceIDs(c.DockerID, image, labels)
+	svc.ADIdentifiers = ComputeContainerServiceIDs(docker.ContainerIDToEntityName(c.DockerID), image, labels)
+
+	// Labels
+	svc.Labels = labels
 
 	// Host
 	ips := make(map[string]string)
@@ -211,3 +217,25 @@ func (s *ECSService) GetTags() ([]string, error) {
 func (s *ECSService) GetPid() (int, error) {
 	return -1, ErrNotSupported
 }
+
+// GetEnv inspects the container and returns its environment variables: they
+// aren't in the metadata api
+func (s *ECSService) GetEnv() (map[string]string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.env != nil {
+		return s.env, nil
+	}
+	env, err := inspectEnv(s.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.env = env
+	return env, nil
+}
+
+// GetLabels returns the container's labels
+func (s *ECSService) GetLabels() (map[string]string, error) {
+	return s.Labels, nil
+}
@@ -36,6 +36,8 @@ type PodContainerService struct {
 	ADIdentifiers []string
 	Hosts         map[string]string
 	Ports         []int
+	Env           map[string]string
+	Labels        map[string]string
 }
 
 func init() {
@@ -133,22 +135,35 @@ func (l *KubeletListener) createService(id ID, pod *kubelet.Pod) {
 	}
 	svc.Hosts = map[string]string{"pod": podIp}
 
-	// Ports
+	// Ports and environment
 	var ports []int
+	env := make(map[string]string)
 	for _, container := range pod.Spec.Containers {
 		if container.Name == containerName {
 			for _, port := range container.Ports {
 				ports = append(ports, port.ContainerPort)
 			}
+			// variables set from a source (valueFrom) are not resolved, they
+			// are left out for their template variables to be reported as
+			// unresolved instead of resolving to an empty value
+			for _, v := range container.Env {
+				if v.Value != "" {
+					env[v.Name] = v.Value
+				}
+			}
 			break
 		}
 	}
+	svc.Env = env
 	svc.Ports = ports
 	if len(svc.Ports) == 0 {
 		// Port might not be specified in pod spec
 		log.Errorf("Failed to get ports for pod %s", podName)
 	}
 
+	// Labels
+	svc.Labels = pod.Metadata.Labels
+
 	l.m.Lock()
 	l.services[ID(id)] = &svc
 	l.m.Unlock()
@@ -197,6 +212,16 @@ func (s *PodContainerService) GetPorts() ([]int, error) {
 	return s.Ports, nil
 }
 
+// GetEnv returns the container's environment variables from the pod spec
+func (s *PodContainerService) GetEnv() (map[string]string, error) {
+	return s.Env, nil
+}
+
+// GetLabels returns the pod labels
+func (s *PodContainerService) GetLabels() (map[string]string, error) {
+	return s.Labels, nil
+}
+
 // GetTags retrieves tags using the Tagger
 func (s *PodContainerService) GetTags() ([]string, error) {
 	return tagger.Tag(string(s.ID), false)
@@ -33,6 +33,11 @@ func getMockedPod() *kubelet.Pod {
 					Protocol:      "UDP",
 				},
 			},
+			Env: []kubelet.EnvVar{
+				{Name: "FOO_USER", Value: "foo"},
+				// set from a secret
+				{Name: "FOO_PASSWORD"},
+			},
 		},
 		{
 			Name:  "bar",
@@ -99,6 +104,9 @@ func TestProcessNewPod(t *testing.T) {
 		ports, err := service.GetPorts()
 		assert.Nil(t, err)
 		assert.Equal(t, []int{1337, 1339}, ports)
+		env, err := service.GetEnv()
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"FOO_USER": "foo"}, env)
 		_, err = service.GetPid()
 		assert.Equal(t, ErrNotSupported, err)
 	default:
@@ -18,12 +18,14 @@ type ID string
 // It should be matched with a check template by the ConfigResolver using the
 // ADIdentifiers field.
 type Service interface {
-	GetID() ID                            // unique ID
-	GetADIdentifiers() ([]string, error)  // identifiers on which templates will be matched
-	GetHosts() (map[string]string, error) // network --> IP address
-	GetPorts() ([]int, error)             // network ports
-	GetTags() ([]string, error)           // tags
-	GetPid() (int, error)                 // process identifier
+	GetID() ID                             // unique ID
+	GetADIdentifiers() ([]string, error)   // identifiers on which templates will be matched
+	GetHosts() (map[string]string, error)  // network --> IP address
+	GetPorts() ([]int, error)              // network ports
+	GetTags() ([]string, error)            // tags
+	GetPid() (int, error)                  // process identifier
+	GetEnv() (map[string]string, error)    // environment variables
+	GetLabels() (map[string]string, error) // container or pod labels
 }
 
 // ServiceListener monitors running services and triggers check (un)scheduling
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package providers
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
+)
+
+// getClusterCheckConfigs queries the cluster agent for the check
+// configurations of the cluster, unless their version is still `version`,
+// declared as var to ease testing
+var getClusterCheckConfigs = func(version string) (clusteragent.CheckConfigs, bool, error) {
+	dca, err := clusteragent.GetClusterAgentClient()
+	if err != nil {
+		return clusteragent.CheckConfigs{}, false, err
+	}
+	return dca.GetCheckConfigsIfModified(version)
+}
+
+// ClusterAgentConfigProvider implements the ConfigProvider interface for the
+// check configurations served by the cluster agent, that reads them from the
+// Kubernetes ConfigMaps.
+type ClusterAgentConfigProvider struct {
+	version string                     // version of the last collected configurations
+	fetched *clusteragent.CheckConfigs // configurations fetched by IsUpToDate
+}
+
+// NewClusterAgentConfigProvider returns a new ConfigProvider polling the
+// cluster agent. Connectivity is not checked at this stage to allow for
+// retries, Collect will do it.
+func NewClusterAgentConfigProvider(cfg config.ConfigurationProviders) (ConfigProvider, error) {
+	return &ClusterAgentConfigProvider{}, nil
+}
+
+func (p *ClusterAgentConfigProvider) String() string {
+	return "Cluster Agent"
+}
+
+// Collect retrieves the check configurations from the cluster agent
+func (p *ClusterAgentConfigProvider) Collect() ([]check.Config, error) {
+	checkConfigs := p.fetched
+	p.fetched = nil
+	if checkConfigs == nil {
+		fetched, _, err := getClusterCheckConfigs("")
+		if err != nil {
+			return []check.Config{}, err
+		}
+		checkConfigs = &fetched
+	}
+
+	p.version = checkConfigs.Version
+	if checkConfigs.Configs == nil {
+		return []check.Config{}, nil
+	}
+	return checkConfigs.Configs, nil
+}
+
+// IsUpToDate returns whether the configurations served by the cluster agent
+// didn't change since the last Collect, they're only downloaded if they did
+func (p *ClusterAgentConfigProvider) IsUpToDate() (bool, error) {
+	checkConfigs, modified, err := getClusterCheckConfigs(p.version)
+	if err != nil {
+		return false, err
+	}
+	if !modified || checkConfigs.Version == p.version {
+		return true, nil
+	}
+	// keep them for the Collect following this call
+	p.fetched = &checkConfigs
+	return false, nil
+}
+
+func init() {
+	RegisterProvider("clusteragent", NewClusterAgentConfigProvider)
+}
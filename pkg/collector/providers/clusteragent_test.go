@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package providers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
+)
+
+func TestClusterAgentCollect(t *testing.T) {
+	checkConfigs := clusteragent.CheckConfigs{
+		Version: "1",
+		Configs: []check.Config{{Name: "http_check", Instances: []check.ConfigData{check.ConfigData("url: http://example.com")}}},
+	}
+	var err error
+	calls, downloads := 0, 0
+	original := getClusterCheckConfigs
+	defer func() { getClusterCheckConfigs = original }()
+	getClusterCheckConfigs = func(version string) (clusteragent.CheckConfigs, bool, error) {
+		calls++
+		if err != nil {
+			return clusteragent.CheckConfigs{}, false, err
+		}
+		if version != "" && version == checkConfigs.Version {
+			return clusteragent.CheckConfigs{Version: version}, false, nil
+		}
+		downloads++
+		return checkConfigs, true, nil
+	}
+
+	p := &ClusterAgentConfigProvider{}
+	upToDate, e := p.IsUpToDate()
+	require.NoError(t, e)
+	assert.False(t, upToDate)
+
+	// the configurations fetched by IsUpToDate are reused
+	configs, e := p.Collect()
+	require.NoError(t, e)
+	assert.Equal(t, checkConfigs.Configs, configs)
+	assert.Equal(t, 1, calls)
+
+	// the configurations aren't downloaded again when they didn't change
+	upToDate, e = p.IsUpToDate()
+	require.NoError(t, e)
+	assert.True(t, upToDate)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 1, downloads)
+
+	checkConfigs = clusteragent.CheckConfigs{Version: "2"}
+	upToDate, e = p.IsUpToDate()
+	require.NoError(t, e)
+	assert.False(t, upToDate)
+	configs, e = p.Collect()
+	require.NoError(t, e)
+	assert.Empty(t, configs)
+
+	err = errors.New("unreachable")
+	_, e = p.Collect()
+	assert.Error(t, e)
+}
@@ -244,18 +244,24 @@ func (c *FileConfigProvider) collectDir(parentPath string, folder os.FileInfo) c
 
 // GetCheckConfigFromFile returns an instance of check.Config if `fpath` points to a valid config file
 func GetCheckConfigFromFile(name, fpath string) (check.Config, error) {
-	cf := configFormat{}
-	config := check.Config{Name: name}
-
 	// Read file contents
 	// FIXME: ReadFile reads the entire file, possible security implications
 	yamlFile, err := ioutil.ReadFile(fpath)
 	if err != nil {
-		return config, err
+		return check.Config{Name: name}, err
 	}
 
+	return GetCheckConfigFromBytes(name, yamlFile)
+}
+
+// GetCheckConfigFromBytes returns an instance of check.Config if `yamlFile`
+// holds a valid configuration, in the format of the config files
+func GetCheckConfigFromBytes(name string, yamlFile []byte) (check.Config, error) {
+	cf := configFormat{}
+	config := check.Config{Name: name}
+
 	// Parse configuration
-	err = yaml.Unmarshal(yamlFile, &cf)
+	err := yaml.Unmarshal(yamlFile, &cf)
 	if err != nil {
 		return config, err
 	}
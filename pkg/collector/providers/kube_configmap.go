@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package providers
+
+import (
+	"hash/fnv"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+)
+
+// listConfigMaps returns the ConfigMaps matching a label selector, declared
+// as var to ease testing
+var listConfigMaps = apiserver.ListConfigMaps
+
+// KubeConfigMapConfigProvider implements the ConfigProvider interface for the
+// Kubernetes ConfigMaps labeled with `kubernetes_check_configmap_label`. Every
+// key of their data named after a check, e.g. `redisdb.yaml`, holds a check
+// configuration in the format of the config files.
+type KubeConfigMapConfigProvider struct {
+	labelSelector string
+	version       string // version of the last collected ConfigMaps
+}
+
+// NewKubeConfigMapConfigProvider returns a new ConfigProvider reading the
+// ConfigMaps through the apiserver.
+func NewKubeConfigMapConfigProvider(cfg config.ConfigurationProviders) (ConfigProvider, error) {
+	return &KubeConfigMapConfigProvider{
+		labelSelector: config.Datadog.GetString("kubernetes_check_configmap_label"),
+	}, nil
+}
+
+func (p *KubeConfigMapConfigProvider) String() string {
+	return "Kubernetes ConfigMaps"
+}
+
+// Collect retrieves the check configurations from the ConfigMaps
+func (p *KubeConfigMapConfigProvider) Collect() ([]check.Config, error) {
+	configs, version, err := CollectConfigMapChecks(p.labelSelector)
+	if err != nil {
+		return []check.Config{}, err
+	}
+	p.version = version
+	return configs, nil
+}
+
+// IsUpToDate returns whether no ConfigMap was created, updated or deleted
+// since the last Collect
+func (p *KubeConfigMapConfigProvider) IsUpToDate() (bool, error) {
+	configMaps, err := listConfigMaps(p.labelSelector)
+	if err != nil {
+		return false, err
+	}
+	return configMapsVersion(configMaps) == p.version, nil
+}
+
+// CollectConfigMapChecks returns the check configurations held by the
+// ConfigMaps matching the label selector, and a version of these ConfigMaps
+// changing whenever one of them is created, updated or deleted
+func CollectConfigMapChecks(labelSelector string) ([]check.Config, string, error) {
+	configMaps, err := listConfigMaps(labelSelector)
+	if err != nil {
+		return nil, "", err
+	}
+	return parseConfigMaps(configMaps), configMapsVersion(configMaps), nil
+}
+
+func parseConfigMaps(configMaps []apiserver.ConfigMap) []check.Config {
+	configs := []check.Config{}
+	for _, cm := range configMaps {
+		keys := make([]string, 0, len(cm.Data))
+		for key := range cm.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			ext := filepath.Ext(key)
+			if ext != ".yaml" && ext != ".yml" {
+				log.Debugf("Skipping key %s of the ConfigMap %s/%s, not a check configuration", key, cm.Namespace, cm.Name)
+				continue
+			}
+			c, err := GetCheckConfigFromBytes(strings.TrimSuffix(key, ext), []byte(cm.Data[key]))
+			if err != nil {
+				log.Warnf("Can't parse the configuration %s of the ConfigMap %s/%s: %s", key, cm.Namespace, cm.Name, err)
+				continue
+			}
+			configs = append(configs, c)
+		}
+	}
+	return configs
+}
+
+// configMapsVersion digests the resource versions of the ConfigMaps
+func configMapsVersion(configMaps []apiserver.ConfigMap) string {
+	versions := make([]string, 0, len(configMaps))
+	for _, cm := range configMaps {
+		versions = append(versions, cm.Namespace+"/"+cm.Name+":"+cm.ResourceVersion)
+	}
+	sort.Strings(versions)
+
+	h := fnv.New64()
+	for _, v := range versions {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+func init() {
+	RegisterProvider("kube_configmaps", NewKubeConfigMapConfigProvider)
+}
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+)
+
+func TestKubeConfigMapCollect(t *testing.T) {
+	configMaps := []apiserver.ConfigMap{
+		{
+			Namespace:       "default",
+			Name:            "checks",
+			ResourceVersion: "12",
+			Data: map[string]string{
+				"redisdb.yaml":   "ad_identifiers:\n  - redis\ninit_config:\ninstances:\n  - host: '%%host%%'\n",
+				"http_check.yml": "init_config:\ninstances:\n  - url: http://example.com\n",
+				"README":         "not a check",
+				"invalid.yaml":   "init_config:\n",
+			},
+		},
+	}
+	var selector string
+	listConfigMaps = func(labelSelector string) ([]apiserver.ConfigMap, error) {
+		selector = labelSelector
+		return configMaps, nil
+	}
+	defer func() { listConfigMaps = apiserver.ListConfigMaps }()
+
+	p := &KubeConfigMapConfigProvider{labelSelector: "ad.datadoghq.com/checks"}
+	upToDate, err := p.IsUpToDate()
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+
+	configs, err := p.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, "ad.datadoghq.com/checks", selector)
+	require.Len(t, configs, 2)
+	assert.Equal(t, "http_check", configs[0].Name)
+	assert.Equal(t, []check.ConfigData{check.ConfigData("url: http://example.com\n")}, configs[0].Instances)
+	assert.Equal(t, "redisdb", configs[1].Name)
+	assert.Equal(t, []string{"redis"}, configs[1].ADIdentifiers)
+
+	upToDate, err = p.IsUpToDate()
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+
+	// an update of the ConfigMap changes its resource version
+	configMaps[0].ResourceVersion = "13"
+	upToDate, err = p.IsUpToDate()
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+
+	// so does a deletion
+	_, err = p.Collect()
+	require.NoError(t, err)
+	configMaps = nil
+	upToDate, err = p.IsUpToDate()
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+}
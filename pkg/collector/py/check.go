@@ -10,6 +10,7 @@ package py
 import (
 	"errors"
 	"fmt"
+	"os"
 	"runtime"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/sbinet/go-python"
+	"github.com/shirou/gopsutil/process"
 
 	log "github.com/cihub/seelog"
 )
@@ -34,7 +36,26 @@ type PythonCheck struct {
 	ModuleName   string
 	config       *python.PyObject
 	interval     time.Duration
+	timeout      time.Duration
+	jitter       time.Duration
+	schedule     string
 	lastWarnings []error
+	measureRSS   bool  // whether `check_resource_accounting` is enabled
+	rssDelta     int64 // change of the resident memory of the agent process during the last run, in bytes
+}
+
+// getRSS returns the resident memory of the agent process, declared as var
+// to ease testing
+var getRSS = func() (int64, error) {
+	p, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return 0, err
+	}
+	mem, err := p.MemoryInfo()
+	if err != nil {
+		return 0, err
+	}
+	return int64(mem.RSS), nil
 }
 
 // NewPythonCheck conveniently creates a PythonCheck instance
@@ -47,6 +68,7 @@ func NewPythonCheck(name string, class *python.PyObject) *PythonCheck {
 		class:        class,
 		interval:     check.DefaultCheckInterval,
 		lastWarnings: []error{},
+		measureRSS:   config.Datadog.GetBool("check_resource_accounting"),
 	}
 	runtime.SetFinalizer(pyCheck, pythonCheckFinalizer)
 	return pyCheck
@@ -54,6 +76,24 @@ func NewPythonCheck(name string, class *python.PyObject) *PythonCheck {
 
 // Run a Python check
 func (c *PythonCheck) Run() error {
+	if !c.measureRSS {
+		return c.run()
+	}
+
+	// the Python interpreter allocates its memory out of the Go heap: measure
+	// the resident memory of the process around the run instead, without
+	// holding the GIL
+	c.rssDelta = 0
+	rss, rssErr := getRSS()
+	err := c.run()
+	if newRSS, e := getRSS(); e == nil && rssErr == nil {
+		c.rssDelta = newRSS - rss
+	}
+	return err
+}
+
+// run runs the check holding the GIL and commits the data it sent
+func (c *PythonCheck) run() error {
 	// Lock the GIL and release it at the end of the run
 	gstate := newStickyLock()
 	defer gstate.unlock()
@@ -122,6 +162,13 @@ func (c *PythonCheck) RunSimple() error {
 // Stop does nothing
 func (c *PythonCheck) Stop() {}
 
+// RSSDelta returns the change of the resident memory of the whole agent
+// process during the last run, in bytes, 0 when `check_resource_accounting`
+// is disabled
+func (c *PythonCheck) RSSDelta() int64 {
+	return c.rssDelta
+}
+
 // String representation (for debug and logging)
 func (c *PythonCheck) String() string {
 	return c.ModuleName
@@ -223,6 +270,19 @@ func (c *PythonCheck) Configure(data check.ConfigData, initConfig check.ConfigDa
 		}
 	}
 
+	// See if an execution timeout, a scheduling jitter or a cron schedule was specified
+	c.timeout, err = check.GetInstanceTimeout(data)
+	if err != nil {
+		log.Warnf("ignoring the timeout of check %s: %s", c.ModuleName, err)
+	}
+	c.jitter, err = check.GetInstanceJitter(data)
+	if err != nil {
+		log.Warnf("ignoring the jitter of check %s: %s", c.ModuleName, err)
+	}
+	if c.schedule, err = check.GetInstanceSchedule(data); err != nil {
+		return err
+	}
+
 	// To be retrocompatible with the Python code, still use an `instance` dictionary
 	// to contain the (now) unique instance for the check
 	conf := make(check.ConfigRawMap)
@@ -296,6 +356,24 @@ func (c *PythonCheck) Interval() time.Duration {
 	return c.interval
 }
 
+// Timeout returns the execution timeout set by the `check_timeout` option of the
+// instance, 0 if the agent-wide `check_timeout` applies
+func (c *PythonCheck) Timeout() time.Duration {
+	return c.timeout
+}
+
+// Jitter returns the maximum random delay of each run set by the `jitter`
+// option of the instance
+func (c *PythonCheck) Jitter() time.Duration {
+	return c.jitter
+}
+
+// Schedule returns the cron expression set by the `schedule` option of the
+// instance, empty if the check runs every Interval()
+func (c *PythonCheck) Schedule() string {
+	return c.schedule
+}
+
 // ID returns the ID of the check
 func (c *PythonCheck) ID() check.ID {
 	return c.id
@@ -75,6 +75,26 @@ func TestRun(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestRunRSSDelta(t *testing.T) {
+	defer func(f func() (int64, error)) { getRSS = f }(getRSS)
+	calls := 0
+	getRSS = func() (int64, error) {
+		calls++
+		return int64(calls * 1024), nil
+	}
+
+	check, _ := getCheckInstance("testcheck", "TestCheck")
+	check.measureRSS = true
+	require.Nil(t, check.Run())
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, int64(1024), check.RSSDelta())
+
+	// the resident memory isn't read when resource accounting is disabled
+	check.measureRSS = false
+	require.Nil(t, check.Run())
+	assert.Equal(t, 2, calls)
+}
+
 func TestWarning(t *testing.T) {
 	check, _ := getCheckInstance("testwarnings", "TestCheck")
 	err := check.Run()
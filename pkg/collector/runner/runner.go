@@ -38,6 +38,7 @@ var (
 	maxNumWorkers     = 25
 	runnerStats       *expvar.Map
 	checkStats        *runnerCheckStats
+	timedOutChecks    *runnerTimedOutChecks
 )
 
 func init() {
@@ -46,6 +47,10 @@ func init() {
 	checkStats = &runnerCheckStats{
 		Stats: make(map[check.ID]*check.Stats),
 	}
+	runnerStats.Set("TimedOutChecks", expvar.Func(expTimedOutChecks))
+	timedOutChecks = &runnerTimedOutChecks{
+		Checks: make(map[check.ID]*TimedOutCheck),
+	}
 }
 
 // checkStats holds the stats from the running checks
@@ -54,6 +59,21 @@ type runnerCheckStats struct {
 	M     sync.RWMutex
 }
 
+// TimedOutCheck describes a check run that exceeded its timeout and didn't
+// return yet
+type TimedOutCheck struct {
+	CheckName string
+	CheckID   check.ID
+	StartTime int64 // unix timestamp in seconds
+	Timeout   int64 // in seconds
+}
+
+// runnerTimedOutChecks holds the check runs still going past their timeout
+type runnerTimedOutChecks struct {
+	Checks map[check.ID]*TimedOutCheck
+	M      sync.RWMutex
+}
+
 // Runner ...
 type Runner struct {
 	pending          chan check.Check         // The channel where checks come from
@@ -62,6 +82,7 @@ type Runner struct {
 	m                sync.Mutex               // To control races on runningChecks
 	running          uint32                   // Flag to see if the Runner is, well, running
 	staticNumWorkers bool                     // Flag indicating if numWorkers is dynamically updated
+	measureUsage     bool                     // Flag indicating if `check_resource_accounting` is enabled
 }
 
 // NewRunner takes the number of desired goroutines processing incoming checks.
@@ -78,6 +99,7 @@ func NewRunner() *Runner {
 		runningChecks:    make(map[check.ID]check.Check),
 		running:          1,
 		staticNumWorkers: numWorkers != 0,
+		measureUsage:     config.Datadog.GetBool("check_resource_accounting"),
 	}
 
 	if !r.staticNumWorkers {
@@ -245,12 +267,17 @@ func (r *Runner) work() {
 		}
 
 		// run the check
-		var err error
 		t0 := time.Now()
-
-		err = check.Run()
-
-		warnings := check.GetWarnings()
+		pending, result := r.runCheck(check)
+		err := result.err
+
+		// a timed out check is still running, don't touch its warnings and metric stats
+		var warnings []error
+		var mStats map[string]int64
+		if pending == nil {
+			warnings = check.GetWarnings()
+			mStats, _ = check.GetMetricStats()
+		}
 
 		// use the default sender for the service checks
 		sender, e := aggregator.GetDefaultSender()
@@ -276,19 +303,33 @@ func (r *Runner) work() {
 
 		if sender != nil {
 			sender.ServiceCheck("datadog.agent.check_status", serviceCheckStatus, hostname, serviceCheckTags, "")
+			if pending != nil {
+				sender.ServiceCheck("datadog.agent.check_timeout", metrics.ServiceCheckCritical, hostname, serviceCheckTags, err.Error())
+			} else if r.measureUsage {
+				sendResourceUsage(sender, check, hostname, result.usage, mStats)
+			}
 			sender.Commit()
 		}
 
-		// remove the check from the running list
-		r.m.Lock()
-		delete(r.runningChecks, check.ID())
-		r.m.Unlock()
+		if pending == nil {
+			// remove the check from the running list
+			r.m.Lock()
+			delete(r.runningChecks, check.ID())
+			r.m.Unlock()
+			runnerStats.Add("RunningChecks", -1)
+		} else {
+			// the check stays in the running list until its run returns, so
+			// it's not scheduled again in the meantime
+			go r.waitTimedOutCheck(check, t0, pending)
+		}
 
 		// publish statistics about this run
-		runnerStats.Add("RunningChecks", -1)
 		runnerStats.Add("Runs", 1)
-		mStats, _ := check.GetMetricStats()
 		addWorkStats(check, time.Since(t0), err, warnings, mStats)
+		if pending == nil {
+			addResourceUsage(check, result.usage)
+		}
+		updateBackoff(check, t0)
 
 		l := "Done running check %s"
 		if doLog {
@@ -304,6 +345,94 @@ func (r *Runner) work() {
 	log.Debug("Finished processing checks.")
 }
 
+// runResult is the outcome of a check run
+type runResult struct {
+	err   error
+	usage check.ResourceUsage
+}
+
+// runCheck runs the check and waits for it to return. If the timeout of the
+// check expires first, a TimeoutError is returned along with the channel the
+// result of the run will be sent to.
+func (r *Runner) runCheck(c check.Check) (<-chan runResult, runResult) {
+	timeout := getTimeout(c)
+	if timeout == 0 {
+		usage, err := r.runMeasured(c)
+		return nil, runResult{err: err, usage: usage}
+	}
+
+	returned := make(chan runResult, 1)
+	go func() {
+		usage, err := r.runMeasured(c)
+		returned <- runResult{err: err, usage: usage}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case result := <-returned:
+		return nil, result
+	case <-timer.C:
+		addTimedOutCheck(c, timeout)
+		return returned, runResult{err: check.TimeoutError{Timeout: timeout}}
+	}
+}
+
+// waitTimedOutCheck waits for the run of a timed out check to return before
+// removing it from the running list, so it can be scheduled again
+func (r *Runner) waitTimedOutCheck(c check.Check, start time.Time, pending <-chan runResult) {
+	result := <-pending
+	log.Warnf("Check %s returned after %v, exceeding its timeout: %v", c, time.Since(start), result.err)
+	addResourceUsage(c, result.usage)
+
+	if sender, e := aggregator.GetDefaultSender(); e == nil {
+		serviceCheckTags := []string{fmt.Sprintf("check:%s", c.String())}
+		sender.ServiceCheck("datadog.agent.check_timeout", metrics.ServiceCheckOK, getHostname(), serviceCheckTags, "")
+		sender.Commit()
+	}
+
+	r.m.Lock()
+	delete(r.runningChecks, c.ID())
+	r.m.Unlock()
+	runnerStats.Add("RunningChecks", -1)
+	removeTimedOutCheck(c.ID())
+}
+
+// getTimeout returns how long a run of the check may last, 0 for checks that
+// aren't bound by a timeout
+func getTimeout(c check.Check) time.Duration {
+	// long-running checks are scheduled once and never return
+	if c.Interval() == 0 {
+		return 0
+	}
+	if tc, ok := c.(check.TimeoutCheck); ok && tc.Timeout() > 0 {
+		return tc.Timeout()
+	}
+	return config.Datadog.GetDuration("check_timeout") * time.Second
+}
+
+// IsRunning returns whether a run of the check is in progress, including runs
+// that exceeded their timeout
+func (r *Runner) IsRunning(id check.ID) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	_, isRunning := r.runningChecks[id]
+	return isRunning
+}
+
+// ShouldSkip returns whether the check must not be scheduled now: a run is in
+// progress or it's backing off after consecutive errors
+func (r *Runner) ShouldSkip(id check.ID) bool {
+	if r.IsRunning(id) {
+		return true
+	}
+
+	checkStats.M.RLock()
+	s, found := checkStats.Stats[id]
+	checkStats.M.RUnlock()
+	return found && s.IsBackingOff(time.Now())
+}
+
 func shouldLog(id check.ID) (doLog bool, lastLog bool) {
 	checkStats.M.RLock()
 	defer checkStats.M.RUnlock()
@@ -340,6 +469,37 @@ func addWorkStats(c check.Check, execTime time.Duration, err error, warnings []e
 	s.Add(execTime, err, warnings, mStats)
 }
 
+// updateBackoff makes the check back off after `check_backoff_failures`
+// errors in a row, see check.Stats.UpdateBackoff. Checks scheduled with a
+// cron expression don't back off, they keep running at their planned times.
+func updateBackoff(c check.Check, lastRun time.Time) {
+	if sc, ok := c.(check.ScheduleCheck); ok && sc.Schedule() != "" {
+		return
+	}
+
+	checkStats.M.RLock()
+	s, found := checkStats.Stats[c.ID()]
+	checkStats.M.RUnlock()
+	if !found {
+		return
+	}
+
+	failures := config.Datadog.GetInt("check_backoff_failures")
+	if failures < 0 {
+		failures = 0
+	}
+	maxInterval := config.Datadog.GetDuration("check_backoff_max_interval") * time.Second
+
+	previousInterval, _ := s.BackoffStatus()
+	s.UpdateBackoff(lastRun, c.Interval(), uint64(failures), maxInterval)
+	backoffInterval, consecutiveErrors := s.BackoffStatus()
+	if backoffInterval > 0 {
+		log.Warnf("Check %s failed %d times in a row, backing off: next run in %ds", c, consecutiveErrors, backoffInterval)
+	} else if previousInterval > 0 {
+		log.Infof("Check %s is back to its normal schedule", c)
+	}
+}
+
 func expCheckStats() interface{} {
 	checkStats.M.RLock()
 	defer checkStats.M.RUnlock()
@@ -363,6 +523,42 @@ func RemoveCheckStats(checkID check.ID) {
 	delete(checkStats.Stats, checkID)
 }
 
+func addTimedOutCheck(c check.Check, timeout time.Duration) {
+	timedOutChecks.M.Lock()
+	defer timedOutChecks.M.Unlock()
+
+	timedOutChecks.Checks[c.ID()] = &TimedOutCheck{
+		CheckName: c.String(),
+		CheckID:   c.ID(),
+		StartTime: time.Now().Add(-timeout).Unix(),
+		Timeout:   int64(timeout / time.Second),
+	}
+}
+
+func removeTimedOutCheck(checkID check.ID) {
+	timedOutChecks.M.Lock()
+	defer timedOutChecks.M.Unlock()
+
+	delete(timedOutChecks.Checks, checkID)
+}
+
+func expTimedOutChecks() interface{} {
+	return GetTimedOutChecks()
+}
+
+// GetTimedOutChecks returns the check runs that exceeded their timeout and
+// didn't return yet
+func GetTimedOutChecks() map[check.ID]*TimedOutCheck {
+	timedOutChecks.M.RLock()
+	defer timedOutChecks.M.RUnlock()
+
+	checks := make(map[check.ID]*TimedOutCheck, len(timedOutChecks.Checks))
+	for id, c := range timedOutChecks.Checks {
+		checks[id] = c
+	}
+	return checks
+}
+
 func getHostname() string {
 	hostname, _ := util.GetHostname()
 	return hostname
@@ -11,7 +11,9 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // FIXTURE
@@ -59,6 +61,7 @@ func TestGetChan(t *testing.T) {
 }
 
 func TestWork(t *testing.T) {
+	defer func(numWorkers int) { defaultNumWorkers = numWorkers }(defaultNumWorkers)
 	defaultNumWorkers = 1
 	r := NewRunner()
 	c1 := TestCheck{}
@@ -135,3 +138,111 @@ func TestStopCheck(t *testing.T) {
 	err = r.StopCheck(c2.ID())
 	assert.Equal(t, "timeout during stop operation on check id TestCheck", err.Error())
 }
+
+type HangingCheck struct {
+	TestCheck
+	release chan struct{}
+}
+
+func (c *HangingCheck) ID() check.ID           { return "HangingCheck" }
+func (c *HangingCheck) Timeout() time.Duration { return 50 * time.Millisecond }
+func (c *HangingCheck) Run() error {
+	<-c.release
+	return nil
+}
+
+func TestWorkTimeout(t *testing.T) {
+	defer func(numWorkers int) { defaultNumWorkers = numWorkers }(defaultNumWorkers)
+	defaultNumWorkers = 1
+	r := NewRunner()
+	defer r.Stop()
+
+	c1 := &HangingCheck{release: make(chan struct{})}
+	c2 := &TestCheck{}
+	r.pending <- c1
+	// the single worker is released after the timeout
+	r.pending <- c2
+	r.pending <- c1
+	assert.True(t, c2.hasRun)
+
+	// the timed out run is still tracked until it returns
+	assert.True(t, r.IsRunning(c1.ID()))
+	require.Contains(t, GetTimedOutChecks(), c1.ID())
+	// the expvar is a copy of the timed out checks
+	exported := expTimedOutChecks().(map[check.ID]*TimedOutCheck)
+	delete(exported, c1.ID())
+	require.Contains(t, GetTimedOutChecks(), c1.ID())
+	stats := GetCheckStats()[c1.ID()]
+	require.NotNil(t, stats)
+	assert.Equal(t, uint64(1), stats.TotalTimeouts)
+
+	close(c1.release)
+	for i := 0; i < 100 && r.IsRunning(c1.ID()); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.False(t, r.IsRunning(c1.ID()))
+	assert.NotContains(t, GetTimedOutChecks(), c1.ID())
+}
+
+func TestBackoff(t *testing.T) {
+	config.Datadog.Set("check_backoff_failures", 2)
+	defer config.Datadog.Set("check_backoff_failures", 0)
+
+	r := &Runner{runningChecks: make(map[check.ID]check.Check)}
+	c := &BackoffCheck{}
+	for i := 0; i < 2; i++ {
+		assert.False(t, r.ShouldSkip(c.ID()))
+		addWorkStats(c, time.Millisecond, errors.New("failure"), nil, nil)
+		updateBackoff(c, time.Now())
+	}
+	assert.True(t, r.ShouldSkip(c.ID()))
+	assert.Equal(t, int64(30), GetCheckStats()[c.ID()].BackoffInterval)
+
+	addWorkStats(c, time.Millisecond, nil, nil, nil)
+	updateBackoff(c, time.Now())
+	assert.False(t, r.ShouldSkip(c.ID()))
+}
+
+type BackoffCheck struct {
+	TestCheck
+}
+
+func (c *BackoffCheck) ID() check.ID            { return "BackoffCheck" }
+func (c *BackoffCheck) Interval() time.Duration { return 15 * time.Second }
+
+type UsageCheck struct {
+	TestCheck
+	buffer []byte
+}
+
+func (c *UsageCheck) ID() check.ID    { return "UsageCheck" }
+func (c *UsageCheck) RSSDelta() int64 { return 4096 }
+func (c *UsageCheck) Run() error {
+	c.buffer = make([]byte, 1<<20)
+	return nil
+}
+
+func TestRunMeasured(t *testing.T) {
+	config.Datadog.Set("check_resource_accounting", true)
+	r := NewRunner()
+	defer r.Stop()
+	require.True(t, r.measureUsage)
+
+	c := &UsageCheck{}
+	usage, err := r.runMeasured(c)
+	require.NoError(t, err)
+	assert.True(t, usage.AllocatedBytes >= 1<<20)
+	assert.Equal(t, int64(4096), usage.RSSDelta)
+
+	// nothing is measured when resource accounting is disabled
+	config.Datadog.Set("check_resource_accounting", false)
+	r2 := NewRunner()
+	defer r2.Stop()
+	require.False(t, r2.measureUsage)
+
+	c.buffer = nil
+	usage, err = r2.runMeasured(c)
+	require.NoError(t, err)
+	assert.NotNil(t, c.buffer)
+	assert.Equal(t, check.ResourceUsage{}, usage)
+}
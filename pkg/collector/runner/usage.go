@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package runner
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+// runMeasured runs the check and measures the resources consumed by the run,
+// when `check_resource_accounting` is enabled, which it isn't by default since
+// reading the memory stats stops the world. The goroutine is locked to its
+// thread so that the CPU time of the thread is the one of the check; the Go
+// allocations can't be attributed to a goroutine and include the ones of the
+// goroutines running concurrently.
+func (r *Runner) runMeasured(c check.Check) (check.ResourceUsage, error) {
+	var usage check.ResourceUsage
+	if !r.measureUsage {
+		return usage, c.Run()
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	allocated := memStats.TotalAlloc
+	cpuTime := threadCPUTime()
+
+	err := c.Run()
+
+	usage.CPUTime = threadCPUTime() - cpuTime
+	runtime.ReadMemStats(&memStats)
+	usage.AllocatedBytes = memStats.TotalAlloc - allocated
+	if mc, ok := c.(check.RSSCheck); ok {
+		usage.RSSDelta = mc.RSSDelta()
+	}
+	return usage, err
+}
+
+// addResourceUsage adds the resources consumed by a run to the stats of the
+// check
+func addResourceUsage(c check.Check, usage check.ResourceUsage) {
+	checkStats.M.Lock()
+	s, found := checkStats.Stats[c.ID()]
+	if !found {
+		s = check.NewStats(c)
+		checkStats.Stats[c.ID()] = s
+	}
+	checkStats.M.Unlock()
+
+	s.AddResourceUsage(usage)
+}
+
+// sendResourceUsage sends the resources consumed by a run and the volume of
+// data it sent as internal metrics
+func sendResourceUsage(sender aggregator.Sender, c check.Check, hostname string, usage check.ResourceUsage, mStats map[string]int64) {
+	tags := []string{fmt.Sprintf("check:%s", c.String())}
+	if cpuTimeSupported {
+		sender.Gauge("datadog.agent.check.cpu_time", usage.CPUTime.Seconds(), hostname, tags)
+	}
+	sender.Gauge("datadog.agent.check.allocated_bytes", float64(usage.AllocatedBytes), hostname, tags)
+	if _, ok := c.(check.RSSCheck); ok {
+		sender.Gauge("datadog.agent.check.rss_delta", float64(usage.RSSDelta), hostname, tags)
+	}
+	sender.Gauge("datadog.agent.check.metric_samples", float64(mStats["Metrics"]), hostname, tags)
+	sender.Gauge("datadog.agent.check.events", float64(mStats["Events"]), hostname, tags)
+	sender.Gauge("datadog.agent.check.service_checks", float64(mStats["ServiceChecks"]), hostname, tags)
+}
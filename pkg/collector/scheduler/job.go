@@ -7,6 +7,8 @@ package scheduler
 
 import (
 	"fmt"
+	"hash/fnv"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -14,43 +16,83 @@ import (
 	log "github.com/cihub/seelog"
 )
 
+// jobBucket holds the checks of a queue that are enqueued at the same tick
+type jobBucket struct {
+	jobs []check.Check
+}
+
 // jobQueue contains a list of checks (called jobs) that need to be
-// scheduled at a certain interval.
+// scheduled at a certain interval. The checks are spread in buckets, one for
+// every second of the interval, and the queue enqueues the checks of one
+// bucket at every tick so they don't all start at the same time.
 type jobQueue struct {
-	interval time.Duration
-	stop     chan bool // to stop this queue
-	stopped  chan bool // signals that this queue has stopped
-	ticker   *time.Ticker
-	jobs     []check.Check
-	running  bool
-	mu       sync.RWMutex // to protect critical sections in struct's fields
+	interval    time.Duration
+	stop        chan bool // to stop this queue
+	stopped     chan bool // signals that this queue has stopped
+	ticker      *time.Ticker
+	buckets     []*jobBucket
+	bucketIndex int // bucket enqueued at the next tick, only used by the queue goroutine
+	running     bool
+	shouldSkip  func(check.ID) bool // checks to skip, e.g. still running (optional)
+	jittered    sync.WaitGroup      // tracks the delayed enqueues of checks with a jitter
+	done        chan struct{}       // closed when the queue stops, cancels the delayed enqueues, then recreated
+	mu          sync.RWMutex        // to protect critical sections in struct's fields
+
+	// delayed holds, for every check with a delayed enqueue, a channel closed
+	// when the check is removed from the queue to cancel the enqueue
+	delayed   map[check.ID]chan struct{}
+	delayedMu sync.Mutex
 }
 
 // newJobQueue creates a new jobQueue instance
 func newJobQueue(interval time.Duration) *jobQueue {
+	nbBuckets := int(interval / time.Second)
+	if nbBuckets < 1 {
+		nbBuckets = 1
+	}
+	buckets := make([]*jobBucket, nbBuckets)
+	for i := range buckets {
+		buckets[i] = &jobBucket{}
+	}
+
 	return &jobQueue{
 		interval: interval,
-		ticker:   time.NewTicker(time.Duration(interval)),
+		ticker:   time.NewTicker(interval / time.Duration(nbBuckets)),
+		buckets:  buckets,
 		stop:     make(chan bool),
 		stopped:  make(chan bool),
+		done:     make(chan struct{}),
+		delayed:  make(map[check.ID]chan struct{}),
 	}
 }
 
+// bucketFor returns the bucket of a check: a hash of its ID, so a check is
+// always enqueued at the same offset of the interval
+func (jq *jobQueue) bucketFor(id check.ID) *jobBucket {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return jq.buckets[h.Sum32()%uint32(len(jq.buckets))]
+}
+
 // addJob is a convenience method to add a check to a queue
 func (jq *jobQueue) addJob(c check.Check) {
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
 
-	jq.jobs = append(jq.jobs, c)
+	bucket := jq.bucketFor(c.ID())
+	bucket.jobs = append(bucket.jobs, c)
 }
 
 func (jq *jobQueue) removeJob(id check.ID) error {
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
 
-	for i, c := range jq.jobs {
+	jq.cancelDelayed(id)
+
+	bucket := jq.bucketFor(id)
+	for i, c := range bucket.jobs {
 		if c.ID() == id {
-			jq.jobs = append(jq.jobs[:i], jq.jobs[i+1:]...)
+			bucket.jobs = append(bucket.jobs[:i], bucket.jobs[i+1:]...)
 			return nil
 		}
 	}
@@ -58,6 +100,30 @@ func (jq *jobQueue) removeJob(id check.ID) error {
 	return fmt.Errorf("check with id %s is not in this Job Queue", id)
 }
 
+// size returns the number of checks in the queue
+func (jq *jobQueue) size() int {
+	jq.mu.RLock()
+	defer jq.mu.RUnlock()
+
+	size := 0
+	for _, bucket := range jq.buckets {
+		size += len(bucket.jobs)
+	}
+	return size
+}
+
+// bucketSizes returns the number of checks in every bucket of the queue
+func (jq *jobQueue) bucketSizes() []int {
+	jq.mu.RLock()
+	defer jq.mu.RUnlock()
+
+	sizes := make([]int, len(jq.buckets))
+	for i, bucket := range jq.buckets {
+		sizes[i] = len(bucket.jobs)
+	}
+	return sizes
+}
+
 // run schedules the checks in the queue by posting them to the
 // execution pipeline.
 // Not blocking, runs in a new goroutine.
@@ -65,12 +131,16 @@ func (jq *jobQueue) run(out chan<- check.Check) {
 	go func() {
 		for jq.waitForTick(out) {
 		}
+		close(jq.done)
+		jq.jittered.Wait()
+		// the queue can be run again
+		jq.done = make(chan struct{})
 		jq.stopped <- true
 	}()
 }
 
-// waitForTicks enqueues the checks at a tick, and returns whether the queue
-// should listen to the following tick (or stop)
+// waitForTicks enqueues the checks of the current bucket at a tick, and
+// returns whether the queue should listen to the following tick (or stop)
 func (jq *jobQueue) waitForTick(out chan<- check.Check) bool {
 	select {
 	case <-jq.stop:
@@ -78,9 +148,19 @@ func (jq *jobQueue) waitForTick(out chan<- check.Check) bool {
 		jq.ticker.Stop()
 		return false
 	case <-jq.ticker.C:
-		// normal case, (re)schedule the queue
+		// normal case, (re)schedule the bucket
 		jq.mu.RLock()
-		for _, check := range jq.jobs {
+		bucket := jq.buckets[jq.bucketIndex]
+		jq.bucketIndex = (jq.bucketIndex + 1) % len(jq.buckets)
+		for _, check := range bucket.jobs {
+			if jq.shouldSkip != nil && jq.shouldSkip(check.ID()) {
+				log.Debugf("Skipping check %s for queue %d", check, jq.interval)
+				continue
+			}
+			if jitter := getJitter(check, jq.interval); jitter > 0 {
+				jq.enqueueLater(check, out, time.Duration(rand.Int63n(int64(jitter))))
+				continue
+			}
 			// sending to `out` is blocking, we need to constantly check that someone
 			// isn't asking to stop this queue
 			select {
@@ -97,3 +177,62 @@ func (jq *jobQueue) waitForTick(out chan<- check.Check) bool {
 
 	return true
 }
+
+// enqueueLater enqueues a check after a delay, without blocking the queue.
+// The enqueuing is cancelled when the queue stops or the check is removed.
+func (jq *jobQueue) enqueueLater(c check.Check, out chan<- check.Check, delay time.Duration) {
+	jq.delayedMu.Lock()
+	cancel, found := jq.delayed[c.ID()]
+	if !found {
+		cancel = make(chan struct{})
+		jq.delayed[c.ID()] = cancel
+	}
+	jq.delayedMu.Unlock()
+
+	done := jq.done
+	jq.jittered.Add(1)
+	go func() {
+		defer jq.jittered.Done()
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-cancel:
+			return
+		case <-done:
+			return
+		}
+
+		select {
+		case out <- c:
+			log.Debugf("Enqueuing check %s for queue %d after a jitter of %v", c, jq.interval, delay)
+		case <-cancel:
+		case <-done:
+		}
+	}()
+}
+
+// cancelDelayed cancels the delayed enqueues of a check
+func (jq *jobQueue) cancelDelayed(id check.ID) {
+	jq.delayedMu.Lock()
+	defer jq.delayedMu.Unlock()
+
+	if cancel, found := jq.delayed[id]; found {
+		close(cancel)
+		delete(jq.delayed, id)
+	}
+}
+
+// getJitter returns the jitter of a check, capped to the interval so runs
+// can't be skipped
+func getJitter(c check.Check, interval time.Duration) time.Duration {
+	jc, ok := c.(check.JitterCheck)
+	if !ok {
+		return 0
+	}
+	if jitter := jc.Jitter(); jitter < interval {
+		return jitter
+	}
+	return interval
+}
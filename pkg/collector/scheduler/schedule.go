@@ -0,0 +1,131 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/util/cron"
+)
+
+// ScheduleStats describes the next run of a check scheduled with a cron
+// expression
+type ScheduleStats struct {
+	CheckName string
+	CheckID   check.ID
+	Schedule  string
+	NextRun   int64 // unix timestamp in seconds, 0 if the check won't run anymore
+}
+
+// scheduledJob enqueues a check at the times given by its cron expression
+type scheduledJob struct {
+	check      check.Check
+	spec       string
+	schedule   *cron.Schedule
+	stop       chan struct{} // closed to stop the job, recreated at every start
+	stopped    chan struct{} // closed when the job has stopped, recreated at every start
+	running    bool
+	shouldSkip func(check.ID) bool // checks to skip, e.g. still running (optional)
+	nextRun    time.Time
+	mu         sync.RWMutex // to protect nextRun
+}
+
+// newScheduledJob parses the schedule of a check
+func newScheduledJob(c check.Check, spec string) (*scheduledJob, error) {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &scheduledJob{
+		check:    c,
+		spec:     spec,
+		schedule: schedule,
+	}, nil
+}
+
+// getSchedule returns the cron expression of a check, empty if it's scheduled
+// with an interval
+func getSchedule(c check.Check) string {
+	if sc, ok := c.(check.ScheduleCheck); ok {
+		return sc.Schedule()
+	}
+	return ""
+}
+
+// run enqueues the check at every occurrence of its schedule, until the
+// schedule has no more occurrences or the job is stopped. A stopped job can
+// be run again.
+// Not blocking, runs in a new goroutine.
+func (j *scheduledJob) run(out chan<- check.Check) {
+	j.stop = make(chan struct{})
+	j.stopped = make(chan struct{})
+	stop, stopped := j.stop, j.stopped
+	go func() {
+		defer close(stopped)
+		for j.waitForNextRun(out, stop) {
+		}
+	}()
+}
+
+// waitForNextRun waits for the next occurrence of the schedule and enqueues
+// the check, it returns whether the job should wait for the following one
+func (j *scheduledJob) waitForNextRun(out chan<- check.Check, stop <-chan struct{}) bool {
+	next := j.schedule.Next(time.Now())
+	j.mu.Lock()
+	j.nextRun = next
+	j.mu.Unlock()
+	if next.IsZero() {
+		log.Infof("Schedule %q of check %s has no more runs", j.spec, j.check)
+		return false
+	}
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	select {
+	case <-stop:
+		return false
+	case <-timer.C:
+	}
+
+	if j.shouldSkip != nil && j.shouldSkip(j.check.ID()) {
+		log.Debugf("Skipping check %s scheduled at %s", j.check, next)
+	} else {
+		select {
+		case <-stop:
+			return false
+		case out <- j.check:
+			log.Debugf("Enqueuing check %s scheduled at %s", j.check, next)
+		}
+	}
+
+	if j.schedule.IsOneShot() {
+		j.mu.Lock()
+		j.nextRun = time.Time{}
+		j.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+// stats returns the next run of the job
+func (j *scheduledJob) stats() ScheduleStats {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	stats := ScheduleStats{
+		CheckName: j.check.String(),
+		CheckID:   j.check.ID(),
+		Schedule:  j.spec,
+	}
+	if !j.nextRun.IsZero() {
+		stats.NextRun = j.nextRun.Unix()
+	}
+	return stats
+}
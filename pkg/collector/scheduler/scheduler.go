@@ -38,6 +38,8 @@ type Scheduler struct {
 	running       uint32                      // Flag to see if the scheduler is running
 	cancelOneTime chan bool                   // Used to internally communicate a cancel signal to one-time schedule goroutines
 	wgOneTime     sync.WaitGroup              // WaitGroup to track the exit of one-time schedule goroutines
+	shouldSkip    func(check.ID) bool         // Reports the checks the queues must skip (optional)
+	scheduledJobs map[check.ID]*scheduledJob  // Checks scheduled with a cron expression
 }
 
 // NewScheduler create a Scheduler and returns a pointer to it.
@@ -49,15 +51,32 @@ func NewScheduler(checksPipe chan<- check.Check) *Scheduler {
 		started:       make(chan bool),
 		jobQueues:     make(map[time.Duration]*jobQueue),
 		checkToQueue:  make(map[check.ID]*jobQueue),
+		scheduledJobs: make(map[check.ID]*scheduledJob),
 		running:       0,
 		cancelOneTime: make(chan bool),
 		wgOneTime:     sync.WaitGroup{},
 	}
 }
 
+// SkipChecks makes the queues skip the checks for which `shouldSkip` returns
+// true, for instance when their previous run is still in progress, instead of
+// enqueuing them. It must be called before any check is entered.
+func (s *Scheduler) SkipChecks(shouldSkip func(check.ID) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shouldSkip = shouldSkip
+}
+
 // Enter schedules a `Check`s for execution accordingly to the `Check.Interval()` value.
 // If the interval is 0, the check is supposed to run only once.
+// Checks implementing `check.ScheduleCheck` with a non empty schedule are run
+// at the times given by their cron expression instead.
 func (s *Scheduler) Enter(check check.Check) error {
+	if spec := getSchedule(check); spec != "" {
+		return s.enterScheduled(check, spec)
+	}
+
 	// enqueue immediately if this is a one-time schedule
 	if check.Interval() == 0 {
 		s.enqueueOnce(check)
@@ -76,6 +95,7 @@ func (s *Scheduler) Enter(check check.Check) error {
 
 	if _, ok := s.jobQueues[check.Interval()]; !ok {
 		s.jobQueues[check.Interval()] = newJobQueue(check.Interval())
+		s.jobQueues[check.Interval()].shouldSkip = s.shouldSkip
 		s.startQueue(s.jobQueues[check.Interval()])
 		schedulerStats.Add("QueuesCount", 1)
 	}
@@ -94,6 +114,13 @@ func (s *Scheduler) Cancel(id check.ID) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if job, ok := s.scheduledJobs[id]; ok {
+		s.stopScheduledJob(job)
+		delete(s.scheduledJobs, id)
+		schedulerStats.Add("ChecksEntered", -1)
+		return nil
+	}
+
 	if _, ok := s.checkToQueue[id]; !ok {
 		return nil
 	}
@@ -186,6 +213,9 @@ func (s *Scheduler) stopQueues() {
 			q.running = false
 		}
 	}
+	for _, job := range s.scheduledJobs {
+		s.stopScheduledJob(job)
+	}
 }
 
 // startQueues loads the timer for each queue
@@ -197,6 +227,9 @@ func (s *Scheduler) startQueues() {
 	for _, q := range s.jobQueues {
 		s.startQueue(q)
 	}
+	for _, job := range s.scheduledJobs {
+		s.startScheduledJob(job)
+	}
 }
 
 // startQueue starts a queue (non-blocking operation) if it's not running yet
@@ -207,6 +240,62 @@ func (s *Scheduler) startQueue(q *jobQueue) {
 	}
 }
 
+// enterScheduled schedules a check with a cron expression
+func (s *Scheduler) enterScheduled(check check.Check, spec string) error {
+	job, err := newScheduledJob(check, spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule for check %s: %s", check, err)
+	}
+
+	log.Infof("Scheduling check %v with the schedule %q", check, spec)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if previous, ok := s.scheduledJobs[check.ID()]; ok {
+		s.stopScheduledJob(previous)
+	} else {
+		schedulerStats.Add("ChecksEntered", 1)
+	}
+	job.shouldSkip = s.shouldSkip
+	s.scheduledJobs[check.ID()] = job
+	s.startScheduledJob(job)
+
+	schedulerStats.Set("Schedules", expvar.Func(expSchedules(s)))
+	return nil
+}
+
+// startScheduledJob starts a scheduled job (non-blocking operation) if it's
+// not running yet
+func (s *Scheduler) startScheduledJob(job *scheduledJob) {
+	if !job.running {
+		job.run(s.checksPipe)
+		job.running = true
+	}
+}
+
+// stopScheduledJob stops a scheduled job, blocks until it has stopped
+func (s *Scheduler) stopScheduledJob(job *scheduledJob) {
+	if job.running {
+		close(job.stop)
+		<-job.stopped
+		job.running = false
+	}
+}
+
+// GetSchedules returns the next run of the checks scheduled with a cron
+// expression
+func (s *Scheduler) GetSchedules() map[check.ID]ScheduleStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedules := make(map[check.ID]ScheduleStats, len(s.scheduledJobs))
+	for id, job := range s.scheduledJobs {
+		schedules[id] = job.stats()
+	}
+	return schedules
+}
+
 // enqueueOnce enqueues a check once to the checksPipe.
 // Do not block, in case the runner has not started yet.
 // The queuing can be cancelled by closing the `cancelOneTime` channel.
@@ -233,10 +322,18 @@ func expQueues(s *Scheduler) func() interface{} {
 		for interval, queue := range s.jobQueues {
 			queueStats := map[string]interface{}{
 				"Interval": interval / time.Second,
-				"Size":     len(queue.jobs),
+				"Size":     queue.size(),
+				"Buckets":  queue.bucketSizes(),
 			}
 			queues = append(queues, queueStats)
 		}
 		return queues
 	}
 }
+
+// expSchedules return a function to get the stats for the scheduled checks
+func expSchedules(s *Scheduler) func() interface{} {
+	return func() interface{} {
+		return s.GetSchedules()
+	}
+}
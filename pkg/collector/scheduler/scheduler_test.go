@@ -6,11 +6,14 @@
 package scheduler
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // FIXTURE
@@ -76,21 +79,21 @@ func TestEnter(t *testing.T) {
 	c.intl = 1 * time.Second
 	s.Enter(c)
 	assert.Len(t, s.jobQueues, 1)
-	assert.Len(t, s.jobQueues[c.intl].jobs, 1)
+	assert.Equal(t, 1, s.jobQueues[c.intl].size())
 
 	// schedule another, same interval
 	c = &TestCheck{intl: c.intl}
 	s.Enter(c)
 	assert.Len(t, s.jobQueues, 1)
-	assert.Len(t, s.jobQueues[c.intl].jobs, 2)
+	assert.Equal(t, 2, s.jobQueues[c.intl].size())
 
 	// schedule again the previous plus another with different interval
 	s.Enter(c)
 	c = &TestCheck{intl: 20 * time.Second}
 	s.Enter(c)
 	assert.Len(t, s.jobQueues, 2)
-	assert.Len(t, s.jobQueues[1*time.Second].jobs, 3)
-	assert.Len(t, s.jobQueues[c.intl].jobs, 1)
+	assert.Equal(t, 3, s.jobQueues[1*time.Second].size())
+	assert.Equal(t, 1, s.jobQueues[c.intl].size())
 }
 
 func TestCancel(t *testing.T) {
@@ -101,7 +104,7 @@ func TestCancel(t *testing.T) {
 	s.Enter(c)
 	s.Run()
 	s.Cancel(c.ID())
-	assert.Len(t, s.jobQueues[c.intl].jobs, 0)
+	assert.Equal(t, 0, s.jobQueues[c.intl].size())
 }
 
 func TestRun(t *testing.T) {
@@ -174,3 +177,204 @@ func TestStopOneTimeSchedule(t *testing.T) {
 	// sleep to make the runtime schedule the hanging goroutines, if there are any
 	time.Sleep(time.Millisecond)
 }
+
+func TestSkipChecks(t *testing.T) {
+	out := make(chan check.Check, 10)
+	s := NewScheduler(out)
+	minAllowedInterval = time.Millisecond // for the purpose of this test, so that the scheduler actually schedules the checks
+	defer resetMinAllowedInterval()
+
+	var m sync.Mutex
+	running := true
+	s.SkipChecks(func(check.ID) bool {
+		m.Lock()
+		defer m.Unlock()
+		return running
+	})
+	s.Enter(&TestCheck{intl: 10 * time.Millisecond})
+	s.Run()
+	defer s.Stop()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, out, 0)
+
+	m.Lock()
+	running = false
+	m.Unlock()
+	assert.True(t, consistently(func() bool { return len(out) > 0 }))
+}
+
+type IDCheck struct {
+	TestCheck
+	id     check.ID
+	jitter time.Duration
+}
+
+func (c *IDCheck) ID() check.ID          { return c.id }
+func (c *IDCheck) Jitter() time.Duration { return c.jitter }
+
+func TestJobQueueBuckets(t *testing.T) {
+	jq := newJobQueue(15 * time.Second)
+	defer jq.ticker.Stop()
+	require.Len(t, jq.buckets, 15)
+
+	for i := 0; i < 150; i++ {
+		jq.addJob(&IDCheck{id: check.ID(fmt.Sprintf("check:%d", i))})
+	}
+	assert.Equal(t, 150, jq.size())
+
+	// the checks are spread, and always land in the same bucket
+	for _, size := range jq.bucketSizes() {
+		assert.True(t, size > 0 && size < 30, "unbalanced bucket of size %d", size)
+	}
+	assert.True(t, jq.bucketFor("check:42") == jq.bucketFor("check:42"))
+
+	require.Nil(t, jq.removeJob("check:42"))
+	assert.Equal(t, 149, jq.size())
+	assert.NotNil(t, jq.removeJob("check:42"))
+
+	// sub-second intervals use a single bucket
+	assert.Len(t, newJobQueue(500*time.Millisecond).buckets, 1)
+}
+
+func TestJobQueueTicks(t *testing.T) {
+	jq := newJobQueue(2 * time.Second)
+	jq.ticker.Stop()
+	jq.addJob(&IDCheck{id: "a"})
+	jq.addJob(&IDCheck{id: "b"})
+	jq.addJob(&IDCheck{id: "c"})
+
+	// every tick enqueues the next bucket, an interval enqueues every check once
+	out := make(chan check.Check, 10)
+	tick := make(chan time.Time, 1)
+	jq.ticker.C = tick
+	for i := 0; i < len(jq.buckets); i++ {
+		tick <- time.Now()
+		assert.True(t, jq.waitForTick(out))
+		assert.Equal(t, jq.bucketSizes()[i], len(out))
+		for len(out) > 0 {
+			<-out
+		}
+	}
+	assert.Equal(t, 0, jq.bucketIndex)
+}
+
+func TestJitter(t *testing.T) {
+	jq := newJobQueue(time.Second)
+	jq.ticker.Stop()
+	jq.addJob(&IDCheck{id: "a", jitter: 50 * time.Millisecond})
+
+	out := make(chan check.Check, 1)
+	tick := make(chan time.Time, 1)
+	jq.ticker.C = tick
+	tick <- time.Now()
+	assert.True(t, jq.waitForTick(out))
+	// the check is enqueued later, without blocking the queue
+	assert.True(t, consistently(func() bool { return len(out) == 1 }))
+
+	// pending delayed enqueues are cancelled when the queue stops
+	jq.addJob(&IDCheck{id: "b", jitter: time.Hour})
+	tick <- time.Now()
+	jq.waitForTick(out)
+	jq.run(out)
+	jq.stop <- true
+	<-jq.stopped
+
+	// the queue can be run again
+	jq.run(out)
+	jq.stop <- true
+	<-jq.stopped
+}
+
+func TestJitterRemoveJob(t *testing.T) {
+	jq := newJobQueue(time.Second)
+	jq.ticker.Stop()
+	jq.addJob(&IDCheck{id: "a", jitter: 100 * time.Millisecond})
+
+	out := make(chan check.Check, 1)
+	tick := make(chan time.Time, 1)
+	jq.ticker.C = tick
+	tick <- time.Now()
+	assert.True(t, jq.waitForTick(out))
+
+	// the delayed enqueue is cancelled when the check is removed
+	require.Nil(t, jq.removeJob("a"))
+	jq.jittered.Wait()
+	assert.Len(t, out, 0)
+	assert.Len(t, jq.delayed, 0)
+}
+
+type ScheduledCheck struct {
+	IDCheck
+	schedule string
+}
+
+func (c *ScheduledCheck) Schedule() string { return c.schedule }
+
+func TestEnterScheduled(t *testing.T) {
+	out := make(chan check.Check, 10)
+	s := NewScheduler(out)
+
+	// the interval isn't used for checks with a schedule
+	c := &ScheduledCheck{IDCheck: IDCheck{id: "cron"}, schedule: "0 3 * * *"}
+	require.Nil(t, s.Enter(c))
+	assert.Len(t, s.jobQueues, 0)
+	schedules := s.GetSchedules()
+	require.Contains(t, schedules, check.ID("cron"))
+	assert.Equal(t, "0 3 * * *", schedules["cron"].Schedule)
+	assert.True(t, consistently(func() bool { return s.GetSchedules()["cron"].NextRun > time.Now().Unix() }))
+
+	assert.NotNil(t, s.Enter(&ScheduledCheck{IDCheck: IDCheck{id: "invalid"}, schedule: "0 25 * * *"}))
+	assert.NotContains(t, s.GetSchedules(), check.ID("invalid"))
+
+	require.Nil(t, s.Cancel("cron"))
+	assert.Len(t, s.GetSchedules(), 0)
+	assert.Len(t, out, 0)
+}
+
+func TestOneShotSchedule(t *testing.T) {
+	out := make(chan check.Check, 10)
+	s := NewScheduler(out)
+	s.Run()
+	defer s.Stop()
+
+	require.Nil(t, s.Enter(&ScheduledCheck{IDCheck: IDCheck{id: "once"}, schedule: "@once"}))
+	at := time.Now().Add(50 * time.Millisecond).Format(time.RFC3339Nano)
+	require.Nil(t, s.Enter(&ScheduledCheck{IDCheck: IDCheck{id: "at"}, schedule: at}))
+
+	assert.True(t, consistently(func() bool { return len(out) == 2 }))
+	// one-shot checks run only once, and don't have a next run anymore
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, out, 2)
+	assert.Equal(t, int64(0), s.GetSchedules()["once"].NextRun)
+	assert.Equal(t, int64(0), s.GetSchedules()["at"].NextRun)
+}
+
+func TestScheduledSkipChecks(t *testing.T) {
+	out := make(chan check.Check, 10)
+	s := NewScheduler(out)
+	s.SkipChecks(func(check.ID) bool { return true })
+	s.Run()
+	defer s.Stop()
+
+	require.Nil(t, s.Enter(&ScheduledCheck{IDCheck: IDCheck{id: "once"}, schedule: "@once"}))
+	assert.True(t, consistently(func() bool { return s.GetSchedules()["once"].NextRun == 0 }))
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, out, 0)
+}
+
+func TestRestartScheduledJob(t *testing.T) {
+	out := make(chan check.Check, 10)
+	s := NewScheduler(out)
+	job, err := newScheduledJob(&ScheduledCheck{IDCheck: IDCheck{id: "cron"}}, "0 3 * * *")
+	require.Nil(t, err)
+
+	// the job can be stopped and started again without closing its channels twice
+	for i := 0; i < 2; i++ {
+		s.startScheduledJob(job)
+		assert.True(t, job.running)
+		s.stopScheduledJob(job)
+		assert.False(t, job.running)
+	}
+	assert.Len(t, out, 0)
+}
@@ -55,6 +55,17 @@ type Proxy struct {
 	NoProxy []string `mapstructure:"no_proxy"`
 }
 
+// DomainTransport helps unmarshalling the `forwarder_domains` config param,
+// it holds the TLS and proxy settings used to reach a single domain
+type DomainTransport struct {
+	CAFile        string `mapstructure:"tls_ca_file"`
+	CertFile      string `mapstructure:"tls_cert_file"`
+	KeyFile       string `mapstructure:"tls_key_file"`
+	MinTLSVersion string `mapstructure:"tls_min_version"`
+	Proxy         *Proxy `mapstructure:"proxy"`
+	NoProxy       bool   `mapstructure:"no_proxy"`
+}
+
 func init() {
 	// config identifiers
 	Datadog.SetConfigName("datadog")
@@ -88,6 +99,10 @@ func init() {
 	Datadog.SetDefault("enable_metadata_collection", true)
 	Datadog.SetDefault("enable_gohai", true)
 	Datadog.SetDefault("check_runners", int64(0))
+	BindEnvAndSetDefault("check_timeout", 0)              // in seconds, Notice: 0 means no timeout
+	BindEnvAndSetDefault("check_backoff_failures", 0)       // Notice: 0 means checks never back off
+	BindEnvAndSetDefault("check_backoff_max_interval", 600) // in seconds
+	BindEnvAndSetDefault("check_resource_accounting", false)
 	Datadog.SetDefault("expvar_port", "5000")
 
 	// Use to output logs in JSON format
@@ -96,6 +111,12 @@ func init() {
 	// IPC API server timeout
 	BindEnvAndSetDefault("server_timeout", 15)
 
+	// Secrets
+	BindEnvAndSetDefault("secret_backend_command", "")
+	Datadog.SetDefault("secret_backend_arguments", []string{})
+	BindEnvAndSetDefault("secret_backend_timeout", 5)                 // in seconds
+	BindEnvAndSetDefault("secret_backend_output_max_size", 1024*1024) // in bytes
+
 	// Use to force client side TLS version to 1.2
 	BindEnvAndSetDefault("force_tls_12", false)
 
@@ -123,9 +144,18 @@ func init() {
 	Datadog.SetDefault("use_v2_api.series", false)
 	Datadog.SetDefault("use_v2_api.events", false)
 	Datadog.SetDefault("use_v2_api.service_checks", false)
+	BindEnvAndSetDefault("serializer_compressor_kind", "zlib")
+	BindEnvAndSetDefault("serializer_compression_level", 0) // Notice: 0 means the codec default level
 	// Forwarder
 	Datadog.SetDefault("forwarder_timeout", 20)
 	Datadog.SetDefault("forwarder_retry_queue_max_size", 30)
+	// Cloud provider host tags
+	BindEnvAndSetDefault("cloud_tags_refresh_interval", 600) // in seconds
+	BindEnvAndSetDefault("cloud_tags_include", []string{})
+	BindEnvAndSetDefault("cloud_tags_exclude", []string{})
+	BindEnvAndSetDefault("cloud_tags_on_metrics", false)
+	// Shutdown
+	BindEnvAndSetDefault("shutdown_timeout", 5) // in seconds
 	// Dogstatsd
 	Datadog.SetDefault("use_dogstatsd", true)
 	Datadog.SetDefault("dogstatsd_port", 8125)          // Notice: 0 means UDP port closed
@@ -137,9 +167,23 @@ func init() {
 	Datadog.SetDefault("dogstatsd_stats_buffer", 10)
 	Datadog.SetDefault("dogstatsd_expiry_seconds", 300)
 	Datadog.SetDefault("dogstatsd_origin_detection", false) // Only supported for socket traffic
+	// SNMP traps
+	Datadog.SetDefault("snmp_traps_enabled", false)
+	Datadog.SetDefault("snmp_traps_port", 162)
+	Datadog.SetDefault("snmp_traps_bind_host", "0.0.0.0")
+	Datadog.SetDefault("snmp_traps_community_strings", []string{})
+	Datadog.SetDefault("snmp_traps_users", []map[string]string{})
+	Datadog.SetDefault("snmp_traps_db_folder", "") // Notice: empty means the traps_db folder of the snmp check configuration
+	Datadog.SetDefault("snmp_traps_output", "events")
 	// Autoconfig
 	Datadog.SetDefault("autoconf_template_dir", "/datadog/check_configs")
 	Datadog.SetDefault("exclude_pause_container", true)
+	// Container runtime
+	Datadog.SetDefault("container_runtime", "docker")
+	Datadog.SetDefault("cri_socket_path", "")              // Notice: empty means autodetected
+	Datadog.SetDefault("cri_connection_timeout", int64(1)) // in seconds
+	Datadog.SetDefault("cri_query_timeout", int64(5))      // in seconds
+	Datadog.SetDefault("cri_events_interval", int64(10))   // in seconds
 	// Docker
 	Datadog.SetDefault("docker_labels_as_tags", map[string]string{})
 	Datadog.SetDefault("docker_env_as_tags", map[string]string{})
@@ -158,6 +202,7 @@ func init() {
 
 	// Kube ApiServer
 	Datadog.SetDefault("kubernetes_kubeconfig_path", "")
+	Datadog.SetDefault("kubernetes_check_configmap_label", "ad.datadoghq.com/checks")
 
 	// Datadog cluster agent
 	Datadog.SetDefault("cluster_agent.auth_token", "")
@@ -223,6 +268,7 @@ func init() {
 	Datadog.BindEnv("histogram_aggregates")
 	Datadog.BindEnv("histogram_percentiles")
 	Datadog.BindEnv("kubernetes_kubeconfig_path")
+	Datadog.BindEnv("kubernetes_check_configmap_label")
 }
 
 // BindEnvAndSetDefault sets the default value for a config parameter, and adds an env binding
@@ -243,6 +289,12 @@ func GetMultipleEndpoints() (map[string][]string, error) {
 	return getMultipleEndpoints(Datadog)
 }
 
+// GetDomainTransports returns the per-domain transport settings specified in
+// the main agent config, keyed the same way as GetMultipleEndpoints
+func GetDomainTransports() (map[string]DomainTransport, error) {
+	return getDomainTransports(Datadog)
+}
+
 // addAgentVersionToDomain prefix the domain with the agent version: X-Y-Z.domain
 func addAgentVersionToDomain(domain string, app string) (string, error) {
 	u, err := url.Parse(domain)
@@ -322,6 +374,25 @@ func getMultipleEndpoints(config *viper.Viper) (map[string][]string, error) {
 	return keysPerDomain, nil
 }
 
+// getDomainTransports implements the logic to extract the transport settings per domain from an agent config
+func getDomainTransports(config *viper.Viper) (map[string]DomainTransport, error) {
+	var domainTransports map[string]DomainTransport
+	if err := config.UnmarshalKey("forwarder_domains", &domainTransports); err != nil {
+		return nil, err
+	}
+
+	transports := make(map[string]DomainTransport, len(domainTransports))
+	for domain, settings := range domainTransports {
+		updatedDomain, err := addAgentVersionToDomain(domain, "app")
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse url from 'forwarder_domains' %s: %s", domain, err)
+		}
+		transports[updatedDomain] = settings
+	}
+
+	return transports, nil
+}
+
 // IsContainerized returns whether the Agent is running on a Docker container
 func IsContainerized() bool {
 	return os.Getenv("DOCKER_DD_AGENT") == "yes"
@@ -158,3 +158,50 @@ func TestAddAgentVersionToDomain(t *testing.T) {
 	require.Nil(t, err)
 	assert.Equal(t, "https://app.myproxy.com", newURL)
 }
+
+func TestGetDomainTransports(t *testing.T) {
+	datadogYaml := `
+dd_url: "https://app.datadoghq.com"
+api_key: fakeapikey
+
+forwarder_domains:
+  "https://app.datadoghq.com":
+    tls_ca_file: /etc/ssl/ca.pem
+    tls_min_version: tlsv1.2
+  "https://relay.example.com":
+    tls_cert_file: /etc/relay.crt
+    tls_key_file: /etc/relay.key
+    no_proxy: true
+  "https://other.example.com":
+    proxy:
+      https: http://proxy.example.com:3128
+`
+	testConfig := setupViperConf(datadogYaml)
+
+	transports, err := getDomainTransports(testConfig)
+	require.Nil(t, err)
+
+	expected := map[string]DomainTransport{
+		"https://" + targetDomain + ".datadoghq.com": {
+			CAFile:        "/etc/ssl/ca.pem",
+			MinTLSVersion: "tlsv1.2",
+		},
+		"https://relay.example.com": {
+			CertFile: "/etc/relay.crt",
+			KeyFile:  "/etc/relay.key",
+			NoProxy:  true,
+		},
+		"https://other.example.com": {
+			Proxy: &Proxy{HTTPS: "http://proxy.example.com:3128"},
+		},
+	}
+	assert.Equal(t, expected, transports)
+}
+
+func TestGetDomainTransportsEmpty(t *testing.T) {
+	testConfig := setupViperConf(`api_key: fakeapikey`)
+
+	transports, err := getDomainTransports(testConfig)
+	require.Nil(t, err)
+	assert.Len(t, transports, 0)
+}
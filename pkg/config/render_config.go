@@ -22,6 +22,7 @@ type context struct {
 	Metadata          bool
 	Dogstatsd         bool
 	LogsAgent         bool
+	SNMPTraps         bool
 	JMX               bool
 	Autoconfig        bool
 	Logging           bool
@@ -44,6 +45,7 @@ func mkContext(buildType string) context {
 			Metadata:          true,
 			Dogstatsd:         true,
 			LogsAgent:         true,
+			SNMPTraps:         true,
 			JMX:               true,
 			Autoconfig:        true,
 			Logging:           true,
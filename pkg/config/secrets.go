@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/secrets"
+)
+
+// ResolveSecrets sets up the secrets backend from the configuration and
+// replaces the `ENC[<handle>]` values of the configuration file with their
+// secrets. It must be called once the configuration file is read.
+func ResolveSecrets() error {
+	secrets.Init(
+		Datadog.GetString("secret_backend_command"),
+		Datadog.GetStringSlice("secret_backend_arguments"),
+		time.Duration(Datadog.GetInt("secret_backend_timeout"))*time.Second,
+		Datadog.GetInt("secret_backend_output_max_size"),
+	)
+
+	path := Datadog.ConfigFileUsed()
+	if path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %s", path, err)
+	}
+	decrypted, err := secrets.Decrypt(data)
+	if err != nil {
+		return fmt.Errorf("unable to decrypt the secrets of %s: %s", path, err)
+	}
+	if bytes.Equal(data, decrypted) {
+		return nil
+	}
+	return Datadog.MergeConfig(bytes.NewReader(decrypted))
+}
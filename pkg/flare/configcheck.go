@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package flare
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/collector/autodiscovery"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+// CleanConfigCheck returns a copy of a configcheck response with the
+// credentials of the configurations scrubbed, the resolved templates can hold
+// secrets read from the environment of the services.
+func CleanConfigCheck(response autodiscovery.ConfigCheckResponse) (autodiscovery.ConfigCheckResponse, error) {
+	cleaned := response
+	var err error
+
+	cleaned.Configs = make([]autodiscovery.LoadedConfig, len(response.Configs))
+	for i, c := range response.Configs {
+		cleaned.Configs[i] = c
+		if cleaned.Configs[i].Config, err = cleanConfig(c.Config); err != nil {
+			return cleaned, err
+		}
+	}
+
+	cleaned.Unresolved = make([]autodiscovery.UnresolvedTemplate, len(response.Unresolved))
+	for i, u := range response.Unresolved {
+		cleaned.Unresolved[i] = u
+		if cleaned.Unresolved[i].Template, err = cleanConfig(u.Template); err != nil {
+			return cleaned, err
+		}
+	}
+
+	cleaned.Templates = make([]check.Config, len(response.Templates))
+	for i, tpl := range response.Templates {
+		if cleaned.Templates[i], err = cleanConfig(tpl); err != nil {
+			return cleaned, err
+		}
+	}
+	return cleaned, nil
+}
+
+// cleanConfig returns a copy of a configuration with the credentials of its
+// YAML data scrubbed
+func cleanConfig(c check.Config) (check.Config, error) {
+	cleaned := c
+	var err error
+
+	cleaned.Instances = make([]check.ConfigData, len(c.Instances))
+	for i, instance := range c.Instances {
+		if cleaned.Instances[i], err = cleanConfigData(instance); err != nil {
+			return cleaned, err
+		}
+	}
+	if cleaned.InitConfig, err = cleanConfigData(c.InitConfig); err != nil {
+		return cleaned, err
+	}
+	if cleaned.MetricConfig, err = cleanConfigData(c.MetricConfig); err != nil {
+		return cleaned, err
+	}
+	cleaned.LogsConfig, err = cleanConfigData(c.LogsConfig)
+	return cleaned, err
+}
+
+func cleanConfigData(data check.ConfigData) (check.ConfigData, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	return credentialsCleanerBytes(data)
+}
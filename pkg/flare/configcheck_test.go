@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package flare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/autodiscovery"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+func TestCleanConfigCheck(t *testing.T) {
+	resolved := check.Config{
+		Name:       "redisdb",
+		InitConfig: check.ConfigData("{}"),
+		Instances:  []check.ConfigData{check.ConfigData("host: 10.0.0.1\npassword: secret\n")},
+	}
+	template := check.Config{
+		Name:      "redisdb",
+		Instances: []check.ConfigData{check.ConfigData("host: \"%%host%%\"\npassword: \"%%env_REDIS_PASSWORD%%\"\n")},
+	}
+	response := autodiscovery.ConfigCheckResponse{
+		Configs:    []autodiscovery.LoadedConfig{{Config: resolved, ServiceID: "docker://abc"}},
+		Unresolved: []autodiscovery.UnresolvedTemplate{{Template: template, Reasons: []string{"no service"}}},
+		Templates:  []check.Config{template},
+	}
+
+	cleaned, err := CleanConfigCheck(response)
+	require.NoError(t, err)
+	require.Len(t, cleaned.Configs, 1)
+	assert.Equal(t, "docker://abc", cleaned.Configs[0].ServiceID)
+	assert.Equal(t, "host: 10.0.0.1\npassword: ********\n", string(cleaned.Configs[0].Config.Instances[0]))
+	assert.Equal(t, "{}\n", string(cleaned.Configs[0].Config.InitConfig))
+	assert.Nil(t, cleaned.Configs[0].Config.LogsConfig)
+	assert.Equal(t, "host: \"%%host%%\"\npassword: ********\n", string(cleaned.Unresolved[0].Template.Instances[0]))
+	assert.Equal(t, []string{"no service"}, cleaned.Unresolved[0].Reasons)
+	assert.Equal(t, "host: \"%%host%%\"\npassword: ********\n", string(cleaned.Templates[0].Instances[0]))
+
+	// the configurations of AutoConfig are left untouched
+	assert.Equal(t, "host: 10.0.0.1\npassword: secret\n", string(response.Configs[0].Config.Instances[0]))
+}
@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package forwarder
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+)
+
+const diagnoseTimeout = 10 * time.Second
+
+func init() {
+	diagnosis.Register("Forwarder endpoints connectivity", diagnose)
+}
+
+// diagnose tests the connection and the TLS handshake with every domain,
+// using the TLS and proxy settings of that domain
+func diagnose() error {
+	if !validateDomainTransports() {
+		return fmt.Errorf("invalid forwarder domain settings")
+	}
+
+	keysPerDomain, err := config.GetMultipleEndpoints()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	failures := 0
+	for domain := range keysPerDomain {
+		if err := diagnoseDomain(domain); err != nil {
+			log.Errorf("Could not reach %s: %s", domain, err)
+			failures++
+			continue
+		}
+		log.Infof("Successfully reached %s", domain)
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d endpoint(s) out of %d could not be reached", failures, len(keysPerDomain))
+	}
+	return nil
+}
+
+func diagnoseDomain(domain string) error {
+	transport, err := newDomainHTTPTransport(domain)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Timeout:   diagnoseTimeout,
+		Transport: transport,
+	}
+
+	// any HTTP response means that the connection and the TLS handshake succeeded
+	resp, err := client.Head(domain)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.TLS != nil {
+		log.Debugf("TLS handshake with %s succeeded, negotiated version: %s", domain, tlsVersionName(resp.TLS.Version))
+	}
+	return nil
+}
+
+func tlsVersionName(version uint16) string {
+	for name, v := range tlsVersions {
+		if v == version {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
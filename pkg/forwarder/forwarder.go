@@ -91,6 +91,7 @@ type Transaction interface {
 type Forwarder interface {
 	Start() error
 	Stop()
+	Drain(timeout time.Duration) error
 	SubmitV1Series(payload Payloads, extra http.Header) error
 	SubmitV1Intake(payload Payloads, extra http.Header) error
 	SubmitV1CheckRuns(payload Payloads, extra http.Header) error
@@ -213,9 +214,13 @@ func (f *DefaultForwarder) Start() error {
 	// reset internal state to purge transactions from past starts
 	f.init()
 
+	// domains with invalid TLS or proxy settings fall back to the global ones
+	transport, errs := newHTTPTransport()
+	logDomainTransportErrors(errs)
+
 	blockedList := newBlockedEndpoints()
 	for i := 0; i < f.NumberOfWorkers; i++ {
-		w := NewWorker(f.highPrio, f.lowPrio, f.requeuedTransaction, blockedList)
+		w := NewWorker(f.highPrio, f.lowPrio, f.requeuedTransaction, blockedList, transport)
 		w.Start()
 		f.workers = append(f.workers, w)
 	}
@@ -254,15 +259,87 @@ func (f *DefaultForwarder) Stop() {
 	f.internalState = Stopped
 
 	f.stopRetry <- true
+	f.stopWorkers()
+	log.Info("DefaultForwarder stopped")
+}
+
+// Drain stops a DefaultForwarder once the transactions still queued, including
+// the ones waiting to be retried, are processed. Transactions failing again or
+// not processed before timeout are lost, an error reports how many.
+func (f *DefaultForwarder) Drain(timeout time.Duration) error {
+	// Lock so we can't start a DefaultForwarder while is stopping
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.internalState == Stopped {
+		return fmt.Errorf("the forwarder is already stopped")
+	}
+
+	// new transactions are refused from now on
+	f.internalState = Stopped
+	f.stopRetry <- true
+
+	expired := make(chan struct{})
+	timer := time.AfterFunc(timeout, func() { close(expired) })
+	defer timer.Stop()
+
+	// the workers process the transactions as last attempts before the retry
+	// queue is pushed, for the ones picked before the drain signal not to be
+	// requeued
+	for _, w := range f.workers {
+		w.beginDrain()
+	}
+
+	// transactions requeued since the last retry get a last attempt as well
+	for len(f.requeuedTransaction) > 0 {
+		f.requeueTransaction(<-f.requeuedTransaction)
+	}
+	retried := 0
+retryLoop:
+	for _, t := range f.retryQueue {
+		select {
+		case f.lowPrio <- t:
+			retried++
+		case <-expired:
+			break retryLoop
+		}
+	}
+
+	drained := true
+	for _, w := range f.workers {
+		select {
+		case <-w.Drain():
+		case <-expired:
+			drained = false
+		}
+	}
+
+	// what's left when the workers stop is lost
+	lost := len(f.highPrio) + len(f.lowPrio) + len(f.requeuedTransaction) + len(f.retryQueue) - retried
+	f.stopWorkers()
+	transactionsExpvar.Add("Dropped", int64(lost))
+
+	if !drained {
+		return fmt.Errorf("DefaultForwarder stopped before being drained, %d transaction(s) lost", lost)
+	}
+	if lost > 0 {
+		return fmt.Errorf("DefaultForwarder drained, %d transaction(s) failed and are lost", lost)
+	}
+	log.Info("DefaultForwarder drained and stopped")
+	return nil
+}
+
+// stopWorkers stops the workers and drops the transactions left.
+func (f *DefaultForwarder) stopWorkers() {
 	for _, w := range f.workers {
 		w.Stop()
 	}
 	f.workers = []*Worker{}
 	f.retryQueue = []Transaction{}
+	retryQueueSize.Set(0)
 	close(f.highPrio)
 	close(f.lowPrio)
 	close(f.requeuedTransaction)
-	log.Info("DefaultForwarder stopped")
 }
 
 func (f *DefaultForwarder) createHTTPTransactions(endpoint string, payloads Payloads, apiKeyInQueryString bool, extra http.Header) []*HTTPTransaction {
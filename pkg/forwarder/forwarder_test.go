@@ -256,3 +256,67 @@ func TestForwarderRetryLimitQueue(t *testing.T) {
 	// assert that the oldest transaction was dropped
 	assert.Equal(t, transaction2, forwarder.retryQueue[0])
 }
+
+func TestDrain(t *testing.T) {
+	forwarder := NewDefaultForwarder(nil)
+	forwarder.Start()
+
+	queued := newTestTransaction()
+	retried := newTestTransaction()
+	queued.On("Process", forwarder.workers[0].Client).Return(nil).Times(1)
+	queued.On("GetTarget").Return("").Times(1)
+	retried.On("Process", forwarder.workers[0].Client).Return(nil).Times(1)
+	retried.On("GetTarget").Return("").Times(1)
+
+	forwarder.highPrio <- queued
+	forwarder.requeuedTransaction <- retried
+
+	assert.Nil(t, forwarder.Drain(5*time.Second))
+	assert.Equal(t, Stopped, forwarder.State())
+	queued.AssertExpectations(t)
+	retried.AssertExpectations(t)
+
+	// a stopped forwarder can't be drained
+	assert.NotNil(t, forwarder.Drain(time.Second))
+}
+
+func TestDrainBlockedRetry(t *testing.T) {
+	forwarder := NewDefaultForwarder(nil)
+	forwarder.Start()
+
+	// the retried transactions get their last attempt, even for blocked
+	// endpoints and when picked by a worker before its drain signal
+	forwarder.workers[0].blockedList.block("error_url")
+	for i := 0; i < 10; i++ {
+		retried := newTestTransaction()
+		retried.On("Process", forwarder.workers[0].Client).Return(nil).Times(1)
+		retried.On("GetTarget").Return("error_url").Times(1)
+		defer retried.AssertExpectations(t)
+		forwarder.requeueTransaction(retried)
+	}
+
+	assert.Nil(t, forwarder.Drain(5*time.Second))
+	assert.Equal(t, Stopped, forwarder.State())
+}
+
+func TestDrainTimeout(t *testing.T) {
+	forwarder := NewDefaultForwarder(nil)
+	forwarder.NumberOfWorkers = 1
+	forwarder.Start()
+
+	release := make(chan time.Time)
+	defer close(release)
+	slow := newTestTransaction()
+	slow.On("Process", forwarder.workers[0].Client).Return(nil).WaitUntil(release)
+	slow.On("GetTarget").Return("")
+	pending := newTestTransaction()
+
+	forwarder.highPrio <- slow
+	forwarder.highPrio <- pending
+
+	start := time.Now()
+	assert.NotNil(t, forwarder.Drain(100*time.Millisecond))
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.Equal(t, Stopped, forwarder.State())
+	pending.AssertNumberOfCalls(t, "Process", 0)
+}
@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package forwarder
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util"
+)
+
+var tlsVersions = map[string]uint16{
+	"tlsv1.0": tls.VersionTLS10,
+	"tlsv1.1": tls.VersionTLS11,
+	"tlsv1.2": tls.VersionTLS12,
+}
+
+// domainRoundTripper routes each request to the transport configured for
+// its domain, falling back to the default transport.
+type domainRoundTripper struct {
+	defaultTransport http.RoundTripper
+	// transports are indexed by "scheme://host"
+	transports map[string]http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (d *domainRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if transport, found := d.transports[strings.ToLower(req.URL.Scheme+"://"+req.URL.Host)]; found {
+		return transport.RoundTrip(req)
+	}
+	return d.defaultTransport.RoundTrip(req)
+}
+
+// newHTTPTransport returns the transport used by the workers. Domains with
+// invalid settings are reported in the returned errors and use the default
+// transport.
+func newHTTPTransport() (http.RoundTripper, []error) {
+	domainTransports, err := config.GetDomainTransports()
+	if err != nil {
+		return util.CreateHTTPTransport(), []error{fmt.Errorf("could not load 'forwarder_domains': %s", err)}
+	}
+	if len(domainTransports) == 0 {
+		return util.CreateHTTPTransport(), nil
+	}
+
+	errs := []error{}
+	roundTripper := &domainRoundTripper{
+		defaultTransport: util.CreateHTTPTransport(),
+		transports:       make(map[string]http.RoundTripper, len(domainTransports)),
+	}
+	for domain, settings := range domainTransports {
+		key, err := domainKey(domain)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		transport, err := createDomainTransport(settings)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid settings for domain %q: %s", domain, err))
+			continue
+		}
+		roundTripper.transports[key] = transport
+	}
+	return roundTripper, errs
+}
+
+// newDomainHTTPTransport returns the transport used to reach the given domain
+func newDomainHTTPTransport(domain string) (*http.Transport, error) {
+	domainTransports, err := config.GetDomainTransports()
+	if err != nil {
+		return nil, err
+	}
+	for d, settings := range domainTransports {
+		if sameDomain(d, domain) {
+			return createDomainTransport(settings)
+		}
+	}
+	return util.CreateHTTPTransport(), nil
+}
+
+// createDomainTransport applies the domain settings on top of the global ones
+func createDomainTransport(settings config.DomainTransport) (*http.Transport, error) {
+	transport := util.CreateHTTPTransport()
+	tlsConfig := transport.TLSClientConfig
+
+	if settings.MinTLSVersion != "" {
+		version, found := tlsVersions[strings.ToLower(settings.MinTLSVersion)]
+		if !found {
+			return nil, fmt.Errorf("unknown TLS version %q, supported versions are tlsv1.0, tlsv1.1 and tlsv1.2", settings.MinTLSVersion)
+		}
+		// force_tls_12 can't be loosened by a domain setting
+		if version > tlsConfig.MinVersion {
+			tlsConfig.MinVersion = version
+		}
+	}
+
+	if settings.CAFile != "" {
+		pem, err := ioutil.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the CA bundle: %s", err)
+		}
+		// the custom bundle is trusted on top of the system roots
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificate found in the CA bundle %s", settings.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		if settings.CertFile == "" || settings.KeyFile == "" {
+			return nil, fmt.Errorf("both 'tls_cert_file' and 'tls_key_file' must be set to use a client certificate")
+		}
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if settings.NoProxy {
+		transport.Proxy = nil
+	} else if settings.Proxy != nil {
+		for _, p := range []string{settings.Proxy.HTTP, settings.Proxy.HTTPS} {
+			if p == "" {
+				continue
+			}
+			if _, err := url.Parse(p); err != nil {
+				return nil, fmt.Errorf("could not parse the proxy URL: %s", err)
+			}
+		}
+		transport.Proxy = util.GetProxyTransportFunc(settings.Proxy)
+	}
+
+	return transport, nil
+}
+
+// validateDomainTransports logs every invalid domain setting and returns
+// whether the settings are valid
+func validateDomainTransports() bool {
+	_, errs := newHTTPTransport()
+	logDomainTransportErrors(errs)
+	return len(errs) == 0
+}
+
+func logDomainTransportErrors(errs []error) {
+	for _, err := range errs {
+		log.Errorf("Misconfiguration of the forwarder domains: %s", err)
+	}
+}
+
+func domainKey(domain string) (string, error) {
+	u, err := url.Parse(domain)
+	if err != nil {
+		return "", fmt.Errorf("could not parse domain %q: %s", domain, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("domain %q must be an URL including the scheme", domain)
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
+func sameDomain(a, b string) bool {
+	keyA, errA := domainKey(a)
+	keyB, errB := domainKey(b)
+	return errA == nil && errB == nil && keyA == keyB
+}
@@ -0,0 +1,243 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package forwarder
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// writeCertificate generates a self-signed certificate and writes it along with
+// its key in dir
+func writeCertificate(t *testing.T, dir, name string) (tls.Certificate, string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	require.Nil(t, ioutil.WriteFile(certFile, certPEM, 0600))
+	require.Nil(t, ioutil.WriteFile(keyFile, keyPEM, 0600))
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.Nil(t, err)
+	return cert, certFile, keyFile
+}
+
+// writeServerCA writes the certificate of a httptest TLS server in dir
+func writeServerCA(t *testing.T, dir string, ts *httptest.Server) string {
+	caFile := filepath.Join(dir, "server-ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.TLS.Certificates[0].Certificate[0]})
+	require.Nil(t, ioutil.WriteFile(caFile, caPEM, 0600))
+	return caFile
+}
+
+func TestNewHTTPTransportNoDomainSettings(t *testing.T) {
+	transport, errs := newHTTPTransport()
+	assert.Len(t, errs, 0)
+	_, isHTTPTransport := transport.(*http.Transport)
+	assert.True(t, isHTTPTransport)
+}
+
+func TestDomainCAFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forwarder-tls")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	// the server certificate isn't trusted by default
+	client := &http.Client{Transport: mustCreateDomainTransport(t, config.DomainTransport{})}
+	_, err = client.Get(ts.URL)
+	assert.NotNil(t, err)
+
+	transport := mustCreateDomainTransport(t, config.DomainTransport{CAFile: writeServerCA(t, dir, ts)})
+	client = &http.Client{Transport: transport}
+	resp, err := client.Get(ts.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestDomainClientCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forwarder-tls")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	clientCert, certFile, keyFile := writeCertificate(t, dir, "client")
+	leaf, err := x509.ParseCertificate(clientCert.Certificate[0])
+	require.Nil(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(leaf)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+	caFile := writeServerCA(t, dir, ts)
+
+	// without client certificate the handshake fails
+	client := &http.Client{Transport: mustCreateDomainTransport(t, config.DomainTransport{CAFile: caFile})}
+	_, err = client.Get(ts.URL)
+	assert.NotNil(t, err)
+
+	transport := mustCreateDomainTransport(t, config.DomainTransport{
+		CAFile:   caFile,
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	})
+	client = &http.Client{Transport: transport}
+	resp, err := client.Get(ts.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestDomainTransportInvalidSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forwarder-tls")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	_, certFile, _ := writeCertificate(t, dir, "client")
+
+	invalids := []config.DomainTransport{
+		{MinTLSVersion: "sslv3"},
+		{CAFile: filepath.Join(dir, "missing.pem")},
+		{CertFile: certFile},
+		{CertFile: certFile, KeyFile: filepath.Join(dir, "missing.key")},
+		{Proxy: &config.Proxy{HTTPS: "http://[::1"}},
+	}
+	for _, settings := range invalids {
+		_, err := createDomainTransport(settings)
+		assert.NotNil(t, err, "%+v", settings)
+	}
+}
+
+func TestDomainMinTLSVersion(t *testing.T) {
+	transport := mustCreateDomainTransport(t, config.DomainTransport{MinTLSVersion: "TLSv1.1"})
+	assert.Equal(t, uint16(tls.VersionTLS11), transport.TLSClientConfig.MinVersion)
+
+	// force_tls_12 can't be loosened
+	config.Datadog.Set("force_tls_12", true)
+	defer config.Datadog.Set("force_tls_12", false)
+	transport = mustCreateDomainTransport(t, config.DomainTransport{MinTLSVersion: "tlsv1.0"})
+	assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+}
+
+func TestDomainProxy(t *testing.T) {
+	config.Datadog.Set("proxy", map[string]interface{}{"https": "http://global-proxy:3128"})
+	defer config.Datadog.Set("proxy", nil)
+
+	req, err := http.NewRequest("GET", "https://relay.example.com/api/v1/series", nil)
+	require.Nil(t, err)
+
+	// global proxy
+	transport := mustCreateDomainTransport(t, config.DomainTransport{})
+	proxyURL, err := transport.Proxy(req)
+	require.Nil(t, err)
+	assert.Equal(t, "global-proxy:3128", proxyURL.Host)
+
+	// domain proxy
+	transport = mustCreateDomainTransport(t, config.DomainTransport{Proxy: &config.Proxy{HTTPS: "http://domain-proxy:3128"}})
+	proxyURL, err = transport.Proxy(req)
+	require.Nil(t, err)
+	assert.Equal(t, "domain-proxy:3128", proxyURL.Host)
+
+	// proxy bypassed
+	transport = mustCreateDomainTransport(t, config.DomainTransport{NoProxy: true})
+	assert.Nil(t, transport.Proxy)
+}
+
+func TestDomainRoundTripper(t *testing.T) {
+	var defaultHits, domainHits int
+	defaultTransport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		defaultHits++
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	domainTransport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		domainHits++
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+
+	key, err := domainKey("https://Relay.example.com/")
+	require.Nil(t, err)
+	rt := &domainRoundTripper{
+		defaultTransport: defaultTransport,
+		transports:       map[string]http.RoundTripper{key: domainTransport},
+	}
+
+	req, _ := http.NewRequest("POST", "https://relay.example.com/api/v1/series?api_key=foo", nil)
+	rt.RoundTrip(req)
+	req, _ = http.NewRequest("POST", "https://app.datadoghq.com/api/v1/series?api_key=foo", nil)
+	rt.RoundTrip(req)
+	req, _ = http.NewRequest("POST", "http://relay.example.com/api/v1/series?api_key=foo", nil)
+	rt.RoundTrip(req)
+
+	assert.Equal(t, 1, domainHits)
+	assert.Equal(t, 2, defaultHits)
+}
+
+func TestNewHTTPTransportReportsInvalidDomains(t *testing.T) {
+	config.Datadog.Set("forwarder_domains", map[string]interface{}{
+		"https://valid.example.com":   map[string]interface{}{"tls_min_version": "tlsv1.2"},
+		"https://invalid.example.com": map[string]interface{}{"tls_min_version": "sslv3"},
+	})
+	defer config.Datadog.Set("forwarder_domains", nil)
+
+	transport, errs := newHTTPTransport()
+	assert.Len(t, errs, 1)
+	rt, ok := transport.(*domainRoundTripper)
+	require.True(t, ok)
+	assert.Len(t, rt.transports, 1)
+	assert.Contains(t, rt.transports, "https://valid.example.com")
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func mustCreateDomainTransport(t *testing.T, settings config.DomainTransport) *http.Transport {
+	transport, err := createDomainTransport(settings)
+	require.Nil(t, err)
+	return transport
+}
@@ -9,12 +9,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
-	"github.com/DataDog/datadog-agent/pkg/util"
 )
 
 // Worker comsumes Transaction (aka transactions) from the Forwarder and
@@ -31,14 +31,16 @@ type Worker struct {
 	RequeueChan chan<- Transaction
 
 	stopChan    chan bool
+	drainChan   chan bool
+	stopped     chan struct{}
+	draining    uint32
 	blockedList *blockedEndpoints
 }
 
 // NewWorker returns a new worker to consume Transaction from inputChan
-// and push back erroneous ones into requeueChan.
-func NewWorker(highPrioChan <-chan Transaction, lowPrioChan <-chan Transaction, requeueChan chan<- Transaction, blocked *blockedEndpoints) *Worker {
-	transport := util.CreateHTTPTransport()
-
+// and push back erroneous ones into requeueChan. The transport is shared by
+// all the workers of a forwarder.
+func NewWorker(highPrioChan <-chan Transaction, lowPrioChan <-chan Transaction, requeueChan chan<- Transaction, blocked *blockedEndpoints, transport http.RoundTripper) *Worker {
 	httpClient := &http.Client{
 		Timeout:   config.Datadog.GetDuration("forwarder_timeout") * time.Second,
 		Transport: transport,
@@ -49,6 +51,8 @@ func NewWorker(highPrioChan <-chan Transaction, lowPrioChan <-chan Transaction,
 		LowPrio:     lowPrioChan,
 		RequeueChan: requeueChan,
 		stopChan:    make(chan bool),
+		drainChan:   make(chan bool, 1),
+		stopped:     make(chan struct{}),
 		Client:      httpClient,
 		blockedList: blocked,
 	}
@@ -56,12 +60,37 @@ func NewWorker(highPrioChan <-chan Transaction, lowPrioChan <-chan Transaction,
 
 // Stop stops the worker.
 func (w *Worker) Stop() {
-	w.stopChan <- true
+	select {
+	case w.stopChan <- true:
+	case <-w.stopped:
+		// the worker already stopped after draining its channels
+	}
+}
+
+// beginDrain makes the transactions processed from now on get their last
+// attempt, even for blocked endpoints. The worker keeps running until Drain.
+func (w *Worker) beginDrain() {
+	atomic.StoreUint32(&w.draining, 1)
+}
+
+// Drain makes the worker process the transactions left in its channels and
+// stop once they are empty. The returned channel is closed when the worker
+// is stopped.
+func (w *Worker) Drain() <-chan struct{} {
+	w.beginDrain()
+	select {
+	case w.drainChan <- true:
+	default:
+		// a drain is already requested
+	}
+	return w.stopped
 }
 
 // Start starts a Worker.
 func (w *Worker) Start() {
 	go func() {
+		defer close(w.stopped)
+
 		for {
 			// handling high priority transactions first
 			select {
@@ -84,6 +113,9 @@ func (w *Worker) Start() {
 				if w.callProcess(t) != nil {
 					return
 				}
+			case <-w.drainChan:
+				w.drain()
+				return
 			case <-w.stopChan:
 				return
 			}
@@ -91,14 +123,41 @@ func (w *Worker) Start() {
 	}()
 }
 
+// drain processes the transactions left in the channels, it returns once they
+// are empty or if the worker is requested to stop.
+func (w *Worker) drain() {
+	for {
+		select {
+		case t := <-w.HighPrio:
+			if w.callProcessDraining(t, true) != nil {
+				return
+			}
+		case t := <-w.LowPrio:
+			if w.callProcessDraining(t, true) != nil {
+				return
+			}
+		case <-w.stopChan:
+			return
+		default:
+			return
+		}
+	}
+}
+
 // callProcess will process a transaction and cancel it if we need to stop the
 // worker.
 func (w *Worker) callProcess(t Transaction) error {
+	return w.callProcessDraining(t, atomic.LoadUint32(&w.draining) == 1)
+}
+
+// callProcessDraining is callProcess, the transactions for blocked endpoints
+// being sent anyway while draining since it's their last attempt.
+func (w *Worker) callProcessDraining(t Transaction, draining bool) error {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	done := make(chan interface{})
 	go func() {
-		w.process(ctx, t)
+		w.process(ctx, t, draining)
 		done <- nil
 	}()
 
@@ -114,7 +173,7 @@ func (w *Worker) callProcess(t Transaction) error {
 	return nil
 }
 
-func (w *Worker) process(ctx context.Context, t Transaction) {
+func (w *Worker) process(ctx context.Context, t Transaction, draining bool) {
 	requeue := func() {
 		t.Reschedule()
 		select {
@@ -126,7 +185,7 @@ func (w *Worker) process(ctx context.Context, t Transaction) {
 
 	// First we check if we don't have recently received an error for that endpoint
 	target := t.GetTarget()
-	if w.blockedList.isBlock(target) {
+	if !draining && w.blockedList.isBlock(target) {
 		requeue()
 		log.Errorf("Too many errors for endpoint '%s': retrying later", target)
 	} else if err := t.Process(ctx, w.Client); err != nil {
@@ -6,6 +6,7 @@
 package forwarder
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestNewWorker(t *testing.T) {
@@ -20,7 +22,7 @@ func TestNewWorker(t *testing.T) {
 	lowPrio := make(chan Transaction)
 	requeue := make(chan Transaction)
 
-	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints())
+	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints(), http.DefaultTransport)
 	assert.NotNil(t, w)
 	assert.Equal(t, w.Client.Timeout, config.Datadog.GetDuration("forwarder_timeout")*time.Second)
 }
@@ -33,7 +35,9 @@ func TestNewNoSSLWorker(t *testing.T) {
 	config.Datadog.Set("skip_ssl_validation", true)
 	defer config.Datadog.Set("skip_ssl_validation", false)
 
-	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints())
+	transport, errs := newHTTPTransport()
+	assert.Empty(t, errs)
+	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints(), transport)
 	assert.True(t, w.Client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify)
 }
 
@@ -41,7 +45,7 @@ func TestWorkerStart(t *testing.T) {
 	highPrio := make(chan Transaction)
 	lowPrio := make(chan Transaction)
 	requeue := make(chan Transaction, 1)
-	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints())
+	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints(), http.DefaultTransport)
 
 	mock := newTestTransaction()
 	mock.On("Process", w.Client).Return(nil).Times(1)
@@ -78,7 +82,7 @@ func TestWorkerRetry(t *testing.T) {
 	highPrio := make(chan Transaction)
 	lowPrio := make(chan Transaction)
 	requeue := make(chan Transaction, 1)
-	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints())
+	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints(), http.DefaultTransport)
 
 	mock := newTestTransaction()
 	mock.On("Process", w.Client).Return(fmt.Errorf("some kind of error")).Times(1)
@@ -101,7 +105,7 @@ func TestWorkerRetryBlockedTransaction(t *testing.T) {
 	highPrio := make(chan Transaction)
 	lowPrio := make(chan Transaction)
 	requeue := make(chan Transaction, 1)
-	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints())
+	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints(), http.DefaultTransport)
 
 	mock := newTestTransaction()
 	mock.On("Reschedule").Return(nil).Times(1)
@@ -119,3 +123,79 @@ func TestWorkerRetryBlockedTransaction(t *testing.T) {
 	assert.Equal(t, mock, retryTransaction)
 	assert.True(t, w.blockedList.isBlock("error_url"))
 }
+
+func TestWorkerDrain(t *testing.T) {
+	highPrio := make(chan Transaction, 1)
+	lowPrio := make(chan Transaction, 1)
+	requeue := make(chan Transaction, 1)
+	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints(), http.DefaultTransport)
+
+	mock := newTestTransaction()
+	mock.On("Process", w.Client).Return(nil).Times(1)
+	mock.On("GetTarget").Return("").Times(1)
+	mock2 := newTestTransaction()
+	mock2.On("Process", w.Client).Return(nil).Times(1)
+	mock2.On("GetTarget").Return("").Times(1)
+
+	highPrio <- mock
+	lowPrio <- mock2
+	w.Start()
+
+	select {
+	case <-w.Drain():
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "worker not stopped after draining")
+	}
+	mock.AssertExpectations(t)
+	mock2.AssertExpectations(t)
+
+	// stopping a drained worker doesn't block
+	w.Stop()
+}
+
+func TestWorkerBeginDrain(t *testing.T) {
+	highPrio := make(chan Transaction)
+	lowPrio := make(chan Transaction)
+	requeue := make(chan Transaction, 1)
+	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints(), http.DefaultTransport)
+
+	processed := make(chan time.Time)
+	transaction := newTestTransaction()
+	transaction.On("Process", w.Client).Return(nil).Times(1).Run(func(mock.Arguments) { close(processed) })
+	transaction.On("GetTarget").Return("error_url").Times(1)
+
+	// a transaction picked after beginDrain gets its last attempt even if
+	// the worker didn't get the drain signal yet
+	w.blockedList.block("error_url")
+	w.beginDrain()
+	w.Start()
+	lowPrio <- transaction
+	select {
+	case <-processed:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "transaction not processed")
+	}
+	<-w.Drain()
+	transaction.AssertExpectations(t)
+	transaction.AssertNumberOfCalls(t, "Reschedule", 0)
+	assert.Len(t, requeue, 0)
+}
+
+func TestWorkerDrainBlockedTransaction(t *testing.T) {
+	highPrio := make(chan Transaction)
+	lowPrio := make(chan Transaction)
+	requeue := make(chan Transaction, 1)
+	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints(), http.DefaultTransport)
+
+	mock := newTestTransaction()
+	mock.On("Process", w.Client).Return(nil).Times(1)
+	mock.On("GetTarget").Return("error_url").Times(1)
+
+	// the transactions get a last attempt while draining, even for blocked endpoints
+	w.blockedList.block("error_url")
+	w.process(context.Background(), mock, true)
+	mock.AssertExpectations(t)
+	mock.AssertNumberOfCalls(t, "Reschedule", 0)
+	assert.Len(t, requeue, 0)
+	assert.False(t, w.blockedList.isBlock("error_url"))
+}
@@ -44,6 +44,8 @@ type Auditor struct {
 	cleanupTicker *time.Ticker
 	cleanupPeriod time.Duration
 	entryTTL      time.Duration
+
+	done chan struct{}
 }
 
 // New returns an initialized Auditor
@@ -56,6 +58,8 @@ func New(inputChan chan message.Message) *Auditor {
 		flushPeriod:   defaultFlushPeriod,
 		cleanupPeriod: defaultCleanupPeriod,
 		entryTTL:      defaultTTL,
+
+		done: make(chan struct{}),
 	}
 }
 
@@ -68,9 +72,17 @@ func (a *Auditor) Start() {
 	go a.cleanupRegistryPeriodically()
 }
 
+// Stop stops the periodic flushes of the registry and persists it a last
+// time, the offsets committed afterwards won't be saved
+func (a *Auditor) Stop() error {
+	close(a.done)
+	return a.flushRegistry(a.registry, a.registryPath)
+}
+
 // flushRegistryPediodically periodically saves the registry in its current state
 func (a *Auditor) flushRegistryPediodically() {
 	a.flushTicker = time.NewTicker(a.flushPeriod)
+	defer a.flushTicker.Stop()
 	for {
 		select {
 		case <-a.flushTicker.C:
@@ -78,6 +90,8 @@ func (a *Auditor) flushRegistryPediodically() {
 			if err != nil {
 				log.Warn(err)
 			}
+		case <-a.done:
+			return
 		}
 	}
 }
@@ -85,10 +99,13 @@ func (a *Auditor) flushRegistryPediodically() {
 // cleanupRegistryPeriodically periodically removes from the registry expired offsets
 func (a *Auditor) cleanupRegistryPeriodically() {
 	a.cleanupTicker = time.NewTicker(a.cleanupPeriod)
+	defer a.cleanupTicker.Stop()
 	for {
 		select {
 		case <-a.cleanupTicker.C:
 			a.cleanupRegistry(a.registry)
+		case <-a.done:
+			return
 		}
 	}
 }
@@ -96,6 +113,10 @@ func (a *Auditor) cleanupRegistryPeriodically() {
 // run lets the auditor update the registry
 func (a *Auditor) run() {
 	for msg := range a.inputChan {
+		if drain, isDrain := msg.(*message.DrainMessage); isDrain {
+			drain.Ack()
+			continue
+		}
 		// An empty Identifier means that we don't want to track down the offset
 		// This is useful for origins that don't have offsets (networks), or when we
 		// specially want to avoid storing the offset
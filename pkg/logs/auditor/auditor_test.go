@@ -63,6 +63,35 @@ func (suite *AuditorTestSuite) TestAuditorUpdatesRegistry() {
 	suite.Equal(ts, suite.a.registry["containerid"].Timestamp)
 }
 
+func (suite *AuditorTestSuite) TestAuditorStopPersistsRegistry() {
+	suite.a.Start()
+	suite.a.updateRegistry(suite.source.Config.Path, 42, "")
+	suite.Nil(suite.a.Stop())
+
+	r := suite.a.recoverRegistry(suite.testPath)
+	suite.Equal(int64(42), r[suite.source.Config.Path].Offset)
+}
+
+func (suite *AuditorTestSuite) TestAuditorAcksDrainMessage() {
+	suite.a.Start()
+	defer suite.a.Stop()
+
+	msg := message.NewFileMessage(nil)
+	msg.SetOrigin(&message.Origin{Identifier: suite.source.Config.Path, Offset: 42})
+	drain := message.NewDrainMessage()
+	suite.inputChan <- msg
+	suite.inputChan <- drain
+
+	select {
+	case <-drain.Done():
+	case <-time.After(time.Second):
+		suite.Fail("drain message not acknowledged")
+	}
+	suite.a.registryMutex.Lock()
+	suite.Equal(int64(42), suite.a.registry[suite.source.Config.Path].Offset)
+	suite.a.registryMutex.Unlock()
+}
+
 func (suite *AuditorTestSuite) TestAuditorFlushesAndRecoversRegistry() {
 	suite.a.registry = make(map[string]*RegistryEntry)
 	suite.a.registry[suite.source.Config.Path] = &RegistryEntry{
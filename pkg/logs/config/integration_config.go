@@ -23,6 +23,13 @@ const (
 	UDPType    = "udp"
 	FileType   = "file"
 	DockerType = "docker"
+	// SNMPTrapsType is the source of the traps received by the SNMP traps server
+	SNMPTrapsType = "snmp_traps"
+)
+
+// Logs source formats
+const (
+	SyslogFormat = "syslog"
 )
 
 // Logs rule types
@@ -57,8 +64,9 @@ type LogsProcessingRule struct {
 type LogsConfig struct {
 	Type string
 
-	Port int    // Network
-	Path string // File
+	Port   int    // Network
+	Format string // Network
+	Path   string // File
 
 	Image string // Docker
 	Label string // Docker
@@ -189,7 +197,8 @@ func validateConfig(config LogsConfig) error {
 	case FileType,
 		DockerType,
 		TCPType,
-		UDPType:
+		UDPType,
+		SNMPTrapsType:
 	default:
 		return fmt.Errorf("A source must have a valid type (got %s)", config.Type)
 	}
@@ -206,6 +215,16 @@ func validateConfig(config LogsConfig) error {
 		return fmt.Errorf("A udp source must have a port")
 	}
 
+	switch config.Format {
+	case "":
+	case SyslogFormat:
+		if config.Type != TCPType && config.Type != UDPType {
+			return fmt.Errorf("Only tcp and udp sources can have the %s format", config.Format)
+		}
+	default:
+		return fmt.Errorf("A source must have a valid format (got %s)", config.Format)
+	}
+
 	return nil
 }
 
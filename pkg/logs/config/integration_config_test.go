@@ -42,6 +42,7 @@ func TestBuildLogsAgentIntegrationsConfigs(t *testing.T) {
 
 	assert.Equal(t, "tcp", sources[1].Config.Type)
 	assert.Equal(t, 10514, sources[1].Config.Port)
+	assert.Equal(t, "syslog", sources[1].Config.Format)
 	assert.Equal(t, "devteam", sources[1].Config.Logset)
 	assert.Equal(t, "", sources[1].Config.Service)
 	assert.Equal(t, "", sources[1].Config.Source)
@@ -101,6 +102,10 @@ func TestBuildLogsAgentIntegrationConfigsWithMisconfiguredFile(t *testing.T) {
 	ddconfdPath = filepath.Join(testsPath, "misconfigured_5", "conf.d")
 	_, err = buildLogSources(ddconfdPath)
 	assert.NotNil(t, err)
+
+	ddconfdPath = filepath.Join(testsPath, "misconfigured_6", "conf.d")
+	_, err = buildLogSources(ddconfdPath)
+	assert.NotNil(t, err)
 }
 
 func TestBuildTagsPayload(t *testing.T) {
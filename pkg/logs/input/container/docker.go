@@ -8,7 +8,6 @@
 package container
 
 import (
-	"context"
 	"fmt"
 	"io"
 	"reflect"
@@ -16,6 +15,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/tagger"
+	containerutil "github.com/DataDog/datadog-agent/pkg/util/container"
 	dockerutil "github.com/DataDog/datadog-agent/pkg/util/docker"
 	log "github.com/cihub/seelog"
 
@@ -24,23 +24,20 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/decoder"
 	parser "github.com/DataDog/datadog-agent/pkg/logs/docker"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
-
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 )
 
 const defaultSleepDuration = 1 * time.Second
 const tagsUpdatePeriod = 10 * time.Second
 
-// DockerTailer tails logs coming from stdout and stderr of a docker container
-// With docker api, there is no way to know if a log comes from strout or stderr
-// so if we want to capture the severity, we need to tail both in two goroutines
+// DockerTailer tails logs coming from stdout and stderr of a container,
+// streamed by its runtime in the multiplexed format of the docker api
 type DockerTailer struct {
 	ContainerID   string
+	entityID      string
 	outputChan    chan message.Message
 	d             *decoder.Decoder
 	reader        io.ReadCloser
-	cli           *client.Client
+	runtime       containerutil.Runtime
 	source        *config.LogSource
 	containerTags []string
 	tagsPayload   []byte
@@ -50,13 +47,14 @@ type DockerTailer struct {
 }
 
 // NewDockerTailer returns a new DockerTailer
-func NewDockerTailer(cli *client.Client, container types.Container, source *config.LogSource, outputChan chan message.Message) *DockerTailer {
+func NewDockerTailer(runtime containerutil.Runtime, container *dockerutil.Container, source *config.LogSource, outputChan chan message.Message) *DockerTailer {
 	return &DockerTailer{
 		ContainerID: container.ID,
+		entityID:    container.EntityID,
 		outputChan:  outputChan,
 		d:           decoder.InitializeDecoder(source),
 		source:      source,
-		cli:         cli,
+		runtime:     runtime,
 
 		sleepDuration: defaultSleepDuration,
 	}
@@ -116,18 +114,10 @@ func (dt *DockerTailer) tailFrom(from string) error {
 	return dt.startReading(from)
 }
 
-// startReading starts the reader that reads the container's stdout,
-// with proper configuration
+// startReading starts the reader that reads the container's stdout and
+// stderr
 func (dt *DockerTailer) startReading(from string) error {
-	options := types.ContainerLogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-		Follow:     true,
-		Timestamps: true,
-		Details:    false,
-		Since:      from,
-	}
-	reader, err := dt.cli.ContainerLogs(context.Background(), dt.ContainerID, options)
+	reader, err := dt.runtime.ContainerLogs(dt.ContainerID, from)
 	if err != nil {
 		dt.source.Status.Error(err)
 		return err
@@ -213,7 +203,7 @@ func (dt *DockerTailer) keepDockerTagsUpdated() {
 }
 
 func (dt *DockerTailer) checkForNewDockerTags() {
-	tags, err := tagger.Tag(dockerutil.ContainerIDToEntityName(dt.ContainerID), true)
+	tags, err := tagger.Tag(dt.entityID, true)
 	if err != nil {
 		log.Warn(err)
 	} else {
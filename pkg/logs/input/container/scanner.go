@@ -8,32 +8,32 @@
 package container
 
 import (
-	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/tagger"
+	containerutil "github.com/DataDog/datadog-agent/pkg/util/container"
+	dockerutil "github.com/DataDog/datadog-agent/pkg/util/docker"
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 )
 
 const scanPeriod = 10 * time.Second
-const dockerAPIVersion = "1.25"
 
-// A Scanner listens for stdout and stderr of containers
+// A Scanner listens for stdout and stderr of the containers of the runtime
+// set by the container_runtime option
 type Scanner struct {
 	pp      pipeline.Provider
 	sources []*config.LogSource
 	tailers map[string]*DockerTailer
-	cli     *client.Client
+	runtime containerutil.Runtime
 	auditor *auditor.Auditor
+	stop    chan struct{}
 }
 
 // New returns an initialized Scanner
@@ -53,6 +53,7 @@ func New(sources []*config.LogSource, pp pipeline.Provider, a *auditor.Auditor)
 		sources: containerSources,
 		tailers: make(map[string]*DockerTailer),
 		auditor: a,
+		stop:    make(chan struct{}, 1),
 	}
 }
 
@@ -64,11 +65,17 @@ func (s *Scanner) Start() {
 	}
 }
 
-// run lets the Scanner tail docker stdouts
+// run lets the Scanner tail container stdouts
 func (s *Scanner) run() {
 	ticker := time.NewTicker(scanPeriod)
-	for range ticker.C {
-		s.scan(true)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.scan(true)
+		case <-s.stop:
+			return
+		}
 	}
 }
 
@@ -91,7 +98,7 @@ func (s *Scanner) scan(tailFromBeginning bool) {
 					isTailed = false
 				}
 				if !isTailed {
-					s.setupTailer(s.cli, container, source, tailFromBeginning, s.pp.NextPipelineChan())
+					s.setupTailer(container, source, tailFromBeginning, s.pp.NextPipelineChan())
 				}
 			}
 		}
@@ -112,12 +119,12 @@ func (s *Scanner) stopTailer(tailer *DockerTailer) {
 	delete(s.tailers, tailer.ContainerID)
 }
 
-func (s *Scanner) listContainers() []types.Container {
-	containers, err := s.cli.ContainerList(context.Background(), types.ContainerListOptions{})
+func (s *Scanner) listContainers() []*dockerutil.Container {
+	containers, err := s.runtime.ListContainers(&dockerutil.ContainerListConfig{FlagExcluded: true})
 	if err != nil {
 		log.Error("Can't tail containers, ", err)
 		log.Error("Is datadog-agent part of docker user group?")
-		return []types.Container{}
+		return []*dockerutil.Container{}
 	}
 	return containers
 }
@@ -126,7 +133,7 @@ func (s *Scanner) listContainers() []types.Container {
 // Both image and label may be used:
 // - If the source defines an image, the container must match it exactly.
 // - If the source defines one or several labels, at least one of them must match the labels of the container.
-func (s *Scanner) sourceShouldMonitorContainer(source *config.LogSource, container types.Container) bool {
+func (s *Scanner) sourceShouldMonitorContainer(source *config.LogSource, container *dockerutil.Container) bool {
 	if source.Config.Image != "" && container.Image != source.Config.Image {
 		return false
 	}
@@ -157,14 +164,12 @@ func (s *Scanner) setup() error {
 
 	// List available containers
 
-	cli, err := client.NewEnvClient()
-	// Docker's api updates quickly and is pretty unstable, best pinpoint it
-	cli.UpdateClientVersion(dockerAPIVersion)
-	s.cli = cli
+	rt, err := containerutil.GetRuntime()
 	if err != nil {
 		log.Error("Can't tail containers,", err)
 		return fmt.Errorf("Can't initialize client")
 	}
+	s.runtime = rt
 
 	// Initialize docker utils
 	err = tagger.Init()
@@ -178,9 +183,9 @@ func (s *Scanner) setup() error {
 }
 
 // setupTailer sets one tailer, making it tail from the beginning or the end
-func (s *Scanner) setupTailer(cli *client.Client, container types.Container, source *config.LogSource, tailFromBeginning bool, outputChan chan message.Message) {
+func (s *Scanner) setupTailer(container *dockerutil.Container, source *config.LogSource, tailFromBeginning bool, outputChan chan message.Message) {
 	log.Info("Detected container ", container.Image, " - ", s.humanReadableContainerID(container.ID))
-	t := NewDockerTailer(cli, container, source, outputChan)
+	t := NewDockerTailer(s.runtime, container, source, outputChan)
 	var err error
 	if tailFromBeginning {
 		err = t.tailFromBeginning()
@@ -195,6 +200,10 @@ func (s *Scanner) setupTailer(cli *client.Client, container types.Container, sou
 
 // Stop stops the Scanner and its tailers
 func (s *Scanner) Stop() {
+	select {
+	case s.stop <- struct{}{}:
+	default:
+	}
 	for _, t := range s.tailers {
 		t.Stop()
 	}
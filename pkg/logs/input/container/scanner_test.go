@@ -11,8 +11,8 @@ import (
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
 
-	"github.com/docker/docker/api/types"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -27,18 +27,18 @@ func (suite *ContainerScannerTestSuite) SetupTest() {
 
 func (suite *ContainerScannerTestSuite) TestContainerScannerFilter() {
 	cfg := config.NewLogSource("", &config.LogsConfig{Type: config.DockerType, Image: "myapp"})
-	container := types.Container{Image: "myapp"}
+	container := &docker.Container{Image: "myapp"}
 	suite.True(suite.c.sourceShouldMonitorContainer(cfg, container))
-	container = types.Container{Image: "myapp2"}
+	container = &docker.Container{Image: "myapp2"}
 	suite.False(suite.c.sourceShouldMonitorContainer(cfg, container))
 
 	cfg = config.NewLogSource("", &config.LogsConfig{Type: config.DockerType, Image: "myapp", Label: "mylabel"})
 	l1 := make(map[string]string)
 	l2 := make(map[string]string)
 	l2["mylabel"] = "anything"
-	container = types.Container{Image: "myapp", Labels: l1}
+	container = &docker.Container{Image: "myapp", Labels: l1}
 	suite.False(suite.c.sourceShouldMonitorContainer(cfg, container))
-	container = types.Container{Image: "myapp", Labels: l2}
+	container = &docker.Container{Image: "myapp", Labels: l2}
 	suite.True(suite.c.sourceShouldMonitorContainer(cfg, container))
 
 	cfg = config.NewLogSource("", &config.LogsConfig{Type: config.DockerType})
@@ -72,7 +72,7 @@ func (suite *ContainerScannerTestSuite) TestContainerLabelFilter() {
 
 func (suite *ContainerScannerTestSuite) shouldMonitor(configLabel string, containerLabels map[string]string) bool {
 	cfg := config.NewLogSource("", &config.LogsConfig{Type: config.DockerType, Label: configLabel})
-	container := types.Container{Labels: containerLabels}
+	container := &docker.Container{Labels: containerLabels}
 	return suite.c.sourceShouldMonitorContainer(cfg, container)
 }
 
@@ -15,6 +15,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/decoder"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/syslog"
 )
 
 // ConnectionHandler reads bytes from new connections, passes them to a decoder and
@@ -31,14 +32,39 @@ func (connHandler *ConnectionHandler) forwardMessages(d *decoder.Decoder, output
 			return
 		}
 
-		netMsg := message.NewNetworkMessage(output.Content)
 		o := message.NewOrigin()
 		o.LogSource = connHandler.source
+		if connHandler.source.Config.Format == config.SyslogFormat {
+			outputChan <- connHandler.newSyslogMessage(output.Content, o)
+			continue
+		}
+		netMsg := message.NewNetworkMessage(output.Content)
 		netMsg.SetOrigin(o)
 		outputChan <- netMsg
 	}
 }
 
+// newSyslogMessage returns a message whose severity, timestamp and host are
+// the ones of the syslog header, the messages that can't be parsed being
+// forwarded as is
+func (connHandler *ConnectionHandler) newSyslogMessage(content []byte, o *message.Origin) message.Message {
+	parsed, err := syslog.ParseMessage(content)
+	if err != nil {
+		log.Debug(err)
+		netMsg := message.NewNetworkMessage(content)
+		netMsg.SetOrigin(o)
+		return netMsg
+	}
+
+	netMsg := message.NewNetworkMessage(parsed.Content)
+	o.Timestamp = parsed.Timestamp
+	o.Hostname = parsed.Hostname
+	o.Service = parsed.AppName
+	netMsg.SetOrigin(o)
+	netMsg.SetSeverity(parsed.Severity)
+	return netMsg
+}
+
 // handleConnection reads bytes from a connection and passes them to a decoder
 func (connHandler *ConnectionHandler) handleConnection(conn net.Conn) {
 	d := decoder.InitializeDecoder(connHandler.source)
@@ -44,6 +44,8 @@ func (l *Listener) Start() {
 			} else {
 				udpl.Start()
 			}
+		case config.SNMPTrapsType:
+			NewTrapsListener(l.pp, source).Start()
 		default:
 		}
 	}
@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listener
+
+import (
+	"errors"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/snmp/traps"
+)
+
+// A TrapsListener forwards the SNMP traps received by the traps server
+type TrapsListener struct {
+	pp        pipeline.Provider
+	source    *config.LogSource
+	inputChan <-chan []byte
+}
+
+// NewTrapsListener returns an initialized TrapsListener
+func NewTrapsListener(pp pipeline.Provider, source *config.LogSource) *TrapsListener {
+	if traps.LogsEnabled() {
+		source.Status.Success()
+	} else {
+		err := errors.New("the SNMP traps are not forwarded as logs, snmp_traps_enabled must be true and snmp_traps_output must be logs")
+		source.Status.Error(err)
+		log.Warn(err)
+	}
+	return newTrapsListener(pp, source, traps.LogsChannel())
+}
+
+func newTrapsListener(pp pipeline.Provider, source *config.LogSource, inputChan <-chan []byte) *TrapsListener {
+	return &TrapsListener{
+		pp:        pp,
+		source:    source,
+		inputChan: inputChan,
+	}
+}
+
+// Start forwards the traps on another routine
+func (l *TrapsListener) Start() {
+	go l.run()
+}
+
+// run forwards the traps, formatted as JSON by the traps server
+func (l *TrapsListener) run() {
+	outputChan := l.pp.NextPipelineChan()
+	for content := range l.inputChan {
+		msg := message.NewNetworkMessage(content)
+		o := message.NewOrigin()
+		o.LogSource = l.source
+		msg.SetOrigin(o)
+		outputChan <- msg
+	}
+}
@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listener
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+)
+
+func TestTrapsListenerForwardsTraps(t *testing.T) {
+	pp := mock.NewMockProvider()
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.SNMPTrapsType})
+	inputChan := make(chan []byte, 1)
+	l := newTrapsListener(pp, source, inputChan)
+	l.Start()
+
+	inputChan <- []byte(`{"trap":"linkDown"}`)
+	msg := <-pp.NextPipelineChan()
+	assert.Equal(t, `{"trap":"linkDown"}`, string(msg.Content()))
+	assert.Equal(t, source, msg.GetOrigin().LogSource)
+	close(inputChan)
+}
@@ -14,10 +14,14 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
-const udpTestPort = 10513
+const (
+	udpTestPort       = 10513
+	udpSyslogTestPort = 10515
+)
 
 type UDPTestSuite struct {
 	suite.Suite
@@ -49,3 +53,27 @@ func (suite *UDPTestSuite) TestUDPReceivesMessages() {
 func TestUDPTestSuite(t *testing.T) {
 	suite.Run(t, new(UDPTestSuite))
 }
+
+func TestUDPParsesSyslogMessages(t *testing.T) {
+	pp := mock.NewMockProvider()
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.UDPType, Port: udpSyslogTestPort, Format: config.SyslogFormat})
+	udpl, err := NewUDPListener(pp, source)
+	assert.Nil(t, err)
+	udpl.Start()
+
+	conn, err := net.Dial("udp", fmt.Sprintf("localhost:%d", udpSyslogTestPort))
+	assert.Nil(t, err)
+	fmt.Fprintf(conn, "<34>1 2003-10-11T22:14:15.003Z router su - ID47 - 'su root' failed\n")
+	msg := <-pp.NextPipelineChan()
+	assert.Equal(t, "'su root' failed", string(msg.Content()))
+	assert.Equal(t, "<34>", string(msg.GetSeverity()))
+	assert.Equal(t, "2003-10-11T22:14:15.003000000Z", msg.GetTimestamp())
+	assert.Equal(t, "router", msg.GetOrigin().Hostname)
+	assert.Equal(t, "su", msg.GetOrigin().Service)
+
+	// the messages that aren't syslog are forwarded as is
+	fmt.Fprintf(conn, "hello world\n")
+	msg = <-pp.NextPipelineChan()
+	assert.Equal(t, "hello world", string(msg.Content()))
+	assert.Nil(t, msg.GetSeverity())
+}
@@ -27,6 +27,7 @@ type Scanner struct {
 	fileProvider *FileProvider
 	tailers      map[string]*Tailer
 	auditor      *auditor.Auditor
+	stop         chan struct{}
 }
 
 // New returns an initialized Scanner
@@ -45,6 +46,7 @@ func New(sources []*config.LogSource, tailingLimit int, pp pipeline.Provider, au
 		fileProvider: NewFileProvider(tailSources, tailingLimit),
 		tailers:      make(map[string]*Tailer),
 		auditor:      auditor,
+		stop:         make(chan struct{}, 1),
 	}
 }
 
@@ -88,8 +90,14 @@ func (s *Scanner) Start() {
 // run lets the Scanner tail its file
 func (s *Scanner) run() {
 	ticker := time.NewTicker(scanPeriod)
-	for range ticker.C {
-		s.scan()
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.scan()
+		case <-s.stop:
+			return
+		}
 	}
 }
 
@@ -165,6 +173,10 @@ func (s *Scanner) stopTailer(tailer *Tailer) {
 
 // Stop stops the Scanner and its tailers
 func (s *Scanner) Stop() {
+	select {
+	case s.stop <- struct{}{}:
+	default:
+	}
 	shouldTrackOffset := true
 	for _, t := range s.tailers {
 		t.Stop(shouldTrackOffset)
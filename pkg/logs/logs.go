@@ -6,6 +6,11 @@
 package logs
 
 import (
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/container"
@@ -17,8 +22,17 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/status"
 )
 
-// isRunning indicates whether logs-agent is running or not
-var isRunning bool
+var (
+	// isRunning indicates whether logs-agent is running or not
+	isRunning bool
+
+	// m protects the components stopped by Stop, they are set up asynchronously
+	m                sync.Mutex
+	auditorInstance  *auditor.Auditor
+	pipelineProvider pipeline.Provider
+	fileScanner      *tailer.Scanner
+	containerScanner *container.Scanner
+)
 
 // Start starts logs-agent
 func Start() error {
@@ -32,6 +46,9 @@ func Start() error {
 
 // run sets up the pipeline to process logs and them to Datadog back-end
 func run() {
+	m.Lock()
+	defer m.Unlock()
+
 	isRunning = true
 
 	cm := sender.NewConnectionManager(
@@ -61,6 +78,36 @@ func run() {
 
 	status.Initialize(sources.GetSources())
 
+	auditorInstance = a
+	pipelineProvider = pp
+	fileScanner = s
+	containerScanner = c
+}
+
+// Stop stops logs-agent: files and containers stop being tailed, the lines
+// already read are sent until timeout expires, and the offsets of the lines
+// sent are persisted so that tailing resumes from there on the next start.
+// The network listeners have no offset to track and are not stopped.
+func Stop(timeout time.Duration) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if !isRunning {
+		return nil
+	}
+	isRunning = false
+
+	fileScanner.Stop()
+	containerScanner.Stop()
+
+	drainErr := pipelineProvider.Drain(timeout)
+	if drainErr != nil {
+		log.Warnf("Could not send all the logs before stopping: %s", drainErr)
+	}
+	if err := auditorInstance.Stop(); err != nil {
+		return err
+	}
+	return drainErr
 }
 
 // GetStatus returns logs-agent status
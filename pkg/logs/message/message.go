@@ -28,6 +28,11 @@ type Origin struct {
 	LogSource  *config.LogSource
 	Offset     int64
 	Timestamp  string
+	// Hostname overrides the hostname of the agent when the message comes
+	// from another host, e.g. a device sending syslog
+	Hostname string
+	// Service is used when the source has no service
+	Service string
 }
 
 type message struct {
@@ -116,6 +121,31 @@ func NewStopMessage() *StopMessage {
 	}
 }
 
+// DrainMessage is sent through a pipeline behind the messages to flush, it's
+// acknowledged by the auditor once the messages ahead of it are sent
+type DrainMessage struct {
+	*message
+	done chan struct{}
+}
+
+// NewDrainMessage returns a new DrainMessage
+func NewDrainMessage() *DrainMessage {
+	return &DrainMessage{
+		message: newMessage(nil),
+		done:    make(chan struct{}),
+	}
+}
+
+// Ack signals that the message reached the end of the pipeline
+func (m *DrainMessage) Ack() {
+	close(m.done)
+}
+
+// Done returns a channel closed once the message is acknowledged
+func (m *DrainMessage) Done() <-chan struct{} {
+	return m.done
+}
+
 // FileMessage is a message coming from a File
 type FileMessage struct {
 	*message
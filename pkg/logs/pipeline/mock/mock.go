@@ -6,6 +6,8 @@
 package mock
 
 import (
+	"time"
+
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
 	"github.com/DataDog/datadog-agent/pkg/logs/sender"
@@ -30,3 +32,8 @@ func (p *mockProvider) Start(cm *sender.ConnectionManager, auditorChan chan mess
 func (p *mockProvider) NextPipelineChan() chan message.Message {
 	return p.msgChan
 }
+
+// Drain does nothing
+func (p *mockProvider) Drain(timeout time.Duration) error {
+	return nil
+}
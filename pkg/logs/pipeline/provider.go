@@ -6,7 +6,9 @@
 package pipeline
 
 import (
+	"fmt"
 	"sync/atomic"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -18,6 +20,7 @@ import (
 type Provider interface {
 	Start(cm *sender.ConnectionManager, auditorChan chan message.Message)
 	NextPipelineChan() chan message.Message
+	Drain(timeout time.Duration) error
 }
 
 // provider implements providing logic
@@ -25,6 +28,7 @@ type provider struct {
 	numberOfPipelines int32
 	chanSizes         int
 	pipelinesChans    [](chan message.Message)
+	auditorChan       chan message.Message
 
 	currentChanIdx int32
 }
@@ -41,6 +45,7 @@ func NewProvider() Provider {
 
 // Start initializes the pipelines
 func (p *provider) Start(cm *sender.ConnectionManager, auditorChan chan message.Message) {
+	p.auditorChan = auditorChan
 
 	for i := int32(0); i < p.numberOfPipelines; i++ {
 
@@ -66,3 +71,32 @@ func (p *provider) NextPipelineChan() chan message.Message {
 	idx := atomic.AddInt32(&p.currentChanIdx, 1)
 	return p.pipelinesChans[idx%p.numberOfPipelines]
 }
+
+// Drain waits until the messages already in the pipelines are sent and
+// handled by the auditor, it returns an error with the number of pipelines
+// not drained if timeout expires first.
+func (p *provider) Drain(timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	// a drain message is pushed behind the pending messages of every pipeline,
+	// it's acknowledged once the sender wired them
+	var drains []*message.DrainMessage
+	for i, c := range p.pipelinesChans {
+		drain := message.NewDrainMessage()
+		select {
+		case c <- drain:
+			drains = append(drains, drain)
+		case <-timer.C:
+			return fmt.Errorf("%d pipeline(s) not drained", len(p.pipelinesChans)-i)
+		}
+	}
+	for i, drain := range drains {
+		select {
+		case <-drain.Done():
+		case <-timer.C:
+			return fmt.Errorf("%d pipeline(s) not drained", len(drains)-i)
+		}
+	}
+	return nil
+}
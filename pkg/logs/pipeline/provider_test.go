@@ -7,6 +7,7 @@ package pipeline
 
 import (
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/stretchr/testify/suite"
@@ -38,6 +39,31 @@ func (suite *ProviderTestSuite) TestProvider() {
 	suite.Equal(c, suite.p.NextPipelineChan())
 }
 
+func (suite *ProviderTestSuite) TestProviderDrain() {
+	suite.p.pipelinesChans = append(suite.p.pipelinesChans, make(chan message.Message, 2))
+	suite.p.pipelinesChans[0] <- message.NewFileMessage(nil)
+	suite.NotNil(suite.p.Drain(100 * time.Millisecond))
+
+	// the drain message is acknowledged once the messages ahead of it went
+	// through the pipeline
+	<-suite.p.pipelinesChans[0]
+	<-suite.p.pipelinesChans[0]
+	suite.p.pipelinesChans[0] <- message.NewFileMessage(nil)
+	handled := make(chan message.Message, 1)
+	go func() {
+		for msg := range suite.p.pipelinesChans[0] {
+			if drain, isDrain := msg.(*message.DrainMessage); isDrain {
+				drain.Ack()
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+			handled <- msg
+		}
+	}()
+	suite.Nil(suite.p.Drain(time.Second))
+	suite.Len(handled, 1)
+}
+
 func TestProviderTestSuite(t *testing.T) {
 	suite.Run(t, new(ProviderTestSuite))
 }
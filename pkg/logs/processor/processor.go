@@ -50,6 +50,10 @@ func (p *Processor) Start() {
 // run starts the processing of the inputChan
 func (p *Processor) run() {
 	for msg := range p.inputChan {
+		if _, isDrain := msg.(*message.DrainMessage); isDrain {
+			p.outputChan <- msg
+			continue
+		}
 		shouldProcess, redactedMessage := p.applyRedactingRules(msg)
 		if shouldProcess {
 			extraContent := p.computeExtraContent(msg)
@@ -93,16 +97,23 @@ func (p *Processor) computeExtraContent(msg message.Message) []byte {
 		extraContent = append(extraContent, ' ')
 
 		// Hostname
-		hostname, err := util.GetHostname()
-		if err != nil {
-			// this scenario is not likely to happen since the agent can not start without a hostname
-			hostname = "unknown"
+		hostname := msg.GetOrigin().Hostname
+		if hostname == "" {
+			var err error
+			hostname, err = util.GetHostname()
+			if err != nil {
+				// this scenario is not likely to happen since the agent can not start without a hostname
+				hostname = "unknown"
+			}
 		}
 		extraContent = append(extraContent, []byte(hostname)...)
 		extraContent = append(extraContent, ' ')
 
 		// Service
 		service := msg.GetOrigin().LogSource.Config.Service
+		if service == "" {
+			service = msg.GetOrigin().Service
+		}
 		if service != "" {
 			extraContent = append(extraContent, []byte(service)...)
 		} else {
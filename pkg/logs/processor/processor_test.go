@@ -49,6 +49,18 @@ func TestProcessor(t *testing.T) {
 	assert.Equal(t, "helloworld", string(p.apikeyString))
 }
 
+func TestProcessorForwardsDrainMessage(t *testing.T) {
+	inputChan := make(chan message.Message, 1)
+	outputChan := make(chan message.Message, 1)
+	New(inputChan, outputChan, "hello", "world").Start()
+	defer close(inputChan)
+
+	drain := message.NewDrainMessage()
+	inputChan <- drain
+	assert.Equal(t, drain, <-outputChan)
+	assert.Nil(t, drain.Content())
+}
+
 func TestExclusion(t *testing.T) {
 	p := NewTestProcessor()
 	var shouldProcess bool
@@ -198,6 +210,25 @@ func TestComputeExtraContent(t *testing.T) {
 	assert.Equal(t, "sev0", extraContentParts[0])
 	assert.Equal(t, "ts", extraContentParts[1])
 	assert.Equal(t, "tags", extraContentParts[6])
+
+	// message coming from another host
+	msg = newNetworkMessage([]byte("message"), source)
+	msg.GetOrigin().Hostname = "router"
+	msg.GetOrigin().Service = "sshd"
+
+	extraContent = p.computeExtraContent(msg)
+	extraContentParts = strings.Split(string(extraContent), " ")
+	assert.Equal(t, "router", extraContentParts[2])
+	assert.Equal(t, "sshd", extraContentParts[3])
+
+	// the service of the source wins over the one of the message
+	source = config.NewLogSource("", &config.LogsConfig{Service: "network", TagsPayload: []byte{'-'}})
+	msg = newNetworkMessage([]byte("message"), source)
+	msg.GetOrigin().Service = "sshd"
+
+	extraContent = p.computeExtraContent(msg)
+	extraContentParts = strings.Split(string(extraContent), " ")
+	assert.Equal(t, "network", extraContentParts[3])
 }
 
 func TestComputeApiKeyString(t *testing.T) {
@@ -37,6 +37,11 @@ func (s *Sender) Start() {
 // run lets the sender wire messages
 func (s *Sender) run() {
 	for payload := range s.inputChan {
+		if _, isDrain := payload.(*message.DrainMessage); isDrain {
+			// the messages ahead of it are sent, the auditor acknowledges it
+			s.outputChan <- payload
+			continue
+		}
 		s.wireMessage(payload)
 	}
 }
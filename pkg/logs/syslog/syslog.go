@@ -0,0 +1,221 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// nilValue stands for an empty header field in RFC5424 messages
+const nilValue = "-"
+
+// rfc3164TimestampLayout is the format of the RFC3164 timestamps, which
+// have neither year nor time zone
+const rfc3164TimestampLayout = "Jan _2 15:04:05"
+
+// For testing purpose
+var timeNow = time.Now
+
+// Message is a syslog message split into its header fields and its content
+type Message struct {
+	// Severity is the <PRI> part of the message, holding both the facility
+	// and the severity, as expected by the processor
+	Severity []byte
+	// Timestamp is formatted with config.DateFormat, empty when the
+	// message has no timestamp
+	Timestamp string
+	Hostname  string
+	AppName   string
+	Content   []byte
+}
+
+// ParseMessage parses a RFC5424 or RFC3164 syslog message, the format being
+// guessed from the version following the <PRI> part.
+// See https://tools.ietf.org/html/rfc5424 and https://tools.ietf.org/html/rfc3164
+func ParseMessage(msg []byte) (Message, error) {
+	pri, rest, err := parsePriority(msg)
+	if err != nil {
+		return Message{}, err
+	}
+	if len(rest) > 1 && rest[0] == '1' && rest[1] == ' ' {
+		return parseRFC5424(pri, rest[2:])
+	}
+	return parseRFC3164(pri, rest), nil
+}
+
+// parsePriority returns the <PRI> part of the message and what follows it
+func parsePriority(msg []byte) ([]byte, []byte, error) {
+	if len(msg) == 0 || msg[0] != '<' {
+		return nil, nil, errors.New("Can't parse syslog message: expected a <PRI> part")
+	}
+	end := bytes.IndexByte(msg, '>')
+	if end < 2 || end > 4 {
+		return nil, nil, errors.New("Can't parse syslog message: invalid <PRI> part")
+	}
+	value, err := strconv.Atoi(string(msg[1:end]))
+	if err != nil || value > 191 {
+		return nil, nil, fmt.Errorf("Can't parse syslog message: invalid priority %s", msg[1:end])
+	}
+	return msg[:end+1], msg[end+1:], nil
+}
+
+// parseRFC5424 parses the header following the version of a RFC5424 message:
+// TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func parseRFC5424(pri, msg []byte) (Message, error) {
+	fields := make([]string, 5)
+	for i := range fields {
+		field, rest, found := nextField(msg)
+		if !found {
+			return Message{}, errors.New("Can't parse syslog message: truncated RFC5424 header")
+		}
+		fields[i] = field
+		msg = rest
+	}
+
+	parsed := Message{Severity: pri}
+	if fields[0] != nilValue {
+		timestamp, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return Message{}, fmt.Errorf("Can't parse syslog message: invalid timestamp %s", fields[0])
+		}
+		parsed.Timestamp = timestamp.UTC().Format(config.DateFormat)
+	}
+	if fields[1] != nilValue {
+		parsed.Hostname = fields[1]
+	}
+	if fields[2] != nilValue {
+		parsed.AppName = fields[2]
+	}
+
+	structuredData, content, err := splitStructuredData(msg)
+	if err != nil {
+		return Message{}, err
+	}
+	// the message may start with an UTF-8 byte order mark
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	if structuredData != nilValue {
+		// the structured data is kept in front of the content, not to lose it
+		content = append([]byte(structuredData+" "), content...)
+	}
+	parsed.Content = content
+	return parsed, nil
+}
+
+// splitStructuredData returns the STRUCTURED-DATA field of a RFC5424 message,
+// a dash or a sequence of [SD-ID PARAM="VALUE" ...] elements, and the content
+// following it
+func splitStructuredData(msg []byte) (string, []byte, error) {
+	if len(msg) > 0 && msg[0] == '-' {
+		return nilValue, bytes.TrimPrefix(msg[1:], []byte{' '}), nil
+	}
+
+	inElement, inValue, escaped := false, false, false
+	for i, c := range msg {
+		switch {
+		case escaped:
+			escaped = false
+		case inValue && c == '\\':
+			escaped = true
+		case inElement && c == '"':
+			inValue = !inValue
+		case inValue:
+		case !inElement && c == '[':
+			inElement = true
+		case inElement && c == ']':
+			inElement = false
+		case !inElement && c == ' ':
+			return string(msg[:i]), msg[i+1:], nil
+		case !inElement:
+			return "", nil, errors.New("Can't parse syslog message: invalid structured data")
+		}
+	}
+	if inElement || len(msg) == 0 {
+		return "", nil, errors.New("Can't parse syslog message: invalid structured data")
+	}
+	return string(msg), nil, nil
+}
+
+// parseRFC3164 parses a BSD syslog message: TIMESTAMP HOSTNAME TAG: MSG.
+// The format being loosely followed by the devices, the header fields that
+// can't be found are left empty and the rest of the message is the content.
+func parseRFC3164(pri, msg []byte) Message {
+	parsed := Message{Severity: pri}
+
+	timestamp, rest, ok := parseRFC3164Timestamp(msg)
+	if !ok {
+		parsed.Content = msg
+		return parsed
+	}
+	parsed.Timestamp = timestamp.UTC().Format(config.DateFormat)
+	msg = rest
+
+	// the hostname is omitted by some devices, the tag coming first
+	if field, rest, found := nextField(msg); found && !isTag(field) {
+		parsed.Hostname = field
+		msg = rest
+	}
+
+	if field, rest, found := nextField(msg); found && isTag(field) {
+		parsed.AppName = field[:len(field)-1]
+		if i := strings.IndexByte(parsed.AppName, '['); i > 0 {
+			parsed.AppName = parsed.AppName[:i]
+		}
+		msg = rest
+	}
+	parsed.Content = msg
+	return parsed
+}
+
+// parseRFC3164Timestamp parses the timestamp at the start of the message,
+// in the local time zone and the current year. The timestamps
+// formatted as RFC3339 by some senders are accepted too.
+func parseRFC3164Timestamp(msg []byte) (time.Time, []byte, bool) {
+	if field, rest, found := nextField(msg); found {
+		if timestamp, err := time.Parse(time.RFC3339Nano, field); err == nil {
+			return timestamp, rest, true
+		}
+	}
+
+	layoutLength := len(rfc3164TimestampLayout)
+	if len(msg) <= layoutLength || msg[layoutLength] != ' ' {
+		return time.Time{}, nil, false
+	}
+	timestamp, err := time.Parse(rfc3164TimestampLayout, string(msg[:layoutLength]))
+	if err != nil {
+		return time.Time{}, nil, false
+	}
+
+	now := timeNow()
+	year := now.Year()
+	if timestamp.Month() == time.December && now.Month() == time.January {
+		// a message of December received in January
+		year--
+	}
+	timestamp = time.Date(year, timestamp.Month(), timestamp.Day(), timestamp.Hour(), timestamp.Minute(), timestamp.Second(), 0, time.Local)
+	return timestamp, msg[layoutLength+1:], true
+}
+
+// isTag returns whether the field is a RFC3164 tag, e.g. "sshd[42]:"
+func isTag(field string) bool {
+	return len(field) > 1 && field[len(field)-1] == ':'
+}
+
+// nextField returns the field at the start of the message, up to the next
+// space, and what follows the space
+func nextField(msg []byte) (string, []byte, bool) {
+	i := bytes.IndexByte(msg, ' ')
+	if i <= 0 {
+		return "", nil, false
+	}
+	return string(msg[:i]), msg[i+1:], true
+}
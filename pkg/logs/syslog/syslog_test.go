@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func TestParseRFC5424Message(t *testing.T) {
+	msg, err := ParseMessage([]byte("<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut=\"3\" eventSource=\"Application\"] \xef\xbb\xbfAn application event log entry"))
+	assert.Nil(t, err)
+	assert.Equal(t, "<165>", string(msg.Severity))
+	assert.Equal(t, "2003-10-11T22:14:15.003000000Z", msg.Timestamp)
+	assert.Equal(t, "mymachine.example.com", msg.Hostname)
+	assert.Equal(t, "evntslog", msg.AppName)
+	assert.Equal(t, "[exampleSDID@32473 iut=\"3\" eventSource=\"Application\"] An application event log entry", string(msg.Content))
+
+	msg, err = ParseMessage([]byte("<34>1 2003-10-11T22:14:15.003-07:00 mymachine.example.com su - ID47 - 'su root' failed for lonvick on /dev/pts/8"))
+	assert.Nil(t, err)
+	assert.Equal(t, "<34>", string(msg.Severity))
+	assert.Equal(t, "2003-10-12T05:14:15.003000000Z", msg.Timestamp)
+	assert.Equal(t, "su", msg.AppName)
+	assert.Equal(t, "'su root' failed for lonvick on /dev/pts/8", string(msg.Content))
+
+	// escaped characters and several elements in the structured data
+	msg, err = ParseMessage([]byte("<13>1 - - - - - [a x=\"\\] \\\"\"][b y=\"z\"] hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, "", msg.Timestamp)
+	assert.Equal(t, "", msg.Hostname)
+	assert.Equal(t, "", msg.AppName)
+	assert.Equal(t, "[a x=\"\\] \\\"\"][b y=\"z\"] hello", string(msg.Content))
+
+	// no message
+	msg, err = ParseMessage([]byte("<13>1 - host app - - -"))
+	assert.Nil(t, err)
+	assert.Equal(t, "host", msg.Hostname)
+	assert.Equal(t, "", string(msg.Content))
+}
+
+func TestParseRFC3164Message(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2018, time.January, 2, 0, 0, 0, 0, time.Local) }
+	defer func() { timeNow = time.Now }()
+
+	msg, err := ParseMessage([]byte("<34>Oct 11 22:14:15 mymachine su[42]: 'su root' failed for lonvick on /dev/pts/8"))
+	assert.Nil(t, err)
+	assert.Equal(t, "<34>", string(msg.Severity))
+	assert.Equal(t, time.Date(2018, time.October, 11, 22, 14, 15, 0, time.Local).UTC().Format(config.DateFormat), msg.Timestamp)
+	assert.Equal(t, "mymachine", msg.Hostname)
+	assert.Equal(t, "su", msg.AppName)
+	assert.Equal(t, "'su root' failed for lonvick on /dev/pts/8", string(msg.Content))
+
+	// a message of December received in January, the day being padded
+	msg, err = ParseMessage([]byte("<13>Dec  1 23:59:59 router link down"))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2017, time.December, 1, 23, 59, 59, 0, time.Local).UTC().Format(config.DateFormat), msg.Timestamp)
+	assert.Equal(t, "router", msg.Hostname)
+	assert.Equal(t, "", msg.AppName)
+	assert.Equal(t, "link down", string(msg.Content))
+
+	// no hostname
+	msg, err = ParseMessage([]byte("<13>Oct 11 22:14:15 sshd: connection closed"))
+	assert.Nil(t, err)
+	assert.Equal(t, "", msg.Hostname)
+	assert.Equal(t, "sshd", msg.AppName)
+	assert.Equal(t, "connection closed", string(msg.Content))
+
+	// RFC3339 timestamp
+	msg, err = ParseMessage([]byte("<13>2018-03-01T10:00:00+01:00 host app: hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, "2018-03-01T09:00:00.000000000Z", msg.Timestamp)
+	assert.Equal(t, "host", msg.Hostname)
+	assert.Equal(t, "app", msg.AppName)
+	assert.Equal(t, "hello", string(msg.Content))
+
+	// no timestamp, the whole message is the content
+	msg, err = ParseMessage([]byte("<189>123: *Mar  1 18:46:11: %SYS-5-CONFIG_I: Configured from console"))
+	assert.Nil(t, err)
+	assert.Equal(t, "<189>", string(msg.Severity))
+	assert.Equal(t, "", msg.Timestamp)
+	assert.Equal(t, "", msg.Hostname)
+	assert.Equal(t, "123: *Mar  1 18:46:11: %SYS-5-CONFIG_I: Configured from console", string(msg.Content))
+}
+
+func TestParseInvalidMessage(t *testing.T) {
+	var err error
+	_, err = ParseMessage([]byte("hello world"))
+	assert.NotNil(t, err)
+	_, err = ParseMessage([]byte("<>hello world"))
+	assert.NotNil(t, err)
+	_, err = ParseMessage([]byte("<192>hello world"))
+	assert.NotNil(t, err)
+	_, err = ParseMessage([]byte("<1x>hello world"))
+	assert.NotNil(t, err)
+	_, err = ParseMessage([]byte("<13>1 2003-10-11T22:14:15.003Z host"))
+	assert.NotNil(t, err)
+	_, err = ParseMessage([]byte("<13>1 yesterday host app - - - hello"))
+	assert.NotNil(t, err)
+	_, err = ParseMessage([]byte("<13>1 - host app - - [unclosed hello"))
+	assert.NotNil(t, err)
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/util/azure"
 	"github.com/DataDog/datadog-agent/pkg/util/cloudfoundry"
+	"github.com/DataDog/datadog-agent/pkg/util/cloudtags"
 	"github.com/DataDog/datadog-agent/pkg/util/ec2"
 	"github.com/DataDog/datadog-agent/pkg/util/gce"
 	log "github.com/cihub/seelog"
@@ -76,22 +77,14 @@ func GetMeta() *Meta {
 
 func getHostTags() *tags {
 	hostTags := config.Datadog.GetStringSlice("tags")
-	var gceTags []string
+	cloudTags := cloudtags.GetTagsByProvider()
 
-	ec2Tags, err := ec2.GetTags()
-	if err != nil {
-		log.Debugf("No EC2 host tags %v", err)
-	}
-	hostTags = append(hostTags, ec2Tags...)
-
-	gceTags, err = gce.GetTags()
-	if err != nil {
-		log.Debugf("No GCE host tags %v", err)
-	}
+	hostTags = append(hostTags, cloudTags[cloudtags.EC2]...)
+	hostTags = append(hostTags, cloudTags[cloudtags.Azure]...)
 
 	return &tags{
 		System:              hostTags,
-		GoogleCloudPlatform: gceTags,
+		GoogleCloudPlatform: cloudTags[cloudtags.GCE],
 	}
 }
 
@@ -29,6 +29,24 @@ func TestWritePID(t *testing.T) {
 	assert.Equal(t, pid, os.Getpid())
 }
 
+func TestReadPID(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "agent_test")
+	defer os.RemoveAll(dir)
+
+	pidFilePath := filepath.Join(dir, "agent.pid")
+	ioutil.WriteFile(pidFilePath, []byte("1234\n"), 0644)
+	pid, err := ReadPID(pidFilePath)
+	assert.Nil(t, err)
+	assert.Equal(t, 1234, pid)
+
+	ioutil.WriteFile(pidFilePath, []byte("agent"), 0644)
+	_, err = ReadPID(pidFilePath)
+	assert.NotNil(t, err)
+
+	_, err = ReadPID(filepath.Join(dir, "missing.pid"))
+	assert.NotNil(t, err)
+}
+
 func TestIsProcess(t *testing.T) {
 	assert.True(t, isProcess(os.Getpid()))
 }
@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !windows
+
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkRights makes sure the executable belongs to the user running the
+// agent, that only this user can read, write and execute it
+func checkRights(path string) error {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return fmt.Errorf("invalid executable '%s': can't stat it: %s", path, err)
+	}
+
+	if int(stat.Uid) != os.Geteuid() {
+		return fmt.Errorf("invalid executable '%s': it isn't owned by the user running the agent", path)
+	}
+	if stat.Mode&syscall.S_IFMT != syscall.S_IFREG {
+		return fmt.Errorf("invalid executable '%s': not a regular file", path)
+	}
+	if stat.Mode&0077 != 0 {
+		return fmt.Errorf("invalid executable '%s': other users or groups than the owner have rights on it", path)
+	}
+	if stat.Mode&0100 == 0 {
+		return fmt.Errorf("invalid executable '%s': not executable by its owner", path)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build windows
+
+package secrets
+
+import (
+	"fmt"
+)
+
+// checkRights refuses any executable: its ACLs aren't checked yet, and
+// running a command other users may have replaced would leak the secrets
+func checkRights(path string) error {
+	return fmt.Errorf("invalid executable '%s': secret_backend_command isn't supported on Windows", path)
+}
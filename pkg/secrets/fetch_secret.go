@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// PayloadVersion is the version of the payload sent to the executable
+const PayloadVersion = "1.0"
+
+// secretsRequest is the payload sent to the executable on its standard input
+type secretsRequest struct {
+	Version string   `json:"version"`
+	Secrets []string `json:"secrets"`
+}
+
+// secret is the result the executable returns for a handle
+type secret struct {
+	Value    string `json:"value,omitempty"`
+	ErrorMsg string `json:"error,omitempty"`
+}
+
+// limitBuffer is a buffer refusing to grow beyond a maximum size
+type limitBuffer struct {
+	max int
+	buf *bytes.Buffer
+}
+
+func (b *limitBuffer) Write(p []byte) (int, error) {
+	if len(p)+b.buf.Len() > b.max {
+		return 0, fmt.Errorf("command output was too long: exceeded %d bytes", b.max)
+	}
+	return b.buf.Write(p)
+}
+
+// execCommand runs the executable with the payload on its standard input
+// and returns its output
+func execCommand(payload []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretBackendTimeout)
+	defer cancel()
+
+	if err := checkRights(secretBackendCommand); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, secretBackendCommand, secretBackendArguments...)
+	cmd.Stdin = bytes.NewReader(payload)
+	stdout := limitBuffer{max: secretBackendOutputMaxSize, buf: &bytes.Buffer{}}
+	stderr := limitBuffer{max: secretBackendOutputMaxSize, buf: &bytes.Buffer{}}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("error while running '%s': command timeout", secretBackendCommand)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while running '%s': %s (%s)", secretBackendCommand, err, strings.TrimSpace(stderr.buf.String()))
+	}
+	return stdout.buf.Bytes(), nil
+}
+
+// fetchSecrets asks the executable for the secrets of the handles
+func fetchSecrets(handles []string) (map[string]string, error) {
+	sort.Strings(handles)
+	payload, err := json.Marshal(secretsRequest{Version: PayloadVersion, Secrets: handles})
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize the secrets to fetch: %s", err)
+	}
+
+	output, err := execCommand(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := map[string]secret{}
+	if err := json.Unmarshal(output, &secrets); err != nil {
+		return nil, fmt.Errorf("could not unmarshal the output of '%s': %s", secretBackendCommand, err)
+	}
+
+	res := map[string]string{}
+	for _, handle := range handles {
+		s, found := secrets[handle]
+		if !found {
+			return nil, fmt.Errorf("secret handle '%s' was not decrypted by the secret_backend_command", handle)
+		}
+		if s.ErrorMsg != "" {
+			return nil, fmt.Errorf("an error occurred while decrypting '%s': %s", handle, s.ErrorMsg)
+		}
+		if s.Value == "" {
+			return nil, fmt.Errorf("decrypted secret for '%s' is empty", handle)
+		}
+		res[handle] = s.Value
+	}
+	return res, nil
+}
@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package secrets resolves the `ENC[<handle>]` values of YAML configurations
+// with a user-provided executable, so that secrets don't have to be stored in
+// plain text in the configuration files.
+package secrets
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	encRegex = regexp.MustCompile(`^ENC\[(.+)\]$`)
+
+	secretBackendCommand       string
+	secretBackendArguments     []string
+	secretBackendTimeout       = 5 * time.Second
+	secretBackendOutputMaxSize = 1024 * 1024
+
+	// secretCache maps the handles to the secrets already fetched
+	secretCache = map[string]string{}
+	m           sync.Mutex // to protect the backend settings and the cache
+)
+
+// Init sets the executable fetching the secrets and its limits
+func Init(command string, arguments []string, timeout time.Duration, maxSize int) {
+	m.Lock()
+	defer m.Unlock()
+
+	secretBackendCommand = command
+	secretBackendArguments = arguments
+	secretBackendTimeout = timeout
+	secretBackendOutputMaxSize = maxSize
+}
+
+// IsEnabled returns whether an executable fetching the secrets is set
+func IsEnabled() bool {
+	m.Lock()
+	defer m.Unlock()
+	return secretBackendCommand != ""
+}
+
+// Decrypt replaces the `ENC[<handle>]` values of a YAML document with the
+// secrets the executable returns for their handles. The document is returned
+// untouched when it holds no handle.
+func Decrypt(data []byte) ([]byte, error) {
+	var config interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("could not parse the YAML: %s", err)
+	}
+
+	handles := map[string]struct{}{}
+	walk(config, func(value string) string {
+		if handle, ok := parseHandle(value); ok {
+			handles[handle] = struct{}{}
+		}
+		return value
+	})
+	if len(handles) == 0 {
+		return data, nil
+	}
+
+	secrets, err := getSecrets(handles)
+	if err != nil {
+		return nil, err
+	}
+
+	config = walk(config, func(value string) string {
+		if handle, ok := parseHandle(value); ok {
+			return secrets[handle]
+		}
+		return value
+	})
+	return yaml.Marshal(config)
+}
+
+// parseHandle returns the handle of an `ENC[<handle>]` value
+func parseHandle(value string) (string, bool) {
+	match := encRegex.FindStringSubmatch(value)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// walk calls `f` on every string value of a YAML document, keys excluded,
+// and replaces them with its result
+func walk(data interface{}, f func(string) string) interface{} {
+	switch v := data.(type) {
+	case string:
+		return f(v)
+	case map[interface{}]interface{}:
+		for key, value := range v {
+			v[key] = walk(value, f)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = walk(value, f)
+		}
+	}
+	return data
+}
+
+// getSecrets returns the secrets of the handles, from the cache or from the
+// executable for the ones not fetched yet
+func getSecrets(handles map[string]struct{}) (map[string]string, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	secrets := map[string]string{}
+	missing := []string{}
+	for handle := range handles {
+		if secret, found := secretCache[handle]; found {
+			secrets[handle] = secret
+		} else {
+			missing = append(missing, handle)
+		}
+	}
+	if len(missing) == 0 {
+		return secrets, nil
+	}
+
+	if secretBackendCommand == "" {
+		return nil, fmt.Errorf("secret_backend_command is not set, could not decrypt the secrets %v", missing)
+	}
+	fetched, err := fetchSecrets(missing)
+	if err != nil {
+		return nil, err
+	}
+	for handle, secret := range fetched {
+		secretCache[handle] = secret
+		secrets[handle] = secret
+	}
+	return secrets, nil
+}
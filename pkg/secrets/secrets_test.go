@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !windows
+
+package secrets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCommand writes a shell script usable as secret_backend_command
+func writeCommand(t *testing.T, dir, script string, mode os.FileMode) string {
+	path := filepath.Join(dir, "secret_command")
+	require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), mode))
+	require.NoError(t, os.Chmod(path, mode))
+	return path
+}
+
+func setup(t *testing.T, script string) (string, func()) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	command := writeCommand(t, dir, script, 0700)
+	Init(command, nil, 5*time.Second, 1024)
+	secretCache = map[string]string{}
+
+	return dir, func() {
+		Init("", nil, 5*time.Second, 1024*1024)
+		secretCache = map[string]string{}
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDecryptNoHandle(t *testing.T) {
+	Init("", nil, 5*time.Second, 1024*1024)
+	data := []byte("host: localhost\nport: 5432\n")
+
+	res, err := Decrypt(data)
+	require.NoError(t, err)
+	assert.Equal(t, data, res)
+}
+
+func TestDecryptNoCommand(t *testing.T) {
+	Init("", nil, 5*time.Second, 1024*1024)
+	_, err := Decrypt([]byte("password: ENC[db_password]"))
+	assert.Error(t, err)
+}
+
+func TestDecrypt(t *testing.T) {
+	dir, teardown := setup(t, `cat > "$(dirname $0)/input"
+echo '{"db_password": {"value": "secret1"}, "api_key": {"value": "secret2"}}'
+`)
+	defer teardown()
+
+	data := []byte(`
+password: ENC[db_password]
+nested:
+  - key: ENC[api_key]
+    other: ENC[db_password]
+  - plain
+`)
+	res, err := Decrypt(data)
+	require.NoError(t, err)
+	assert.Equal(t, `nested:
+- key: secret2
+  other: secret1
+- plain
+password: secret1
+`, string(res))
+
+	input, err := ioutil.ReadFile(filepath.Join(dir, "input"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"version":"1.0","secrets":["api_key","db_password"]}`, string(input))
+
+	// the secrets are cached, the command isn't called again
+	writeCommand(t, dir, "exit 1", 0700)
+	res, err = Decrypt([]byte("password: ENC[db_password]"))
+	require.NoError(t, err)
+	assert.Equal(t, "password: secret1\n", string(res))
+}
+
+func TestDecryptErrors(t *testing.T) {
+	for name, script := range map[string]string{
+		"error":        `echo '{"db_password": {"error": "not found"}}'`,
+		"missing":      `echo '{}'`,
+		"empty":        `echo '{"db_password": {"value": ""}}'`,
+		"invalid json": `echo 'password'`,
+		"exit code":    `exit 1`,
+		"timeout":      `exec sleep 5`,
+		"too long":     `head -c 2048 /dev/zero`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir, teardown := setup(t, script)
+			defer teardown()
+			Init(filepath.Join(dir, "secret_command"), nil, 500*time.Millisecond, 1024)
+
+			_, err := Decrypt([]byte("password: ENC[db_password]"))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCheckRights(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := writeCommand(t, dir, "", 0700)
+	assert.NoError(t, checkRights(path))
+
+	for _, mode := range []os.FileMode{0600, 0710, 0701, 0744} {
+		require.NoError(t, os.Chmod(path, mode))
+		assert.Error(t, checkRights(path), "mode %o", mode)
+	}
+
+	assert.Error(t, checkRights(dir))
+	assert.Error(t, checkRights(filepath.Join(dir, "missing")))
+}
@@ -64,12 +64,27 @@ func initExtraHeaders() {
 		protobufExtraHeadersWithCompression.Set(k, protobufExtraHeaders.Get(k))
 	}
 
-	if compression.ContentEncoding != "" {
-		jsonExtraHeadersWithCompression.Set("Content-Encoding", compression.ContentEncoding)
-		protobufExtraHeadersWithCompression.Set("Content-Encoding", compression.ContentEncoding)
+	if contentEncoding := compression.ContentEncoding(); contentEncoding != "" {
+		jsonExtraHeadersWithCompression.Set("Content-Encoding", contentEncoding)
+		protobufExtraHeadersWithCompression.Set("Content-Encoding", contentEncoding)
 	}
 }
 
+// InitCompression selects the compression codec and level from the configuration
+// and refreshes the extra headers accordingly. It must be called once the
+// configuration is loaded and before any payload is sent.
+func InitCompression() error {
+	kind := config.Datadog.GetString("serializer_compressor_kind")
+	level := config.Datadog.GetInt("serializer_compression_level")
+	if err := compression.Configure(kind, level); err != nil {
+		return fmt.Errorf("could not configure %q compression: %s", kind, err)
+	}
+	initExtraHeaders()
+
+	log.Infof("Using %q compression for the payloads", kind)
+	return nil
+}
+
 // Serializer serializes metrics to the correct format and routes the payloads to the correct endpoint in the Forwarder
 type Serializer struct {
 	Forwarder forwarder.Forwarder
@@ -19,15 +19,13 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
-var initialContentEncoding = compression.ContentEncoding
-
 func resetContentEncoding() {
-	compression.ContentEncoding = initialContentEncoding
+	compression.Configure(compression.ZlibKind, compression.DefaultLevel)
 	initExtraHeaders()
 }
 
 func TestInitExtraHeadersNoopCompression(t *testing.T) {
-	compression.ContentEncoding = ""
+	require.NoError(t, compression.Configure(compression.NoneKind, compression.DefaultLevel))
 	defer resetContentEncoding()
 
 	initExtraHeaders()
@@ -53,7 +51,7 @@ func TestInitExtraHeadersNoopCompression(t *testing.T) {
 }
 
 func TestInitExtraHeadersWithCompression(t *testing.T) {
-	compression.ContentEncoding = "zstd"
+	require.NoError(t, compression.Configure(compression.ZlibKind, compression.DefaultLevel))
 	defer resetContentEncoding()
 
 	initExtraHeaders()
@@ -70,12 +68,12 @@ func TestInitExtraHeadersWithCompression(t *testing.T) {
 	// "Content-Encoding" header present with correct value
 	expected = make(http.Header)
 	expected.Set("Content-Type", jsonContentType)
-	expected.Set("Content-Encoding", compression.ContentEncoding)
+	expected.Set("Content-Encoding", "deflate")
 	assert.Equal(t, expected, jsonExtraHeadersWithCompression)
 
 	expected = make(http.Header)
 	expected.Set("Content-Type", protobufContentType)
-	expected.Set("Content-Encoding", compression.ContentEncoding)
+	expected.Set("Content-Encoding", "deflate")
 	expected.Set(payloadVersionHTTPHeader, "")
 	assert.Equal(t, expected, protobufExtraHeadersWithCompression)
 }
@@ -283,3 +281,22 @@ func TestSendJSONToV1Intake(t *testing.T) {
 	err = s.SendJSONToV1Intake(errPayload)
 	require.NotNil(t, err)
 }
+
+func TestInitCompression(t *testing.T) {
+	defer resetContentEncoding()
+	defer compression.Configure(compression.ZlibKind, compression.DefaultLevel)
+	defer config.Datadog.Set("serializer_compressor_kind", "zlib")
+
+	config.Datadog.Set("serializer_compressor_kind", "none")
+	require.Nil(t, InitCompression())
+	assert.Equal(t, "", jsonExtraHeadersWithCompression.Get("Content-Encoding"))
+	assert.Equal(t, "", protobufExtraHeadersWithCompression.Get("Content-Encoding"))
+
+	config.Datadog.Set("serializer_compressor_kind", "zlib")
+	require.Nil(t, InitCompression())
+	assert.Equal(t, "deflate", jsonExtraHeadersWithCompression.Get("Content-Encoding"))
+	assert.Equal(t, "deflate", protobufExtraHeadersWithCompression.Get("Content-Encoding"))
+
+	config.Datadog.Set("serializer_compressor_kind", "unknown")
+	assert.NotNil(t, InitCompression())
+}
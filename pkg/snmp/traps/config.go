@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package traps
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/k-sone/snmpgo"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// Outputs of the traps
+const (
+	EventsOutput = "events"
+	LogsOutput   = "logs"
+)
+
+// UserV3 is a SNMPv3 user allowed to send traps, the keys of the user are
+// localized with the engine ID of the device sending the traps
+type UserV3 struct {
+	Username     string `mapstructure:"user"`
+	EngineID     string `mapstructure:"engineID"`
+	AuthKey      string `mapstructure:"authKey"`
+	AuthProtocol string `mapstructure:"authProtocol"`
+	PrivKey      string `mapstructure:"privKey"`
+	PrivProtocol string `mapstructure:"privProtocol"`
+}
+
+// Config is the configuration of the traps server
+type Config struct {
+	Port             uint16
+	BindHost         string
+	CommunityStrings []string
+	Users            []UserV3
+	DBFolder         string
+	Output           string
+}
+
+// ReadConfig reads the configuration of the traps server from the agent
+// configuration
+func ReadConfig() (*Config, error) {
+	c := &Config{
+		Port:             uint16(config.Datadog.GetInt("snmp_traps_port")),
+		BindHost:         config.Datadog.GetString("snmp_traps_bind_host"),
+		CommunityStrings: config.Datadog.GetStringSlice("snmp_traps_community_strings"),
+		DBFolder:         config.Datadog.GetString("snmp_traps_db_folder"),
+		Output:           config.Datadog.GetString("snmp_traps_output"),
+	}
+	if err := config.Datadog.UnmarshalKey("snmp_traps_users", &c.Users); err != nil {
+		return nil, fmt.Errorf("invalid snmp_traps_users: %s", err)
+	}
+	if c.DBFolder == "" {
+		c.DBFolder = filepath.Join(config.Datadog.GetString("confd_path"), "snmp.d", "traps_db")
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	if c.Port == 0 {
+		return errors.New("snmp_traps_port must be set")
+	}
+	if len(c.CommunityStrings) == 0 && len(c.Users) == 0 {
+		return errors.New("neither community string nor user is configured, every trap would be dropped")
+	}
+	switch c.Output {
+	case EventsOutput, LogsOutput:
+	default:
+		return fmt.Errorf("snmp_traps_output must be %s or %s, got %q", EventsOutput, LogsOutput, c.Output)
+	}
+	for _, user := range c.Users {
+		if _, err := user.securityEntry(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// securityEntries returns the communities and users the traps are
+// authenticated with
+func (c *Config) securityEntries() ([]*snmpgo.SecurityEntry, error) {
+	entries := make([]*snmpgo.SecurityEntry, 0, len(c.CommunityStrings)+len(c.Users))
+	for _, community := range c.CommunityStrings {
+		entries = append(entries, &snmpgo.SecurityEntry{Version: snmpgo.V2c, Community: community})
+	}
+	for _, user := range c.Users {
+		entry, err := user.securityEntry()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// securityEntry returns the entry the traps of the user are authenticated
+// and decrypted with, its security level being required from the traps
+func (u UserV3) securityEntry() (*snmpgo.SecurityEntry, error) {
+	if u.Username == "" {
+		return nil, errors.New("every SNMPv3 user must have a name")
+	}
+	// RFC 3411 engine IDs are 5 to 32 octets long
+	if engineID, err := hex.DecodeString(u.EngineID); err != nil || len(engineID) < 5 || len(engineID) > 32 {
+		return nil, fmt.Errorf("the engineID of the user %s must be the engine ID of the device as 5 to 32 hexadecimal octets", u.Username)
+	}
+	entry := &snmpgo.SecurityEntry{
+		Version:          snmpgo.V3,
+		UserName:         u.Username,
+		SecurityLevel:    snmpgo.NoAuthNoPriv,
+		SecurityEngineId: u.EngineID,
+	}
+
+	if u.AuthKey != "" {
+		entry.SecurityLevel = snmpgo.AuthNoPriv
+		entry.AuthPassword = u.AuthKey
+		switch strings.ToUpper(u.AuthProtocol) {
+		case "", "MD5":
+			entry.AuthProtocol = snmpgo.Md5
+		case "SHA":
+			entry.AuthProtocol = snmpgo.Sha
+		default:
+			return nil, fmt.Errorf("unsupported authProtocol %s for the user %s", u.AuthProtocol, u.Username)
+		}
+	}
+
+	if u.PrivKey != "" {
+		if u.AuthKey == "" {
+			return nil, fmt.Errorf("the user %s has a privKey but no authKey, privacy requires authentication", u.Username)
+		}
+		entry.SecurityLevel = snmpgo.AuthPriv
+		entry.PrivPassword = u.PrivKey
+		switch strings.ToUpper(u.PrivProtocol) {
+		case "", "DES":
+			entry.PrivProtocol = snmpgo.Des
+		case "AES":
+			entry.PrivProtocol = snmpgo.Aes
+		default:
+			return nil, fmt.Errorf("unsupported privProtocol %s for the user %s", u.PrivProtocol, u.Username)
+		}
+	}
+	return entry, nil
+}
+
+// LogsEnabled returns whether the traps are forwarded to the logs-agent
+func LogsEnabled() bool {
+	return config.Datadog.GetBool("snmp_traps_enabled") && config.Datadog.GetString("snmp_traps_output") == LogsOutput
+}
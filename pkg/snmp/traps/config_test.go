@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package traps
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/k-sone/snmpgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func readTestConfig(t *testing.T, yamlConfig string) (*Config, error) {
+	config.Datadog.SetConfigType("yaml")
+	require.NoError(t, config.Datadog.ReadConfig(bytes.NewBuffer([]byte(yamlConfig))))
+	defer config.Datadog.ReadConfig(bytes.NewBuffer(nil))
+	return ReadConfig()
+}
+
+func TestReadConfig(t *testing.T) {
+	c, err := readTestConfig(t, `
+confd_path: /etc/datadog-agent/conf.d
+snmp_traps_enabled: true
+snmp_traps_community_strings:
+  - public
+snmp_traps_users:
+  - user: alice
+    engineID: 80001f8880010203040506
+    authKey: password
+    authProtocol: SHA
+    privKey: private
+    privProtocol: AES
+`)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(162), c.Port)
+	assert.Equal(t, "0.0.0.0", c.BindHost)
+	assert.Equal(t, []string{"public"}, c.CommunityStrings)
+	assert.Equal(t, []UserV3{{Username: "alice", EngineID: "80001f8880010203040506", AuthKey: "password", AuthProtocol: "SHA", PrivKey: "private", PrivProtocol: "AES"}}, c.Users)
+	assert.Equal(t, filepath.Join("/etc/datadog-agent/conf.d", "snmp.d", "traps_db"), c.DBFolder)
+	assert.Equal(t, EventsOutput, c.Output)
+
+	_, err = readTestConfig(t, `
+snmp_traps_enabled: true
+`)
+	assert.EqualError(t, err, "neither community string nor user is configured, every trap would be dropped")
+
+	_, err = readTestConfig(t, `
+snmp_traps_community_strings: [public]
+snmp_traps_output: metrics
+`)
+	assert.Error(t, err)
+}
+
+func TestUserSecurityEntry(t *testing.T) {
+	entry, err := UserV3{Username: "alice", EngineID: testEngineID}.securityEntry()
+	require.NoError(t, err)
+	assert.Equal(t, &snmpgo.SecurityEntry{
+		Version:          snmpgo.V3,
+		UserName:         "alice",
+		SecurityLevel:    snmpgo.NoAuthNoPriv,
+		SecurityEngineId: testEngineID,
+	}, entry)
+
+	// the protocols default to MD5 and DES
+	entry, err = UserV3{Username: "alice", EngineID: testEngineID, AuthKey: "password", PrivKey: "private"}.securityEntry()
+	require.NoError(t, err)
+	assert.Equal(t, snmpgo.AuthPriv, entry.SecurityLevel)
+	assert.Equal(t, snmpgo.Md5, entry.AuthProtocol)
+	assert.Equal(t, snmpgo.Des, entry.PrivProtocol)
+
+	entry, err = UserV3{Username: "alice", EngineID: testEngineID, AuthKey: "password", AuthProtocol: "sha"}.securityEntry()
+	require.NoError(t, err)
+	assert.Equal(t, snmpgo.AuthNoPriv, entry.SecurityLevel)
+	assert.Equal(t, snmpgo.Sha, entry.AuthProtocol)
+
+	_, err = UserV3{EngineID: testEngineID, AuthKey: "password"}.securityEntry()
+	assert.Error(t, err)
+	_, err = UserV3{Username: "alice", AuthKey: "password"}.securityEntry()
+	assert.Error(t, err)
+	_, err = UserV3{Username: "alice", EngineID: "8000", AuthKey: "password"}.securityEntry()
+	assert.Error(t, err)
+	_, err = UserV3{Username: "alice", EngineID: testEngineID, PrivKey: "private"}.securityEntry()
+	assert.Error(t, err)
+	_, err = UserV3{Username: "alice", EngineID: testEngineID, AuthKey: "password", AuthProtocol: "SHA256"}.securityEntry()
+	assert.Error(t, err)
+	_, err = UserV3{Username: "alice", EngineID: testEngineID, AuthKey: "password", PrivKey: "private", PrivProtocol: "AES256"}.securityEntry()
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package traps
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/k-sone/snmpgo"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// OIDs of the variables starting the SNMPv2 traps, see RFC 3416
+const (
+	sysUpTimeOID   = "1.3.6.1.2.1.1.3.0"
+	snmpTrapOIDOID = "1.3.6.1.6.3.1.1.4.1.0"
+)
+
+// trap is a trap of any version whose OIDs are resolved
+type trap struct {
+	OID       string
+	Name      string
+	MIB       string
+	Version   string
+	Device    string
+	Uptime    uint32
+	Variables []variable
+}
+
+// variable is a variable of a trap, the value of the enums being replaced
+// by their name
+type variable struct {
+	OID   string
+	Name  string
+	Value interface{}
+}
+
+// newTrap builds the trap from the v2c or v3 notification sent by the device
+func newTrap(pdu snmpgo.Pdu, device string, resolver *oidResolver) (*trap, error) {
+	switch pdu.PduType() {
+	case snmpgo.SNMPTrapV2, snmpgo.InformRequest:
+	default:
+		return nil, fmt.Errorf("unexpected PDU type %d", pdu.PduType())
+	}
+
+	t := &trap{Device: device, Version: "2c"}
+	if _, ok := pdu.(*snmpgo.ScopedPdu); ok {
+		t.Version = "3"
+	}
+
+	variables := pdu.VarBinds()
+	if len(variables) < 2 || normalizeOID(variables[0].Oid.String()) != sysUpTimeOID || normalizeOID(variables[1].Oid.String()) != snmpTrapOIDOID {
+		return nil, errors.New("the trap doesn't start with sysUpTime.0 and snmpTrapOID.0")
+	}
+	if variables[1].Variable.Type() != "Oid" {
+		return nil, errors.New("snmpTrapOID.0 is not an OID")
+	}
+	uptime, err := variables[0].Variable.BigInt()
+	if err != nil {
+		return nil, fmt.Errorf("invalid sysUpTime.0: %s", err)
+	}
+	t.Uptime = uint32(uptime.Uint64())
+	t.OID = normalizeOID(variables[1].Variable.String())
+
+	t.Name = t.OID
+	if spec, found := resolver.trap(t.OID); found {
+		t.Name = spec.Name
+		t.MIB = spec.MIB
+	}
+
+	for _, v := range variables[2:] {
+		t.Variables = append(t.Variables, newVariable(v, resolver))
+	}
+	return t, nil
+}
+
+func newVariable(vb *snmpgo.VarBind, resolver *oidResolver) variable {
+	v := variable{
+		OID:   normalizeOID(vb.Oid.String()),
+		Value: variableValue(vb.Variable),
+	}
+	v.Name = v.OID
+	spec, found := resolver.variable(v.OID)
+	if !found {
+		return v
+	}
+	v.Name = spec.Name
+	if value, ok := v.Value.(int); ok {
+		if name, found := spec.Enum[value]; found {
+			v.Value = name
+		}
+	}
+	return v
+}
+
+// variableValue returns the value of the variable, the octet strings being
+// returned as strings when printable and as hexadecimal otherwise
+func variableValue(value snmpgo.Variable) interface{} {
+	switch value.Type() {
+	case "Integer":
+		if n, err := value.BigInt(); err == nil {
+			return int(n.Int64())
+		}
+	case "Counter32", "Gauge32", "TimeTicks", "Counter64":
+		if n, err := value.BigInt(); err == nil {
+			return n.Uint64()
+		}
+	case "OctetString":
+		if s, ok := value.(*snmpgo.OctetString); ok {
+			if isPrintable(s.Value) {
+				return string(s.Value)
+			}
+			return "0x" + hex.EncodeToString(s.Value)
+		}
+	case "Oid":
+		return normalizeOID(value.String())
+	case "Null", "NoSucheObject", "NoSucheInstance", "EndOfMibView":
+		return nil
+	}
+	return value.String()
+}
+
+func isPrintable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// tags returns the tags of the events of the trap
+func (t *trap) tags() []string {
+	tags := []string{
+		"snmp_version:" + t.Version,
+		"snmp_device:" + t.Device,
+		"snmp_trap_oid:" + t.OID,
+	}
+	if t.Name != t.OID {
+		tags = append(tags, "snmp_trap:"+t.Name)
+	}
+	if t.MIB != "" {
+		tags = append(tags, "snmp_trap_mib:"+t.MIB)
+	}
+	return tags
+}
+
+// event returns the trap as an event, its variables being its text
+func (t *trap) event() metrics.Event {
+	lines := make([]string, 0, len(t.Variables))
+	for _, v := range t.Variables {
+		lines = append(lines, fmt.Sprintf("%s: %v", v.Name, v.Value))
+	}
+	return metrics.Event{
+		Title:          fmt.Sprintf("SNMP trap %s from %s", t.Name, t.Device),
+		Text:           strings.Join(lines, "\n"),
+		Priority:       metrics.EventPriorityNormal,
+		AlertType:      metrics.EventAlertTypeInfo,
+		Tags:           t.tags(),
+		AggregationKey: t.Device + ":" + t.OID,
+		SourceTypeName: "snmp",
+	}
+}
+
+// trapLog is the structured log of a trap
+type trapLog struct {
+	Trap      string                 `json:"trap"`
+	OID       string                 `json:"oid"`
+	MIB       string                 `json:"mib,omitempty"`
+	Version   string                 `json:"snmp_version"`
+	Device    string                 `json:"device"`
+	Uptime    uint32                 `json:"uptime"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// log returns the trap as a JSON log, its variables being keyed by name
+func (t *trap) log() ([]byte, error) {
+	l := trapLog{
+		Trap:      t.Name,
+		OID:       t.OID,
+		MIB:       t.MIB,
+		Version:   t.Version,
+		Device:    t.Device,
+		Uptime:    t.Uptime,
+		Variables: make(map[string]interface{}, len(t.Variables)),
+	}
+	for _, v := range t.Variables {
+		l.Variables[v.Name] = v.Value
+	}
+	return json.Marshal(l)
+}
@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package traps
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/cihub/seelog"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// trapSpec is the definition of a notification in a MIB
+type trapSpec struct {
+	Name string `yaml:"name"`
+	MIB  string `yaml:"mib"`
+}
+
+// variableSpec is the definition of an object in a MIB, the names of its
+// integer values being listed in enum
+type variableSpec struct {
+	Name string         `yaml:"name"`
+	MIB  string         `yaml:"mib"`
+	Enum map[int]string `yaml:"enum"`
+}
+
+// trapDBFile is a file of the traps database, usually generated from MIBs
+type trapDBFile struct {
+	Traps     map[string]trapSpec     `yaml:"traps"`
+	Variables map[string]variableSpec `yaml:"vars"`
+}
+
+// oidResolver resolves the OIDs of the traps and of their variables to
+// their names
+type oidResolver struct {
+	traps     map[string]trapSpec
+	variables map[string]variableSpec
+}
+
+// newOIDResolver loads the yaml files of the traps database folder, the
+// definitions of a file overriding the ones of the files coming before it in
+// lexical order
+func newOIDResolver(folder string) (*oidResolver, error) {
+	r := &oidResolver{
+		traps:     map[string]trapSpec{},
+		variables: map[string]variableSpec{},
+	}
+
+	files, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the traps database: %s", err)
+	}
+	names := []string{}
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if !f.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(folder, name))
+		if err != nil {
+			return nil, err
+		}
+		var db trapDBFile
+		if err := yaml.Unmarshal(data, &db); err != nil {
+			return nil, fmt.Errorf("invalid traps database file %s: %s", name, err)
+		}
+		for oid, spec := range db.Traps {
+			r.traps[normalizeOID(oid)] = spec
+		}
+		for oid, spec := range db.Variables {
+			r.variables[normalizeOID(oid)] = spec
+		}
+		log.Debugf("Loaded %d traps and %d variables from %s", len(db.Traps), len(db.Variables), name)
+	}
+	return r, nil
+}
+
+// trap returns the definition of the notification
+func (r *oidResolver) trap(oid string) (trapSpec, bool) {
+	spec, found := r.traps[normalizeOID(oid)]
+	return spec, found
+}
+
+// variable returns the definition of the object the variable is an instance
+// of, e.g. ifIndex for 1.3.6.1.2.1.2.2.1.1.3 that is ifIndex of the row 3
+func (r *oidResolver) variable(oid string) (variableSpec, bool) {
+	oid = normalizeOID(oid)
+	for {
+		if spec, found := r.variables[oid]; found {
+			return spec, true
+		}
+		i := strings.LastIndexByte(oid, '.')
+		if i < 0 {
+			return variableSpec{}, false
+		}
+		oid = oid[:i]
+	}
+}
+
+// normalizeOID removes the leading dot the OIDs can be written with
+func normalizeOID(oid string) string {
+	return strings.TrimPrefix(oid, ".")
+}
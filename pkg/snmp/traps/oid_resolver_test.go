@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package traps
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDResolver(t *testing.T) {
+	r, err := newOIDResolver(trapsDBFolder)
+	require.NoError(t, err)
+
+	spec, found := r.trap(".1.3.6.1.6.3.1.1.5.4")
+	assert.True(t, found)
+	assert.Equal(t, trapSpec{Name: "linkUp", MIB: "IF-MIB"}, spec)
+	_, found = r.trap("1.3.6.1.6.3.1.1.5.4.1")
+	assert.False(t, found)
+
+	// the instances of the objects are resolved
+	variable, found := r.variable("1.3.6.1.2.1.2.2.1.8.12")
+	assert.True(t, found)
+	assert.Equal(t, "ifOperStatus", variable.Name)
+	assert.Equal(t, "lowerLayerDown", variable.Enum[7])
+	variable, found = r.variable(".1.3.6.1.2.1.1.3.0")
+	assert.True(t, found)
+	assert.Equal(t, "sysUpTime", variable.Name)
+	_, found = r.variable("1.3.6.1.2.1.2.2.1.9.12")
+	assert.False(t, found)
+}
+
+func TestOIDResolverOverride(t *testing.T) {
+	folder, err := ioutil.TempDir("", "traps_db")
+	require.NoError(t, err)
+	defer os.RemoveAll(folder)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(folder, "a.yaml"), []byte(`
+traps:
+  1.3.6.1.4.1.9999.0.1:
+    name: first
+vars:
+  1.3.6.1.4.1.9999.1:
+    name: firstVariable
+`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(folder, "b.yml"), []byte(`
+traps:
+  1.3.6.1.4.1.9999.0.1:
+    name: second
+    mib: SECOND-MIB
+`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(folder, "c.txt"), []byte(`not yaml`), 0644))
+
+	r, err := newOIDResolver(folder)
+	require.NoError(t, err)
+	spec, _ := r.trap("1.3.6.1.4.1.9999.0.1")
+	assert.Equal(t, trapSpec{Name: "second", MIB: "SECOND-MIB"}, spec)
+	variable, _ := r.variable("1.3.6.1.4.1.9999.1.0")
+	assert.Equal(t, "firstVariable", variable.Name)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(folder, "d.yaml"), []byte(`traps: [`), 0644))
+	_, err = newOIDResolver(folder)
+	assert.Error(t, err)
+
+	_, err = newOIDResolver(filepath.Join(folder, "missing"))
+	assert.Error(t, err)
+}
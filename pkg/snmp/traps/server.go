@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package traps
+
+import (
+	"expvar"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/k-sone/snmpgo"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// logsBufferSize is the number of traps waiting for the logs-agent, the
+// traps received while the buffer is full are dropped
+const logsBufferSize = 1000
+
+// listenDelay is how long the server waits for an error binding its socket,
+// snmpgo doesn't tell when the socket is bound
+const listenDelay = 200 * time.Millisecond
+
+var (
+	trapsExpvars = expvar.NewMap("snmp_traps")
+
+	// logsChan holds the traps formatted as logs until the logs-agent reads them
+	logsChan = make(chan []byte, logsBufferSize)
+)
+
+// LogsChannel returns the channel of the traps formatted as JSON logs, read
+// by the logs-agent when the output of the traps is logs
+func LogsChannel() <-chan []byte {
+	return logsChan
+}
+
+// TrapServer receives the SNMP traps and informs sent by the devices and
+// forwards them as events or logs. It is built on the trap server of snmpgo,
+// the SNMP library of the snmp check, which authenticates the traps with the
+// configured communities and users and answers the informs. snmpgo doesn't
+// decode the v1 traps, they are dropped as invalid.
+type TrapServer struct {
+	config   *Config
+	resolver *oidResolver
+	server   *snmpgo.TrapServer
+	eventOut chan<- metrics.Event
+	logsOut  chan<- []byte
+}
+
+// NewTrapServer returns a running trap server configured by the agent
+// configuration
+func NewTrapServer(eventOut chan<- metrics.Event) (*TrapServer, error) {
+	c, err := ReadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return newTrapServer(c, eventOut, logsChan)
+}
+
+func newTrapServer(c *Config, eventOut chan<- metrics.Event, logsOut chan<- []byte) (*TrapServer, error) {
+	resolver, err := newOIDResolver(c.DBFolder)
+	if err != nil {
+		log.Warnf("The OIDs of the traps won't be resolved: %s", err)
+		resolver = &oidResolver{}
+	}
+
+	entries, err := c.securityEntries()
+	if err != nil {
+		return nil, err
+	}
+	server, err := snmpgo.NewTrapServer(snmpgo.ServerArguments{
+		LocalAddr: net.JoinHostPort(c.BindHost, strconv.Itoa(int(c.Port))),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create the traps server: %s", err)
+	}
+	for _, entry := range entries {
+		if err := server.AddSecurity(entry); err != nil {
+			return nil, fmt.Errorf("invalid community or user: %s", err)
+		}
+	}
+
+	s := &TrapServer{
+		config:   c,
+		resolver: resolver,
+		server:   server,
+		eventOut: eventOut,
+		logsOut:  logsOut,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(s)
+	}()
+	select {
+	case err := <-errs:
+		return nil, fmt.Errorf("could not listen for traps: %s", err)
+	case <-time.After(listenDelay):
+		go func() {
+			if err := <-errs; err != nil {
+				log.Debugf("The SNMP traps server stopped: %s", err)
+			}
+		}()
+	}
+	log.Infof("Listening for SNMP traps on %s:%d", c.BindHost, c.Port)
+	return s, nil
+}
+
+// Stop stops the server
+func (s *TrapServer) Stop() {
+	s.server.Close()
+}
+
+// OnTRAP forwards the trap, snmpgo reporting the traps it couldn't decode or
+// authenticate as errors
+func (s *TrapServer) OnTRAP(request *snmpgo.TrapRequest) {
+	trapsExpvars.Add("Received", 1)
+	device := sourceHost(request.Source)
+	if request.Error != nil {
+		trapsExpvars.Add("Invalid", 1)
+		log.Debugf("Dropping the trap from %s: %s", device, request.Error)
+		return
+	}
+
+	t, err := newTrap(request.Pdu, device, s.resolver)
+	if err != nil {
+		trapsExpvars.Add("Invalid", 1)
+		log.Debugf("Dropping the trap from %s: %s", device, err)
+		return
+	}
+
+	switch s.config.Output {
+	case EventsOutput:
+		// the server handles the traps one at a time, a busy aggregator
+		// must not block the reception of the next ones
+		select {
+		case s.eventOut <- t.event():
+		default:
+			trapsExpvars.Add("Dropped", 1)
+			log.Warnf("Dropping the trap %s from %s, the aggregator doesn't keep up", t.Name, device)
+		}
+	case LogsOutput:
+		content, err := t.log()
+		if err != nil {
+			trapsExpvars.Add("Invalid", 1)
+			log.Debugf("Dropping the trap from %s: %s", device, err)
+			return
+		}
+		select {
+		case s.logsOut <- content:
+		default:
+			trapsExpvars.Add("Dropped", 1)
+			log.Warnf("Dropping the trap %s from %s, the logs-agent doesn't keep up", t.Name, device)
+		}
+	}
+}
+
+// sourceHost returns the IP address a trap was sent from
+func sourceHost(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	if udpAddr, ok := addr.(*net.UDPAddr); ok {
+		return udpAddr.IP.String()
+	}
+	if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+		return host
+	}
+	return addr.String()
+}
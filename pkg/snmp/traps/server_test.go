@@ -0,0 +1,264 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package traps
+
+import (
+	"encoding/json"
+	"expvar"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/k-sone/snmpgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+var trapsDBFolder = filepath.Join("..", "..", "..", "cmd", "agent", "dist", "conf.d", "snmp.d", "traps_db")
+
+// linkDownVariables are the variables of a linkDown notification
+var linkDownVariables = snmpgo.VarBinds{
+	snmpgo.NewVarBind(snmpgo.MustNewOid("1.3.6.1.2.1.2.2.1.1.3"), snmpgo.NewInteger(3)),
+	snmpgo.NewVarBind(snmpgo.MustNewOid("1.3.6.1.2.1.2.2.1.7.3"), snmpgo.NewInteger(1)),
+	snmpgo.NewVarBind(snmpgo.MustNewOid("1.3.6.1.2.1.2.2.1.8.3"), snmpgo.NewInteger(2)),
+	snmpgo.NewVarBind(snmpgo.MustNewOid("1.3.6.1.2.1.2.2.1.2.3"), snmpgo.NewOctetString([]byte("eth0"))),
+}
+
+// testEngineID is the engine ID of the device sending the v3 traps
+const testEngineID = "80001f8880010203040506"
+
+func freePort(t *testing.T) uint16 {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+	defer conn.Close()
+	return uint16(conn.LocalAddr().(*net.UDPAddr).Port)
+}
+
+func startTestServer(t *testing.T, c *Config) (*TrapServer, chan metrics.Event, chan []byte) {
+	c.Port = freePort(t)
+	c.BindHost = "127.0.0.1"
+	c.DBFolder = trapsDBFolder
+	if c.Output == "" {
+		c.Output = EventsOutput
+	}
+	eventOut := make(chan metrics.Event, 10)
+	logsOut := make(chan []byte, 10)
+	s, err := newTrapServer(c, eventOut, logsOut)
+	require.NoError(t, err)
+	return s, eventOut, logsOut
+}
+
+func sendTrap(t *testing.T, s *TrapServer, args snmpgo.SNMPArguments, varBinds snmpgo.VarBinds) {
+	args.Address = net.JoinHostPort("127.0.0.1", strconv.Itoa(int(s.config.Port)))
+	args.Timeout = time.Second
+	sender, err := snmpgo.NewSNMP(args)
+	require.NoError(t, err)
+	require.NoError(t, sender.Open())
+	defer sender.Close()
+	require.NoError(t, sender.V2Trap(varBinds))
+}
+
+func v2Trap(oid string, variables ...*snmpgo.VarBind) snmpgo.VarBinds {
+	return append(snmpgo.VarBinds{
+		snmpgo.NewVarBind(snmpgo.OidSysUpTime, snmpgo.NewTimeTicks(1000)),
+		snmpgo.NewVarBind(snmpgo.OidSnmpTrap, snmpgo.MustNewOid(oid)),
+	}, variables...)
+}
+
+func community(name string) snmpgo.SNMPArguments {
+	return snmpgo.SNMPArguments{Version: snmpgo.V2c, Community: name}
+}
+
+func receiveEvent(t *testing.T, eventOut chan metrics.Event) metrics.Event {
+	select {
+	case e := <-eventOut:
+		return e
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event received")
+	}
+	return metrics.Event{}
+}
+
+func assertNoEvent(t *testing.T, eventOut chan metrics.Event) {
+	select {
+	case e := <-eventOut:
+		assert.Fail(t, "unexpected event", e.Title)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestV2cTrap(t *testing.T) {
+	s, eventOut, _ := startTestServer(t, &Config{CommunityStrings: []string{"public", "private"}})
+	defer s.Stop()
+
+	sendTrap(t, s, community("private"), v2Trap("1.3.6.1.6.3.1.1.5.3", linkDownVariables...))
+	e := receiveEvent(t, eventOut)
+	assert.Equal(t, "SNMP trap linkDown from 127.0.0.1", e.Title)
+	assert.Equal(t, "ifIndex: 3\nifAdminStatus: up\nifOperStatus: down\nifDescr: eth0", e.Text)
+	assert.Equal(t, "snmp", e.SourceTypeName)
+	assert.Equal(t, "127.0.0.1:1.3.6.1.6.3.1.1.5.3", e.AggregationKey)
+	assert.Equal(t, []string{
+		"snmp_version:2c",
+		"snmp_device:127.0.0.1",
+		"snmp_trap_oid:1.3.6.1.6.3.1.1.5.3",
+		"snmp_trap:linkDown",
+		"snmp_trap_mib:IF-MIB",
+	}, e.Tags)
+
+	// unknown OIDs are kept as is
+	sendTrap(t, s, community("public"), v2Trap("1.3.6.1.4.1.9999.0.1",
+		snmpgo.NewVarBind(snmpgo.MustNewOid("1.3.6.1.4.1.9999.1.1"), snmpgo.NewOctetString([]byte{0, 1, 255}))))
+	e = receiveEvent(t, eventOut)
+	assert.Equal(t, "SNMP trap 1.3.6.1.4.1.9999.0.1 from 127.0.0.1", e.Title)
+	assert.Equal(t, "1.3.6.1.4.1.9999.1.1: 0x0001ff", e.Text)
+	assert.NotContains(t, e.Tags, "snmp_trap_mib:")
+
+	// wrong community
+	sendTrap(t, s, community("secret"), v2Trap("1.3.6.1.6.3.1.1.5.3", linkDownVariables...))
+	assertNoEvent(t, eventOut)
+}
+
+func TestV3Trap(t *testing.T) {
+	s, eventOut, _ := startTestServer(t, &Config{
+		CommunityStrings: []string{"public"},
+		Users: []UserV3{
+			{Username: "alice", EngineID: testEngineID, AuthKey: "alice_password", AuthProtocol: "SHA", PrivKey: "alice_private", PrivProtocol: "AES"},
+			{Username: "bob", EngineID: testEngineID, AuthKey: "bob_password"},
+		},
+	})
+	defer s.Stop()
+
+	user := func(name string, level snmpgo.SecurityLevel, authKey string, authProtocol snmpgo.AuthProtocol, privKey string, privProtocol snmpgo.PrivProtocol) snmpgo.SNMPArguments {
+		return snmpgo.SNMPArguments{
+			Version:          snmpgo.V3,
+			UserName:         name,
+			SecurityLevel:    level,
+			AuthPassword:     authKey,
+			AuthProtocol:     authProtocol,
+			PrivPassword:     privKey,
+			PrivProtocol:     privProtocol,
+			SecurityEngineId: testEngineID,
+		}
+	}
+
+	sendTrap(t, s, user("alice", snmpgo.AuthPriv, "alice_password", snmpgo.Sha, "alice_private", snmpgo.Aes), v2Trap("1.3.6.1.6.3.1.1.5.1"))
+	e := receiveEvent(t, eventOut)
+	assert.Equal(t, "SNMP trap coldStart from 127.0.0.1", e.Title)
+	assert.Contains(t, e.Tags, "snmp_version:3")
+
+	sendTrap(t, s, user("bob", snmpgo.AuthNoPriv, "bob_password", snmpgo.Md5, "", ""), v2Trap("1.3.6.1.6.3.1.1.5.2"))
+	e = receiveEvent(t, eventOut)
+	assert.Equal(t, "SNMP trap warmStart from 127.0.0.1", e.Title)
+
+	// the v2c traps are still received
+	sendTrap(t, s, community("public"), v2Trap("1.3.6.1.6.3.1.1.5.4"))
+	e = receiveEvent(t, eventOut)
+	assert.Equal(t, "SNMP trap linkUp from 127.0.0.1", e.Title)
+
+	// wrong password
+	sendTrap(t, s, user("bob", snmpgo.AuthNoPriv, "wrong_password", snmpgo.Md5, "", ""), v2Trap("1.3.6.1.6.3.1.1.5.2"))
+	assertNoEvent(t, eventOut)
+
+	// security level lower than the one of the user
+	sendTrap(t, s, user("alice", snmpgo.NoAuthNoPriv, "", "", "", ""), v2Trap("1.3.6.1.6.3.1.1.5.2"))
+	assertNoEvent(t, eventOut)
+
+	// unknown user
+	sendTrap(t, s, user("eve", snmpgo.NoAuthNoPriv, "", "", "", ""), v2Trap("1.3.6.1.6.3.1.1.5.2"))
+	assertNoEvent(t, eventOut)
+}
+
+func TestEventsDropped(t *testing.T) {
+	c := &Config{
+		Port:             freePort(t),
+		BindHost:         "127.0.0.1",
+		CommunityStrings: []string{"public"},
+		DBFolder:         trapsDBFolder,
+		Output:           EventsOutput,
+	}
+	// nobody reads the events
+	eventOut := make(chan metrics.Event, 1)
+	s, err := newTrapServer(c, eventOut, nil)
+	require.NoError(t, err)
+	defer s.Stop()
+
+	dropped := func() int64 {
+		if v, ok := trapsExpvars.Get("Dropped").(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	before := dropped()
+
+	sendTrap(t, s, community("public"), v2Trap("1.3.6.1.6.3.1.1.5.3", linkDownVariables...))
+	sendTrap(t, s, community("public"), v2Trap("1.3.6.1.6.3.1.1.5.4"))
+
+	// the second trap is dropped instead of blocking the listener
+	require.True(t, waitFor(func() bool { return dropped() == before+1 }), "the trap wasn't dropped")
+	e := receiveEvent(t, eventOut)
+	assert.Equal(t, "SNMP trap linkDown from 127.0.0.1", e.Title)
+}
+
+func waitFor(condition func() bool) bool {
+	for i := 0; i < 50; i++ {
+		if condition() {
+			return true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return false
+}
+
+func TestLogsOutput(t *testing.T) {
+	s, eventOut, logsOut := startTestServer(t, &Config{CommunityStrings: []string{"public"}, Output: LogsOutput})
+	defer s.Stop()
+
+	sendTrap(t, s, community("public"), v2Trap("1.3.6.1.6.3.1.1.5.3", linkDownVariables...))
+	var content []byte
+	select {
+	case content = <-logsOut:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no log received")
+	}
+
+	var l map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &l))
+	assert.Equal(t, map[string]interface{}{
+		"trap":         "linkDown",
+		"oid":          "1.3.6.1.6.3.1.1.5.3",
+		"mib":          "IF-MIB",
+		"snmp_version": "2c",
+		"device":       "127.0.0.1",
+		"uptime":       float64(1000),
+		"variables": map[string]interface{}{
+			"ifIndex":       float64(3),
+			"ifAdminStatus": "up",
+			"ifOperStatus":  "down",
+			"ifDescr":       "eth0",
+		},
+	}, l)
+	assertNoEvent(t, eventOut)
+}
+
+func TestServerPortInUse(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	c := &Config{
+		Port:             uint16(conn.LocalAddr().(*net.UDPAddr).Port),
+		BindHost:         "127.0.0.1",
+		CommunityStrings: []string{"public"},
+		DBFolder:         trapsDBFolder,
+		Output:           EventsOutput,
+	}
+	_, err = newTrapServer(c, nil, nil)
+	assert.Error(t, err)
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	units "github.com/docker/go-units"
 	"golang.org/x/text/unicode/norm"
 )
 
@@ -27,6 +28,8 @@ func init() {
 		"printDashes":        printDashes,
 		"formatUnixTime":     FormatUnixTime,
 		"humanize":           MkHuman,
+		"formatBytes":        FormatBytes,
+		"formatMillis":       FormatMillis,
 	}
 }
 
@@ -116,6 +119,19 @@ func MkHuman(f float64) string {
 	return str
 }
 
+// FormatBytes formats a size in bytes, e.g. 1.5MiB, sizes can be negative
+func FormatBytes(f float64) string {
+	if f < 0 {
+		return "-" + units.BytesSize(-f)
+	}
+	return units.BytesSize(f)
+}
+
+// FormatMillis formats a duration in milliseconds, e.g. 1m30s
+func FormatMillis(f float64) string {
+	return (time.Duration(f) * time.Millisecond).String()
+}
+
 func stringLength(s string) int {
 	/*
 		len(string) is wrong if the string has unicode characters in it,
@@ -35,6 +35,7 @@ func FormatStatus(data []byte) (string, error) {
 	json.Unmarshal(data, &stats)
 	forwarderStats := stats["forwarderStats"]
 	runnerStats := stats["runnerStats"]
+	schedulerStats := stats["schedulerStats"]
 	autoConfigStats := stats["autoConfigStats"]
 	aggregatorStats := stats["aggregatorStats"]
 	jmxStats := stats["JMXStatus"]
@@ -42,7 +43,7 @@ func FormatStatus(data []byte) (string, error) {
 	title := fmt.Sprintf("Agent (v%s)", stats["version"])
 	stats["title"] = title
 	renderHeader(b, stats)
-	renderChecksStats(b, runnerStats, autoConfigStats, "")
+	renderChecksStats(b, runnerStats, schedulerStats, autoConfigStats, "")
 	renderJMXFetchStatus(b, jmxStats)
 	renderForwarderStatus(b, forwarderStats)
 	renderLogsStatus(b, logsStats)
@@ -75,9 +76,10 @@ func renderForwarderStatus(w io.Writer, forwarderStats interface{}) {
 	}
 }
 
-func renderChecksStats(w io.Writer, runnerStats interface{}, autoConfigStats interface{}, onlyCheck string) {
+func renderChecksStats(w io.Writer, runnerStats interface{}, schedulerStats interface{}, autoConfigStats interface{}, onlyCheck string) {
 	checkStats := make(map[string]interface{})
 	checkStats["RunnerStats"] = runnerStats
+	checkStats["SchedulerStats"] = schedulerStats
 	checkStats["AutoConfigStats"] = autoConfigStats
 	checkStats["OnlyCheck"] = onlyCheck
 	t := template.Must(template.New("collector.tmpl").Funcs(fmap).ParseFiles(filepath.Join(templateFolder, "collector.tmpl")))
@@ -94,8 +96,9 @@ func renderCheckStats(data []byte, checkName string) (string, error) {
 	stats := make(map[string]interface{})
 	json.Unmarshal(data, &stats)
 	runnerStats := stats["runnerStats"]
+	schedulerStats := stats["schedulerStats"]
 	autoConfigStats := stats["autoConfigStats"]
-	renderChecksStats(b, runnerStats, autoConfigStats, checkName)
+	renderChecksStats(b, runnerStats, schedulerStats, autoConfigStats, checkName)
 
 	return b.String(), nil
 }
@@ -125,6 +125,11 @@ func expvarStats(stats map[string]interface{}) (map[string]interface{}, error) {
 	json.Unmarshal(runnerStatsJSON, &runnerStats)
 	stats["runnerStats"] = runnerStats
 
+	schedulerStatsJSON := []byte(expvar.Get("scheduler").String())
+	schedulerStats := make(map[string]interface{})
+	json.Unmarshal(schedulerStatsJSON, &schedulerStats)
+	stats["schedulerStats"] = schedulerStats
+
 	autoConfigStatsJSON := []byte(expvar.Get("autoconfig").String())
 	autoConfigStats := make(map[string]interface{})
 	json.Unmarshal(autoConfigStatsJSON, &autoConfigStats)
@@ -11,27 +11,22 @@ import (
 	"strings"
 
 	log "github.com/cihub/seelog"
-	"github.com/docker/docker/api/types"
 
 	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
 )
 
-// extractFromInspect extract tags for a container inspect JSON
-func (c *DockerCollector) extractFromInspect(co types.ContainerJSON) ([]string, []string, error) {
+// extractFromContainer extract tags for an inspected container
+func (c *DockerCollector) extractFromContainer(co *docker.Container) ([]string, []string, error) {
 	tags := utils.NewTagList()
 
-	//TODO: remove when Inspect returns resolved image names
-	dockerImage, err := c.dockerUtil.ResolveImageName(co.Image)
-	if err != nil {
-		log.Debugf("error resolving image %s: %s", co.Image, err)
-	} else {
-		dockerExtractImage(tags, dockerImage)
+	if co.Image != "" {
+		dockerExtractImage(tags, co.Image)
 	}
-	dockerExtractLabels(tags, co.Config.Labels, c.labelsAsTags)
-	dockerExtractEnvironmentVariables(tags, co.Config.Env, c.envAsTags)
+	dockerExtractLabels(tags, co.Labels, c.labelsAsTags)
+	dockerExtractEnvironmentVariables(tags, co.Env, c.envAsTags)
 
-	tags.AddHigh("container_name", strings.TrimPrefix(co.Name, "/"))
+	tags.AddHigh("container_name", co.Name)
 	tags.AddHigh("container_id", co.ID)
 
 	low, high := tags.Compute()
@@ -77,17 +72,8 @@ func dockerExtractLabels(tags *utils.TagList, containerLabels map[string]string,
 
 // dockerExtractEnvironmentVariables contain hard-coded environment variables from:
 // - Mesos/DCOS tags (mesos, marathon, chronos)
-func dockerExtractEnvironmentVariables(tags *utils.TagList, containerEnvVariables []string, envAsTags map[string]string) {
-	var envSplit []string
-	var envName, envValue string
-
-	for _, envEntry := range containerEnvVariables {
-		envSplit = strings.SplitN(envEntry, "=", 2)
-		if len(envSplit) != 2 {
-			continue
-		}
-		envName = envSplit[0]
-		envValue = envSplit[1]
+func dockerExtractEnvironmentVariables(tags *utils.TagList, containerEnvVariables map[string]string, envAsTags map[string]string) {
+	for envName, envValue := range containerEnvVariables {
 		switch envName {
 		// Mesos/DCOS tags (mesos, marathon, chronos)
 		case "MARATHON_APP_ID":
@@ -100,7 +86,7 @@ func dockerExtractEnvironmentVariables(tags *utils.TagList, containerEnvVariable
 			tags.AddHigh("mesos_task", envValue)
 
 		default:
-			if tagName, found := envAsTags[strings.ToLower(envSplit[0])]; found {
+			if tagName, found := envAsTags[strings.ToLower(envName)]; found {
 				tags.AddAuto(tagName, envValue)
 			}
 		}
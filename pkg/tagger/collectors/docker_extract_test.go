@@ -11,17 +11,16 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/container"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
 )
 
 func TestDockerRecordsFromInspect(t *testing.T) {
 	testCases := []struct {
 		testName             string
-		co                   *types.ContainerJSON
+		co                   *docker.Container
 		toRecordEnvAsTags    map[string]string
 		toRecordLabelsAsTags map[string]string
 		expectedLow          []string
@@ -29,11 +28,9 @@ func TestDockerRecordsFromInspect(t *testing.T) {
 	}{
 		{
 			testName: "emptyExtract",
-			co: &types.ContainerJSON{
-				Config: &container.Config{
-					Env:    []string{"k=v"},
-					Labels: map[string]string{"labelKey": "labelValue"},
-				},
+			co: &docker.Container{
+				Env:    map[string]string{"k": "v"},
+				Labels: map[string]string{"labelKey": "labelValue"},
 			},
 			toRecordEnvAsTags:    map[string]string{},
 			toRecordLabelsAsTags: map[string]string{},
@@ -42,11 +39,9 @@ func TestDockerRecordsFromInspect(t *testing.T) {
 		},
 		{
 			testName: "extractOneLowEnv",
-			co: &types.ContainerJSON{
-				Config: &container.Config{
-					Env:    []string{"k=v"},
-					Labels: map[string]string{"labelKey": "labelKey"},
-				},
+			co: &docker.Container{
+				Env:    map[string]string{"k": "v"},
+				Labels: map[string]string{"labelKey": "labelKey"},
 			},
 			toRecordEnvAsTags:    map[string]string{"k": "becomeK"},
 			toRecordLabelsAsTags: map[string]string{"labelKey": "labelValue"},
@@ -55,11 +50,9 @@ func TestDockerRecordsFromInspect(t *testing.T) {
 		},
 		{
 			testName: "extractTwoLowOneHigh",
-			co: &types.ContainerJSON{
-				Config: &container.Config{
-					Env:    []string{"k=v", "l=t"},
-					Labels: map[string]string{"labelKey": "labelValue"},
-				},
+			co: &docker.Container{
+				Env:    map[string]string{"k": "v", "l": "t"},
+				Labels: map[string]string{"labelKey": "labelValue"},
 			},
 			toRecordEnvAsTags:    map[string]string{"k": "+becomeK", "l": "expectedLow"},
 			toRecordLabelsAsTags: map[string]string{"labelkey": "labelKey"},
@@ -68,11 +61,9 @@ func TestDockerRecordsFromInspect(t *testing.T) {
 		},
 		{
 			testName: "extractOneLowTwoHigh",
-			co: &types.ContainerJSON{
-				Config: &container.Config{
-					Env:    []string{"k=v", "l=t"},
-					Labels: map[string]string{"labelKey": "labelValue"},
-				},
+			co: &docker.Container{
+				Env:    map[string]string{"k": "v", "l": "t"},
+				Labels: map[string]string{"labelKey": "labelValue"},
 			},
 			toRecordEnvAsTags:    map[string]string{"k": "+becomeK", "l": "expectedLow"},
 			toRecordLabelsAsTags: map[string]string{"labelkey": "+labelKey"},
@@ -81,16 +72,14 @@ func TestDockerRecordsFromInspect(t *testing.T) {
 		},
 		{
 			testName: "extractMesosDCOS",
-			co: &types.ContainerJSON{
-				Config: &container.Config{
-					Env: []string{
-						"MARATHON_APP_ID=/system/dd-agent",
-						"CHRONOS_JOB_NAME=app1_process-orders",
-						"CHRONOS_JOB_OWNER=qa",
-						"MESOS_TASK_ID=system_dd-agent.dcc75b42-4b87-11e7-9a62-70b3d5800001",
-					},
-					Labels: map[string]string{},
+			co: &docker.Container{
+				Env: map[string]string{
+					"MARATHON_APP_ID":   "/system/dd-agent",
+					"CHRONOS_JOB_NAME":  "app1_process-orders",
+					"CHRONOS_JOB_OWNER": "qa",
+					"MESOS_TASK_ID":     "system_dd-agent.dcc75b42-4b87-11e7-9a62-70b3d5800001",
 				},
+				Labels: map[string]string{},
 			},
 			toRecordEnvAsTags:    map[string]string{},
 			toRecordLabelsAsTags: map[string]string{},
@@ -103,14 +92,12 @@ func TestDockerRecordsFromInspect(t *testing.T) {
 		},
 		{
 			testName: "NoValue",
-			co: &types.ContainerJSON{
-				Config: &container.Config{
-					Env: []string{
-						"NOVALUE=",
-						"AVALUE=value",
-					},
-					Labels: map[string]string{},
+			co: &docker.Container{
+				Env: map[string]string{
+					"NOVALUE": "",
+					"AVALUE":  "value",
 				},
+				Labels: map[string]string{},
 			},
 			toRecordEnvAsTags:    map[string]string{"avalue": "v"},
 			toRecordLabelsAsTags: map[string]string{},
@@ -119,17 +106,15 @@ func TestDockerRecordsFromInspect(t *testing.T) {
 		},
 		{
 			testName: "extractSwarmLabels",
-			co: &types.ContainerJSON{
-				Config: &container.Config{
-					Env: []string{"PATH=/bin"},
-					Labels: map[string]string{
-						"com.docker.swarm.node.id":      "zdtab51ei97djzrpa1y2tz8li",
-						"com.docker.swarm.service.id":   "tef96xrdmlj82c7nt57jdntl8",
-						"com.docker.swarm.service.name": "helloworld",
-						"com.docker.swarm.task":         "",
-						"com.docker.swarm.task.id":      "knk1rz1szius7pvyznn9zolld",
-						"com.docker.swarm.task.name":    "helloworld.1.knk1rz1szius7pvyznn9zolld",
-					},
+			co: &docker.Container{
+				Env: map[string]string{"PATH": "/bin"},
+				Labels: map[string]string{
+					"com.docker.swarm.node.id":      "zdtab51ei97djzrpa1y2tz8li",
+					"com.docker.swarm.service.id":   "tef96xrdmlj82c7nt57jdntl8",
+					"com.docker.swarm.service.name": "helloworld",
+					"com.docker.swarm.task":         "",
+					"com.docker.swarm.task.id":      "knk1rz1szius7pvyznn9zolld",
+					"com.docker.swarm.task.name":    "helloworld.1.knk1rz1szius7pvyznn9zolld",
 				},
 			},
 			toRecordEnvAsTags:    map[string]string{},
@@ -139,17 +124,15 @@ func TestDockerRecordsFromInspect(t *testing.T) {
 		},
 		{
 			testName: "extractSwarmLabelsWithCustomLabelsAdds",
-			co: &types.ContainerJSON{
-				Config: &container.Config{
-					Env: []string{"PATH=/bin"},
-					Labels: map[string]string{
-						"com.docker.swarm.node.id":      "zdtab51ei97djzrpa1y2tz8li",
-						"com.docker.swarm.service.id":   "tef96xrdmlj82c7nt57jdntl8",
-						"com.docker.swarm.service.name": "helloworld",
-						"com.docker.swarm.task":         "",
-						"com.docker.swarm.task.id":      "knk1rz1szius7pvyznn9zolld",
-						"com.docker.swarm.task.name":    "helloworld.1.knk1rz1szius7pvyznn9zolld",
-					},
+			co: &docker.Container{
+				Env: map[string]string{"PATH": "/bin"},
+				Labels: map[string]string{
+					"com.docker.swarm.node.id":      "zdtab51ei97djzrpa1y2tz8li",
+					"com.docker.swarm.service.id":   "tef96xrdmlj82c7nt57jdntl8",
+					"com.docker.swarm.service.name": "helloworld",
+					"com.docker.swarm.task":         "",
+					"com.docker.swarm.task.id":      "knk1rz1szius7pvyznn9zolld",
+					"com.docker.swarm.task.name":    "helloworld.1.knk1rz1szius7pvyznn9zolld",
 				},
 			},
 			toRecordEnvAsTags: map[string]string{},
@@ -163,25 +146,23 @@ func TestDockerRecordsFromInspect(t *testing.T) {
 		},
 		{
 			testName: "extractRancherLabels",
-			co: &types.ContainerJSON{
-				Config: &container.Config{
-					Env: []string{"PATH=/bin"},
-					Labels: map[string]string{
-						"io.rancher.cni.network":             "ipsec",
-						"io.rancher.cni.wait":                "true",
-						"io.rancher.container.ip":            "10.42.234.7/16",
-						"io.rancher.container.mac_address":   "02:f1:dd:48:4c:d9",
-						"io.rancher.container.name":          "testAD-redis-1",
-						"io.rancher.container.pull_image":    "always",
-						"io.rancher.container.uuid":          "8e969193-2bc7-4a58-9a54-9eed44b01bb2",
-						"io.rancher.environment.uuid":        "adminProject",
-						"io.rancher.project.name":            "testAD",
-						"io.rancher.project_service.name":    "testAD/redis",
-						"io.rancher.service.deployment.unit": "06c082fc-4b66-4b6c-b098-30dbf29ed204",
-						"io.rancher.service.launch.config":   "io.rancher.service.primary.launch.config",
-						"io.rancher.stack.name":              "testAD",
-						"io.rancher.stack_service.name":      "testAD/redis",
-					},
+			co: &docker.Container{
+				Env: map[string]string{"PATH": "/bin"},
+				Labels: map[string]string{
+					"io.rancher.cni.network":             "ipsec",
+					"io.rancher.cni.wait":                "true",
+					"io.rancher.container.ip":            "10.42.234.7/16",
+					"io.rancher.container.mac_address":   "02:f1:dd:48:4c:d9",
+					"io.rancher.container.name":          "testAD-redis-1",
+					"io.rancher.container.pull_image":    "always",
+					"io.rancher.container.uuid":          "8e969193-2bc7-4a58-9a54-9eed44b01bb2",
+					"io.rancher.environment.uuid":        "adminProject",
+					"io.rancher.project.name":            "testAD",
+					"io.rancher.project_service.name":    "testAD/redis",
+					"io.rancher.service.deployment.unit": "06c082fc-4b66-4b6c-b098-30dbf29ed204",
+					"io.rancher.service.launch.config":   "io.rancher.service.primary.launch.config",
+					"io.rancher.stack.name":              "testAD",
+					"io.rancher.stack_service.name":      "testAD/redis",
 				},
 			},
 			toRecordEnvAsTags:    map[string]string{},
@@ -202,8 +183,8 @@ func TestDockerRecordsFromInspect(t *testing.T) {
 			dc.envAsTags = test.toRecordEnvAsTags
 			dc.labelsAsTags = test.toRecordLabelsAsTags
 			tags := utils.NewTagList()
-			dockerExtractEnvironmentVariables(tags, test.co.Config.Env, test.toRecordEnvAsTags)
-			dockerExtractLabels(tags, test.co.Config.Labels, test.toRecordLabelsAsTags)
+			dockerExtractEnvironmentVariables(tags, test.co.Env, test.toRecordEnvAsTags)
+			dockerExtractLabels(tags, test.co.Labels, test.toRecordLabelsAsTags)
 			low, high := tags.Compute()
 
 			// Low card tags
@@ -14,6 +14,7 @@ import (
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/container"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
 )
 
@@ -21,25 +22,26 @@ const (
 	dockerCollectorName = "docker"
 )
 
-// DockerCollector listens to events on the docker socket to get new/dead containers
-// and feed a stram of TagInfo. It requires access to the docker socket.
+// DockerCollector listens to the events of the container runtime set by the
+// container_runtime option to get new/dead containers and feed a stram of
+// TagInfo. It requires access to the docker or CRI socket.
 // It will also embed DockerExtractor collectors for container tagging.
 type DockerCollector struct {
-	dockerUtil   *docker.DockerUtil
+	runtime      container.Runtime
 	stop         chan bool
 	infoOut      chan<- []*TagInfo
 	labelsAsTags map[string]string
 	envAsTags    map[string]string
 }
 
-// Detect tries to connect to the docker socket and returns success
+// Detect tries to connect to the container runtime and returns success
 func (c *DockerCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error) {
-	du, err := docker.GetDockerUtil()
+	rt, err := container.GetRuntime()
 	if err != nil {
 		return NoCollection, err
 	}
 
-	c.dockerUtil = du
+	c.runtime = rt
 	c.stop = make(chan bool)
 	c.infoOut = out
 
@@ -55,7 +57,7 @@ func (c *DockerCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error)
 // Stream runs the continuous event watching loop and sends new info
 // to the channel. But be called in a goroutine.
 func (c *DockerCollector) Stream() error {
-	messages, errs, err := c.dockerUtil.SubscribeToContainerEvents("DockerCollector")
+	messages, errs, err := c.runtime.SubscribeToEvents("DockerCollector")
 	if err != nil {
 		return err
 	}
@@ -63,7 +65,7 @@ func (c *DockerCollector) Stream() error {
 	for {
 		select {
 		case <-c.stop:
-			return c.dockerUtil.UnsubscribeFromContainerEvents("DockerCollector")
+			return c.runtime.UnsubscribeFromEvents("DockerCollector")
 		case msg := <-messages:
 			c.processEvent(msg)
 		case err := <-errs:
@@ -83,12 +85,12 @@ func (c *DockerCollector) Stop() error {
 }
 
 // Fetch inspect a given container to get its tags on-demand (cache miss)
-func (c *DockerCollector) Fetch(container string) ([]string, []string, error) {
-	cid := strings.TrimPrefix(container, docker.DockerEntityPrefix)
-	if cid == container || len(cid) == 0 {
+func (c *DockerCollector) Fetch(entity string) ([]string, []string, error) {
+	parts := strings.SplitN(entity, "://", 2)
+	if len(parts) != 2 || len(parts[1]) == 0 {
 		return nil, nil, ErrNotFound
 	}
-	return c.fetchForDockerID(cid)
+	return c.fetchForContainer(entity, parts[1])
 }
 
 func (c *DockerCollector) processEvent(e *docker.ContainerEvent) {
@@ -98,20 +100,25 @@ func (c *DockerCollector) processEvent(e *docker.ContainerEvent) {
 	case "die":
 		out[0] = &TagInfo{Entity: e.ContainerEntityName(), Source: dockerCollectorName, DeleteEntity: true}
 	case "start":
-		low, high, _ := c.fetchForDockerID(e.ContainerID)
+		low, high, _ := c.fetchForContainer(e.ContainerEntityName(), e.ContainerID)
 		out[0] = &TagInfo{Entity: e.ContainerEntityName(), Source: dockerCollectorName, LowCardTags: low, HighCardTags: high}
 	}
 	c.infoOut <- out
 }
 
-func (c *DockerCollector) fetchForDockerID(cID string) ([]string, []string, error) {
-	co, err := c.dockerUtil.Inspect(cID, false)
+// fetchForContainer inspects a container of the runtime, the entities of the
+// other runtimes are not found
+func (c *DockerCollector) fetchForContainer(entity, cID string) ([]string, []string, error) {
+	co, err := c.runtime.InspectContainer(cID)
 	if err != nil {
 		// TODO separate "not found" and inspect error
 		log.Errorf("Failed to inspect container %s - %s", cID[:12], err)
 		return nil, nil, err
 	}
-	return c.extractFromInspect(co)
+	if co.EntityID != entity {
+		return nil, nil, ErrNotFound
+	}
+	return c.extractFromContainer(co)
 }
 
 func dockerFactory() Collector {
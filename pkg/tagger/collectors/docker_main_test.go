@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build docker
+
+package collectors
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+)
+
+// fakeRuntime is a container runtime serving a single container
+type fakeRuntime struct {
+	ctr *docker.Container
+}
+
+func (r *fakeRuntime) ListContainers(cfg *docker.ContainerListConfig) ([]*docker.Container, error) {
+	return []*docker.Container{r.ctr}, nil
+}
+
+func (r *fakeRuntime) InspectContainer(id string) (*docker.Container, error) {
+	if id != r.ctr.ID {
+		return nil, errors.New("container not found")
+	}
+	return r.ctr, nil
+}
+
+func (r *fakeRuntime) SubscribeToEvents(name string) (<-chan *docker.ContainerEvent, <-chan error, error) {
+	return nil, nil, errors.New("not supported")
+}
+
+func (r *fakeRuntime) UnsubscribeFromEvents(name string) error {
+	return errors.New("not supported")
+}
+
+func (r *fakeRuntime) ContainerLogs(id string, since string) (io.ReadCloser, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestDockerCollectorFetch(t *testing.T) {
+	c := &DockerCollector{runtime: &fakeRuntime{ctr: &docker.Container{
+		ID:       "3e13513f94b41d23429804243820438f",
+		EntityID: "containerd://3e13513f94b41d23429804243820438f",
+		Name:     "redis",
+		Image:    "docker.io/library/redis:4.0",
+		Labels:   map[string]string{"com.docker.swarm.service.name": "cache"},
+		Env:      map[string]string{"MARATHON_APP_ID": "/cache"},
+	}}}
+
+	low, high, err := c.Fetch("containerd://3e13513f94b41d23429804243820438f")
+	require.NoError(t, err)
+	assertTagInfoEqual(t, &TagInfo{
+		LowCardTags: []string{
+			"docker_image:docker.io/library/redis:4.0",
+			"image_name:docker.io/library/redis",
+			"image_tag:4.0",
+			"swarm_service:cache",
+			"marathon_app:/cache",
+		},
+		HighCardTags: []string{
+			"container_name:redis",
+			"container_id:3e13513f94b41d23429804243820438f",
+		},
+	}, &TagInfo{LowCardTags: low, HighCardTags: high})
+
+	// the entities of the other runtimes are not found
+	_, _, err = c.Fetch("docker://3e13513f94b41d23429804243820438f")
+	assert.Equal(t, ErrNotFound, err)
+	_, _, err = c.Fetch("containerd://")
+	assert.Equal(t, ErrNotFound, err)
+	_, _, err = c.Fetch("3e13513f94b41d23429804243820438f")
+	assert.Equal(t, ErrNotFound, err)
+}
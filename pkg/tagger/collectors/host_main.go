@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package collectors
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cloudtags"
+)
+
+const (
+	hostCollectorName = "host"
+
+	// HostEntity is the entity holding the tags of the host the agent runs on
+	HostEntity = "host"
+)
+
+// declared as a var to ease testing
+var getHostTags = cloudtags.GetTags
+
+// HostCollector feeds the cloud provider tags of the host, refreshed
+// according to `cloud_tags_refresh_interval`
+type HostCollector struct {
+	infoOut  chan<- []*TagInfo
+	m        sync.Mutex
+	lastTags []string
+}
+
+// Detect always succeeds, hosts outside of a cloud provider get no tags
+func (c *HostCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error) {
+	c.infoOut = out
+	return PullCollection, nil
+}
+
+// Pull sends the host tags when they changed since the last call
+func (c *HostCollector) Pull() error {
+	tags := getHostTags()
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.lastTags != nil && reflect.DeepEqual(tags, c.lastTags) {
+		return nil
+	}
+	c.lastTags = tags
+
+	c.infoOut <- []*TagInfo{{
+		Source:      hostCollectorName,
+		Entity:      HostEntity,
+		LowCardTags: tags,
+	}}
+	return nil
+}
+
+// Fetch returns the host tags for the host entity
+func (c *HostCollector) Fetch(entity string) ([]string, []string, error) {
+	if entity != HostEntity {
+		return nil, nil, ErrNotFound
+	}
+	return getHostTags(), []string{}, nil
+}
+
+func hostFactory() Collector {
+	return &HostCollector{}
+}
+
+func init() {
+	registerCollector(hostCollectorName, hostFactory)
+}
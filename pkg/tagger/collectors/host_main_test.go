@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package collectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cloudtags"
+)
+
+func TestHostCollector(t *testing.T) {
+	tags := []string{"region:us-east-1", "env:prod"}
+	getHostTags = func() []string { return tags }
+	defer func() { getHostTags = cloudtags.GetTags }()
+
+	out := make(chan []*TagInfo, 10)
+	c := &HostCollector{}
+	mode, err := c.Detect(out)
+	require.NoError(t, err)
+	assert.Equal(t, PullCollection, mode)
+
+	require.NoError(t, c.Pull())
+	require.Len(t, out, 1)
+	assertTagInfoEqual(t, &TagInfo{
+		Source:      hostCollectorName,
+		Entity:      HostEntity,
+		LowCardTags: []string{"region:us-east-1", "env:prod"},
+	}, (<-out)[0])
+
+	// unchanged tags are not sent again
+	require.NoError(t, c.Pull())
+	assert.Len(t, out, 0)
+
+	tags = []string{"region:us-east-1"}
+	require.NoError(t, c.Pull())
+	require.Len(t, out, 1)
+	assert.Equal(t, []string{"region:us-east-1"}, (<-out)[0].LowCardTags)
+
+	low, high, err := c.Fetch(HostEntity)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"region:us-east-1"}, low)
+	assert.Empty(t, high)
+
+	_, _, err = c.Fetch("docker://abcdef")
+	assert.Equal(t, ErrNotFound, err)
+}
@@ -0,0 +1,15 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !azure
+
+package azure
+
+// GetTags gets the tags from the Azure Metadata api
+func GetTags() ([]string, error) {
+	tags := []string{}
+
+	return tags, nil
+}
@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build azure
+
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// computeMetadata is the subset of the instance compute metadata used for tags
+type computeMetadata struct {
+	Location          string `json:"location"`
+	VMID              string `json:"vmId"`
+	VMSize            string `json:"vmSize"`
+	ResourceGroupName string `json:"resourceGroupName"`
+	SubscriptionID    string `json:"subscriptionId"`
+	// Tags holds the user tags as "key1:value1;key2:value2"
+	Tags string `json:"tags"`
+}
+
+// GetTags returns the tags of the VM from the Azure Metadata api: the tags set
+// by the user and tags describing the VM
+func GetTags() ([]string, error) {
+	tags := []string{}
+
+	res, err := getResponse(metadataURL + "/metadata/instance/compute?api-version=2017-08-01")
+	if err != nil {
+		return tags, fmt.Errorf("Azure Tags: unable to query metadata endpoint: %s", err)
+	}
+
+	defer res.Body.Close()
+	all, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return tags, fmt.Errorf("error while reading response from azure metadata endpoint: %s", err)
+	}
+
+	metadata := computeMetadata{}
+	if err := json.Unmarshal(all, &metadata); err != nil {
+		return tags, fmt.Errorf("unable to unmarshal azure metadata: %s", err)
+	}
+
+	for _, tag := range strings.Split(metadata.Tags, ";") {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if metadata.Location != "" {
+		tags = append(tags, fmt.Sprintf("region:%s", metadata.Location))
+	}
+	if metadata.VMSize != "" {
+		tags = append(tags, fmt.Sprintf("instance-type:%s", metadata.VMSize))
+	}
+	if metadata.VMID != "" {
+		tags = append(tags, fmt.Sprintf("instance-id:%s", metadata.VMID))
+	}
+	if metadata.ResourceGroupName != "" {
+		tags = append(tags, fmt.Sprintf("resource_group:%s", metadata.ResourceGroupName))
+	}
+	if metadata.SubscriptionID != "" {
+		tags = append(tags, fmt.Sprintf("subscription_id:%s", metadata.SubscriptionID))
+	}
+
+	return tags, nil
+}
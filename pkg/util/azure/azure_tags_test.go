@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build azure
+
+package azure
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTags(t *testing.T) {
+	var lastRequest *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{
+			"location": "westeurope",
+			"name": "myvm",
+			"resourceGroupName": "my-rg",
+			"subscriptionId": "8d10da13-8125-4ba9-a717-bf7490507b3d",
+			"tags": "env:prod;team:web",
+			"vmId": "5d33a910-a7a0-4443-9f01-6a807801b29b",
+			"vmSize": "Standard_D2s_v3"
+		}`)
+		lastRequest = r
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+
+	tags, err := GetTags()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"env:prod",
+		"team:web",
+		"region:westeurope",
+		"instance-type:Standard_D2s_v3",
+		"instance-id:5d33a910-a7a0-4443-9f01-6a807801b29b",
+		"resource_group:my-rg",
+		"subscription_id:8d10da13-8125-4ba9-a717-bf7490507b3d",
+	}, tags)
+	assert.Equal(t, "/metadata/instance/compute", lastRequest.URL.Path)
+	assert.Equal(t, "true", lastRequest.Header.Get("Metadata"))
+}
+
+func TestGetTagsError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+
+	tags, err := GetTags()
+	assert.NotNil(t, err)
+	assert.Len(t, tags, 0)
+}
@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package cloudtags
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/azure"
+	"github.com/DataDog/datadog-agent/pkg/util/ec2"
+	"github.com/DataDog/datadog-agent/pkg/util/gce"
+)
+
+// Names of the cloud providers
+const (
+	EC2   = "ec2"
+	GCE   = "gce"
+	Azure = "azure"
+)
+
+// declare these as vars not const to ease testing
+var (
+	providers = map[string]func() ([]string, error){
+		EC2:   ec2.GetTags,
+		GCE:   gce.GetTags,
+		Azure: azure.GetTags,
+	}
+	now = time.Now
+)
+
+var (
+	m           sync.Mutex
+	lastTags    map[string][]string
+	lastRefresh time.Time
+	// refreshing is closed once the running refresh is over, nil if none is
+	refreshing chan struct{}
+)
+
+// GetTagsByProvider returns the host tags of every cloud provider, filtered
+// with the `cloud_tags_include` and `cloud_tags_exclude` options. The tags are
+// cached for `cloud_tags_refresh_interval`, the last tags of a provider are
+// kept when its metadata service can't be reached. The metadata services are
+// queried without holding the cache lock, the callers get the cached tags
+// while they are refreshed.
+func GetTagsByProvider() map[string][]string {
+	refreshInterval := config.Datadog.GetDuration("cloud_tags_refresh_interval") * time.Second
+
+	m.Lock()
+	if refreshing == nil && (lastTags == nil || now().Sub(lastRefresh) >= refreshInterval) {
+		done := make(chan struct{})
+		refreshing = done
+		previous := lastTags
+		m.Unlock()
+
+		tags := fetchTags(previous)
+
+		m.Lock()
+		lastTags = tags
+		lastRefresh = now()
+		refreshing = nil
+		close(done)
+	} else if lastTags == nil {
+		// nothing is cached yet, wait for the first refresh
+		done := refreshing
+		m.Unlock()
+		<-done
+		m.Lock()
+	}
+
+	tags := make(map[string][]string, len(lastTags))
+	for name, providerTags := range lastTags {
+		tags[name] = append([]string{}, providerTags...)
+	}
+	m.Unlock()
+	return tags
+}
+
+// GetTags returns the host tags of all the cloud providers, see
+// GetTagsByProvider
+func GetTags() []string {
+	tagsByProvider := GetTagsByProvider()
+
+	names := make([]string, 0, len(tagsByProvider))
+	for name := range tagsByProvider {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tags := []string{}
+	for _, name := range names {
+		tags = append(tags, tagsByProvider[name]...)
+	}
+	return tags
+}
+
+// fetchTags queries every provider, the previous tags of a provider are
+// returned when it fails
+func fetchTags(previous map[string][]string) map[string][]string {
+	include := config.Datadog.GetStringSlice("cloud_tags_include")
+	exclude := config.Datadog.GetStringSlice("cloud_tags_exclude")
+
+	tags := make(map[string][]string, len(providers))
+	for name, getTags := range providers {
+		providerTags, err := getTags()
+		if err != nil {
+			log.Debugf("No %s host tags: %s", name, err)
+			if previousTags, found := previous[name]; found {
+				tags[name] = previousTags
+			}
+			continue
+		}
+		tags[name] = filterTags(providerTags, include, exclude)
+	}
+	return tags
+}
+
+// filterTags keeps the tags whose key matches one of the include patterns (all
+// of them if there's none) and none of the exclude patterns
+func filterTags(tags []string, include, exclude []string) []string {
+	filtered := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		key := strings.SplitN(tag, ":", 2)[0]
+		if len(include) > 0 && !matchAny(key, include) {
+			continue
+		}
+		if matchAny(key, exclude) {
+			continue
+		}
+		filtered = append(filtered, tag)
+	}
+	return filtered
+}
+
+func matchAny(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+	return false
+}
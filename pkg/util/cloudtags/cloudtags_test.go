@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package cloudtags
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// mockProviders replaces the providers and returns a function restoring them
+func mockProviders(mocks map[string]func() ([]string, error)) func() {
+	previous := providers
+	providers = mocks
+	lastTags = nil
+	return func() {
+		providers = previous
+		lastTags = nil
+	}
+}
+
+func TestGetTags(t *testing.T) {
+	defer mockProviders(map[string]func() ([]string, error){
+		EC2:   func() ([]string, error) { return []string{"env:prod"}, nil },
+		GCE:   func() ([]string, error) { return nil, fmt.Errorf("not on GCE") },
+		Azure: func() ([]string, error) { return []string{"region:westeurope"}, nil },
+	})()
+
+	assert.Equal(t, []string{"region:westeurope", "env:prod"}, GetTags())
+	assert.Equal(t, map[string][]string{
+		EC2:   {"env:prod"},
+		Azure: {"region:westeurope"},
+	}, GetTagsByProvider())
+}
+
+func TestGetTagsRefresh(t *testing.T) {
+	calls := 0
+	fail := false
+	defer mockProviders(map[string]func() ([]string, error){
+		EC2: func() ([]string, error) {
+			calls++
+			if fail {
+				return nil, fmt.Errorf("metadata service unavailable")
+			}
+			return []string{fmt.Sprintf("revision:%d", calls)}, nil
+		},
+	})()
+
+	current := time.Now()
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+	config.Datadog.Set("cloud_tags_refresh_interval", 60)
+	defer config.Datadog.Set("cloud_tags_refresh_interval", 600)
+
+	assert.Equal(t, []string{"revision:1"}, GetTags())
+
+	// cached
+	current = current.Add(30 * time.Second)
+	assert.Equal(t, []string{"revision:1"}, GetTags())
+	assert.Equal(t, 1, calls)
+
+	// refreshed
+	current = current.Add(30 * time.Second)
+	assert.Equal(t, []string{"revision:2"}, GetTags())
+
+	// the last tags are kept when the metadata service is unavailable
+	fail = true
+	current = current.Add(60 * time.Second)
+	assert.Equal(t, []string{"revision:2"}, GetTags())
+	assert.Equal(t, 3, calls)
+}
+
+func TestGetTagsRefreshUnlocked(t *testing.T) {
+	release := make(chan struct{})
+	defer mockProviders(map[string]func() ([]string, error){
+		EC2: func() ([]string, error) {
+			<-release
+			return []string{"env:prod"}, nil
+		},
+	})()
+
+	current := time.Now()
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+	config.Datadog.Set("cloud_tags_refresh_interval", 60)
+	defer config.Datadog.Set("cloud_tags_refresh_interval", 600)
+
+	// the callers wait for the first refresh
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, []string{"env:prod"}, GetTags())
+		}()
+	}
+	release <- struct{}{}
+	wg.Wait()
+
+	// then they get the cached tags while the provider is queried
+	current = current.Add(60 * time.Second)
+	refreshed := make(chan []string)
+	go func() { refreshed <- GetTags() }()
+	for running := false; !running; {
+		time.Sleep(time.Millisecond)
+		m.Lock()
+		running = refreshing != nil
+		m.Unlock()
+	}
+	assert.Equal(t, []string{"env:prod"}, GetTags())
+	release <- struct{}{}
+	assert.Equal(t, []string{"env:prod"}, <-refreshed)
+}
+
+func TestGetTagsFilters(t *testing.T) {
+	defer mockProviders(map[string]func() ([]string, error){
+		EC2: func() ([]string, error) {
+			return []string{"env:prod", "team:web", "aws:cloudformation:stack-name:web", "kubernetes.io/role:master", "standalone"}, nil
+		},
+	})()
+
+	config.Datadog.Set("cloud_tags_exclude", []string{"aws", "kubernetes.io/*"})
+	assert.Equal(t, []string{"env:prod", "team:web", "standalone"}, GetTags())
+
+	lastTags = nil
+	config.Datadog.Set("cloud_tags_include", []string{"env", "team"})
+	config.Datadog.Set("cloud_tags_exclude", []string{"team"})
+	defer config.Datadog.Set("cloud_tags_include", []string{})
+	defer config.Datadog.Set("cloud_tags_exclude", []string{})
+	assert.Equal(t, []string{"env:prod"}, GetTags())
+}
@@ -19,6 +19,7 @@ import (
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/retry"
 )
@@ -37,6 +38,13 @@ var globalClusterAgentClient *DCAClient
 
 type serviceNames []string
 
+// CheckConfigs holds the check configurations the cluster agent serves to
+// the node agents, the version changes whenever a configuration changes
+type CheckConfigs struct {
+	Version string         `json:"version"`
+	Configs []check.Config `json:"configs"`
+}
+
 // DCAClient is required to query the API of Datadog cluster agent
 type DCAClient struct {
 	// used to setup the DCAClient
@@ -221,3 +229,55 @@ func (c *DCAClient) GetKubernetesServiceNames(nodeName, podName string) ([]strin
 
 	return serviceNames, nil
 }
+
+// GetCheckConfigs queries the datadog cluster agent to get the check configurations
+// stored in Kubernetes ConfigMaps.
+func (c *DCAClient) GetCheckConfigs() (CheckConfigs, error) {
+	checkConfigs, _, err := c.GetCheckConfigsIfModified("")
+	return checkConfigs, err
+}
+
+// GetCheckConfigsIfModified queries the datadog cluster agent to get the check
+// configurations stored in Kubernetes ConfigMaps, unless their version is
+// still `version`: then the cluster agent doesn't send them again and
+// `modified` is false.
+func (c *DCAClient) GetCheckConfigsIfModified(version string) (checkConfigs CheckConfigs, modified bool, err error) {
+	const dcaCheckConfigsPath = "api/v1/checkconfigs"
+
+	req := &http.Request{
+		Header: http.Header{},
+	}
+	for k, v := range *c.clusterAgentAPIRequestHeaders {
+		req.Header[k] = v
+	}
+	if version != "" {
+		req.Header.Set("If-None-Match", fmt.Sprintf("%q", version))
+	}
+	// https://host:port /api/v1/checkconfigs
+	rawURL := fmt.Sprintf("%s/%s", c.clusterAgentAPIEndpoint, dcaCheckConfigsPath)
+	req.URL, err = url.Parse(rawURL)
+	if err != nil {
+		return checkConfigs, false, err
+	}
+
+	resp, err := c.clusterAgentAPIClient.Do(req)
+	if err != nil {
+		return checkConfigs, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		checkConfigs.Version = version
+		return checkConfigs, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return checkConfigs, false, fmt.Errorf("unexpected status code from cluster agent: %d", resp.StatusCode)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return checkConfigs, false, err
+	}
+	err = json.Unmarshal(b, &checkConfigs)
+	return checkConfigs, err == nil, err
+}
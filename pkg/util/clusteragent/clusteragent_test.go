@@ -24,11 +24,13 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/config"
 )
 
 type dummyClusterAgent struct {
-	responses map[string][]string
+	responses    map[string][]string
+	checkConfigs CheckConfigs
 	sync.RWMutex
 	token string
 }
@@ -43,6 +45,16 @@ func newDummyClusterAgent() (*dummyClusterAgent, error) {
 			"node2/pod-00005": {"svc3"},
 			"node2/pod-00006": {},
 		},
+		checkConfigs: CheckConfigs{
+			Version: "1",
+			Configs: []check.Config{
+				{
+					Name:       "http_check",
+					InitConfig: check.ConfigData("{}"),
+					Instances:  []check.ConfigData{check.ConfigData("url: http://example.com")},
+				},
+			},
+		},
 		token: config.Datadog.GetString("cluster_agent.auth_token"),
 	}
 	return dca, nil
@@ -56,6 +68,24 @@ func (d *dummyClusterAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(403)
 		return
 	}
+	if r.URL.Path == "/api/v1/checkconfigs" {
+		d.RLock()
+		defer d.RUnlock()
+		etag := fmt.Sprintf("%q", d.checkConfigs.Version)
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		b, err := json.Marshal(d.checkConfigs)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(b)
+		return
+	}
+
 	// path should be like: /api/v1/metadata/{nodeName}/{pod-[0-9a-z]+}
 	s := strings.Split(r.URL.Path, "/")
 	if len(s) != 6 {
@@ -117,6 +147,8 @@ func (suite *clusterAgentSuite) SetupTest() {
 	config.Datadog.Set("cluster_agent.kubernetes_service_name", "")
 	os.Unsetenv(clusterAgentServiceHost)
 	os.Unsetenv(clusterAgentServicePort)
+	// the client is initialized with the endpoint of each test
+	globalClusterAgentClient = nil
 }
 
 func (suite *clusterAgentSuite) TestGetClusterAgentEndpointEmpty() {
@@ -304,6 +336,35 @@ func (suite *clusterAgentSuite) TestGetKubernetesServiceNames() {
 	}
 }
 
+func (suite *clusterAgentSuite) TestGetCheckConfigs() {
+	dca, err := newDummyClusterAgent()
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+
+	ts, p, err := dca.StartTLS()
+	defer ts.Close()
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+
+	config.Datadog.Set("cluster_agent.url", fmt.Sprintf("https://127.0.0.1:%d", p))
+
+	ca, err := GetClusterAgentClient()
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+
+	checkConfigs, err := ca.GetCheckConfigs()
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+	assert.Equal(suite.T(), dca.checkConfigs, checkConfigs)
+
+	// the configurations are only sent again when their version changed
+	checkConfigs, modified, err := ca.GetCheckConfigsIfModified("1")
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+	assert.False(suite.T(), modified)
+	assert.Equal(suite.T(), CheckConfigs{Version: "1"}, checkConfigs)
+
+	checkConfigs, modified, err = ca.GetCheckConfigsIfModified("0")
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+	assert.True(suite.T(), modified)
+	assert.Equal(suite.T(), dca.checkConfigs, checkConfigs)
+}
+
 func TestClusterAgentSuite(t *testing.T) {
 	fakeDir, err := ioutil.TempDir("", "fake-datadog-etc")
 	require.Nil(t, err, fmt.Sprintf("%v", err))
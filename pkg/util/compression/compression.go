@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package compression
+
+import (
+	"compress/zlib"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const (
+	// NoneKind disables compression
+	NoneKind = "none"
+	// ZlibKind selects the zlib (deflate) codec
+	ZlibKind = "zlib"
+	// ZstdKind selects the Zstandard codec, only available when built with the `zstd` tag
+	ZstdKind = "zstd"
+
+	// DefaultLevel tells the codec to use its own default compression level
+	DefaultLevel = 0
+)
+
+// Compressor is a compression codec usable by the serializer
+type Compressor interface {
+	Compress(dst []byte, src []byte) ([]byte, error)
+	Decompress(dst []byte, src []byte) ([]byte, error)
+	// ContentEncoding returns the HTTP header value associated with the codec,
+	// empty if the payloads are left as-is
+	ContentEncoding() string
+}
+
+// factory builds a Compressor for the given level
+type factory func(level int) (Compressor, error)
+
+var (
+	factories = map[string]factory{}
+
+	// zlib is the default codec, as long as the agent doesn't override it
+	currentMutex sync.RWMutex
+	current      Compressor = &zlibCompressor{level: zlib.DefaultCompression}
+)
+
+// register makes a codec available under the given kind, it's meant to be
+// called from the `init` function of the codec implementations
+func register(kind string, f factory) {
+	factories[kind] = f
+}
+
+// AvailableKinds returns the list of codecs compiled in the binary
+func AvailableKinds() []string {
+	kinds := make([]string, 0, len(factories))
+	for kind := range factories {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// New returns a new Compressor for the given kind and level, without
+// changing the codec used by the package-level functions
+func New(kind string, level int) (Compressor, error) {
+	f, found := factories[kind]
+	if !found {
+		return nil, fmt.Errorf("unknown compression kind %q, available kinds in this build: %v", kind, AvailableKinds())
+	}
+	return f(level)
+}
+
+// Configure selects the codec used by Compress and Decompress
+func Configure(kind string, level int) error {
+	c, err := New(kind, level)
+	if err != nil {
+		return err
+	}
+
+	currentMutex.Lock()
+	defer currentMutex.Unlock()
+	current = c
+	return nil
+}
+
+func getCurrent() Compressor {
+	currentMutex.RLock()
+	defer currentMutex.RUnlock()
+	return current
+}
+
+// ContentEncoding returns the HTTP header value associated with the selected
+// codec, empty if the payloads are left as-is
+func ContentEncoding() string {
+	return getCurrent().ContentEncoding()
+}
+
+// Compress will compress the data with the selected codec
+func Compress(dst []byte, src []byte) ([]byte, error) {
+	return getCurrent().Compress(dst, src)
+}
+
+// Decompress will decompress the data with the selected codec
+func Decompress(dst []byte, src []byte) ([]byte, error) {
+	return getCurrent().Decompress(dst, src)
+}
@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package compression
+
+import (
+	"io/ioutil"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetCompression() {
+	Configure(ZlibKind, DefaultLevel)
+}
+
+func TestDefaultIsZlib(t *testing.T) {
+	assert.Equal(t, "deflate", ContentEncoding())
+	assert.Contains(t, AvailableKinds(), NoneKind)
+	assert.Contains(t, AvailableKinds(), ZlibKind)
+}
+
+func TestConfigure(t *testing.T) {
+	defer resetCompression()
+
+	require.Nil(t, Configure(NoneKind, DefaultLevel))
+	assert.Equal(t, "", ContentEncoding())
+
+	payload := []byte("some payload")
+	compressed, err := Compress(nil, payload)
+	require.Nil(t, err)
+	assert.Equal(t, payload, compressed)
+
+	require.Nil(t, Configure(ZlibKind, 9))
+	assert.Equal(t, "deflate", ContentEncoding())
+
+	err = Configure("lzma", DefaultLevel)
+	assert.NotNil(t, err)
+	// the previous codec is kept on error
+	assert.Equal(t, "deflate", ContentEncoding())
+
+	assert.NotNil(t, Configure(ZlibKind, 42))
+}
+
+func TestRoundTrip(t *testing.T) {
+	payload, err := ioutil.ReadFile("testdata/series.json")
+	require.Nil(t, err)
+
+	for _, kind := range AvailableKinds() {
+		c, err := New(kind, DefaultLevel)
+		require.Nil(t, err, kind)
+
+		compressed, err := c.Compress(nil, payload)
+		require.Nil(t, err, kind)
+		decompressed, err := c.Decompress(nil, compressed)
+		require.Nil(t, err, kind)
+		assert.Equal(t, payload, decompressed, kind)
+	}
+}
+
+// BenchmarkCompress compares the ratio and the CPU cost of the available codecs
+// on a payload captured from a running agent. Run it with:
+//   go test -tags zstd -bench Compress ./pkg/util/compression/
+func BenchmarkCompress(b *testing.B) {
+	payload, err := ioutil.ReadFile("testdata/series.json")
+	require.Nil(b, err)
+
+	levels := map[string][]int{
+		NoneKind: {DefaultLevel},
+		ZlibKind: {1, 6, 9},
+		ZstdKind: {1, 3, 9, 19},
+	}
+
+	for _, kind := range AvailableKinds() {
+		for _, level := range levels[kind] {
+			c, err := New(kind, level)
+			require.Nil(b, err)
+
+			compressed, err := c.Compress(nil, payload)
+			require.Nil(b, err)
+			name := kind + "-" + strconv.Itoa(level)
+			ratio := float64(len(payload)) / float64(len(compressed))
+			b.Logf("%s ratio: %.2f (%d bytes -> %d bytes)", name, ratio, len(payload), len(compressed))
+
+			b.Run(name, func(b *testing.B) {
+				b.SetBytes(int64(len(payload)))
+				b.ReportAllocs()
+				for n := 0; n < b.N; n++ {
+					c.Compress(nil, payload)
+				}
+			})
+		}
+	}
+}
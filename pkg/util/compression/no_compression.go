@@ -3,23 +3,30 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2018 Datadog, Inc.
 
-// +build !zlib,!zstd
-
 package compression
 
-// ContentEncoding describes the HTTP header value associated with the compression method
-// empty here since there's no compression
-// var instead of const to ease testing
-var ContentEncoding = ""
+func init() {
+	register(NoneKind, func(level int) (Compressor, error) {
+		return noneCompressor{}, nil
+	})
+}
+
+// noneCompressor will not compress anything
+type noneCompressor struct{}
 
 // Compress will not compress anything
-func Compress(dst []byte, src []byte) ([]byte, error) {
+func (noneCompressor) Compress(dst []byte, src []byte) ([]byte, error) {
 	dst = src
 	return dst, nil
 }
 
 // Decompress will not decompress anything
-func Decompress(dst []byte, src []byte) ([]byte, error) {
+func (noneCompressor) Decompress(dst []byte, src []byte) ([]byte, error) {
 	dst = src
 	return dst, nil
 }
+
+// ContentEncoding is empty here since there's no compression
+func (noneCompressor) ContentEncoding() string {
+	return ""
+}
@@ -7,21 +7,46 @@
 
 package compression
 
-import "github.com/DataDog/zstd"
+import (
+	"fmt"
 
-// TODO: the intake still uses a pre-v1 (unstable) version of the zstd compression format.
-// The agent shouldn't use zstd compression until the intake supports a stable v1 format.
+	"github.com/DataDog/zstd"
+)
 
-// ContentEncoding describes the HTTP header value associated with the compression method
-// var instead of const to ease testing
-var ContentEncoding = "zstd"
+// zstd levels above 19 need a lot of memory on both sides, the intake
+// doesn't accept them
+const zstdMaxLevel = 19
+
+func init() {
+	register(ZstdKind, newZstdCompressor)
+}
+
+// zstdCompressor compresses the data with the stable (v1) zstd format
+type zstdCompressor struct {
+	level int
+}
+
+func newZstdCompressor(level int) (Compressor, error) {
+	if level == DefaultLevel {
+		level = zstd.DefaultCompression
+	}
+	if level < zstd.BestSpeed || level > zstdMaxLevel {
+		return nil, fmt.Errorf("invalid zstd compression level %d, must be between %d and %d", level, zstd.BestSpeed, zstdMaxLevel)
+	}
+	return &zstdCompressor{level: level}, nil
+}
 
 // Compress will compress the data with zstd
-func Compress(dst []byte, src []byte) ([]byte, error) {
-	return zstd.Compress(dst, src)
+func (c *zstdCompressor) Compress(dst []byte, src []byte) ([]byte, error) {
+	return zstd.CompressLevel(dst, src, c.level)
 }
 
 // Decompress will decompress the data with zstd
-func Decompress(dst []byte, src []byte) ([]byte, error) {
+func (c *zstdCompressor) Decompress(dst []byte, src []byte) ([]byte, error) {
 	return zstd.Decompress(dst, src)
 }
+
+// ContentEncoding returns the HTTP header value associated with zstd
+func (c *zstdCompressor) ContentEncoding() string {
+	return "zstd"
+}
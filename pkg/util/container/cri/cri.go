@@ -0,0 +1,278 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cri
+
+package cri
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"google.golang.org/grpc"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/container"
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+	"github.com/DataDog/datadog-agent/pkg/util/retry"
+)
+
+const criRuntimeName = "cri"
+
+var (
+	globalCRIUtil *CRIUtil
+
+	// defaultSockets are the CRI sockets looked for when cri_socket_path is unset
+	defaultSockets = []string{
+		"/var/run/containerd/containerd.sock",
+		"/var/run/crio/crio.sock",
+	}
+
+	// For testing purpose
+	scrapeAllCgroups = docker.ScrapeAllCgroups
+)
+
+// CRIUtil wraps interactions with a runtime implementing the kubernetes
+// Container Runtime Interface over gRPC. It implements container.Runtime.
+type CRIUtil struct {
+	// used to setup the CRIUtil
+	initRetry retry.Retrier
+
+	sync.Mutex
+	conn           *grpc.ClientConn
+	client         pb.RuntimeServiceClient
+	runtimeName    string
+	queryTimeout   time.Duration
+	eventsInterval time.Duration
+	filter         *docker.Filter
+	// event subscribers and state
+	eventState *eventStreamState
+}
+
+// GetCRIUtil returns a ready to use CRIUtil. It is backed by a shared singleton.
+func GetCRIUtil() (*CRIUtil, error) {
+	if globalCRIUtil == nil {
+		globalCRIUtil = &CRIUtil{}
+		globalCRIUtil.initRetry.SetupRetrier(&retry.Config{
+			Name:          "criutil",
+			AttemptMethod: globalCRIUtil.init,
+			Strategy:      retry.RetryCount,
+			RetryCount:    10,
+			RetryDelay:    30 * time.Second,
+		})
+	}
+	err := globalCRIUtil.initRetry.TriggerRetry()
+	if err != nil {
+		log.Debugf("init error: %s", err)
+		return nil, err
+	}
+	return globalCRIUtil, nil
+}
+
+// init makes an empty CRIUtil bootstrap itself.
+// This is not exposed as public API but is called by the retrier embed.
+func (c *CRIUtil) init() error {
+	socket, err := criSocketPath()
+	if err != nil {
+		return err
+	}
+
+	filter, err := docker.NewFilterFromConfig()
+	if err != nil {
+		return err
+	}
+
+	connectionTimeout := time.Duration(config.Datadog.GetInt64("cri_connection_timeout")) * time.Second
+	conn, err := grpc.Dial(socket,
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.WithTimeout(connectionTimeout),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, timeout)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("could not connect to the CRI socket %s: %s", socket, err)
+	}
+
+	c.conn = conn
+	c.client = pb.NewRuntimeServiceClient(conn)
+	c.queryTimeout = time.Duration(config.Datadog.GetInt64("cri_query_timeout")) * time.Second
+	c.eventsInterval = time.Duration(config.Datadog.GetInt64("cri_events_interval")) * time.Second
+	c.filter = filter
+	c.eventState = newEventStreamState()
+
+	// the runtime name prefixes the container IDs reported by the kubelet,
+	// such as containerd:// or cri-o://
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+	version, err := c.client.Version(ctx, &pb.VersionRequest{})
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("could not get the version of the CRI runtime: %s", err)
+	}
+	c.runtimeName = version.RuntimeName
+	log.Debugf("connected to %s %s through %s", version.RuntimeName, version.RuntimeVersion, socket)
+	return nil
+}
+
+// criSocketPath returns the cri_socket_path option, or the first default
+// socket found
+func criSocketPath() (string, error) {
+	if socket := config.Datadog.GetString("cri_socket_path"); socket != "" {
+		return socket, nil
+	}
+	for _, socket := range defaultSockets {
+		if _, err := os.Stat(socket); err == nil {
+			return socket, nil
+		}
+	}
+	return "", fmt.Errorf("no CRI socket found, please set the cri_socket_path option")
+}
+
+// ListContainers returns the containers and their labels, with the cgroup
+// statistics of the running ones. The exited and the excluded containers
+// are listed as set by the ContainerListConfig.
+func (c *CRIUtil) ListContainers(cfg *docker.ContainerListConfig) ([]*docker.Container, error) {
+	cgByContainer, err := scrapeAllCgroups()
+	if err != nil {
+		return nil, fmt.Errorf("could not get cgroups: %s", err)
+	}
+
+	var stateFilter *pb.ContainerStateValue
+	if !cfg.IncludeExited {
+		stateFilter = &pb.ContainerStateValue{State: pb.ContainerState_CONTAINER_RUNNING}
+	}
+	criContainers, err := c.listContainers(stateFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]*docker.Container, 0, len(criContainers))
+	for _, criContainer := range criContainers {
+		ctr := c.convertContainer(criContainer)
+		ctr.Excluded = c.filter.IsExcluded(ctr)
+		if ctr.Excluded && !cfg.FlagExcluded {
+			continue
+		}
+		if ctr.State != docker.ContainerRunningState || ctr.Excluded {
+			containers = append(containers, ctr)
+			continue
+		}
+		cgroup, found := cgByContainer[ctr.ID]
+		if !found {
+			log.Debugf("container id %s has no cgroup, skipping", ctr.ID)
+			continue
+		}
+		ctr.SetCgroup(cgroup)
+		if err := ctr.FillCgroupStats(); err != nil {
+			log.Debugf("skipping container %s: %s", ctr.ID, err)
+			continue
+		}
+		containers = append(containers, ctr)
+	}
+	return containers, nil
+}
+
+// InspectContainer returns the details of a container, without statistics
+func (c *CRIUtil) InspectContainer(id string) (*docker.Container, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+	resp, err := c.client.ContainerStatus(ctx, &pb.ContainerStatusRequest{ContainerId: id})
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting container %s: %s", id, err)
+	}
+	status := resp.GetStatus()
+	if status == nil {
+		return nil, fmt.Errorf("no status returned for container %s", id)
+	}
+
+	ctr := &docker.Container{
+		Type:      c.runtimeName,
+		ID:        status.Id,
+		EntityID:  c.entityID(status.Id),
+		Name:      status.GetMetadata().GetName(),
+		Image:     status.GetImage().GetImage(),
+		ImageID:   status.ImageRef,
+		Created:   nanoToSeconds(status.CreatedAt),
+		StartedAt: nanoToSeconds(status.StartedAt),
+		State:     convertState(status.State),
+		Labels:    status.Labels,
+		// the CRI exposes neither the environment nor the network of the
+		// containers
+		Env:   map[string]string{},
+		Hosts: map[string]string{},
+	}
+	ctr.Excluded = c.filter.IsExcluded(ctr)
+	return ctr, nil
+}
+
+// listContainers returns the containers of the runtime in a given state,
+// all of them if the state is nil
+func (c *CRIUtil) listContainers(state *pb.ContainerStateValue) ([]*pb.Container, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+	resp, err := c.client.ListContainers(ctx, &pb.ListContainersRequest{
+		Filter: &pb.ContainerFilter{State: state},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing containers: %s", err)
+	}
+	return resp.GetContainers(), nil
+}
+
+// convertContainer converts a CRI container to the runtime-neutral Container
+func (c *CRIUtil) convertContainer(criContainer *pb.Container) *docker.Container {
+	return &docker.Container{
+		Type:     c.runtimeName,
+		ID:       criContainer.Id,
+		EntityID: c.entityID(criContainer.Id),
+		Name:     criContainer.GetMetadata().GetName(),
+		Image:    criContainer.GetImage().GetImage(),
+		ImageID:  criContainer.ImageRef,
+		Created:  nanoToSeconds(criContainer.CreatedAt),
+		State:    convertState(criContainer.State),
+		Labels:   criContainer.Labels,
+	}
+}
+
+// entityID returns the tagger entity of a container, formatted as the
+// container IDs of the kubelet pod statuses
+func (c *CRIUtil) entityID(id string) string {
+	return fmt.Sprintf("%s://%s", c.runtimeName, id)
+}
+
+// convertState converts a CRI container state to the docker ones
+func convertState(state pb.ContainerState) string {
+	switch state {
+	case pb.ContainerState_CONTAINER_CREATED:
+		return docker.ContainerCreatedState
+	case pb.ContainerState_CONTAINER_RUNNING:
+		return docker.ContainerRunningState
+	case pb.ContainerState_CONTAINER_EXITED:
+		return docker.ContainerExitedState
+	default:
+		return ""
+	}
+}
+
+// nanoToSeconds converts the nanosecond timestamps of the CRI
+func nanoToSeconds(t int64) int64 {
+	return t / int64(time.Second)
+}
+
+func criRuntimeFactory() (container.Runtime, error) {
+	return GetCRIUtil()
+}
+
+func init() {
+	container.RegisterRuntime(criRuntimeName, criRuntimeFactory)
+}
@@ -0,0 +1,302 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cri
+
+package cri
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+)
+
+const (
+	redisID = "3e13513f94b41d23429804243820438fb9a214238bf2d4f384741a48b575670a"
+	nginxID = "5b0d7a43c31e8e3b1a6b1d3e9ab2d1c0f4a1c1d3f46d0a3b5d1b9d0e8c7b6a51"
+	pauseID = "8c2a2e8ef7e63b1ab0c3a8d52b6b0cd2d1a6c6b3f8a3b1d4e5f6a7b8c9d0e1f2"
+)
+
+// fakeRuntimeService is a CRI runtime serving a list of containers, the
+// calls it does not implement panic through the nil embedded interface
+type fakeRuntimeService struct {
+	pb.RuntimeServiceServer
+	sync.Mutex
+	containers []*pb.Container
+	logPath    string
+}
+
+func (f *fakeRuntimeService) Version(ctx context.Context, req *pb.VersionRequest) (*pb.VersionResponse, error) {
+	return &pb.VersionResponse{
+		Version:           "0.1.0",
+		RuntimeName:       "containerd",
+		RuntimeVersion:    "v1.1.0",
+		RuntimeApiVersion: "v1alpha2",
+	}, nil
+}
+
+func (f *fakeRuntimeService) ListContainers(ctx context.Context, req *pb.ListContainersRequest) (*pb.ListContainersResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	var containers []*pb.Container
+	for _, ctr := range f.containers {
+		if filter := req.GetFilter(); filter != nil && filter.State != nil && filter.State.State != ctr.State {
+			continue
+		}
+		containers = append(containers, ctr)
+	}
+	return &pb.ListContainersResponse{Containers: containers}, nil
+}
+
+func (f *fakeRuntimeService) ContainerStatus(ctx context.Context, req *pb.ContainerStatusRequest) (*pb.ContainerStatusResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	for _, ctr := range f.containers {
+		if ctr.Id != req.ContainerId {
+			continue
+		}
+		return &pb.ContainerStatusResponse{Status: &pb.ContainerStatus{
+			Id:        ctr.Id,
+			Metadata:  ctr.Metadata,
+			State:     ctr.State,
+			CreatedAt: ctr.CreatedAt,
+			StartedAt: ctr.CreatedAt + int64(time.Second),
+			Image:     ctr.Image,
+			ImageRef:  ctr.ImageRef,
+			Labels:    ctr.Labels,
+			LogPath:   f.logPath,
+		}}, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "container %s not found", req.ContainerId)
+}
+
+func (f *fakeRuntimeService) setContainers(containers ...*pb.Container) {
+	f.Lock()
+	defer f.Unlock()
+	f.containers = containers
+}
+
+func newCRIContainer(id, name, image string, state pb.ContainerState) *pb.Container {
+	return &pb.Container{
+		Id:           id,
+		PodSandboxId: "sandbox-" + name,
+		Metadata:     &pb.ContainerMetadata{Name: name},
+		Image:        &pb.ImageSpec{Image: image},
+		ImageRef:     "sha256:" + id,
+		State:        state,
+		CreatedAt:    1525012345 * int64(time.Second),
+		Labels:       map[string]string{"io.kubernetes.container.name": name},
+	}
+}
+
+// startFakeRuntime serves the fake runtime on a unix socket and returns a
+// CRIUtil connected to it
+func startFakeRuntime(t *testing.T, fake *fakeRuntimeService) (*CRIUtil, func()) {
+	dir, err := ioutil.TempDir("", "cri-test")
+	require.NoError(t, err)
+	socket := filepath.Join(dir, "cri.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	pb.RegisterRuntimeServiceServer(server, fake)
+	go server.Serve(listener)
+
+	config.Datadog.Set("cri_socket_path", socket)
+	config.Datadog.Set("ac_exclude", []string{"image:k8s.gcr.io/pause.*"})
+	cu := &CRIUtil{}
+	require.NoError(t, cu.init())
+
+	return cu, func() {
+		cu.conn.Close()
+		server.Stop()
+		os.RemoveAll(dir)
+		config.Datadog.Set("cri_socket_path", "")
+		config.Datadog.Set("ac_exclude", []string{})
+	}
+}
+
+// fakeCgroup returns a cgroup v2 directory reporting some memory usage
+func fakeCgroup(t *testing.T, root, id string) *docker.ContainerCgroup {
+	dir := filepath.Join(root, "kubepods", id)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "memory.stat"), []byte("anon 1048576\nfile 4096\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pids.current"), []byte("3\n"), 0644))
+	return &docker.ContainerCgroup{
+		ContainerID: id,
+		Pids:        []int32{42},
+		Paths:       map[string]string{"unified": filepath.Join("kubepods", id)},
+		Mounts:      map[string]string{"unified": root},
+	}
+}
+
+func TestInit(t *testing.T) {
+	cu, stop := startFakeRuntime(t, &fakeRuntimeService{})
+	defer stop()
+
+	assert.Equal(t, "containerd", cu.runtimeName)
+	assert.Equal(t, "containerd://"+redisID, cu.entityID(redisID))
+}
+
+func TestInitNoSocket(t *testing.T) {
+	config.Datadog.Set("cri_socket_path", "/does/not/exist.sock")
+	defer config.Datadog.Set("cri_socket_path", "")
+
+	cu := &CRIUtil{}
+	assert.Error(t, cu.init())
+}
+
+func TestListContainers(t *testing.T) {
+	fake := &fakeRuntimeService{}
+	fake.setContainers(
+		newCRIContainer(redisID, "redis", "docker.io/library/redis:4.0", pb.ContainerState_CONTAINER_RUNNING),
+		newCRIContainer(nginxID, "nginx", "docker.io/library/nginx:1.13", pb.ContainerState_CONTAINER_EXITED),
+		newCRIContainer(pauseID, "POD", "k8s.gcr.io/pause:3.1", pb.ContainerState_CONTAINER_RUNNING),
+	)
+	cu, stop := startFakeRuntime(t, fake)
+	defer stop()
+
+	cgroupRoot, err := ioutil.TempDir("", "cri-cgroups")
+	require.NoError(t, err)
+	defer os.RemoveAll(cgroupRoot)
+	defer func() { scrapeAllCgroups = docker.ScrapeAllCgroups }()
+	scrapeAllCgroups = func() (map[string]*docker.ContainerCgroup, error) {
+		return map[string]*docker.ContainerCgroup{
+			redisID: fakeCgroup(t, cgroupRoot, redisID),
+			pauseID: fakeCgroup(t, cgroupRoot, pauseID),
+		}, nil
+	}
+
+	// the exited and the excluded pause containers are not listed
+	containers, err := cu.ListContainers(&docker.ContainerListConfig{})
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+
+	redis := containers[0]
+	assert.Equal(t, "containerd", redis.Type)
+	assert.Equal(t, redisID, redis.ID)
+	assert.Equal(t, "containerd://"+redisID, redis.EntityID)
+	assert.Equal(t, "redis", redis.Name)
+	assert.Equal(t, "docker.io/library/redis:4.0", redis.Image)
+	assert.Equal(t, "sha256:"+redisID, redis.ImageID)
+	assert.Equal(t, int64(1525012345), redis.Created)
+	assert.Equal(t, docker.ContainerRunningState, redis.State)
+	assert.Equal(t, []int32{42}, redis.Pids)
+	require.NotNil(t, redis.Memory)
+	assert.Equal(t, uint64(1048576), redis.Memory.RSS)
+	assert.Equal(t, uint64(3), redis.ThreadCount)
+	assert.Equal(t, map[string]string{"io.kubernetes.container.name": "redis"}, redis.Labels)
+
+	// they are when asked for, without statistics
+	containers, err = cu.ListContainers(&docker.ContainerListConfig{IncludeExited: true, FlagExcluded: true})
+	require.NoError(t, err)
+	require.Len(t, containers, 3)
+	byID := make(map[string]*docker.Container)
+	for _, ctr := range containers {
+		byID[ctr.ID] = ctr
+	}
+	require.Contains(t, byID, nginxID)
+	assert.Equal(t, docker.ContainerExitedState, byID[nginxID].State)
+	assert.Nil(t, byID[nginxID].Memory)
+	require.Contains(t, byID, pauseID)
+	assert.True(t, byID[pauseID].Excluded)
+	assert.Nil(t, byID[pauseID].Memory)
+	require.Contains(t, byID, redisID)
+	assert.NotNil(t, byID[redisID].Memory)
+}
+
+func TestInspectContainer(t *testing.T) {
+	fake := &fakeRuntimeService{}
+	fake.setContainers(newCRIContainer(nginxID, "nginx", "docker.io/library/nginx:1.13", pb.ContainerState_CONTAINER_EXITED))
+	cu, stop := startFakeRuntime(t, fake)
+	defer stop()
+
+	nginx, err := cu.InspectContainer(nginxID)
+	require.NoError(t, err)
+	assert.Equal(t, "containerd://"+nginxID, nginx.EntityID)
+	assert.Equal(t, "nginx", nginx.Name)
+	assert.Equal(t, docker.ContainerExitedState, nginx.State)
+	assert.Equal(t, int64(1525012346), nginx.StartedAt)
+	assert.False(t, nginx.Excluded)
+	assert.Equal(t, "nginx", nginx.Labels["io.kubernetes.container.name"])
+
+	_, err = cu.InspectContainer(redisID)
+	assert.Error(t, err)
+}
+
+func TestEvents(t *testing.T) {
+	fake := &fakeRuntimeService{}
+	redis := newCRIContainer(redisID, "redis", "docker.io/library/redis:4.0", pb.ContainerState_CONTAINER_RUNNING)
+	fake.setContainers(redis)
+	cu, stop := startFakeRuntime(t, fake)
+	defer stop()
+	cu.eventsInterval = 10 * time.Millisecond
+
+	events, errs, err := cu.SubscribeToEvents("test")
+	require.NoError(t, err)
+	_, _, err = cu.SubscribeToEvents("test")
+	assert.Equal(t, docker.ErrAlreadySubscribed, err)
+
+	// let the dispatcher list the initial containers
+	time.Sleep(50 * time.Millisecond)
+	fake.setContainers(newCRIContainer(nginxID, "nginx", "docker.io/library/nginx:1.13", pb.ContainerState_CONTAINER_RUNNING))
+
+	var received []*docker.ContainerEvent
+	for len(received) < 2 {
+		select {
+		case ev := <-events:
+			received = append(received, ev)
+		case err := <-errs:
+			require.FailNow(t, "unexpected error", "%s", err)
+		case <-time.After(2 * time.Second):
+			require.FailNow(t, "timeout waiting for the container events")
+		}
+	}
+
+	assert.Equal(t, redisID, received[0].ContainerID)
+	assert.Equal(t, "die", received[0].Action)
+	assert.Equal(t, "redis", received[0].ContainerName)
+	assert.Equal(t, nginxID, received[1].ContainerID)
+	assert.Equal(t, "start", received[1].Action)
+	assert.Equal(t, "docker.io/library/nginx:1.13", received[1].ImageName)
+
+	assert.NoError(t, cu.UnsubscribeFromEvents("test"))
+	assert.Equal(t, docker.ErrNotSubscribed, cu.UnsubscribeFromEvents("test"))
+}
+
+func TestDiffContainers(t *testing.T) {
+	redis := newCRIContainer(redisID, "redis", "redis", pb.ContainerState_CONTAINER_RUNNING)
+	nginx := newCRIContainer(nginxID, "nginx", "nginx", pb.ContainerState_CONTAINER_RUNNING)
+	now := time.Now()
+
+	assert.Empty(t, diffContainers(
+		map[string]*pb.Container{redisID: redis},
+		map[string]*pb.Container{redisID: redis},
+		now,
+	))
+
+	events := diffContainers(nil, map[string]*pb.Container{redisID: redis, nginxID: nginx}, now)
+	require.Len(t, events, 2)
+	assert.Equal(t, nginxID, events[0].ContainerID)
+	assert.Equal(t, "start", events[0].Action)
+	assert.Equal(t, now, events[0].Timestamp)
+	assert.Equal(t, redisID, events[1].ContainerID)
+	assert.Equal(t, "start", events[1].Action)
+}
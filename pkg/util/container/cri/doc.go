@@ -0,0 +1,11 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+/*
+Package cri implements the container Runtime over the kubernetes Container
+Runtime Interface, to collect the containers of containerd and CRI-O
+
+*/
+package cri
@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cri
+
+package cri
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+)
+
+const (
+	eventSendBuffer  = 5
+	eventSendTimeout = 100 * time.Millisecond
+)
+
+// eventSubscriber holds the state for a subscriber
+type eventSubscriber struct {
+	name      string
+	eventChan chan *docker.ContainerEvent
+	errorChan chan error
+}
+
+// eventStreamState holds the state for event streaming towards subscribers
+type eventStreamState struct {
+	sync.RWMutex
+	subscribers map[string]*eventSubscriber
+	cancelChan  chan struct{}
+	running     bool
+}
+
+func newEventStreamState() *eventStreamState {
+	return &eventStreamState{
+		subscribers: make(map[string]*eventSubscriber),
+		// buffered so that the dispatch goroutine can stop itself
+		cancelChan: make(chan struct{}, 1),
+	}
+}
+
+// SubscribeToEvents allows a package to subscribe to the container events.
+// CRI runtimes have no event stream, start and die events are computed by
+// listing the running containers every cri_events_interval.
+// A unique subscriber name should be provided.
+func (c *CRIUtil) SubscribeToEvents(name string) (<-chan *docker.ContainerEvent, <-chan error, error) {
+	e := c.eventState
+	e.Lock()
+	defer e.Unlock()
+	if _, found := e.subscribers[name]; found {
+		return nil, nil, docker.ErrAlreadySubscribed
+	}
+
+	sub := &eventSubscriber{
+		name:      name,
+		eventChan: make(chan *docker.ContainerEvent, eventSendBuffer),
+		errorChan: make(chan error, 1),
+	}
+	e.subscribers[name] = sub
+	if !e.running {
+		e.running = true
+		go c.dispatchEvents(e.cancelChan)
+	}
+	return sub.eventChan, sub.errorChan, nil
+}
+
+// UnsubscribeFromEvents allows a package to unsubscribe.
+func (c *CRIUtil) UnsubscribeFromEvents(name string) error {
+	e := c.eventState
+	e.Lock()
+	defer e.Unlock()
+
+	sub, found := e.subscribers[name]
+	if !found {
+		return docker.ErrNotSubscribed
+	}
+	close(sub.errorChan)
+	close(sub.eventChan)
+	delete(e.subscribers, name)
+
+	// Stop dispatch if no subs remaining
+	if e.running && len(e.subscribers) == 0 {
+		e.running = false
+		select {
+		case e.cancelChan <- struct{}{}:
+		default:
+			// a stop is already pending
+		}
+	}
+	return nil
+}
+
+// dispatchEvents polls the running containers and sends the start and die
+// events computed from their changes
+func (c *CRIUtil) dispatchEvents(cancelChan <-chan struct{}) {
+	ticker := time.NewTicker(c.eventsInterval)
+	defer ticker.Stop()
+
+	running, err := c.runningContainers()
+	if err != nil {
+		log.Warnf("error listing the running containers: %s", err)
+	}
+	for {
+		select {
+		case <-cancelChan:
+			return
+		case now := <-ticker.C:
+			current, err := c.runningContainers()
+			if err != nil {
+				log.Warnf("error listing the running containers: %s", err)
+				continue
+			}
+			events := diffContainers(running, current, now)
+			running = current
+
+			for _, event := range events {
+				event.EntityID = c.entityID(event.ContainerID)
+				for _, sub := range c.eventState.dispatch(event) {
+					c.UnsubscribeFromEvents(sub.name)
+				}
+			}
+		}
+	}
+}
+
+// runningContainers returns the running containers by ID
+func (c *CRIUtil) runningContainers() (map[string]*pb.Container, error) {
+	containers, err := c.listContainers(&pb.ContainerStateValue{State: pb.ContainerState_CONTAINER_RUNNING})
+	if err != nil {
+		return nil, err
+	}
+	running := make(map[string]*pb.Container, len(containers))
+	for _, ctr := range containers {
+		running[ctr.Id] = ctr
+	}
+	return running, nil
+}
+
+// diffContainers returns a start event for the containers of current that
+// were not running before, and a die event for the ones that stopped
+func diffContainers(previous, current map[string]*pb.Container, now time.Time) []*docker.ContainerEvent {
+	var events []*docker.ContainerEvent
+	for id, ctr := range current {
+		if _, found := previous[id]; !found {
+			events = append(events, newContainerEvent(ctr, "start", now))
+		}
+	}
+	for id, ctr := range previous {
+		if _, found := current[id]; !found {
+			events = append(events, newContainerEvent(ctr, "die", now))
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ContainerID < events[j].ContainerID
+	})
+	return events
+}
+
+func newContainerEvent(ctr *pb.Container, action string, now time.Time) *docker.ContainerEvent {
+	return &docker.ContainerEvent{
+		ContainerID:   ctr.Id,
+		ContainerName: ctr.GetMetadata().GetName(),
+		ImageName:     ctr.GetImage().GetImage(),
+		Action:        action,
+		Timestamp:     now,
+		Attributes:    ctr.Labels,
+	}
+}
+
+func (e *eventStreamState) dispatch(event *docker.ContainerEvent) []*eventSubscriber {
+	var badSubs []*eventSubscriber
+	e.RLock()
+	for _, sub := range e.subscribers {
+		if err := sendEvent(event, sub); err != nil {
+			badSubs = append(badSubs, sub)
+		}
+	}
+	e.RUnlock()
+	return badSubs
+}
+
+func sendEvent(ev *docker.ContainerEvent, sub *eventSubscriber) error {
+	select {
+	case sub.eventChan <- ev:
+		return nil
+	case <-time.After(eventSendTimeout):
+	}
+
+	// We timeouted, let's try to send the error to the subscriber
+	select {
+	case sub.errorChan <- docker.ErrEventTimeout:
+	case <-time.After(eventSendTimeout):
+	}
+	return docker.ErrEventTimeout
+}
@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cri
+
+package cri
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// logPollInterval is the time waited for new lines at the end of a log file
+var logPollInterval = 1 * time.Second
+
+// the stream types of the docker multiplexed logs
+const (
+	stdoutStream byte = 1
+	stderrStream byte = 2
+)
+
+// ContainerLogs follows the log file written by the kubelet for a container
+// from a date formatted as RFC3339Nano. The CRI log lines are converted to
+// the multiplexed and timestamped stream of the docker API, for the logs
+// tailers to parse them the same way.
+func (c *CRIUtil) ContainerLogs(id string, since string) (io.ReadCloser, error) {
+	var sinceTime time.Time
+	if since != "" {
+		var err error
+		sinceTime, err = time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid logs date %q: %s", since, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+	resp, err := c.client.ContainerStatus(ctx, &pb.ContainerStatusRequest{ContainerId: id})
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting container %s: %s", id, err)
+	}
+	path := resp.GetStatus().GetLogPath()
+	if path == "" {
+		return nil, fmt.Errorf("container %s has no log file", id)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the logs of container %s: %s", id, err)
+	}
+
+	reader, writer := io.Pipe()
+	stream := &logStream{PipeReader: reader, stop: make(chan struct{})}
+	go followLog(path, file, sinceTime, logPollInterval, writer, stream.stop)
+	return stream, nil
+}
+
+// logStream stops following the log file when closed
+type logStream struct {
+	*io.PipeReader
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Close stops following the log file
+func (s *logStream) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return s.PipeReader.Close()
+}
+
+// followLog writes the lines of a log file converted to the docker format,
+// polling it for new lines and reopening it when the kubelet rotates it
+func followLog(path string, file *os.File, since time.Time, pollInterval time.Duration, w *io.PipeWriter, stop <-chan struct{}) {
+	defer func() { file.Close() }()
+	reader := bufio.NewReader(file)
+	var line []byte
+	for {
+		chunk, err := reader.ReadBytes('\n')
+		line = append(line, chunk...)
+		if err == nil {
+			if frame, ok := convertLogLine(line, since); ok {
+				if _, err := w.Write(frame); err != nil {
+					// the stream was closed
+					return
+				}
+			}
+			line = line[:0]
+			continue
+		}
+		if err != io.EOF {
+			w.CloseWithError(err)
+			return
+		}
+
+		select {
+		case <-stop:
+			w.Close()
+			return
+		case <-time.After(pollInterval):
+		}
+
+		if rotated(file, path) {
+			rotatedFile, err := os.Open(path)
+			if err != nil {
+				// the new file is not created yet
+				continue
+			}
+			file.Close()
+			file = rotatedFile
+			reader.Reset(file)
+			line = line[:0]
+		}
+	}
+}
+
+// rotated returns whether the path no longer points to the open file
+func rotated(file *os.File, path string) bool {
+	openInfo, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	pathInfo, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return !os.SameFile(openInfo, pathInfo)
+}
+
+// convertLogLine converts a CRI log line, formatted as
+// "timestamp stream tag content", to a docker log frame: an 8 bytes header
+// holding the stream type and the frame size, followed by
+// "timestamp content". The lines older than since are skipped.
+func convertLogLine(line []byte, since time.Time) ([]byte, bool) {
+	parts := bytes.SplitN(bytes.TrimSuffix(line, []byte{'\n'}), []byte{' '}, 3)
+	if len(parts) != 3 {
+		return nil, false
+	}
+	ts, err := time.Parse(time.RFC3339Nano, string(parts[0]))
+	if err != nil || ts.Before(since) {
+		return nil, false
+	}
+
+	var stream byte
+	switch string(parts[1]) {
+	case "stdout":
+		stream = stdoutStream
+	case "stderr":
+		stream = stderrStream
+	default:
+		return nil, false
+	}
+
+	// the tag is P for the partial lines and F for the full ones
+	content := parts[2]
+	if bytes.HasPrefix(content, []byte("P ")) || bytes.HasPrefix(content, []byte("F ")) {
+		content = content[2:]
+	} else if bytes.Equal(content, []byte("P")) || bytes.Equal(content, []byte("F")) {
+		content = nil
+	}
+
+	size := len(parts[0]) + 1 + len(content) + 1
+	frame := make([]byte, 8, 8+size)
+	frame[0] = stream
+	binary.BigEndian.PutUint32(frame[4:], uint32(size))
+	frame = append(frame, parts[0]...)
+	frame = append(frame, ' ')
+	frame = append(frame, content...)
+	frame = append(frame, '\n')
+	return frame, true
+}
@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cri
+
+package cri
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// frame builds the docker log frame of a payload
+func frame(stream byte, payload string) []byte {
+	size := len(payload)
+	header := []byte{stream, 0, 0, 0, byte(size >> 24), byte(size >> 16), byte(size >> 8), byte(size)}
+	return append(header, payload...)
+}
+
+func TestConvertLogLine(t *testing.T) {
+	for name, tc := range map[string]struct {
+		line  string
+		since time.Time
+		frame []byte
+	}{
+		"stdout full line": {
+			line:  "2018-05-01T10:00:00.123456789Z stdout F hello world\n",
+			frame: frame(stdoutStream, "2018-05-01T10:00:00.123456789Z hello world\n"),
+		},
+		"stderr partial line": {
+			line:  "2018-05-01T10:00:00Z stderr P oops",
+			frame: frame(stderrStream, "2018-05-01T10:00:00Z oops\n"),
+		},
+		"untagged line": {
+			line:  "2018-05-01T10:00:00Z stdout hello",
+			frame: frame(stdoutStream, "2018-05-01T10:00:00Z hello\n"),
+		},
+		"empty line": {
+			line:  "2018-05-01T10:00:00Z stdout F\n",
+			frame: frame(stdoutStream, "2018-05-01T10:00:00Z \n"),
+		},
+		"older than since": {
+			line:  "2018-05-01T10:00:00Z stdout F hello\n",
+			since: time.Date(2018, 5, 1, 11, 0, 0, 0, time.UTC),
+		},
+		"invalid timestamp": {
+			line: "yesterday stdout F hello\n",
+		},
+		"invalid stream": {
+			line: "2018-05-01T10:00:00Z stdin F hello\n",
+		},
+		"truncated": {
+			line: "2018-05-01T10:00:00Z\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			frame, ok := convertLogLine([]byte(tc.line), tc.since)
+			assert.Equal(t, tc.frame != nil, ok)
+			assert.Equal(t, tc.frame, frame)
+		})
+	}
+}
+
+func TestContainerLogs(t *testing.T) {
+	defer func(d time.Duration) { logPollInterval = d }(logPollInterval)
+	logPollInterval = 10 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "cri-logs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "redis.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte(
+		"2018-05-01T10:00:00Z stdout F before\n"+
+			"2018-05-01T12:00:00Z stdout F after\n"+
+			"2018-05-01T12:00:01Z stderr P incompl"), 0644))
+
+	fake := &fakeRuntimeService{logPath: path}
+	fake.setContainers(newCRIContainer(redisID, "redis", "docker.io/library/redis:4.0", pb.ContainerState_CONTAINER_RUNNING))
+	cu, stop := startFakeRuntime(t, fake)
+	defer stop()
+
+	_, err = cu.ContainerLogs(redisID, "yesterday")
+	assert.Error(t, err)
+
+	logs, err := cu.ContainerLogs(redisID, "2018-05-01T11:00:00Z")
+	require.NoError(t, err)
+	defer logs.Close()
+	reader := bufio.NewReader(logs)
+	readFrame := func(stream byte, payload string) {
+		buf := make([]byte, 8+len(payload))
+		done := make(chan error, 1)
+		go func() {
+			_, err := io.ReadFull(reader, buf)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(2 * time.Second):
+			require.FailNow(t, "timeout reading the logs")
+		}
+		assert.Equal(t, frame(stream, payload), buf)
+	}
+
+	readFrame(stdoutStream, "2018-05-01T12:00:00Z after\n")
+
+	// the end of a line written later is appended to its beginning
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("ete\n")
+	require.NoError(t, err)
+	f.Close()
+	readFrame(stderrStream, "2018-05-01T12:00:01Z incomplete\n")
+
+	// the new file is followed once the kubelet rotates the logs
+	require.NoError(t, os.Rename(path, path+".20180501-120002"))
+	require.NoError(t, ioutil.WriteFile(path, []byte("2018-05-01T12:00:03Z stdout F rotated\n"), 0644))
+	readFrame(stdoutStream, "2018-05-01T12:00:03Z rotated\n")
+
+	// the stream ends once closed
+	assert.NoError(t, logs.Close())
+	_, err = reader.ReadByte()
+	assert.Error(t, err)
+}
@@ -9,7 +9,6 @@ package container
 import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
-	"github.com/DataDog/datadog-agent/pkg/util/ecs"
 	log "github.com/cihub/seelog"
 )
 
@@ -27,46 +26,38 @@ func IsAvailable() bool {
 }
 
 // GetContainers is the unique method that returns all containers on the host (or in the task)
-// TODO: create a container interface that docker and ecs can implement
-// and that other agents can consume so that we don't have to
-// convert all containers to the format.
-// TODO: move to a catalog and registration pattern
+// The docker listener lists the containers of the Runtime set by the
+// container_runtime option, the ecs one the containers of the task.
 func GetContainers() ([]*docker.Container, error) {
 	var listeners []config.Listeners
-	var err error
-	if err = config.Datadog.UnmarshalKey("listeners", &listeners); err != nil {
+	if err := config.Datadog.UnmarshalKey("listeners", &listeners); err != nil {
 		log.Errorf("unable to parse listeners from the datadog config - %s", err)
 		return nil, err
 	}
 
 	containers := make([]*docker.Container, 0)
-	ctrListConfig := docker.ContainerListConfig{
-		IncludeExited: false,
-		FlagExcluded:  false,
-	}
 
 	for _, l := range listeners {
+		var rt Runtime
+		var err error
 		switch l.Name {
 		case "docker":
-			du, err := docker.GetDockerUtil()
-			if err != nil {
-				log.Errorf("unable to connect to docker, passing this provider - %s", err)
-				continue
-			}
-			ctrs, err := du.Containers(&ctrListConfig)
-			if err != nil {
-				log.Errorf("failed to get container list from docker - %s", err)
-			}
-			containers = append(containers, ctrs...)
+			rt, err = GetRuntime()
 		case "ecs":
-			ctrs, err := ecs.GetContainers()
-			if err != nil {
-				log.Errorf("failed to get container list from ecs - %s", err)
-			}
-			containers = append(containers, ctrs...)
+			rt, err = getRuntime(ecsRuntimeName)
 		default:
 			log.Warnf("listener %s is not a known container provider, skipping it", l.Name)
+			continue
+		}
+		if err != nil {
+			log.Errorf("unable to connect to the container runtime of the %s listener, passing this provider - %s", l.Name, err)
+			continue
+		}
+		ctrs, err := rt.ListContainers(&docker.ContainerListConfig{})
+		if err != nil {
+			log.Errorf("failed to get container list from the %s listener - %s", l.Name, err)
 		}
+		containers = append(containers, ctrs...)
 	}
-	return containers, err
+	return containers, nil
 }
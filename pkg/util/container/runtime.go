@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package container
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+)
+
+// Runtime is the interface the container runtimes implement to expose their
+// containers to the agent. The docker.Container and docker.ContainerEvent
+// types are shared by every runtime, their cgroup statistics not being tied
+// to docker.
+type Runtime interface {
+	// ListContainers returns the containers and their labels, with the
+	// cgroup statistics of the running ones. The exited and the excluded
+	// containers are listed as set by the ContainerListConfig.
+	ListContainers(cfg *docker.ContainerListConfig) ([]*docker.Container, error)
+	// InspectContainer returns the details of a container, without statistics
+	InspectContainer(id string) (*docker.Container, error)
+	// SubscribeToEvents streams the container events to a named subscriber
+	SubscribeToEvents(name string) (<-chan *docker.ContainerEvent, <-chan error, error)
+	// UnsubscribeFromEvents stops the event stream of a subscriber
+	UnsubscribeFromEvents(name string) error
+	// ContainerLogs follows the stdout and stderr of a container from a
+	// date formatted as RFC3339Nano. The stream is multiplexed and
+	// timestamped as the logs of the docker API.
+	ContainerLogs(id string, since string) (io.ReadCloser, error)
+}
+
+// RuntimeFactory connects to a container runtime
+type RuntimeFactory func() (Runtime, error)
+
+var (
+	runtimesMutex sync.RWMutex
+	runtimes      = make(map[string]RuntimeFactory)
+)
+
+// RegisterRuntime adds a container runtime to the catalog, it is to be
+// called in the init of the runtime implementation
+func RegisterRuntime(name string, factory RuntimeFactory) {
+	runtimesMutex.Lock()
+	defer runtimesMutex.Unlock()
+	runtimes[name] = factory
+}
+
+// GetRuntime returns the container runtime set by the container_runtime
+// option, docker by default
+func GetRuntime() (Runtime, error) {
+	return getRuntime(config.Datadog.GetString("container_runtime"))
+}
+
+// getRuntime returns a container runtime of the catalog
+func getRuntime(name string) (Runtime, error) {
+	runtimesMutex.RLock()
+	factory, found := runtimes[name]
+	runtimesMutex.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("unknown container runtime %q, available runtimes: %s", name, strings.Join(registeredRuntimes(), ", "))
+	}
+	return factory()
+}
+
+// registeredRuntimes returns the names of the runtimes of the catalog
+func registeredRuntimes() []string {
+	runtimesMutex.RLock()
+	defer runtimesMutex.RUnlock()
+	names := make([]string, 0, len(runtimes))
+	for name := range runtimes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
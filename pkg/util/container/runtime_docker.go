@@ -0,0 +1,206 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build docker
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-connections/nat"
+
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+)
+
+const dockerRuntimeName = "docker"
+
+// dockerRuntime exposes the DockerUtil as a container Runtime
+type dockerRuntime struct {
+	du *docker.DockerUtil
+}
+
+// ListContainers returns the containers and their labels, with the
+// cgroup statistics of the running ones
+func (r *dockerRuntime) ListContainers(cfg *docker.ContainerListConfig) ([]*docker.Container, error) {
+	return r.du.Containers(cfg)
+}
+
+// InspectContainer returns the details of a container, without statistics
+func (r *dockerRuntime) InspectContainer(id string) (*docker.Container, error) {
+	inspect, err := r.du.Inspect(id, false)
+	if err != nil {
+		return nil, err
+	}
+	return r.convertInspect(inspect), nil
+}
+
+// SubscribeToEvents streams the docker container events to a named subscriber
+func (r *dockerRuntime) SubscribeToEvents(name string) (<-chan *docker.ContainerEvent, <-chan error, error) {
+	return r.du.SubscribeToContainerEvents(name)
+}
+
+// UnsubscribeFromEvents stops the event stream of a subscriber
+func (r *dockerRuntime) UnsubscribeFromEvents(name string) error {
+	return r.du.UnsubscribeFromContainerEvents(name)
+}
+
+// ContainerLogs follows the stdout and stderr of a container from a date
+func (r *dockerRuntime) ContainerLogs(id string, since string) (io.ReadCloser, error) {
+	return r.du.ContainerLogs(context.Background(), id, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Timestamps: true,
+		Details:    false,
+		Since:      since,
+	})
+}
+
+// convertInspect converts a docker inspect to the runtime-neutral Container
+func (r *dockerRuntime) convertInspect(inspect types.ContainerJSON) *docker.Container {
+	container := &docker.Container{
+		Type:     "Docker",
+		ID:       inspect.ID,
+		EntityID: docker.ContainerIDToEntityName(inspect.ID),
+		Name:     strings.TrimPrefix(inspect.Name, "/"),
+		ImageID:  inspect.Image,
+		Created:  parseDockerTime(inspect.Created),
+	}
+	// the image of the inspect is its ID, resolved to the name it's tagged with
+	image, err := r.du.ResolveImageName(inspect.Image)
+	if err != nil {
+		log.Debugf("can't resolve image name %s: %s", inspect.Image, err)
+	}
+	container.Image = image
+
+	container.Labels = make(map[string]string)
+	container.Env = make(map[string]string)
+	if inspect.Config != nil {
+		for k, v := range inspect.Config.Labels {
+			container.Labels[k] = v
+		}
+		for _, v := range inspect.Config.Env {
+			parts := strings.SplitN(v, "=", 2)
+			if len(parts) == 2 {
+				container.Env[parts[0]] = parts[1]
+			}
+		}
+	}
+	container.Hosts = inspectHosts(inspect)
+	container.Ports = inspectPorts(inspect)
+	if state := inspect.State; state != nil {
+		container.State = state.Status
+		container.StartedAt = parseDockerTime(state.StartedAt)
+		if state.Health != nil {
+			container.Health = state.Health.Status
+		}
+		if state.Pid > 0 {
+			container.Pids = []int32{int32(state.Pid)}
+		}
+	}
+	return container
+}
+
+// inspectHosts returns the IP addresses of a container on its networks
+func inspectHosts(inspect types.ContainerJSON) map[string]string {
+	ips := make(map[string]string)
+	if inspect.NetworkSettings != nil {
+		for net, settings := range inspect.NetworkSettings.Networks {
+			if len(settings.IPAddress) > 0 {
+				ips[net] = settings.IPAddress
+			}
+		}
+	}
+	if inspect.Config != nil {
+		if rancherIP, found := docker.FindRancherIPInLabels(inspect.Config.Labels); found {
+			ips["rancher"] = rancherIP
+		}
+	}
+	return ips
+}
+
+// inspectPorts returns the sorted ports bound by a container, or exposed by
+// its image if it binds none
+func inspectPorts(inspect types.ContainerJSON) []int {
+	// Make a non-nil array to avoid re-running if we find zero port
+	ports := []int{}
+
+	switch {
+	case inspect.NetworkSettings != nil && len(inspect.NetworkSettings.Ports) > 0:
+		for p := range inspect.NetworkSettings.Ports {
+			out, err := parseDockerPort(p)
+			if err != nil {
+				log.Warn(err.Error())
+				continue
+			}
+			ports = append(ports, out...)
+		}
+	case inspect.Config != nil && len(inspect.Config.ExposedPorts) > 0:
+		log.Infof("using ExposedPorts for container %s as no port bindings are listed", inspect.ID[:12])
+		for p := range inspect.Config.ExposedPorts {
+			out, err := parseDockerPort(p)
+			if err != nil {
+				log.Warn(err.Error())
+				continue
+			}
+			ports = append(ports, out...)
+		}
+	}
+
+	sort.Ints(ports)
+	return ports
+}
+
+func parseDockerPort(port nat.Port) ([]int, error) {
+	var output []int
+
+	// Try to parse a port range, eg. 22-25
+	first, last, err := port.Range()
+	if err == nil && last > first {
+		for p := first; p <= last; p++ {
+			output = append(output, p)
+		}
+		return output, nil
+	}
+
+	// Try to parse a single port (most common case)
+	p := port.Int()
+	if p > 0 {
+		output = append(output, p)
+		return output, nil
+	}
+
+	return output, fmt.Errorf("failed to extract port from: %v", port)
+}
+
+// parseDockerTime converts the RFC3339 times of the docker inspects to unix
+// timestamps, 0 meaning unset
+func parseDockerTime(value string) int64 {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || t.IsZero() || t.Unix() < 0 {
+		return 0
+	}
+	return t.Unix()
+}
+
+func dockerRuntimeFactory() (Runtime, error) {
+	du, err := docker.GetDockerUtil()
+	if err != nil {
+		return nil, err
+	}
+	return &dockerRuntime{du: du}, nil
+}
+
+func init() {
+	RegisterRuntime(dockerRuntimeName, dockerRuntimeFactory)
+}
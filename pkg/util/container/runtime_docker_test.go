@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build docker
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDockerPort(t *testing.T) {
+	testCases := []struct {
+		proto         string
+		port          string
+		expectedPorts []int
+		expectedError error
+	}{
+		{
+			proto:         "tcp",
+			port:          "42",
+			expectedPorts: []int{42},
+			expectedError: nil,
+		},
+		{
+			proto:         "udp",
+			port:          "500-503",
+			expectedPorts: []int{500, 501, 502, 503},
+			expectedError: nil,
+		},
+		{
+			proto:         "tcp",
+			port:          "0",
+			expectedPorts: nil,
+			expectedError: errors.New("failed to extract port from: 0/tcp"),
+		},
+	}
+
+	for i, test := range testCases {
+		t.Run(fmt.Sprintf("case %d: %s/%s", i, test.port, test.proto), func(t *testing.T) {
+			p, err := nat.NewPort(test.proto, test.port)
+			assert.Nil(t, err)
+
+			ports, err := parseDockerPort(p)
+			if test.expectedError == nil {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.Equal(t, test.expectedError.Error(), err.Error())
+			}
+
+			assert.Equal(t, test.expectedPorts, ports)
+		})
+	}
+}
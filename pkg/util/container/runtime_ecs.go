@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build docker
+
+package container
+
+import (
+	"errors"
+	"io"
+
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+	"github.com/DataDog/datadog-agent/pkg/util/ecs"
+)
+
+const ecsRuntimeName = "ecs"
+
+// errECSNotSupported is returned for the features the ECS task metadata API
+// doesn't expose
+var errECSNotSupported = errors.New("not supported by the ECS task metadata API")
+
+// ecsRuntime exposes the containers of the ECS Fargate task of the agent
+// as a container Runtime
+type ecsRuntime struct{}
+
+// ListContainers returns the containers of the task, with their statistics.
+// The task metadata API doesn't expose the excluded containers.
+func (r *ecsRuntime) ListContainers(cfg *docker.ContainerListConfig) ([]*docker.Container, error) {
+	containers, err := ecs.GetContainers()
+	if err != nil || cfg.IncludeExited {
+		return containers, err
+	}
+	running := make([]*docker.Container, 0, len(containers))
+	for _, ctr := range containers {
+		if ctr.State == docker.ContainerRunningState {
+			running = append(running, ctr)
+		}
+	}
+	return running, nil
+}
+
+// InspectContainer returns the details of a container, without statistics
+func (r *ecsRuntime) InspectContainer(id string) (*docker.Container, error) {
+	return ecs.InspectContainer(id)
+}
+
+// SubscribeToEvents is not supported, the ecs listener polls the task
+// metadata instead
+func (r *ecsRuntime) SubscribeToEvents(name string) (<-chan *docker.ContainerEvent, <-chan error, error) {
+	return nil, nil, errECSNotSupported
+}
+
+// UnsubscribeFromEvents is not supported
+func (r *ecsRuntime) UnsubscribeFromEvents(name string) error {
+	return errECSNotSupported
+}
+
+// ContainerLogs is not supported
+func (r *ecsRuntime) ContainerLogs(id string, since string) (io.ReadCloser, error) {
+	return nil, errECSNotSupported
+}
+
+func ecsRuntimeFactory() (Runtime, error) {
+	return &ecsRuntime{}, nil
+}
+
+func init() {
+	RegisterRuntime(ecsRuntimeName, ecsRuntimeFactory)
+}
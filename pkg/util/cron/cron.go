@@ -0,0 +1,241 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package cron parses cron expressions and computes their next occurrences.
+//
+// It supports the 5 standard fields (minute, hour, day of month, month, day
+// of week) with lists, ranges, steps and month and day names, the @yearly,
+// @monthly, @weekly, @daily and @hourly macros, and a `CRON_TZ=<zone>` prefix
+// to evaluate the expression in another time zone than the local one.
+// One-shot schedules are expressed with @once, to run right away, or with a
+// RFC 3339 timestamp.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // bit sets of the allowed values
+	location                      *time.Location
+	once                          bool      // @once, run right away
+	at                            time.Time // one-shot run at a given time
+}
+
+type field struct {
+	name     string
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is accepted for sunday and folded to 0
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	macros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// Parse parses a cron expression
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	location := time.Local
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		parts := strings.SplitN(spec, " ", 2)
+		zone := parts[0][strings.Index(parts[0], "=")+1:]
+		var err error
+		if location, err = time.LoadLocation(zone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %s", zone, err)
+		}
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("empty cron expression")
+		}
+		spec = strings.TrimSpace(parts[1])
+	}
+
+	if spec == "@once" {
+		return &Schedule{location: location, once: true}, nil
+	}
+	if at, err := time.Parse(time.RFC3339, spec); err == nil {
+		return &Schedule{location: location, at: at}, nil
+	}
+	if expanded, found := macros[spec]; found {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	s := &Schedule{location: location}
+	var err error
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if s.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if s.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// parse parses a comma-separated list of values, ranges and steps
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, step := part, uint(1)
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.ParseUint(part[i+1:], 10, 8)
+			if err != nil || s == 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", part[i+1:], f.name)
+			}
+			rangeExpr, step = part[:i], uint(s)
+		}
+
+		var start, end uint
+		switch {
+		case rangeExpr == "*":
+			start, end = f.min, f.max
+			if f.name == dowField.name {
+				end = 6
+			}
+		case strings.Contains(rangeExpr, "-"):
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			var err error
+			if start, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if end, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangeExpr, f.name)
+			}
+		default:
+			var err error
+			if start, err = f.value(rangeExpr); err != nil {
+				return 0, err
+			}
+			end = start
+			// `a/n` means every n starting from a
+			if step > 1 {
+				end = f.max
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(expr string) (uint, error) {
+	if v, found := f.names[strings.ToLower(expr)]; found {
+		return v, nil
+	}
+	v, err := strconv.ParseUint(expr, 10, 8)
+	if err != nil || uint(v) < f.min || uint(v) > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, expected %d-%d", expr, f.name, f.min, f.max)
+	}
+	return uint(v), nil
+}
+
+// IsOneShot returns whether the schedule runs only once
+func (s *Schedule) IsOneShot() bool {
+	return s.once || !s.at.IsZero()
+}
+
+// Next returns the first occurrence of the schedule strictly after t, or the
+// zero time if there's none
+func (s *Schedule) Next(t time.Time) time.Time {
+	switch {
+	case s.once:
+		return t
+	case !s.at.IsZero():
+		if s.at.After(t) {
+			return s.at
+		}
+		return time.Time{}
+	}
+
+	// start from the next minute
+	t = t.In(s.location)
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+
+	// an expression like `0 0 30 2 *` never matches, give up after a few years
+	limit := t.Year() + 5
+	for t.Year() <= limit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches follows the cron semantics: when both the day of month and the
+// day of week are restricted, matching either of them is enough
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	allDom := s.dom == domField.all()
+	allDow := s.dow == dowField.all()
+	if allDom || allDow {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// all returns the bits of every value of the field
+func (f field) all() uint64 {
+	bits, _ := f.parse("*")
+	return bits
+}
@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	from := time.Date(2018, time.March, 14, 10, 42, 30, 0, time.UTC)
+	for _, tc := range []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2018, time.March, 14, 10, 43, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2018, time.March, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2018, time.March, 15, 3, 0, 0, 0, time.UTC)},
+		{"30 9-17/4 * * *", time.Date(2018, time.March, 14, 13, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan,jul *", time.Date(2018, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 8 * * mon-fri", time.Date(2018, time.March, 15, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * 7", time.Date(2018, time.March, 18, 8, 0, 0, 0, time.UTC)},
+		// day of month or day of week
+		{"0 0 20 * sat", time.Date(2018, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2020, time.February, 29, 12, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2018, time.March, 14, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2018, time.March, 18, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2018, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := Parse("CRON_TZ=UTC " + tc.spec)
+			require.NoError(t, err)
+			assert.True(t, tc.expected.Equal(s.Next(from)), "expected %s, got %s", tc.expected, s.Next(from))
+			assert.False(t, s.IsOneShot())
+		})
+	}
+}
+
+func TestNextTimeZone(t *testing.T) {
+	s, err := Parse("CRON_TZ=America/New_York 0 3 * * *")
+	require.NoError(t, err)
+
+	from := time.Date(2018, time.March, 14, 10, 42, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2018, time.March, 15, 7, 0, 0, 0, time.UTC), s.Next(from).UTC())
+
+	// 2:30 doesn't exist when switching to daylight saving time
+	s, err = Parse("CRON_TZ=America/New_York 30 2 * * *")
+	require.NoError(t, err)
+	from = time.Date(2018, time.March, 10, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2018, time.March, 12, 6, 30, 0, 0, time.UTC), s.Next(from).UTC())
+}
+
+func TestOneShot(t *testing.T) {
+	from := time.Date(2018, time.March, 14, 10, 42, 0, 0, time.UTC)
+
+	s, err := Parse("@once")
+	require.NoError(t, err)
+	assert.True(t, s.IsOneShot())
+	assert.Equal(t, from, s.Next(from))
+
+	s, err = Parse("2018-03-15T03:00:00Z")
+	require.NoError(t, err)
+	assert.True(t, s.IsOneShot())
+	assert.Equal(t, time.Date(2018, time.March, 15, 3, 0, 0, 0, time.UTC), s.Next(from).UTC())
+	assert.True(t, s.Next(from.Add(24*time.Hour)).IsZero())
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"CRON_TZ=Nowhere/Atlantis * * * * *",
+		"CRON_TZ=UTC",
+	} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -107,6 +107,9 @@ type ContainerCgroup struct {
 // Mem returns the memory statistics for a Cgroup. If the cgroup file is not
 // available then we return an empty stats file.
 func (c ContainerCgroup) Mem() (*CgroupMemStat, error) {
+	if c.isUnified() {
+		return c.memV2()
+	}
 	ret := &CgroupMemStat{ContainerID: c.ContainerID}
 	statfile := c.cgroupFilePath("memory", "memory.stat")
 
@@ -196,6 +199,9 @@ func (c ContainerCgroup) Mem() (*CgroupMemStat, error) {
 // MemLimit returns the memory limit of the cgroup, if it exists. If the file does not
 // exist or there is no limit then this will default to 0.
 func (c ContainerCgroup) MemLimit() (uint64, error) {
+	if c.isUnified() {
+		return c.memLimitV2()
+	}
 	v, err := c.ParseSingleStat("memory", "memory.limit_in_bytes")
 	if os.IsNotExist(err) {
 		log.Debugf("missing cgroup file: %s",
@@ -215,6 +221,9 @@ func (c ContainerCgroup) MemLimit() (uint64, error) {
 // CPU returns the CPU status for this cgroup instance
 // If the cgroup file does not exist then we just log debug return nothing.
 func (c ContainerCgroup) CPU() (*CgroupTimesStat, error) {
+	if c.isUnified() {
+		return c.cpuV2()
+	}
 	ret := &CgroupTimesStat{ContainerID: c.ContainerID}
 	statfile := c.cgroupFilePath("cpuacct", "cpuacct.stat")
 	f, err := os.Open(statfile)
@@ -293,6 +302,9 @@ func (c ContainerCgroup) CPUNrThrottled() (uint64, error) {
 // If the limits files aren't available (on older version) then
 // we'll return the default value of 100.
 func (c ContainerCgroup) CPULimit() (float64, error) {
+	if c.isUnified() {
+		return c.cpuLimitV2()
+	}
 	periodFile := c.cgroupFilePath("cpu", "cpu.cfs_period_us")
 	quotaFile := c.cgroupFilePath("cpu", "cpu.cfs_quota_us")
 	plines, err := readLines(periodFile)
@@ -340,6 +352,9 @@ func (c ContainerCgroup) CPULimit() (float64, error) {
 // 252:0 Total 58945536
 //
 func (c ContainerCgroup) IO() (*CgroupIOStat, error) {
+	if c.isUnified() {
+		return c.ioV2()
+	}
 	ret := &CgroupIOStat{ContainerID: c.ContainerID}
 	statfile := c.cgroupFilePath("blkio", "blkio.throttle.io_service_bytes")
 	f, err := os.Open(statfile)
@@ -372,6 +387,23 @@ func (c ContainerCgroup) IO() (*CgroupIOStat, error) {
 	return ret, nil
 }
 
+// ThreadCount returns the number of tasks in the cgroup, from the pids
+// controller. ErrMissingTarget is returned when the pids controller isn't
+// mounted. If the file does not exist then we just log debug and return 0.
+func (c ContainerCgroup) ThreadCount() (uint64, error) {
+	if !c.isUnified() {
+		if _, ok := c.Mounts["pids"]; !ok {
+			return 0, ErrMissingTarget
+		}
+	}
+	v, err := c.ParseSingleStat("pids", "pids.current")
+	if os.IsNotExist(err) {
+		log.Debugf("missing cgroup file: %s", c.cgroupFilePath("pids", "pids.current"))
+		return 0, nil
+	}
+	return v, err
+}
+
 // ParseSingleStat reads and converts a single-value cgroup stat file content to uint64.
 func (c ContainerCgroup) ParseSingleStat(target, file string) (uint64, error) {
 	statFile := c.cgroupFilePath(target, file)
@@ -404,7 +436,11 @@ func (c ContainerCgroup) ContainerStartTime() (int64, error) {
 }
 
 // cgroupFilePath constructs file path to get targeted stats file.
+// On the unified hierarchy, every target shares the same directory.
 func (c ContainerCgroup) cgroupFilePath(target, file string) string {
+	if c.isUnified() {
+		target = cgroupV2Target
+	}
 	mount, ok := c.Mounts[target]
 	if !ok {
 		log.Errorf("missing target %s from mounts", target)
@@ -442,6 +478,10 @@ func (c ContainerCgroup) cgroupFilePath(target, file string) string {
 //	 cgroup /sys/fs/cgroup/perf_event cgroup rw,relatime,perf_event 0 0
 //	 cgroup /sys/fs/cgroup/hugetlb cgroup rw,relatime,hugetlb 0 0
 //
+// The unified hierarchy (cgroup v2) is a single mount, registered as the
+// "unified" target, e.g. on hosts running it alone:
+//	 cgroup2 /sys/fs/cgroup cgroup2 rw,nosuid,nodev,noexec,relatime 0 0
+//
 // Returns a map for every target (cpuset, cpu, cpuacct) => path
 func cgroupMountPoints() (map[string]string, error) {
 	mountsFile := "/proc/mounts"
@@ -463,6 +503,15 @@ func parseCgroupMountPoints(r io.Reader) map[string]string {
 	for scanner.Scan() {
 		mount := scanner.Text()
 		tokens := strings.Split(mount, " ")
+		// The unified hierarchy can be mounted on the cgroup root itself
+		if len(tokens) >= 3 && tokens[2] == "cgroup2" {
+			cgroupPath := tokens[1]
+			if strings.HasPrefix(cgroupPath+"/", cgroupRoot) {
+				mountPoints[cgroupV2Target] = cgroupPath
+			}
+			continue
+		}
+
 		// Check if the filesystem type is 'cgroup'
 		if len(tokens) >= 3 && tokens[2] == "cgroup" {
 			cgroupPath := tokens[1]
@@ -568,6 +617,11 @@ func readCgroupPaths(pidCgroupPath string) (string, map[string]string, error) {
 // 8:memory:/kubepods/besteffort/pod2baa3444-4d37-11e7-bd2f-080027d2bf10/47fc31db38b4fa0f4db44b99d0cad10e3cd4d5f142135a7721c1c95c1aadfb2e
 // 7:blkio:/kubepods/besteffort/pod2baa3444-4d37-11e7-bd2f-080027d2bf10/47fc31db38b4fa0f4db44b99d0cad10e3cd4d5f142135a7721c1c95c1aadfb2e
 //
+// The unified hierarchy has no controller list, its path is registered as the
+// "unified" target:
+//
+// 0::/system.slice/docker-47fc31db38b4fa0f4db44b99d0cad10e3cd4d5f142135a7721c1c95c1aadfb2e.scope
+//
 // Returns the common containerID and a mapping of target => path
 // If the first line doesn't have a valid container ID we will return an empty string
 func parseCgroupPaths(r io.Reader) (string, map[string]string, error) {
@@ -590,6 +644,10 @@ func parseCgroupPaths(r io.Reader) (string, map[string]string, error) {
 		if len(sp) < 3 {
 			continue
 		}
+		if sp[1] == "" {
+			paths[cgroupV2Target] = sp[2]
+			continue
+		}
 		// Target can be comma-separate values like cpu,cpuacct
 		tsp := strings.Split(sp[1], ",")
 		for _, target := range tsp {
@@ -230,6 +230,26 @@ func TestParseCgroupMountPoints(t *testing.T) {
 				"systemd":    "/sys/fs/cgroup/systemd",
 			},
 		},
+		{
+			contents: []string{
+				"cgroup2 /sys/fs/cgroup cgroup2 rw,nosuid,nodev,noexec,relatime,nsdelegate 0 0",
+				"cgroup2 /var/lib/other cgroup2 rw,nosuid,nodev,noexec,relatime 0 0",
+			},
+			expected: map[string]string{
+				"unified": "/sys/fs/cgroup",
+			},
+		},
+		{
+			contents: []string{
+				"tmpfs /sys/fs/cgroup tmpfs ro,nosuid,nodev,noexec,mode=755 0 0",
+				"cgroup2 /sys/fs/cgroup/unified cgroup2 rw,nosuid,nodev,noexec,relatime,nsdelegate 0 0",
+				"cgroup /sys/fs/cgroup/memory cgroup rw,nosuid,nodev,noexec,relatime,memory 0 0",
+			},
+			expected: map[string]string{
+				"unified": "/sys/fs/cgroup/unified",
+				"memory":  "/sys/fs/cgroup/memory",
+			},
+		},
 		{
 			contents: []string{
 				"",
@@ -290,6 +310,15 @@ func TestParseCgroupPaths(t *testing.T) {
 				"cpu": "/docker/a27f1331f6ddf72629811aac65207949fc858ea90100c438768b531a4c540419",
 			},
 		},
+		{
+			contents: []string{
+				"0::/system.slice/docker-a27f1331f6ddf72629811aac65207949fc858ea90100c438768b531a4c540419.scope",
+			},
+			expectedContainer: "a27f1331f6ddf72629811aac65207949fc858ea90100c438768b531a4c540419",
+			expectedPaths: map[string]string{
+				"unified": "/system.slice/docker-a27f1331f6ddf72629811aac65207949fc858ea90100c438768b531a4c540419.scope",
+			},
+		},
 		{
 			// Melting pot of known cgroup formats
 			contents: []string{
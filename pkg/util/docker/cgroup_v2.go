@@ -0,0 +1,275 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package docker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/cihub/seelog"
+)
+
+// cgroupV2Target is the target the unified hierarchy (cgroup v2) is
+// registered under in the mounts and paths of a ContainerCgroup. Every
+// controller shares its single directory.
+const cgroupV2Target = "unified"
+
+// usecToUserHZDivisor holds the divisor to convert the microseconds of
+// cpu.stat to USER_HZ (1/100)
+const usecToUserHZDivisor float64 = 1e6 / 100
+
+// isUnified returns whether the stats of the cgroup are read from the
+// unified hierarchy. On hybrid hosts, the unified hierarchy is mounted
+// besides the v1 controllers but holds none of them.
+func (c ContainerCgroup) isUnified() bool {
+	if _, ok := c.Mounts[cgroupV2Target]; !ok {
+		return false
+	}
+	_, v1 := c.Mounts["memory"]
+	return !v1
+}
+
+// parseFlatKeyed calls `f` for every "<key> <value>" line of a cgroup v2
+// flat keyed file, such as cpu.stat or memory.stat
+func parseFlatKeyed(statfile string, f func(key string, value uint64)) error {
+	file, err := os.Open(statfile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		f(fields[0], v)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading %s: %s", statfile, err)
+	}
+	return nil
+}
+
+// parseMaxStat reads a single-value cgroup v2 file that can be set to "max",
+// returning 0 in this case
+func (c ContainerCgroup) parseMaxStat(file string) (uint64, error) {
+	statFile := c.cgroupFilePath(cgroupV2Target, file)
+	lines, err := readLines(statFile)
+	if err != nil {
+		return 0, err
+	}
+	if len(lines) != 1 {
+		return 0, fmt.Errorf("wrong file format: %s", statFile)
+	}
+	if lines[0] == "max" {
+		return 0, nil
+	}
+	return strconv.ParseUint(lines[0], 10, 64)
+}
+
+// memV2 reads the memory statistics from memory.stat, memory.current,
+// memory.swap.current and memory.events. The unified hierarchy only reports
+// hierarchical values, they fill both the local and total fields.
+func (c ContainerCgroup) memV2() (*CgroupMemStat, error) {
+	ret := &CgroupMemStat{ContainerID: c.ContainerID}
+	statfile := c.cgroupFilePath(cgroupV2Target, "memory.stat")
+
+	err := parseFlatKeyed(statfile, func(key string, v uint64) {
+		switch key {
+		case "file":
+			ret.Cache = v
+			ret.TotalCache = v
+		case "anon":
+			ret.RSS = v
+			ret.TotalRSS = v
+		case "anon_thp":
+			ret.RSSHuge = v
+			ret.TotalRSSHuge = v
+		case "file_mapped":
+			ret.MappedFile = v
+			ret.TotalMappedFile = v
+		case "pgfault":
+			ret.Pgfault = v
+			ret.TotalPgFault = v
+		case "pgmajfault":
+			ret.Pgmajfault = v
+			ret.TotalPgMajFault = v
+		case "inactive_anon":
+			ret.InactiveAnon = v
+			ret.TotalInactiveAnon = v
+		case "active_anon":
+			ret.ActiveAnon = v
+			ret.TotalActiveAnon = v
+		case "inactive_file":
+			ret.InactiveFile = v
+			ret.TotalInactiveFile = v
+		case "active_file":
+			ret.ActiveFile = v
+			ret.TotalActiveFile = v
+		case "unevictable":
+			ret.Unevictable = v
+			ret.TotalUnevictable = v
+		}
+	})
+	if os.IsNotExist(err) {
+		log.Debugf("missing cgroup file: %s", statfile)
+		return ret, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if v, err := c.ParseSingleStat(cgroupV2Target, "memory.current"); err == nil {
+		ret.MemUsageInBytes = v
+	}
+	if v, err := c.ParseSingleStat(cgroupV2Target, "memory.swap.current"); err == nil {
+		ret.Swap = v
+		ret.SwapPresent = true
+	}
+	// memory.events counts the times the usage hit the limit in "max"
+	parseFlatKeyed(c.cgroupFilePath(cgroupV2Target, "memory.events"), func(key string, v uint64) {
+		if key == "max" {
+			ret.MemFailCnt = v
+		}
+	})
+
+	memLimit, err := c.parseMaxStat("memory.max")
+	if err == nil {
+		ret.HierarchicalMemoryLimit = memLimit
+	}
+	// v1 limits memory+swap where v2 limits the swap alone
+	swapLimit, err := c.parseMaxStat("memory.swap.max")
+	if err == nil && memLimit > 0 && swapLimit > 0 {
+		ret.HierarchicalMemSWLimit = memLimit + swapLimit
+	}
+	return ret, nil
+}
+
+// memLimitV2 reads the memory limit from memory.max, 0 meaning no limit
+func (c ContainerCgroup) memLimitV2() (uint64, error) {
+	v, err := c.parseMaxStat("memory.max")
+	if os.IsNotExist(err) {
+		log.Debugf("missing cgroup file: %s", c.cgroupFilePath(cgroupV2Target, "memory.max"))
+		return 0, nil
+	}
+	return v, err
+}
+
+// cpuV2 reads the CPU times from cpu.stat, reported in microseconds
+func (c ContainerCgroup) cpuV2() (*CgroupTimesStat, error) {
+	ret := &CgroupTimesStat{ContainerID: c.ContainerID}
+	statfile := c.cgroupFilePath(cgroupV2Target, "cpu.stat")
+
+	err := parseFlatKeyed(statfile, func(key string, v uint64) {
+		switch key {
+		case "user_usec":
+			ret.User = uint64(float64(v) / usecToUserHZDivisor)
+		case "system_usec":
+			ret.System = uint64(float64(v) / usecToUserHZDivisor)
+		case "usage_usec":
+			ret.UsageTotal = float64(v) / usecToUserHZDivisor
+		}
+	})
+	if os.IsNotExist(err) {
+		log.Debugf("missing cgroup file: %s", statfile)
+		return ret, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// cpuLimitV2 reads the CPU limit from cpu.max, formatted as "$QUOTA $PERIOD"
+// with "max" as quota when there's no limit
+func (c ContainerCgroup) cpuLimitV2() (float64, error) {
+	statfile := c.cgroupFilePath(cgroupV2Target, "cpu.max")
+	lines, err := readLines(statfile)
+	if os.IsNotExist(err) {
+		log.Debugf("missing cgroup file: %s", statfile)
+		return 100, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(lines) != 1 {
+		return 0, fmt.Errorf("wrong file format: %s", statfile)
+	}
+	fields := strings.Fields(lines[0])
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("wrong file format: %s", statfile)
+	}
+	// default cpu limit is 100%
+	if fields[0] == "max" {
+		return 100, nil
+	}
+	quota, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	period, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	limit := 100.0
+	if (period > 0) && (quota > 0) {
+		limit = (quota / period) * 100.0
+	}
+	return limit, nil
+}
+
+// ioV2 sums the bytes read and written on every device in io.stat.
+// Format:
+//
+// 8:0 rbytes=49225728 wbytes=9850880 rios=1322 wios=311 dbytes=0 dios=0
+// 252:0 rbytes=49094656 wbytes=9850880 rios=1318 wios=311 dbytes=0 dios=0
+//
+func (c ContainerCgroup) ioV2() (*CgroupIOStat, error) {
+	ret := &CgroupIOStat{ContainerID: c.ContainerID}
+	statfile := c.cgroupFilePath(cgroupV2Target, "io.stat")
+	f, err := os.Open(statfile)
+	if os.IsNotExist(err) {
+		log.Debugf("missing cgroup file: %s", statfile)
+		return ret, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		for _, field := range fields[1:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			v, err := strconv.ParseUint(kv[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			switch kv[0] {
+			case "rbytes":
+				ret.ReadBytes += v
+			case "wbytes":
+				ret.WriteBytes += v
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ret, fmt.Errorf("error reading %s: %s", statfile, err)
+	}
+	return ret, nil
+}
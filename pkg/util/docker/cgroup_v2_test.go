@@ -0,0 +1,228 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsUnified(t *testing.T) {
+	assert.False(t, newDummyContainerCgroup("/sys/fs/cgroup", "memory", "cpu").isUnified())
+	assert.True(t, newDummyContainerCgroup("/sys/fs/cgroup", cgroupV2Target).isUnified())
+	// hybrid hosts keep the controllers in the v1 hierarchies
+	assert.False(t, newDummyContainerCgroup("/sys/fs/cgroup", "memory", cgroupV2Target).isUnified())
+}
+
+func TestMemV2(t *testing.T) {
+	tempFolder, err := newTempFolder("mem-v2")
+	require.NoError(t, err)
+	defer tempFolder.removeAll()
+
+	cgroup := newDummyContainerCgroup(tempFolder.RootPath, cgroupV2Target)
+
+	// No file
+	stat, err := cgroup.Mem()
+	assert.NoError(t, err)
+	assert.Equal(t, &CgroupMemStat{ContainerID: "dummy"}, stat)
+
+	tempFolder.add("unified/memory.stat", dummyCgroupStat{
+		"anon":          1024,
+		"file":          2048,
+		"anon_thp":      512,
+		"file_mapped":   256,
+		"pgfault":       10,
+		"pgmajfault":    2,
+		"inactive_anon": 100,
+		"active_anon":   200,
+		"inactive_file": 300,
+		"active_file":   400,
+		"unevictable":   5,
+	}.String())
+	tempFolder.add("unified/memory.current", "4096")
+	tempFolder.add("unified/memory.swap.current", "128")
+	tempFolder.add("unified/memory.events", "low 0\nhigh 0\nmax 3\noom 1\noom_kill 1")
+	tempFolder.add("unified/memory.max", "8192")
+	tempFolder.add("unified/memory.swap.max", "max")
+
+	stat, err = cgroup.Mem()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1024), stat.RSS)
+	assert.Equal(t, uint64(1024), stat.TotalRSS)
+	assert.Equal(t, uint64(2048), stat.Cache)
+	assert.Equal(t, uint64(512), stat.RSSHuge)
+	assert.Equal(t, uint64(256), stat.MappedFile)
+	assert.Equal(t, uint64(10), stat.Pgfault)
+	assert.Equal(t, uint64(2), stat.Pgmajfault)
+	assert.Equal(t, uint64(400), stat.TotalActiveFile)
+	assert.Equal(t, uint64(5), stat.Unevictable)
+	assert.Equal(t, uint64(4096), stat.MemUsageInBytes)
+	assert.Equal(t, uint64(128), stat.Swap)
+	assert.True(t, stat.SwapPresent)
+	assert.Equal(t, uint64(3), stat.MemFailCnt)
+	assert.Equal(t, uint64(8192), stat.HierarchicalMemoryLimit)
+	assert.Equal(t, uint64(0), stat.HierarchicalMemSWLimit)
+
+	tempFolder.add("unified/memory.swap.max", "1024")
+	stat, err = cgroup.Mem()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9216), stat.HierarchicalMemSWLimit)
+}
+
+func TestMemLimitV2(t *testing.T) {
+	tempFolder, err := newTempFolder("mem-limit-v2")
+	require.NoError(t, err)
+	defer tempFolder.removeAll()
+
+	cgroup := newDummyContainerCgroup(tempFolder.RootPath, cgroupV2Target)
+
+	// No file
+	value, err := cgroup.MemLimit()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), value)
+
+	// No limit
+	tempFolder.add("unified/memory.max", "max")
+	value, err = cgroup.MemLimit()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), value)
+
+	// Invalid file
+	tempFolder.add("unified/memory.max", "ab")
+	_, err = cgroup.MemLimit()
+	assert.Error(t, err)
+
+	// Valid value
+	tempFolder.add("unified/memory.max", "1234")
+	value, err = cgroup.MemLimit()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1234), value)
+}
+
+func TestCPUV2(t *testing.T) {
+	tempFolder, err := newTempFolder("cpu-v2")
+	require.NoError(t, err)
+	defer tempFolder.removeAll()
+
+	tempFolder.add("unified/cpu.stat", dummyCgroupStat{
+		"usage_usec":     915266418,
+		"user_usec":      641400000,
+		"system_usec":    183270000,
+		"nr_periods":     20,
+		"nr_throttled":   10,
+		"throttled_usec": 18327,
+	}.String())
+
+	cgroup := newDummyContainerCgroup(tempFolder.RootPath, cgroupV2Target)
+
+	timeStat, err := cgroup.CPU()
+	assert.NoError(t, err)
+	assert.Equal(t, "dummy", timeStat.ContainerID)
+	assert.Equal(t, uint64(64140), timeStat.User)
+	assert.Equal(t, uint64(18327), timeStat.System)
+	assert.InDelta(t, 91526.6418, timeStat.UsageTotal, 0.0000001)
+
+	// nr_throttled has the same name in v1 and v2
+	throttled, err := cgroup.CPUNrThrottled()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), throttled)
+}
+
+func TestCPULimitV2(t *testing.T) {
+	tempFolder, err := newTempFolder("cpu-limit-v2")
+	require.NoError(t, err)
+	defer tempFolder.removeAll()
+
+	cgroup := newDummyContainerCgroup(tempFolder.RootPath, cgroupV2Target)
+
+	// No file
+	limit, err := cgroup.CPULimit()
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, limit)
+
+	// No limit
+	tempFolder.add("unified/cpu.max", "max 100000")
+	limit, err = cgroup.CPULimit()
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, limit)
+
+	// Invalid file
+	tempFolder.add("unified/cpu.max", "50000")
+	_, err = cgroup.CPULimit()
+	assert.Error(t, err)
+
+	// Empty file
+	tempFolder.add("unified/cpu.max", "")
+	_, err = cgroup.CPULimit()
+	assert.Error(t, err)
+
+	// Half a CPU
+	tempFolder.add("unified/cpu.max", "50000 100000")
+	limit, err = cgroup.CPULimit()
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, limit)
+}
+
+func TestIOV2(t *testing.T) {
+	tempFolder, err := newTempFolder("io-v2")
+	require.NoError(t, err)
+	defer tempFolder.removeAll()
+
+	tempFolder.add("unified/io.stat", "8:0 rbytes=49225728 wbytes=9850880 rios=1322 wios=311 dbytes=0 dios=0\n"+
+		"252:0 rbytes=1000 wbytes=2000 rios=1318 wios=311 dbytes=0 dios=0\n")
+
+	cgroup := newDummyContainerCgroup(tempFolder.RootPath, cgroupV2Target)
+
+	ioStat, err := cgroup.IO()
+	assert.NoError(t, err)
+	assert.Equal(t, &CgroupIOStat{ContainerID: "dummy", ReadBytes: 49226728, WriteBytes: 9852880}, ioStat)
+}
+
+func TestThreadCount(t *testing.T) {
+	tempFolder, err := newTempFolder("thread-count")
+	require.NoError(t, err)
+	defer tempFolder.removeAll()
+
+	for _, target := range []string{"pids", cgroupV2Target} {
+		cgroup := newDummyContainerCgroup(tempFolder.RootPath, target)
+
+		// No file
+		value, err := cgroup.ThreadCount()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), value)
+
+		tempFolder.add(target+"/pids.current", "42")
+		value, err = cgroup.ThreadCount()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(42), value)
+	}
+
+	// No pids controller
+	cgroup := newDummyContainerCgroup(tempFolder.RootPath, "memory", "cpu")
+	_, err = cgroup.ThreadCount()
+	assert.Equal(t, ErrMissingTarget, err)
+}
+
+func TestFillCgroupStatsWithoutPids(t *testing.T) {
+	tempFolder, err := newTempFolder("fill-without-pids")
+	require.NoError(t, err)
+	defer tempFolder.removeAll()
+
+	tempFolder.add("memory/memory.stat", "rss 1024\n")
+	tempFolder.add("cpuacct/cpuacct.stat", "user 10\nsystem 20\n")
+	tempFolder.add("cpuacct/cpuacct.usage", "100000000\n")
+	tempFolder.add("cpu/cpu.stat", "nr_throttled 3\n")
+
+	// the container is kept when the pids controller isn't mounted
+	container := &Container{ID: "dummy"}
+	container.SetCgroup(newDummyContainerCgroup(tempFolder.RootPath, "memory", "cpuacct", "cpu", "blkio"))
+	require.NoError(t, container.FillCgroupStats())
+	assert.Equal(t, uint64(1024), container.Memory.RSS)
+	assert.Equal(t, uint64(3), container.CPUNrThrottled)
+	assert.Equal(t, uint64(0), container.ThreadCount)
+}
@@ -5,6 +5,13 @@
 
 package docker
 
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/cihub/seelog"
+)
+
 // Expose container states
 const (
 	ContainerCreatedState    string = "created"
@@ -15,6 +22,10 @@ const (
 	ContainerDeadState       string = "dead"
 )
 
+// missingPidsOnce logs once that the thread count of the containers can't be
+// read, as it then fails for every container at every run
+var missingPidsOnce sync.Once
+
 // Container represents a single Docker container on a machine
 // and includes Cgroup-level statistics about the container.
 type Container struct {
@@ -30,9 +41,17 @@ type Container struct {
 	Pids     []int32
 	Excluded bool
 
+	// Labels, Hosts and Ports are filled when listing and inspecting the
+	// containers of the runtimes exposing them, Env only when inspecting them
+	Labels map[string]string
+	Env    map[string]string
+	Hosts  map[string]string // IP addresses by network name
+	Ports  []int
+
 	CPULimit       float64
 	MemLimit       uint64
 	CPUNrThrottled uint64
+	ThreadCount    uint64
 	CPU            *CgroupTimesStat
 	Memory         *CgroupMemStat
 	IO             *CgroupIOStat
@@ -42,3 +61,82 @@ type Container struct {
 	// For internal use only
 	cgroup *ContainerCgroup
 }
+
+// ContainerListConfig sets the containers listed by the container runtimes
+type ContainerListConfig struct {
+	IncludeExited bool
+	FlagExcluded  bool
+}
+
+func (cfg *ContainerListConfig) GetCacheKey() string {
+	cacheKey := "dockerutil.containers"
+	if cfg.IncludeExited {
+		cacheKey += ".with_exited"
+	} else {
+		cacheKey += ".without_exited"
+	}
+
+	if cfg.FlagExcluded {
+		cacheKey += ".with_excluded"
+	} else {
+		cacheKey += ".without_excluded"
+	}
+
+	return cacheKey
+}
+
+// SetCgroup attaches its cgroup to the container and reads its limits
+func (c *Container) SetCgroup(cgroup *ContainerCgroup) {
+	var err error
+	c.cgroup = cgroup
+	c.CPULimit, err = cgroup.CPULimit()
+	if err != nil {
+		log.Debugf("cgroup cpu limit: %s", err)
+	}
+	c.MemLimit, err = cgroup.MemLimit()
+	if err != nil {
+		log.Debugf("cgroup memory limit: %s", err)
+	}
+}
+
+// FillCgroupStats reads the latest statistics of the cgroup of the
+// container. The network statistics are left to the runtime.
+func (c *Container) FillCgroupStats() error {
+	var err error
+	cgroup := c.cgroup
+	if cgroup == nil {
+		return fmt.Errorf("container id %s has an empty cgroup", c.ID)
+	}
+
+	c.Memory, err = cgroup.Mem()
+	if err != nil {
+		return fmt.Errorf("cgroup memory: %s", err)
+	}
+	c.CPU, err = cgroup.CPU()
+	if err != nil {
+		return fmt.Errorf("cgroup cpu: %s", err)
+	}
+	c.CPUNrThrottled, err = cgroup.CPUNrThrottled()
+	if err != nil {
+		return fmt.Errorf("cgroup cpuNrThrottled: %s", err)
+	}
+	// the thread count is left to 0 when it can't be read
+	c.ThreadCount, err = cgroup.ThreadCount()
+	if err == ErrMissingTarget {
+		missingPidsOnce.Do(func() {
+			log.Infof("the pids cgroup controller isn't mounted, the thread count of the containers won't be reported")
+		})
+	} else if err != nil {
+		log.Debugf("cgroup thread count of container %s: %s", c.ID, err)
+	}
+	c.IO, err = cgroup.IO()
+	if err != nil {
+		return fmt.Errorf("cgroup i/o: %s", err)
+	}
+	c.StartedAt, err = cgroup.ContainerStartTime()
+	if err != nil {
+		return fmt.Errorf("failed to get container start time: %s", err)
+	}
+	c.Pids = cgroup.Pids
+	return nil
+}
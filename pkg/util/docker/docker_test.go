@@ -11,6 +11,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -96,3 +98,77 @@ func TestExtractImageName(t *testing.T) {
 
 	}
 }
+
+func TestHostsFromPs(t *testing.T) {
+	co := types.Container{
+		ID:    "foo",
+		Image: "test",
+	}
+
+	assert.Empty(t, hostsFromPs(co))
+
+	nets := make(map[string]*network.EndpointSettings)
+	nets["bridge"] = &network.EndpointSettings{IPAddress: "172.17.0.2"}
+	nets["foo"] = &network.EndpointSettings{IPAddress: "172.17.0.3"}
+	networkSettings := types.SummaryNetworkSettings{
+		Networks: nets}
+
+	co = types.Container{
+		ID:              "deadbeef",
+		Image:           "test",
+		NetworkSettings: &networkSettings,
+		Ports:           []types.Port{{PrivatePort: 1337}, {PrivatePort: 42}},
+	}
+	hosts := hostsFromPs(co)
+
+	assert.Equal(t, "172.17.0.2", hosts["bridge"])
+	assert.Equal(t, "172.17.0.3", hosts["foo"])
+	assert.Equal(t, 2, len(hosts))
+}
+
+func TestRancherIPFromPs(t *testing.T) {
+	co := types.Container{
+		ID:    "foo",
+		Image: "test",
+	}
+
+	assert.Empty(t, hostsFromPs(co))
+
+	nets := make(map[string]*network.EndpointSettings)
+	nets["none"] = &network.EndpointSettings{}
+	networkSettings := types.SummaryNetworkSettings{
+		Networks: nets}
+
+	co = types.Container{
+		ID:              "deadbeef",
+		Image:           "test",
+		NetworkSettings: &networkSettings,
+		Ports:           []types.Port{{PrivatePort: 1337}, {PrivatePort: 42}},
+		Labels: map[string]string{
+			"io.rancher.container.ip": "10.42.90.224/16",
+		},
+	}
+	hosts := hostsFromPs(co)
+
+	assert.Equal(t, "10.42.90.224", hosts["rancher"])
+	assert.Equal(t, 1, len(hosts))
+}
+
+func TestPortsFromPs(t *testing.T) {
+	co := types.Container{
+		ID:    "foo",
+		Image: "test",
+	}
+	assert.Empty(t, portsFromPs(co))
+	assert.Nil(t, portsFromPs(co)) // return must be nil to trigger GetPorts on resolution
+
+	co.Ports = make([]types.Port, 0)
+	assert.Empty(t, portsFromPs(co))
+
+	co.Ports = append(co.Ports, types.Port{PrivatePort: 1234})
+	co.Ports = append(co.Ports, types.Port{PrivatePort: 4321})
+	ports := portsFromPs(co)
+	assert.Equal(t, 2, len(ports))
+	assert.Contains(t, ports, 1234)
+	assert.Contains(t, ports, 4321)
+}
@@ -11,6 +11,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
@@ -22,21 +23,10 @@ import (
 	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/client"
 
-	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"github.com/DataDog/datadog-agent/pkg/util/retry"
 )
 
-const (
-	pauseContainerGCR       string = "image:gcr.io/google_containers/pause.*"
-	pauseContainerOpenshift string = "image:openshift/origin-pod"
-)
-
-// FIXME: remove once DockerListener is moved to .Containers
-func (d *DockerUtil) ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error) {
-	return d.cli.ContainerList(ctx, options)
-}
-
 // DockerUtil wraps interactions with a local docker API.
 type DockerUtil struct {
 	// used to setup the DockerUtil
@@ -88,15 +78,8 @@ func (d *DockerUtil) init() error {
 		CacheDuration:  10 * time.Second,
 	}
 
-	whitelist := config.Datadog.GetStringSlice("ac_include")
-	blacklist := config.Datadog.GetStringSlice("ac_exclude")
-
-	if config.Datadog.GetBool("exclude_pause_container") {
-		blacklist = append(blacklist, pauseContainerGCR, pauseContainerOpenshift)
-	}
-
 	// Pre-parse the filter and use that internally.
-	cfg.filter, err = newContainerFilter(whitelist, blacklist)
+	cfg.filter, err = NewFilterFromConfig()
 	if err != nil {
 		return err
 	}
@@ -219,6 +202,9 @@ func (d *DockerUtil) dockerContainers(cfg *ContainerListConfig) ([]*Container, e
 			Created:  c.Created,
 			State:    c.State,
 			Health:   parseContainerHealth(c.Status),
+			Labels:   c.Labels,
+			Hosts:    hostsFromPs(c),
+			Ports:    portsFromPs(c),
 		}
 
 		container.Excluded = d.cfg.filter.IsExcluded(container)
@@ -243,6 +229,35 @@ func (d *DockerUtil) dockerContainers(cfg *ContainerListConfig) ([]*Container, e
 	return ret, nil
 }
 
+// hostsFromPs gets the addresses (for now IP address only) of a container
+// on all its networks
+func hostsFromPs(co types.Container) map[string]string {
+	ips := make(map[string]string)
+	if co.NetworkSettings != nil {
+		for net, settings := range co.NetworkSettings.Networks {
+			if len(settings.IPAddress) > 0 {
+				ips[net] = settings.IPAddress
+			}
+		}
+	}
+
+	rancherIP, found := FindRancherIPInLabels(co.Labels)
+	if found {
+		ips["rancher"] = rancherIP
+	}
+	return ips
+}
+
+// portsFromPs gets the service ports of a container, nil meaning that
+// they are to be inspected
+func portsFromPs(co types.Container) []int {
+	var ports []int
+	for _, p := range co.Ports {
+		ports = append(ports, int(p.PrivatePort))
+	}
+	return ports
+}
+
 // Containers gets a list of all containers on the current node using a mix of
 // the Docker APIs and cgroups stats. We attempt to limit syscalls where possible.
 func (d *DockerUtil) Containers(cfg *ContainerListConfig) ([]*Container, error) {
@@ -281,15 +296,7 @@ func (d *DockerUtil) Containers(cfg *ContainerListConfig) ([]*Container, error)
 			if !ok {
 				continue
 			}
-			container.cgroup = cgroup
-			container.CPULimit, err = cgroup.CPULimit()
-			if err != nil {
-				log.Debugf("cgroup cpu limit: %s", err)
-			}
-			container.MemLimit, err = cgroup.MemLimit()
-			if err != nil {
-				log.Debugf("cgroup cpu limit: %s", err)
-			}
+			container.SetCgroup(cgroup)
 		}
 		cache.Cache.Set(cacheKey, containers, d.cfg.CacheDuration)
 	}
@@ -297,7 +304,6 @@ func (d *DockerUtil) Containers(cfg *ContainerListConfig) ([]*Container, error)
 	// Fill in the latest statistics from the cgroups
 	// Creating a new list of containers with copies so we don't lose
 	// the previous state for calculations (e.g. last cpu).
-	var err error
 	newContainers := make([]*Container, 0, len(containers))
 	for _, lastContainer := range containers {
 		if (cfg.IncludeExited && lastContainer.State == ContainerExitedState) || lastContainer.Excluded {
@@ -308,32 +314,11 @@ func (d *DockerUtil) Containers(cfg *ContainerListConfig) ([]*Container, error)
 		container := &Container{}
 		*container = *lastContainer
 
-		cgroup := container.cgroup
-		if cgroup == nil {
-			log.Debugf("container id %s has an empty cgroup, skipping", container.ID[:12])
-			continue
-		}
-
-		container.Memory, err = cgroup.Mem()
-		if err != nil {
-			log.Debugf("cgroup memory: %s", err)
-			continue
-		}
-		container.CPU, err = cgroup.CPU()
-		if err != nil {
-			log.Debugf("cgroup cpu: %s", err)
-			continue
-		}
-		container.CPUNrThrottled, err = cgroup.CPUNrThrottled()
-		if err != nil {
-			log.Debugf("cgroup cpuNrThrottled: %s", err)
-			continue
-		}
-		container.IO, err = cgroup.IO()
-		if err != nil {
-			log.Debugf("cgroup i/o: %s", err)
+		if err := container.FillCgroupStats(); err != nil {
+			log.Debugf("skipping container %s: %s", container.ID[:12], err)
 			continue
 		}
+		cgroup := container.cgroup
 
 		if d.cfg.CollectNetwork {
 			d.Lock()
@@ -351,14 +336,6 @@ func (d *DockerUtil) Containers(cfg *ContainerListConfig) ([]*Container, error)
 			container.Network = NullContainer.Network
 		}
 
-		startedAt, err := cgroup.ContainerStartTime()
-		if err != nil {
-			log.Debugf("failed to get container start time: %s", err)
-			continue
-		}
-		container.StartedAt = startedAt
-		container.Pids = cgroup.Pids
-
 		newContainers = append(newContainers, container)
 	}
 	return newContainers, nil
@@ -446,6 +423,12 @@ func (d *DockerUtil) Inspect(id string, withSize bool) (types.ContainerJSON, err
 	return container, err
 }
 
+// ContainerLogs streams the logs of a container, in the multiplexed format
+// of the docker API
+func (d *DockerUtil) ContainerLogs(ctx context.Context, id string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	return d.cli.ContainerLogs(ctx, id, options)
+}
+
 // Inspect detect the container ID we are running in and returns the inspect contents.
 func (d *DockerUtil) InspectSelf() (types.ContainerJSON, error) {
 	cID, _, err := readCgroupPaths("/proc/self/cgroup")
@@ -19,10 +19,15 @@ type ContainerEvent struct {
 	Action        string
 	Timestamp     time.Time
 	Attributes    map[string]string
+	// EntityID is set by the runtimes whose containers aren't docker ones
+	EntityID string
 }
 
 // ContainerEntityName returns the event's container as a tagger entity name
 func (ev *ContainerEvent) ContainerEntityName() string {
+	if ev.EntityID != "" {
+		return ev.EntityID
+	}
 	return ContainerIDToEntityName(ev.ContainerID)
 }
 
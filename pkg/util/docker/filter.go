@@ -3,17 +3,23 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2018 Datadog, Inc.
 
-// +build docker
-
 package docker
 
 import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	pauseContainerGCR       string = "image:gcr.io/google_containers/pause.*"
+	pauseContainerOpenshift string = "image:openshift/origin-pod"
 )
 
-type containerFilter struct {
+// Filter excludes containers by image or name, whatever their runtime
+type Filter struct {
 	Enabled        bool
 	ImageWhitelist []*regexp.Regexp
 	NameWhitelist  []*regexp.Regexp
@@ -43,11 +49,11 @@ func parseFilters(filters []string) (imageFilters, nameFilters []*regexp.Regexp,
 	return imageFilters, nameFilters, nil
 }
 
-// NewcontainerFilter creates a new container filter from a two slices of
+// NewFilter creates a new container filter from a two slices of
 // regexp patterns for a whitelist and blacklist. Each pattern should have
 // the following format: "field:pattern" where field can be: [image, name].
 // An error is returned if any of the expression don't compile.
-func newContainerFilter(whitelist, blacklist []string) (*containerFilter, error) {
+func NewFilter(whitelist, blacklist []string) (*Filter, error) {
 	iwl, nwl, err := parseFilters(whitelist)
 	if err != nil {
 		return nil, err
@@ -57,7 +63,7 @@ func newContainerFilter(whitelist, blacklist []string) (*containerFilter, error)
 		return nil, err
 	}
 
-	return &containerFilter{
+	return &Filter{
 		Enabled:        len(whitelist) > 0 || len(blacklist) > 0,
 		ImageWhitelist: iwl,
 		NameWhitelist:  nwl,
@@ -66,13 +72,26 @@ func newContainerFilter(whitelist, blacklist []string) (*containerFilter, error)
 	}, nil
 }
 
+// NewFilterFromConfig creates a new container filter from the ac_include
+// and ac_exclude options, excluding the pause containers when
+// exclude_pause_container is set
+func NewFilterFromConfig() (*Filter, error) {
+	whitelist := config.Datadog.GetStringSlice("ac_include")
+	blacklist := config.Datadog.GetStringSlice("ac_exclude")
+
+	if config.Datadog.GetBool("exclude_pause_container") {
+		blacklist = append(blacklist, pauseContainerGCR, pauseContainerOpenshift)
+	}
+	return NewFilter(whitelist, blacklist)
+}
+
 // IsExcluded returns a bool indicating if the container should be excluded
-// based on the filters in the containerFilter instance.
-func (cf containerFilter) IsExcluded(container *Container) bool {
+// based on the filters in the Filter instance.
+func (cf Filter) IsExcluded(container *Container) bool {
 	return cf.computeIsExcluded(container.Name, container.Image)
 }
 
-func (cf containerFilter) computeIsExcluded(containerName, containerImage string) bool {
+func (cf Filter) computeIsExcluded(containerName, containerImage string) bool {
 	if !cf.Enabled {
 		return false
 	}
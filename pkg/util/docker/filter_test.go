@@ -3,8 +3,6 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2018 Datadog, Inc.
 
-// +build docker
-
 package docker
 
 import (
@@ -58,7 +56,7 @@ func TestContainerFilter(t *testing.T) {
 			expectedIDs: []string{"1", "2", "3", "4"},
 		},
 	} {
-		f, err := newContainerFilter(tc.whitelist, tc.blacklist)
+		f, err := NewFilter(tc.whitelist, tc.blacklist)
 		assert.NoError(err, "case %d", i)
 
 		var allowed []string
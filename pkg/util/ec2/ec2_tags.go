@@ -18,6 +18,9 @@ import (
 	"github.com/aws/aws-sdk-go/service/ec2"
 )
 
+// for testing purpose, the EC2 API endpoint of the region is used when empty
+var apiEndpoint = ""
+
 // GetTags grabs the host tags from the EC2 api
 func GetTags() ([]string, error) {
 	tags := []string{}
@@ -34,10 +37,14 @@ func GetTags() ([]string, error) {
 
 	awsCreds := credentials.NewStaticCredentials(iamParams["AccessKeyId"], iamParams["SecretAccessKey"], iamParams["Token"])
 
-	awsSess, err := session.NewSession(&aws.Config{
+	awsConfig := &aws.Config{
 		Region:      aws.String(instanceIdentity["region"]),
 		Credentials: awsCreds,
-	})
+	}
+	if apiEndpoint != "" {
+		awsConfig.Endpoint = aws.String(apiEndpoint)
+	}
+	awsSess, err := session.NewSession(awsConfig)
 	if err != nil {
 		return tags, fmt.Errorf("unable to get aws session, %s", err)
 	}
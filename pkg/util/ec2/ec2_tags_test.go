@@ -13,6 +13,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"sync"
@@ -142,3 +143,70 @@ func TestGetInstanceIdentity(t *testing.T) {
 	assert.Equal(t, expected, val)
 	expectedLock.RUnlock()
 }
+
+func TestGetTags(t *testing.T) {
+	var describeTagsForm url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"region": "us-east-1", "instanceId": "i-07d4be5da8ffb9d1b"}`)
+	})
+	mux.HandleFunc("/meta-data/iam/security-credentials/", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "test-role")
+	})
+	mux.HandleFunc("/meta-data/iam/security-credentials/test-role/", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"AccessKeyId": "123123", "SecretAccessKey": "ddddd", "Token": "asdfasdf"}`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		require.Nil(t, r.ParseForm())
+		describeTagsForm = r.PostForm
+		w.Header().Set("Content-Type", "text/xml")
+		io.WriteString(w, `<DescribeTagsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+  <requestId>7a62c49f-347e-4fc4-9331-6e8eEXAMPLE</requestId>
+  <tagSet>
+    <item>
+      <resourceId>i-07d4be5da8ffb9d1b</resourceId>
+      <resourceType>instance</resourceType>
+      <key>env</key>
+      <value>prod</value>
+    </item>
+    <item>
+      <resourceId>i-07d4be5da8ffb9d1b</resourceId>
+      <resourceType>instance</resourceType>
+      <key>team</key>
+      <value>web</value>
+    </item>
+  </tagSet>
+</DescribeTagsResponse>`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	defer func(metadata, identity string) {
+		metadataURL, instanceIdentityURL, apiEndpoint = metadata, identity, ""
+	}(metadataURL, instanceIdentityURL)
+	metadataURL = ts.URL + "/meta-data"
+	instanceIdentityURL = ts.URL + "/identity/"
+	apiEndpoint = ts.URL
+
+	tags, err := GetTags()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"env:prod", "team:web"}, tags)
+	assert.Equal(t, "DescribeTags", describeTagsForm.Get("Action"))
+	assert.Equal(t, "resource-id", describeTagsForm.Get("Filter.1.Name"))
+	assert.Equal(t, "i-07d4be5da8ffb9d1b", describeTagsForm.Get("Filter.1.Value.1"))
+}
+
+func TestGetTagsMetadataUnavailable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	defer func(metadata, identity string) {
+		metadataURL, instanceIdentityURL = metadata, identity
+	}(metadataURL, instanceIdentityURL)
+	metadataURL = ts.URL
+	instanceIdentityURL = ts.URL
+
+	tags, err := GetTags()
+	assert.NotNil(t, err)
+	assert.Len(t, tags, 0)
+}
@@ -9,6 +9,7 @@ package ecs
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -64,37 +65,7 @@ func GetContainers() ([]*docker.Container, error) {
 		return containers, err
 	}
 	for _, c := range ecsContainers {
-		entityID := docker.ContainerIDToEntityName(c.DockerID)
-		ctr := &docker.Container{
-			Type:     "ECS",
-			ID:       c.DockerID,
-			EntityID: entityID,
-			Name:     c.DockerName,
-			Image:    c.Image,
-			ImageID:  c.ImageID,
-		}
-
-		createdAt, err := time.Parse(time.RFC3339, c.CreatedAt)
-		if err != nil {
-			log.Errorf("unable to determine creation time for container %s - %s", c.DockerID, err)
-		} else {
-			ctr.Created = createdAt.Unix()
-		}
-		startedAt, err := time.Parse(time.RFC3339, c.StartedAt)
-		if err != nil {
-			log.Errorf("unable to determine creation time for container %s - %s", c.DockerID, err)
-		} else {
-			ctr.StartedAt = startedAt.Unix()
-		}
-
-		if l, found := c.Limits["cpu"]; found && l > 0 {
-			ctr.CPULimit = float64(l)
-		} else {
-			ctr.CPULimit = 100
-		}
-		if l, found := c.Limits["memory"]; found && l > 0 {
-			ctr.MemLimit = l
-		}
+		ctr := convertContainer(c)
 		stats, err = GetContainerStats(c)
 		if err != nil {
 			log.Errorf("unable to get stats from ECS for container %s - %s", c.DockerID, err)
@@ -115,6 +86,79 @@ func GetContainers() ([]*docker.Container, error) {
 	return containers, err
 }
 
+// InspectContainer returns a container of the task, without statistics
+func InspectContainer(id string) (*docker.Container, error) {
+	ecsContainers, err := GetECSContainers()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range ecsContainers {
+		if c.DockerID == id {
+			return convertContainer(c), nil
+		}
+	}
+	return nil, fmt.Errorf("container %s not found in the task", id)
+}
+
+// convertContainer transforms an ECS container into a "generic" Docker
+// container, without statistics
+func convertContainer(c Container) *docker.Container {
+	ctr := &docker.Container{
+		Type:     "ECS",
+		ID:       c.DockerID,
+		EntityID: docker.ContainerIDToEntityName(c.DockerID),
+		Name:     c.DockerName,
+		Image:    c.Image,
+		ImageID:  c.ImageID,
+		State:    convertKnownStatus(c.KnownStatus),
+		Labels:   c.Labels,
+		Env:      map[string]string{},
+		Hosts:    make(map[string]string),
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, c.CreatedAt)
+	if err != nil {
+		log.Errorf("unable to determine creation time for container %s - %s", c.DockerID, err)
+	} else {
+		ctr.Created = createdAt.Unix()
+	}
+	startedAt, err := time.Parse(time.RFC3339, c.StartedAt)
+	if err != nil {
+		log.Errorf("unable to determine creation time for container %s - %s", c.DockerID, err)
+	} else {
+		ctr.StartedAt = startedAt.Unix()
+	}
+
+	if l, found := c.Limits["cpu"]; found && l > 0 {
+		ctr.CPULimit = float64(l)
+	} else {
+		ctr.CPULimit = 100
+	}
+	if l, found := c.Limits["memory"]; found && l > 0 {
+		ctr.MemLimit = l
+	}
+
+	for _, net := range c.Networks {
+		if net.NetworkMode == "awsvpc" && len(net.IPv4Addresses) > 0 {
+			ctr.Hosts["awsvpc"] = net.IPv4Addresses[0]
+		}
+	}
+	return ctr
+}
+
+// convertKnownStatus converts the status of an ECS container to the docker
+// container states
+func convertKnownStatus(status string) string {
+	switch status {
+	case "RUNNING":
+		return docker.ContainerRunningState
+	case "STOPPED":
+		return docker.ContainerExitedState
+	default:
+		return docker.ContainerCreatedState
+	}
+}
+
 // GetContainerStats retrives stats about a container from the ECS stats endpoint
 func GetContainerStats(c Container) (ContainerStats, error) {
 	var stats ContainerStats
@@ -154,3 +154,26 @@ func TestLocateECSHTTP(t *testing.T) {
 		assert.FailNow("Timeout on receive channel")
 	}
 }
+
+func TestConvertContainer(t *testing.T) {
+	ctr := convertContainer(Container{
+		DockerID:    "43481a6ce4842eec8fe72fc28500c6b52edcc0917f105b83379f88cac1ff3946",
+		DockerName:  "ecs-nginx-5-nginx-curl-ccccb9f49db0dfe0d901",
+		Image:       "nrdlngr/nginx-curl",
+		KnownStatus: "RUNNING",
+		Labels:      map[string]string{"com.amazonaws.ecs.container-name": "nginx-curl"},
+		Limits:      map[string]uint64{"cpu": 50, "memory": 1024},
+		CreatedAt:   "2018-02-01T20:55:10.554941919Z",
+		Networks:    []Network{{NetworkMode: "awsvpc", IPv4Addresses: []string{"10.0.2.106"}}},
+	})
+	assert.Equal(t, "docker://43481a6ce4842eec8fe72fc28500c6b52edcc0917f105b83379f88cac1ff3946", ctr.EntityID)
+	assert.Equal(t, "running", ctr.State)
+	assert.Equal(t, "nginx-curl", ctr.Labels["com.amazonaws.ecs.container-name"])
+	assert.Equal(t, map[string]string{"awsvpc": "10.0.2.106"}, ctr.Hosts)
+	assert.Equal(t, float64(50), ctr.CPULimit)
+	assert.Equal(t, uint64(1024), ctr.MemLimit)
+	assert.Equal(t, int64(1517518510), ctr.Created)
+
+	assert.Equal(t, "exited", convertContainer(Container{KnownStatus: "STOPPED"}).State)
+	assert.Equal(t, "created", convertContainer(Container{KnownStatus: "PULLED"}).State)
+}
@@ -44,3 +44,30 @@ func TestGetHostTags(t *testing.T) {
 		assert.Equal(t, expected, actual)
 	}
 }
+
+func TestGetHostTagsMetadataUnavailable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	metadataURL = ts.URL
+
+	tags, err := GetTags()
+	assert.NotNil(t, err)
+	assert.Len(t, tags, 0)
+}
+
+func TestGetHostTagsRequest(t *testing.T) {
+	var lastRequest *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"tags": ["web"], "zone": "projects/111111111111/zones/europe-west1-b"}`)
+		lastRequest = r
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+
+	tags, err := GetTags()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"web", "zone:europe-west1-b"}, tags)
+	assert.Equal(t, "/instance", lastRequest.URL.Path)
+	assert.Equal(t, "Google", lastRequest.Header.Get("Metadata-Flavor"))
+}
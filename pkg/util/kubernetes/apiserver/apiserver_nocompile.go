@@ -24,3 +24,8 @@ func GetPodSvcs(nodeName string, podName string) []string {
 	log.Errorf("GetPodSvcs not implemented %s", ErrNotCompiled.Error())
 	return nil
 }
+
+// ListConfigMaps is used by the ConfigMap config provider to collect check configurations.
+func ListConfigMaps(labelSelector string) ([]ConfigMap, error) {
+	return nil, ErrNotCompiled
+}
@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"context"
+
+	"github.com/ericchiang/k8s"
+)
+
+// ListConfigMaps returns the ConfigMaps of all the namespaces matching the
+// given label selector
+func (c *APIClient) ListConfigMaps(labelSelector string) ([]ConfigMap, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	list, err := c.client.CoreV1().ListConfigMaps(ctx, "", k8s.QueryParam("labelSelector", labelSelector))
+	if err != nil {
+		return nil, err
+	}
+
+	configMaps := make([]ConfigMap, 0, len(list.GetItems()))
+	for _, cm := range list.GetItems() {
+		meta := cm.GetMetadata()
+		configMaps = append(configMaps, ConfigMap{
+			Namespace:       meta.GetNamespace(),
+			Name:            meta.GetName(),
+			ResourceVersion: meta.GetResourceVersion(),
+			Data:            cm.GetData(),
+		})
+	}
+	return configMaps, nil
+}
+
+// ListConfigMaps returns the ConfigMaps of all the namespaces matching the
+// given label selector, using the shared APIClient
+func ListConfigMaps(labelSelector string) ([]ConfigMap, error) {
+	c, err := GetAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	return c.ListConfigMaps(labelSelector)
+}
@@ -0,0 +1,14 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package apiserver
+
+// ConfigMap holds the fields of a Kubernetes ConfigMap the agent uses
+type ConfigMap struct {
+	Namespace       string
+	Name            string
+	ResourceVersion string
+	Data            map[string]string
+}
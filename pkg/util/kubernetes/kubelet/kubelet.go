@@ -27,6 +27,7 @@ import (
 
 const (
 	kubeletPodPath         = "/pods"
+	kubeletSummaryPath     = "/stats/summary"
 	authorizationHeaderKey = "Authorization"
 )
 
@@ -138,6 +139,25 @@ func (ku *KubeUtil) GetLocalPodList() ([]*Pod, error) {
 	return v.Items, nil
 }
 
+// GetStatsSummary returns the resource usage of the node and of its pods
+// and containers, as computed by the kubelet
+func (ku *KubeUtil) GetStatsSummary() (*Summary, error) {
+	data, code, err := ku.QueryKubelet(kubeletSummaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("error performing kubelet query %s%s: %s", ku.kubeletApiEndpoint, kubeletSummaryPath, err)
+	}
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d on %s%s: %s", code, ku.kubeletApiEndpoint, kubeletSummaryPath, string(data))
+	}
+
+	v := &Summary{}
+	err = json.Unmarshal(data, v)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
 // GetPodForContainerID fetches the podlist and returns the pod running
 // a given container on the node. Returns a nil pointer if not found.
 func (ku *KubeUtil) GetPodForContainerID(containerID string) (*Pod, error) {
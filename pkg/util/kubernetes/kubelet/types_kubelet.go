@@ -46,9 +46,17 @@ type Spec struct {
 
 // ContainerSpec contains fields for unmarshalling a Pod.Spec.Containers
 type ContainerSpec struct {
-	Name  string              `json:"name"`
-	Image string              `json:"image,omitempty"`
-	Ports []ContainerPortSpec `json:"ports,omitempty"`
+	Name      string                 `json:"name"`
+	Image     string                 `json:"image,omitempty"`
+	Ports     []ContainerPortSpec    `json:"ports,omitempty"`
+	Env       []EnvVar               `json:"env,omitempty"`
+	Resources ContainerResourcesSpec `json:"resources,omitempty"`
+}
+
+// ContainerResourcesSpec contains fields for unmarshalling a Pod.Spec.Containers.Resources
+type ContainerResourcesSpec struct {
+	Requests map[string]string `json:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty"`
 }
 
 // ContainerSpec contains fields for unmarshalling a Pod.Spec.Containers.Ports
@@ -59,6 +67,12 @@ type ContainerPortSpec struct {
 	Protocol      string `json:"protocol"`
 }
 
+// EnvVar contains fields for unmarshalling a Pod.Spec.Containers.Env
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
 // Status contains fields for unmarshalling a Pod.Status
 type Status struct {
 	Phase      string            `json:"phase,omitempty"`
@@ -69,7 +83,34 @@ type Status struct {
 
 // ContainerStatus contains fields for unmarshalling a Pod.Status.Containers
 type ContainerStatus struct {
-	Name  string `json:"name,omitempty"`
-	Image string `json:"image,omitempty"`
-	ID    string `json:"containerID,omitempty"`
+	Name         string         `json:"name,omitempty"`
+	Image        string         `json:"image,omitempty"`
+	ID           string         `json:"containerID,omitempty"`
+	Ready        bool           `json:"ready"`
+	RestartCount int            `json:"restartCount"`
+	State        ContainerState `json:"state,omitempty"`
+}
+
+// ContainerState contains fields for unmarshalling a Pod.Status.Containers.State,
+// only one of them being set
+type ContainerState struct {
+	Waiting    *ContainerStateWaiting    `json:"waiting,omitempty"`
+	Running    *ContainerStateRunning    `json:"running,omitempty"`
+	Terminated *ContainerStateTerminated `json:"terminated,omitempty"`
+}
+
+// ContainerStateWaiting contains fields for unmarshalling a Pod.Status.Containers.State.Waiting
+type ContainerStateWaiting struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// ContainerStateRunning contains fields for unmarshalling a Pod.Status.Containers.State.Running
+type ContainerStateRunning struct {
+	StartedAt string `json:"startedAt,omitempty"`
+}
+
+// ContainerStateTerminated contains fields for unmarshalling a Pod.Status.Containers.State.Terminated
+type ContainerStateTerminated struct {
+	ExitCode int    `json:"exitCode"`
+	Reason   string `json:"reason,omitempty"`
 }
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubelet
+
+package kubelet
+
+// Summary contains fields for unmarshalling the /stats/summary endpoint
+type Summary struct {
+	Node NodeStats  `json:"node"`
+	Pods []PodStats `json:"pods,omitempty"`
+}
+
+// NodeStats contains fields for unmarshalling a Summary.Node
+type NodeStats struct {
+	NodeName string        `json:"nodeName"`
+	CPU      *CPUStats     `json:"cpu,omitempty"`
+	Memory   *MemoryStats  `json:"memory,omitempty"`
+	Network  *NetworkStats `json:"network,omitempty"`
+	Fs       *FsStats      `json:"fs,omitempty"`
+}
+
+// PodStats contains fields for unmarshalling a Summary.Pods
+type PodStats struct {
+	PodRef           PodReference     `json:"podRef"`
+	Containers       []ContainerStats `json:"containers,omitempty"`
+	Network          *NetworkStats    `json:"network,omitempty"`
+	EphemeralStorage *FsStats         `json:"ephemeral-storage,omitempty"`
+}
+
+// PodReference contains fields for unmarshalling a Summary.Pods.PodRef
+type PodReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	UID       string `json:"uid"`
+}
+
+// ContainerStats contains fields for unmarshalling a Summary.Pods.Containers
+type ContainerStats struct {
+	Name   string       `json:"name"`
+	CPU    *CPUStats    `json:"cpu,omitempty"`
+	Memory *MemoryStats `json:"memory,omitempty"`
+	Rootfs *FsStats     `json:"rootfs,omitempty"`
+	Logs   *FsStats     `json:"logs,omitempty"`
+}
+
+// CPUStats contains fields for unmarshalling the cpu stats of a Summary
+type CPUStats struct {
+	UsageNanoCores       *uint64 `json:"usageNanoCores,omitempty"`
+	UsageCoreNanoSeconds *uint64 `json:"usageCoreNanoSeconds,omitempty"`
+}
+
+// MemoryStats contains fields for unmarshalling the memory stats of a Summary
+type MemoryStats struct {
+	AvailableBytes  *uint64 `json:"availableBytes,omitempty"`
+	UsageBytes      *uint64 `json:"usageBytes,omitempty"`
+	WorkingSetBytes *uint64 `json:"workingSetBytes,omitempty"`
+	RSSBytes        *uint64 `json:"rssBytes,omitempty"`
+	PageFaults      *uint64 `json:"pageFaults,omitempty"`
+	MajorPageFaults *uint64 `json:"majorPageFaults,omitempty"`
+}
+
+// NetworkStats contains fields for unmarshalling the network stats of a Summary
+type NetworkStats struct {
+	RxBytes  *uint64 `json:"rxBytes,omitempty"`
+	RxErrors *uint64 `json:"rxErrors,omitempty"`
+	TxBytes  *uint64 `json:"txBytes,omitempty"`
+	TxErrors *uint64 `json:"txErrors,omitempty"`
+}
+
+// FsStats contains fields for unmarshalling the filesystem stats of a Summary
+type FsStats struct {
+	AvailableBytes *uint64 `json:"availableBytes,omitempty"`
+	CapacityBytes  *uint64 `json:"capacityBytes,omitempty"`
+	UsedBytes      *uint64 `json:"usedBytes,omitempty"`
+	InodesFree     *uint64 `json:"inodesFree,omitempty"`
+	Inodes         *uint64 `json:"inodes,omitempty"`
+	InodesUsed     *uint64 `json:"inodesUsed,omitempty"`
+}
@@ -23,6 +23,7 @@ DEFAULT_BUILD_TAGS = [
     "apm",
     "consul",
     "cpython",
+    "cri",
     "docker",
     "ec2",
     "etcd",
@@ -33,7 +34,6 @@ DEFAULT_BUILD_TAGS = [
     "process",
     "snmp",
     "zk",
-    "zlib",
 ]
 
 
@@ -7,8 +7,10 @@ from invoke import task
 # ALL_TAGS lists any available build tag
 ALL_TAGS = set([
     "apm",
+    "azure",
     "consul",
     "cpython",
+    "cri",
     "docker",
     "ec2",
     "etcd",
@@ -19,14 +21,12 @@ ALL_TAGS = set([
     "process",
     "snmp",
     "zk",
-    "zlib",
+    "zstd",
     "kubeapiserver",
 ])
 
 # PUPPY_TAGS lists the tags needed when building the Puppy Agent
-PUPPY_TAGS = set([
-    "zlib",
-])
+PUPPY_TAGS = set([])
 
 
 def get_default_build_tags(puppy=False):
@@ -37,7 +37,7 @@ def get_default_build_tags(puppy=False):
         return PUPPY_TAGS
 
     include = ["all"]
-    exclude = ["docker", "kubelet", "kubeapiserver"] if invoke.platform.WINDOWS else []
+    exclude = ["cri", "docker", "kubelet", "kubeapiserver"] if invoke.platform.WINDOWS else []
     return get_build_tags(include, exclude)
 
 
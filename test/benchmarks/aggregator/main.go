@@ -77,6 +77,9 @@ type forwarderBenchStub struct{}
 
 func (f *forwarderBenchStub) Start() error { return nil }
 func (f *forwarderBenchStub) Stop()        {}
+func (f *forwarderBenchStub) Drain(timeout time.Duration) error {
+	return nil
+}
 func (f *forwarderBenchStub) SubmitV1Series(payloads forwarder.Payloads, extraHeaders http.Header) error {
 	return nil
 }
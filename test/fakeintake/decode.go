@@ -0,0 +1,205 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package fakeintake
+
+import (
+	"encoding/json"
+	"fmt"
+
+	agentpayload "github.com/DataDog/agent-payload/gogen"
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+)
+
+// decompress returns the body of a request according to its Content-Encoding
+func decompress(contentEncoding string, body []byte) ([]byte, error) {
+	var kind string
+	switch contentEncoding {
+	case "":
+		return body, nil
+	case "deflate":
+		kind = compression.ZlibKind
+	case "zstd":
+		kind = compression.ZstdKind
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", contentEncoding)
+	}
+
+	c, err := compression.New(kind, compression.DefaultLevel)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decompress(nil, body)
+}
+
+// v1Serie is the JSON format of a serie on the v1 API, points are sent as
+// [timestamp, value] arrays
+type v1Serie struct {
+	Metric         string       `json:"metric"`
+	Points         [][2]float64 `json:"points"`
+	Tags           []string     `json:"tags"`
+	Host           string       `json:"host"`
+	Device         string       `json:"device"`
+	Type           string       `json:"type"`
+	Interval       int64        `json:"interval"`
+	SourceTypeName string       `json:"source_type_name"`
+}
+
+func decodeV1Series(body []byte) ([]Serie, error) {
+	payload := map[string][]v1Serie{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	series := make([]Serie, 0, len(payload["series"]))
+	for _, s := range payload["series"] {
+		serie := Serie{
+			Metric:         s.Metric,
+			Host:           s.Host,
+			Device:         s.Device,
+			Type:           s.Type,
+			Interval:       s.Interval,
+			SourceTypeName: s.SourceTypeName,
+			Tags:           s.Tags,
+		}
+		// the v1 API carries the device in a dedicated field, put it back in
+		// the tags so that v1 and v2 series can be queried the same way
+		if s.Device != "" {
+			serie.Tags = append(serie.Tags, "device:"+s.Device)
+		}
+		for _, p := range s.Points {
+			serie.Points = append(serie.Points, Point{Ts: p[0], Value: p[1]})
+		}
+		series = append(series, serie)
+	}
+	return series, nil
+}
+
+func decodeV2Series(body []byte) ([]Serie, error) {
+	payload := &agentpayload.MetricsPayload{}
+	if err := proto.Unmarshal(body, payload); err != nil {
+		return nil, err
+	}
+
+	series := make([]Serie, 0, len(payload.Samples))
+	for _, s := range payload.Samples {
+		serie := Serie{
+			Metric:         s.Metric,
+			Host:           s.Host,
+			Type:           s.Type,
+			SourceTypeName: s.SourceTypeName,
+			Tags:           s.Tags,
+		}
+		for _, p := range s.Points {
+			serie.Points = append(serie.Points, Point{Ts: float64(p.Ts), Value: p.Value})
+		}
+		series = append(series, serie)
+	}
+	return series, nil
+}
+
+func decodeV1CheckRuns(body []byte) ([]ServiceCheck, error) {
+	serviceChecks := []ServiceCheck{}
+	err := json.Unmarshal(body, &serviceChecks)
+	return serviceChecks, err
+}
+
+func decodeV2ServiceChecks(body []byte) ([]ServiceCheck, error) {
+	payload := &agentpayload.ServiceChecksPayload{}
+	if err := proto.Unmarshal(body, payload); err != nil {
+		return nil, err
+	}
+
+	serviceChecks := make([]ServiceCheck, 0, len(payload.ServiceChecks))
+	for _, sc := range payload.ServiceChecks {
+		serviceChecks = append(serviceChecks, ServiceCheck{
+			Check:   sc.Name,
+			Host:    sc.Host,
+			Ts:      sc.Ts,
+			Status:  int(sc.Status),
+			Message: sc.Message,
+			Tags:    sc.Tags,
+		})
+	}
+	return serviceChecks, nil
+}
+
+// decodeV1Intake decodes a payload of the universal intake endpoint, and
+// extracts its events if any
+func decodeV1Intake(body []byte) (map[string]interface{}, []Event, error) {
+	intake := map[string]interface{}{}
+	if err := json.Unmarshal(body, &intake); err != nil {
+		return nil, nil, err
+	}
+
+	if _, found := intake["events"]; !found {
+		return intake, nil, nil
+	}
+
+	// events are grouped by source type name
+	payload := struct {
+		Events map[string][]Event `json:"events"`
+	}{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, nil, err
+	}
+	events := []Event{}
+	for sourceTypeName, group := range payload.Events {
+		for _, e := range group {
+			if e.SourceTypeName == "" {
+				e.SourceTypeName = sourceTypeName
+			}
+			events = append(events, e)
+		}
+	}
+	return intake, events, nil
+}
+
+func decodeV2Events(body []byte) ([]Event, error) {
+	payload := &agentpayload.EventsPayload{}
+	if err := proto.Unmarshal(body, payload); err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(payload.Events))
+	for _, e := range payload.Events {
+		events = append(events, Event{
+			Title:          e.Title,
+			Text:           e.Text,
+			Ts:             e.Ts,
+			Priority:       e.Priority,
+			Host:           e.Host,
+			Tags:           e.Tags,
+			AlertType:      e.AlertType,
+			AggregationKey: e.AggregationKey,
+			SourceTypeName: e.SourceTypeName,
+		})
+	}
+	return events, nil
+}
+
+func decodeSketches(body []byte) ([]Sketch, error) {
+	payload := &agentpayload.SketchPayload{}
+	if err := proto.Unmarshal(body, payload); err != nil {
+		return nil, err
+	}
+
+	sketches := make([]Sketch, 0, len(payload.Sketches))
+	for _, s := range payload.Sketches {
+		sketch := Sketch{
+			Metric:        s.Metric,
+			Host:          s.Host,
+			Tags:          s.Tags,
+			Distributions: len(s.Distributions),
+		}
+		for _, d := range s.Distributions {
+			sketch.Count += d.Cnt
+		}
+		sketches = append(sketches, sketch)
+	}
+	return sketches, nil
+}
@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package fakeintake
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+
+	log "github.com/cihub/seelog"
+)
+
+// logsListener accepts the TCP connections of the logs agent, it expects the
+// agent to run with `dev_mode_no_ssl` enabled
+type logsListener struct {
+	listener net.Listener
+	store    *Store
+}
+
+func newLogsListener(addr string, store *Store) (*logsListener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &logsListener{
+		listener: listener,
+		store:    store,
+	}, nil
+}
+
+func (l *logsListener) start() {
+	go func() {
+		for {
+			conn, err := l.listener.Accept()
+			if err != nil {
+				// the listener was closed
+				return
+			}
+			go l.handle(conn)
+		}
+	}()
+}
+
+func (l *logsListener) handle(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		entry, err := parseLogLine(scanner.Text())
+		if err != nil {
+			log.Warnf("fakeintake: dropping log line: %s", err)
+			continue
+		}
+		l.store.addLogs([]Log{entry})
+	}
+}
+
+func (l *logsListener) addr() string {
+	return l.listener.Addr().String()
+}
+
+func (l *logsListener) stop() {
+	l.listener.Close()
+}
+
+// parseLogLine decodes a line sent by the logs agent:
+//   <api_key>[/<logset>] <pri>0 <timestamp> <hostname> <service> - - <structured data> <message>
+// Lines already formatted by the emitter are forwarded as-is after the api key
+func parseLogLine(line string) (Log, error) {
+	entry := Log{}
+
+	apiKey, rest, found := cut(line, " ")
+	if !found {
+		return entry, fmt.Errorf("no api key found in %q", line)
+	}
+	entry.APIKey, entry.Logset, _ = cut(apiKey, "/")
+
+	if !strings.HasPrefix(rest, "<") {
+		return entry, fmt.Errorf("no priority found in %q", line)
+	}
+	end := strings.Index(rest, ">")
+	if end < 0 {
+		return entry, fmt.Errorf("invalid priority in %q", line)
+	}
+	entry.Severity = rest[:end+1]
+	rest = rest[end+1:]
+
+	// RFC5424 header: version, timestamp, hostname, app-name, procid, msgid
+	fields := strings.SplitN(rest, " ", 7)
+	if len(fields) < 7 {
+		// not built by the agent, keep the raw message
+		entry.Message = rest
+		return entry, nil
+	}
+	entry.Timestamp = fields[1]
+	entry.Hostname = fields[2]
+	entry.Service = fields[3]
+	rest = fields[6]
+
+	if strings.HasPrefix(rest, "- ") {
+		entry.Message = rest[2:]
+		return entry, nil
+	}
+
+	for strings.HasPrefix(rest, "[dd ") {
+		end := strings.Index(rest, "\"]")
+		if end < 0 {
+			return entry, fmt.Errorf("invalid structured data in %q", line)
+		}
+		key, value, _ := cut(rest[len("[dd "):end], "=\"")
+		switch key {
+		case "ddsource":
+			entry.Source = value
+		case "ddsourcecategory":
+			entry.SourceCategory = value
+		case "ddtags":
+			entry.Tags = strings.Split(value, ",")
+		}
+		rest = rest[end+2:]
+	}
+	entry.Message = strings.TrimPrefix(rest, " ")
+
+	return entry, nil
+}
+
+// cut slices s around the first instance of sep
+func cut(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,243 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package fakeintake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/gorilla/mux"
+)
+
+// Server emulates the Datadog intake: it decodes the payloads sent by the
+// forwarder and the logs agent and keeps them in its Store
+type Server struct {
+	store    *Store
+	listener net.Listener
+	server   *http.Server
+	logs     *logsListener
+}
+
+// NewServer returns a new Server, not yet listening
+func NewServer() *Server {
+	return &Server{
+		store: NewStore(),
+	}
+}
+
+// Start starts serving the HTTP endpoints on addr and the logs endpoint on
+// logsAddr, use "127.0.0.1:0" to pick random ports
+func (s *Server) Start(addr, logsAddr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	logs, err := newLogsListener(logsAddr, s.store)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+
+	s.listener = listener
+	s.logs = logs
+	s.server = &http.Server{Handler: s.Handler()}
+
+	go s.server.Serve(listener)
+	s.logs.start()
+
+	log.Infof("fakeintake: listening on %s, logs on %s", s.URL(), s.LogsAddr())
+	return nil
+}
+
+// Stop stops the Server
+func (s *Server) Stop() {
+	if s.server != nil {
+		s.server.Close()
+	}
+	if s.logs != nil {
+		s.logs.stop()
+	}
+}
+
+// Store returns the store holding the decoded payloads
+func (s *Server) Store() *Store {
+	return s.store
+}
+
+// URL returns the URL to use as `dd_url`
+func (s *Server) URL() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// LogsAddr returns the address of the logs endpoint
+func (s *Server) LogsAddr() string {
+	return s.logs.addr()
+}
+
+// AgentConfig returns the agent configuration needed to send everything to
+// the Server
+func (s *Server) AgentConfig() string {
+	host, port, _ := net.SplitHostPort(s.LogsAddr())
+	return fmt.Sprintf("dd_url: %s\napi_key: fakeintake\nlog_dd_url: %s\nlog_dd_port: %s\ndev_mode_no_ssl: true\n", s.URL(), host, port)
+}
+
+// Handler returns the http.Handler serving the intake and query endpoints
+func (s *Server) Handler() http.Handler {
+	r := mux.NewRouter()
+
+	// intake endpoints, see pkg/forwarder
+	r.HandleFunc("/api/v1/series", s.intakeHandler(s.handleV1Series)).Methods("POST")
+	r.HandleFunc("/api/v1/check_run", s.intakeHandler(s.handleV1CheckRuns)).Methods("POST")
+	r.HandleFunc("/intake/", s.intakeHandler(s.handleV1Intake)).Methods("POST")
+	r.HandleFunc("/api/v2/series", s.intakeHandler(s.handleV2Series)).Methods("POST")
+	r.HandleFunc("/api/v2/service_checks", s.intakeHandler(s.handleV2ServiceChecks)).Methods("POST")
+	r.HandleFunc("/api/v2/events", s.intakeHandler(s.handleV2Events)).Methods("POST")
+	r.HandleFunc("/api/beta/sketches", s.intakeHandler(s.handleSketches)).Methods("POST")
+	// payloads only kept raw
+	r.HandleFunc("/api/v1/sketches", s.intakeHandler(nil)).Methods("POST")
+	r.HandleFunc("/api/v2/host_metadata", s.intakeHandler(nil)).Methods("POST")
+	r.HandleFunc("/api/v2/metadata", s.intakeHandler(nil)).Methods("POST")
+
+	// query endpoints
+	q := r.PathPrefix("/fakeintake").Subrouter()
+	q.HandleFunc("/payloads", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.store.Payloads())
+	}).Methods("GET")
+	q.HandleFunc("/series", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.store.Series(r.URL.Query().Get("metric"), r.URL.Query()["tag"]...))
+	}).Methods("GET")
+	q.HandleFunc("/service_checks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.store.ServiceChecks(r.URL.Query().Get("check"), r.URL.Query()["tag"]...))
+	}).Methods("GET")
+	q.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.store.Events(r.URL.Query()["tag"]...))
+	}).Methods("GET")
+	q.HandleFunc("/sketches", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.store.Sketches(r.URL.Query().Get("metric"), r.URL.Query()["tag"]...))
+	}).Methods("GET")
+	q.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.store.Logs(r.URL.Query().Get("service")))
+	}).Methods("GET")
+	q.HandleFunc("/intake", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.store.Intake())
+	}).Methods("GET")
+	q.HandleFunc("/flush", func(w http.ResponseWriter, r *http.Request) {
+		s.store.Flush()
+		w.WriteHeader(http.StatusOK)
+	}).Methods("POST")
+
+	return r
+}
+
+// intakeHandler stores the raw payload then decodes it with decode
+func (s *Server) intakeHandler(decode func([]byte) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body, err = decompress(r.Header.Get("Content-Encoding"), body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		apiKey := r.Header.Get("DD-Api-Key")
+		if apiKey == "" {
+			apiKey = r.URL.Query().Get("api_key")
+		}
+		s.store.addPayload(Payload{
+			Endpoint:   r.URL.Path,
+			APIKey:     apiKey,
+			Headers:    r.Header,
+			Body:       body,
+			ReceivedAt: time.Now(),
+		})
+
+		if decode != nil {
+			if err := decode(body); err != nil {
+				log.Warnf("fakeintake: could not decode payload sent to %s: %s", r.URL.Path, err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (s *Server) handleV1Series(body []byte) error {
+	series, err := decodeV1Series(body)
+	if err == nil {
+		s.store.addSeries(series)
+	}
+	return err
+}
+
+func (s *Server) handleV2Series(body []byte) error {
+	series, err := decodeV2Series(body)
+	if err == nil {
+		s.store.addSeries(series)
+	}
+	return err
+}
+
+func (s *Server) handleV1CheckRuns(body []byte) error {
+	serviceChecks, err := decodeV1CheckRuns(body)
+	if err == nil {
+		s.store.addServiceChecks(serviceChecks)
+	}
+	return err
+}
+
+func (s *Server) handleV2ServiceChecks(body []byte) error {
+	serviceChecks, err := decodeV2ServiceChecks(body)
+	if err == nil {
+		s.store.addServiceChecks(serviceChecks)
+	}
+	return err
+}
+
+func (s *Server) handleV1Intake(body []byte) error {
+	intake, events, err := decodeV1Intake(body)
+	if err != nil {
+		return err
+	}
+	s.store.addIntake(intake)
+	if len(events) > 0 {
+		s.store.addEvents(events)
+	}
+	return nil
+}
+
+func (s *Server) handleV2Events(body []byte) error {
+	events, err := decodeV2Events(body)
+	if err == nil {
+		s.store.addEvents(events)
+	}
+	return err
+}
+
+func (s *Server) handleSketches(body []byte) error {
+	sketches, err := decodeSketches(body)
+	if err == nil {
+		s.store.addSketches(sketches)
+	}
+	return err
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
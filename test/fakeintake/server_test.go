@@ -0,0 +1,212 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package fakeintake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+)
+
+func post(t *testing.T, url string, payload []byte) {
+	c, err := compression.New(compression.ZlibKind, compression.DefaultLevel)
+	require.NoError(t, err)
+	body, err := c.Compress(nil, payload)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", c.ContentEncoding())
+	req.Header.Set("DD-Api-Key", "abcdef")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+}
+
+func testSeries() metrics.Series {
+	return metrics.Series{
+		{
+			Name:   "system.load.1",
+			Points: []metrics.Point{{Ts: 1500000000, Value: 1.5}},
+			Tags:   []string{"env:prod", "device:sda1"},
+			Host:   "myhost",
+			MType:  metrics.APIGaugeType,
+		},
+		{
+			Name:   "system.load.5",
+			Points: []metrics.Point{{Ts: 1500000000, Value: 0.5}},
+			Tags:   []string{"env:staging"},
+			Host:   "myhost",
+			MType:  metrics.APIGaugeType,
+		},
+	}
+}
+
+func TestV1Series(t *testing.T) {
+	s := NewServer()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	payload, err := testSeries().MarshalJSON()
+	require.NoError(t, err)
+	post(t, ts.URL+"/api/v1/series", payload)
+
+	series := s.Store().Series("system.load.1", "env:prod", "device:sda1")
+	require.Len(t, series, 1)
+	assert.Equal(t, "myhost", series[0].Host)
+	assert.Equal(t, []Point{{Ts: 1500000000, Value: 1.5}}, series[0].Points)
+	assert.Len(t, s.Store().Series(""), 2)
+	assert.Len(t, s.Store().Series("", "env:prod"), 1)
+
+	payloads := s.Store().Payloads()
+	require.Len(t, payloads, 1)
+	assert.Equal(t, "/api/v1/series", payloads[0].Endpoint)
+	assert.Equal(t, "abcdef", payloads[0].APIKey)
+}
+
+func TestV2Series(t *testing.T) {
+	s := NewServer()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	payload, err := testSeries().Marshal()
+	require.NoError(t, err)
+	post(t, ts.URL+"/api/v2/series", payload)
+
+	assert.Len(t, s.Store().Series("system.load.5", "env:staging"), 1)
+	assert.Len(t, s.Store().Series("system.load.5", "env:prod"), 0)
+}
+
+func TestServiceChecks(t *testing.T) {
+	s := NewServer()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	serviceChecks := metrics.ServiceChecks{
+		{CheckName: "datadog.agent.up", Host: "myhost", Status: metrics.ServiceCheckOK, Tags: []string{"env:prod"}},
+	}
+	payload, err := serviceChecks.MarshalJSON()
+	require.NoError(t, err)
+	post(t, ts.URL+"/api/v1/check_run", payload)
+	payload, err = serviceChecks.Marshal()
+	require.NoError(t, err)
+	post(t, ts.URL+"/api/v2/service_checks", payload)
+
+	received := s.Store().ServiceChecks("datadog.agent.up", "env:prod")
+	require.Len(t, received, 2)
+	for _, sc := range received {
+		assert.Equal(t, "myhost", sc.Host)
+		assert.Equal(t, int(metrics.ServiceCheckOK), sc.Status)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	s := NewServer()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	events := metrics.Events{
+		{Title: "deploy", Text: "v1.2.3 deployed", Host: "myhost", Tags: []string{"env:prod"}, SourceTypeName: "deployer"},
+	}
+	payload, err := events.MarshalJSON()
+	require.NoError(t, err)
+	post(t, ts.URL+"/intake/", payload)
+
+	received := s.Store().Events("env:prod")
+	require.Len(t, received, 1)
+	assert.Equal(t, "deploy", received[0].Title)
+	assert.Equal(t, "deployer", received[0].SourceTypeName)
+	assert.Len(t, s.Store().Intake(), 1)
+}
+
+func TestQueryEndpoints(t *testing.T) {
+	s := NewServer()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	payload, err := testSeries().MarshalJSON()
+	require.NoError(t, err)
+	post(t, ts.URL+"/api/v1/series", payload)
+
+	resp, err := http.Get(ts.URL + "/fakeintake/series?metric=system.load.1&tag=env:prod")
+	require.NoError(t, err)
+	series := []Serie{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&series))
+	resp.Body.Close()
+	assert.Len(t, series, 1)
+
+	resp, err = http.Post(ts.URL+"/fakeintake/flush", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Len(t, s.Store().Series(""), 0)
+	assert.Len(t, s.Store().Payloads(), 0)
+}
+
+func TestInvalidPayload(t *testing.T) {
+	s := NewServer()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/api/v1/series", "application/json", bytes.NewBufferString("not json"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestParseLogLine(t *testing.T) {
+	entry, err := parseLogLine(`abcdef/42 <46>0 2018-05-15T12:00:00Z myhost nginx - - [dd ddsource="nginx"][dd ddtags="env:prod,team:web"] GET /index.html 200`)
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef", entry.APIKey)
+	assert.Equal(t, "42", entry.Logset)
+	assert.Equal(t, "<46>", entry.Severity)
+	assert.Equal(t, "2018-05-15T12:00:00Z", entry.Timestamp)
+	assert.Equal(t, "myhost", entry.Hostname)
+	assert.Equal(t, "nginx", entry.Service)
+	assert.Equal(t, "nginx", entry.Source)
+	assert.Equal(t, []string{"env:prod", "team:web"}, entry.Tags)
+	assert.Equal(t, "GET /index.html 200", entry.Message)
+
+	entry, err = parseLogLine(`abcdef <46>0 2018-05-15T12:00:00Z myhost nginx - - - hello`)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", entry.Message)
+
+	_, err = parseLogLine("abcdef")
+	assert.Error(t, err)
+	_, err = parseLogLine("abcdef hello")
+	assert.Error(t, err)
+}
+
+func TestLogsEndpoint(t *testing.T) {
+	s := NewServer()
+	require.NoError(t, s.Start("127.0.0.1:0", "127.0.0.1:0"))
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", s.LogsAddr())
+	require.NoError(t, err)
+	defer conn.Close()
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(conn, "abcdef <46>0 2018-05-15T12:00:00Z myhost redis - - - line %d\n", i)
+	}
+
+	ok := s.Store().WaitFor(func(store *Store) bool {
+		return len(store.Logs("redis")) == 3
+	}, 5*time.Second)
+	require.True(t, ok)
+	assert.Equal(t, "line 0", s.Store().Logs("redis")[0].Message)
+	assert.Contains(t, s.AgentConfig(), "dd_url: "+s.URL())
+}
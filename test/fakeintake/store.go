@@ -0,0 +1,286 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package fakeintake
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Point is a single value of a Serie
+type Point struct {
+	Ts    float64 `json:"ts"`
+	Value float64 `json:"value"`
+}
+
+// Serie is a decoded timeserie, received on the v1 or v2 series endpoints
+type Serie struct {
+	Metric         string   `json:"metric"`
+	Host           string   `json:"host"`
+	Device         string   `json:"device,omitempty"`
+	Type           string   `json:"type"`
+	Interval       int64    `json:"interval"`
+	SourceTypeName string   `json:"source_type_name,omitempty"`
+	Tags           []string `json:"tags"`
+	Points         []Point  `json:"points"`
+}
+
+// ServiceCheck is a decoded service check, received on the check_run or v2
+// service_checks endpoints
+type ServiceCheck struct {
+	Check   string   `json:"check"`
+	Host    string   `json:"host_name"`
+	Ts      int64    `json:"timestamp"`
+	Status  int      `json:"status"`
+	Message string   `json:"message"`
+	Tags    []string `json:"tags"`
+}
+
+// Event is a decoded event, received on the intake or v2 events endpoints
+type Event struct {
+	Title          string   `json:"msg_title"`
+	Text           string   `json:"msg_text"`
+	Ts             int64    `json:"timestamp"`
+	Priority       string   `json:"priority,omitempty"`
+	Host           string   `json:"host"`
+	Tags           []string `json:"tags,omitempty"`
+	AlertType      string   `json:"alert_type,omitempty"`
+	AggregationKey string   `json:"aggregation_key,omitempty"`
+	SourceTypeName string   `json:"source_type_name,omitempty"`
+}
+
+// Sketch is a decoded distribution, received on the sketches endpoint
+type Sketch struct {
+	Metric string   `json:"metric"`
+	Host   string   `json:"host"`
+	Tags   []string `json:"tags"`
+	// Count is the total number of values over all the distributions
+	Count int64 `json:"count"`
+	// Distributions is the number of flushed distributions
+	Distributions int `json:"distributions"`
+}
+
+// Log is a decoded log line, received on the logs TCP endpoint
+type Log struct {
+	APIKey         string   `json:"api_key"`
+	Logset         string   `json:"logset,omitempty"`
+	Severity       string   `json:"severity"`
+	Timestamp      string   `json:"timestamp"`
+	Hostname       string   `json:"hostname"`
+	Service        string   `json:"service"`
+	Source         string   `json:"source,omitempty"`
+	SourceCategory string   `json:"source_category,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	Message        string   `json:"message"`
+}
+
+// Payload is a raw request received by the intake, kept after decompression
+type Payload struct {
+	Endpoint   string      `json:"endpoint"`
+	APIKey     string      `json:"api_key"`
+	Headers    http.Header `json:"headers"`
+	Body       []byte      `json:"body"`
+	ReceivedAt time.Time   `json:"received_at"`
+}
+
+// Store keeps everything received by the intake in memory
+type Store struct {
+	m             sync.RWMutex
+	payloads      []Payload
+	series        []Serie
+	serviceChecks []ServiceCheck
+	events        []Event
+	sketches      []Sketch
+	logs          []Log
+	intake        []map[string]interface{}
+
+	// notify is closed and replaced every time something is stored
+	notify chan struct{}
+}
+
+// NewStore returns an empty Store
+func NewStore() *Store {
+	return &Store{notify: make(chan struct{})}
+}
+
+func (s *Store) update(f func()) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	f()
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+func (s *Store) addPayload(p Payload) {
+	s.update(func() { s.payloads = append(s.payloads, p) })
+}
+
+func (s *Store) addSeries(series []Serie) {
+	s.update(func() { s.series = append(s.series, series...) })
+}
+
+func (s *Store) addServiceChecks(serviceChecks []ServiceCheck) {
+	s.update(func() { s.serviceChecks = append(s.serviceChecks, serviceChecks...) })
+}
+
+func (s *Store) addEvents(events []Event) {
+	s.update(func() { s.events = append(s.events, events...) })
+}
+
+func (s *Store) addSketches(sketches []Sketch) {
+	s.update(func() { s.sketches = append(s.sketches, sketches...) })
+}
+
+func (s *Store) addLogs(logs []Log) {
+	s.update(func() { s.logs = append(s.logs, logs...) })
+}
+
+func (s *Store) addIntake(intake map[string]interface{}) {
+	s.update(func() { s.intake = append(s.intake, intake) })
+}
+
+// Payloads returns a copy of the raw payloads received
+func (s *Store) Payloads() []Payload {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return append([]Payload{}, s.payloads...)
+}
+
+// Series returns the series received whose name matches metric (all of them
+// if empty) and that have every given tag
+func (s *Store) Series(metric string, tags ...string) []Serie {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	series := []Serie{}
+	for _, serie := range s.series {
+		if (metric == "" || serie.Metric == metric) && hasTags(serie.Tags, tags) {
+			series = append(series, serie)
+		}
+	}
+	return series
+}
+
+// ServiceChecks returns the service checks received whose name matches check
+// (all of them if empty) and that have every given tag
+func (s *Store) ServiceChecks(check string, tags ...string) []ServiceCheck {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	serviceChecks := []ServiceCheck{}
+	for _, sc := range s.serviceChecks {
+		if (check == "" || sc.Check == check) && hasTags(sc.Tags, tags) {
+			serviceChecks = append(serviceChecks, sc)
+		}
+	}
+	return serviceChecks
+}
+
+// Events returns the events received that have every given tag
+func (s *Store) Events(tags ...string) []Event {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	events := []Event{}
+	for _, e := range s.events {
+		if hasTags(e.Tags, tags) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// Sketches returns the sketches received whose name matches metric (all of
+// them if empty) and that have every given tag
+func (s *Store) Sketches(metric string, tags ...string) []Sketch {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	sketches := []Sketch{}
+	for _, sketch := range s.sketches {
+		if (metric == "" || sketch.Metric == metric) && hasTags(sketch.Tags, tags) {
+			sketches = append(sketches, sketch)
+		}
+	}
+	return sketches
+}
+
+// Logs returns the log lines received for the given service (all of them if
+// empty)
+func (s *Store) Logs(service string) []Log {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	logs := []Log{}
+	for _, l := range s.logs {
+		if service == "" || l.Service == service {
+			logs = append(logs, l)
+		}
+	}
+	return logs
+}
+
+// Intake returns the decoded JSON payloads received on the v1 intake
+// endpoint (host metadata, events, processes...)
+func (s *Store) Intake() []map[string]interface{} {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return append([]map[string]interface{}{}, s.intake...)
+}
+
+// Flush drops everything received so far
+func (s *Store) Flush() {
+	s.update(func() {
+		s.payloads = nil
+		s.series = nil
+		s.serviceChecks = nil
+		s.events = nil
+		s.sketches = nil
+		s.logs = nil
+		s.intake = nil
+	})
+}
+
+// WaitFor blocks until cond returns true, re-evaluating it every time
+// something is stored, and returns false if timeout expires first
+func (s *Store) WaitFor(cond func(*Store) bool, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		s.m.RLock()
+		notify := s.notify
+		s.m.RUnlock()
+
+		if cond(s) {
+			return true
+		}
+
+		select {
+		case <-notify:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// hasTags returns whether all the expected tags are in tags
+func hasTags(tags []string, expected []string) bool {
+	for _, e := range expected {
+		found := false
+		for _, t := range tags {
+			if t == e {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}